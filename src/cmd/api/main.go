@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"b2b-diagnostic-aggregator/apis/internal/app"
 )
 
 func main() {
-	if err := app.Run(); err != nil {
+	// Cancelled on SIGINT/SIGTERM: the server drains in-flight requests and background workers stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := app.Run(ctx); err != nil {
 		log.Fatalf("API server failed: %v", err)
 	}
 }
@@ -0,0 +1,100 @@
+// Command mocklis is a stand-in lab information system for trying lab integrations locally. It
+// accepts HTTP_JSON orders on -http (POST any path) and HL7 v2 ORM messages over MLLP on -mllp,
+// logs what it receives and acknowledges them. -fail-every N rejects every Nth order so retries can
+// be exercised.
+//
+//	go run ./cmd/mocklis -http :9090 -mllp :2575 -fail-every 3
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"b2b-diagnostic-aggregator/apis/internal/labintegration"
+)
+
+var received int64
+
+func main() {
+	httpAddr := flag.String("http", ":9090", "listen address for HTTP JSON orders; empty disables")
+	mllpAddr := flag.String("mllp", ":2575", "listen address for HL7 MLLP orders; empty disables")
+	failEvery := flag.Int64("fail-every", 0, "reject every Nth order; 0 accepts all")
+	flag.Parse()
+
+	shouldFail := func() (int64, bool) {
+		n := atomic.AddInt64(&received, 1)
+		return n, *failEvery > 0 && n%*failEvery == 0
+	}
+
+	errs := make(chan error, 2)
+	if *mllpAddr != "" {
+		ln, err := net.Listen("tcp", *mllpAddr)
+		if err != nil {
+			log.Fatalf("mocklis: %v", err)
+		}
+		log.Printf("mocklis: MLLP listening on %s", *mllpAddr)
+		go func() { errs <- serveMLLP(ln, shouldFail) }()
+	}
+	if *httpAddr != "" {
+		log.Printf("mocklis: HTTP listening on %s", *httpAddr)
+		go func() { errs <- http.ListenAndServe(*httpAddr, httpOrders(shouldFail)) }()
+	}
+	log.Fatalf("mocklis: %v", <-errs)
+}
+
+func httpOrders(shouldFail func() (int64, bool)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST an order", http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		n, fail := shouldFail()
+		log.Printf("mocklis: HTTP order #%d %s (Idempotency-Key %s, Authorization %t): %s",
+			n, r.URL.Path, r.Header.Get("Idempotency-Key"), r.Header.Get("Authorization") != "", body)
+		w.Header().Set("Content-Type", "application/json")
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "mock LIS rejected the order"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"orderId": fmt.Sprintf("MOCK-%d", n)})
+	})
+}
+
+func serveMLLP(ln net.Listener, shouldFail func() (int64, bool)) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				msg, err := labintegration.ReadMLLP(r)
+				if err != nil {
+					return
+				}
+				n, fail := shouldFail()
+				log.Printf("mocklis: HL7 order #%d:\n%s", n, strings.ReplaceAll(msg, "\r", "\n"))
+				code, text := "AA", "Order accepted"
+				if fail {
+					code, text = "AE", "Mock LIS rejected the order"
+				}
+				ack := labintegration.BuildACK(msg, code, text)
+				if _, err := conn.Write([]byte("\x0b" + ack + "\x1c\r")); err != nil {
+					return
+				}
+			}
+		}(conn)
+	}
+}
@@ -1,19 +1,38 @@
 package app
 
 import (
+	"context"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"b2b-diagnostic-aggregator/apis/internal/config"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
 	"b2b-diagnostic-aggregator/apis/internal/handlers"
+	"b2b-diagnostic-aggregator/apis/internal/lockout"
 	"b2b-diagnostic-aggregator/apis/internal/logging"
+	"b2b-diagnostic-aggregator/apis/internal/notification"
 	"b2b-diagnostic-aggregator/apis/internal/repository"
 	"b2b-diagnostic-aggregator/apis/internal/service"
+	"b2b-diagnostic-aggregator/apis/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Run() error {
+// shutdownTimeout bounds how long in-flight requests may finish once shutdown starts.
+const shutdownTimeout = 15 * time.Second
+
+// Run starts the API server and its background workers and blocks until ctx is cancelled, then shuts
+// the server down gracefully. The workers stop with ctx, or when the server fails.
+func Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Load configuration
 	cfg := config.LoadConfig()
 
@@ -44,23 +63,152 @@ func Run() error {
 	packageLabMapRepo := repository.NewPackageLabMappingRepository(db)
 	loginRepo := repository.NewLoginRepository(db)
 	forgotPasswordRepo := repository.NewForgotPasswordRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
 	clientRepo := repository.NewClientRepository(db)
 	clientLocationRepo := repository.NewClientLocationRepository(db)
 	employeeRepo := repository.NewEmployeeRepository(db)
 	labRepo := repository.NewLabRepository(db)
 	leadRepo := repository.NewLeadRepository(db)
+	leadHistoryRepo := repository.NewLeadHistoryRepository(db)
+	leadImportJobRepo := repository.NewLeadImportJobRepository(db)
+	patientRepo := repository.NewPatientRepository(db)
 	leadUow := repository.NewLeadUnitOfWork(db)
+	labSlotRepo := repository.NewLabSlotRepository(db)
+	appointmentRepo := repository.NewAppointmentRepository(db)
+	appointmentUow := repository.NewAppointmentUnitOfWork(db)
+	sampleRepo := repository.NewSampleRepository(db)
+	sampleUow := repository.NewSampleUnitOfWork(db)
+	labReportRepo := repository.NewLabReportRepository(db)
+	labReportUow := repository.NewLabReportUnitOfWork(db)
+	testParameterRepo := repository.NewTestParameterRepository(db)
+	testResultRepo := repository.NewTestResultRepository(db)
+	testResultUow := repository.NewTestResultUnitOfWork(db)
+	labIntegrationRepo := repository.NewLabIntegrationRepository(db)
+	labEventUow := repository.NewLabEventUnitOfWork(db)
+	webhookSubscriptionRepo := repository.NewWebhookSubscriptionRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	packageUow := repository.NewPackageUnitOfWork(db)
+	labOrderDeliveryRepo := repository.NewLabOrderDeliveryRepository(db)
+	labOrderUow := repository.NewLabOrderUnitOfWork(db)
 	testRepo := repository.NewTestRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	// Index lab collection pincodes saved before the serviceability table existed
+	if dbReady {
+		if n, err := labRepo.BackfillCollectionPincodes(); err != nil {
+			log.Printf("Failed to backfill lab collection pincodes: %v", err)
+		} else if n > 0 {
+			log.Printf("Indexed collection pincodes for %d labs", n)
+		}
+	}
+
+	// Notifications (forgot-password OTP delivery)
+	notifier, err := notification.NewNotifier(notification.Config{
+		Driver:   cfg.Notification.Driver,
+		FilePath: cfg.Notification.FilePath,
+	})
+	if err != nil {
+		log.Printf("Failed to initialize notifier, falling back to log: %v", err)
+		notifier = &notification.LogNotifier{}
+	}
+
+	// File storage (lab reports)
+	fileStore, err := storage.NewStore(storage.Config{
+		Driver:    cfg.Storage.Driver,
+		LocalDir:  cfg.Storage.LocalDir,
+		Endpoint:  cfg.Storage.S3Endpoint,
+		Region:    cfg.Storage.S3Region,
+		Bucket:    cfg.Storage.S3Bucket,
+		AccessKey: cfg.Storage.S3AccessKey,
+		SecretKey: cfg.Storage.S3SecretKey,
+		PathStyle: cfg.Storage.S3PathStyle,
+	})
+	if err != nil {
+		log.Printf("Failed to initialize file storage, falling back to local: %v", err)
+		if fileStore, err = storage.NewLocalStore(cfg.Storage.LocalDir); err != nil {
+			return fmt.Errorf("initializing local file storage: %w", err)
+		}
+	}
+	reportSigningSecret := cfg.Reports.SigningSecret
+	if reportSigningSecret == "" {
+		// Derive a separate key so report URLs and JWTs are never signed with the same key
+		key, err := hkdf.Key(sha256.New, []byte(cfg.JWT.Secret), nil, "report download URLs", sha256.Size)
+		if err != nil {
+			return fmt.Errorf("deriving report signing secret: %w", err)
+		}
+		reportSigningSecret = string(key)
+	}
+
+	// Failed-login tracking (in-memory; swap the store to share lockouts across instances)
+	lockoutCfg := cfg.Lockout
+	lockoutWindow := time.Duration(lockoutCfg.WindowMinutes) * time.Minute
+	lockDuration := time.Duration(lockoutCfg.LockMinutes) * time.Minute
+	lockoutStore := lockout.NewMemoryStore(lockoutWindow + lockDuration)
+	lockoutPolicy := lockout.Policy{
+		MaxFailures:  lockoutCfg.MaxFailures,
+		Window:       lockoutWindow,
+		LockDuration: lockDuration,
+		DelayAfter:   lockoutCfg.DelayAfter,
+		BaseDelay:    time.Duration(lockoutCfg.BaseDelayMs) * time.Millisecond,
+		MaxDelay:     time.Duration(lockoutCfg.MaxDelayMs) * time.Millisecond,
+	}
+	userGuard := lockout.NewGuard(lockoutStore, lockoutPolicy)
+	ipPolicy := lockoutPolicy
+	ipPolicy.MaxFailures = lockoutCfg.IPMaxFailures
+	ipGuard := lockout.NewGuard(lockoutStore, ipPolicy)
 
 	// Initialize Services
-	packageSvc := service.NewPackageService(packageRepo, testRepo, packageClientMapRepo, packageLabMapRepo, clientRepo, labRepo)
-	loginSvc := service.NewLoginService(loginRepo, forgotPasswordRepo, clientRepo, employeeRepo, labRepo, cfg.JWT)
-	clientSvc := service.NewClientService(clientRepo)
-	clientLocationSvc := service.NewClientLocationService(clientLocationRepo)
-	employeeSvc := service.NewEmployeeService(employeeRepo)
-	labSvc := service.NewLabService(labRepo)
-	leadSvc := service.NewLeadService(leadRepo, leadUow, clientRepo, packageRepo)
+	auditSvc := service.NewAuditService(auditRepo)
+	packageSvc := service.NewPackageService(packageRepo, testRepo, packageClientMapRepo, packageLabMapRepo, clientRepo, labRepo, packageUow, auditSvc)
+	loginSvc := service.NewLoginService(loginRepo, forgotPasswordRepo, clientRepo, employeeRepo, labRepo, refreshTokenRepo, notifier, userGuard, ipGuard, cfg.JWT)
+	clientSvc := service.NewClientService(clientRepo, auditSvc)
+	clientLocationSvc := service.NewClientLocationService(clientLocationRepo, auditSvc)
+	employeeSvc := service.NewEmployeeService(employeeRepo, auditSvc)
+	labSvc := service.NewLabService(labRepo, auditSvc)
+	leadWorkflow, err := service.LoadLeadStatusWorkflow(cfg.Leads.StatusWorkflowFile)
+	if err != nil {
+		log.Printf("Failed to load lead status workflow, using default: %v", err)
+		leadWorkflow = domain.DefaultLeadStatusWorkflow()
+	}
+	leadDuplicates := domain.LeadDuplicatePolicy{Window: time.Duration(cfg.Leads.DuplicateWindowDays) * 24 * time.Hour}
+	leadSvc := service.NewLeadService(leadRepo, leadHistoryRepo, leadUow, clientRepo, packageRepo, employeeRepo, labRepo, leadWorkflow, leadImportJobRepo, patientRepo, leadDuplicates, service.LeadImportSettings{
+		StaleAfter: time.Duration(cfg.Leads.ImportStaleMinutes) * time.Minute,
+	})
+	serviceabilitySvc := service.NewServiceabilityService(labRepo, packageLabMapRepo, packageRepo)
+	labOrderSvc := service.NewLabOrderService(labIntegrationRepo, labOrderDeliveryRepo, labOrderUow, leadRepo, labRepo, packageRepo, testRepo, auditSvc, service.LabOrderSettings{
+		MaxAttempts: cfg.LabOrders.MaxAttempts,
+		BatchSize:   cfg.LabOrders.BatchSize,
+	})
+	labEventSvc := service.NewLabEventService(labIntegrationRepo, labRepo, labEventUow, leadWorkflow, auditSvc, time.Duration(cfg.LabEvents.ToleranceSeconds)*time.Second)
+	labRoutingSvc := service.NewLabRoutingService(leadRepo, labRepo, packageLabMapRepo, leadUow, labOrderSvc, leadWorkflow)
+	patientSvc := service.NewPatientService(patientRepo, leadRepo, packageRepo, leadWorkflow)
+	appointmentSvc := service.NewAppointmentService(appointmentRepo, labSlotRepo, leadRepo, labRepo, packageRepo, appointmentUow, labRoutingSvc, leadWorkflow, auditSvc)
+	sampleSvc := service.NewSampleService(sampleRepo, leadRepo, sampleUow, leadWorkflow)
+	reportSvc := service.NewReportService(labReportRepo, leadRepo, labReportUow, fileStore, leadWorkflow, service.ReportSettings{
+		MaxUploadBytes: int64(cfg.Reports.MaxUploadMB) << 20,
+		SigningSecret:  reportSigningSecret,
+		DownloadURLTTL: time.Duration(cfg.Reports.DownloadURLTTLMinutes) * time.Minute,
+	})
 	testSvc := service.NewTestService(testRepo)
+	testResultSvc := service.NewTestResultService(testParameterRepo, testResultRepo, testRepo, packageRepo, leadRepo, testResultUow, auditSvc)
+	webhookSvc := service.NewWebhookService(webhookSubscriptionRepo, webhookDeliveryRepo, clientRepo, auditSvc, leadWorkflow, service.WebhookSettings{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BatchSize:   cfg.Webhooks.BatchSize,
+		Timeout:     time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second,
+		AllowHTTP:   strings.EqualFold(cfg.Environment, "development"),
+	})
+	eventDispatcher := service.NewEventDispatcher(outboxRepo, service.EventDispatcherSettings{
+		MaxAttempts: cfg.Events.MaxAttempts,
+		BatchSize:   cfg.Events.BatchSize,
+	})
+	eventDispatcher.Subscribe("webhooks", webhookSvc.HandleEvent,
+		domain.EventLeadCreated, domain.EventLeadStatusChanged, domain.EventReportReleased)
+	leadStream := service.NewLeadStream(outboxRepo, leadWorkflow, service.LeadStreamSettings{
+		Buffer:      cfg.LeadStream.BufferSize,
+		ReplayLimit: cfg.LeadStream.ReplayLimit,
+	})
+	fhirSvc := service.NewFHIRService(leadRepo, patientRepo, packageRepo, testRepo, testParameterRepo, testResultRepo, leadWorkflow)
 
 	// Initialize Handlers
 	packageHandler := handlers.NewPackageHandler(packageSvc)
@@ -70,14 +218,53 @@ func Run() error {
 	employeeHandler := handlers.NewEmployeeHandler(employeeSvc)
 	labHandler := handlers.NewLabHandler(labSvc)
 	leadHandler := handlers.NewLeadHandler(leadSvc)
+	leadStreamHandler := handlers.NewLeadStreamHandler(leadStream, time.Duration(cfg.LeadStream.HeartbeatSeconds)*time.Second)
 	testHandler := handlers.NewTestHandler(testSvc)
+	auditHandler := handlers.NewAuditHandler(auditSvc)
+	patientHandler := handlers.NewPatientHandler(patientSvc)
+	labRoutingHandler := handlers.NewLabRoutingHandler(labRoutingSvc)
+	labOrderHandler := handlers.NewLabOrderHandler(labOrderSvc)
+	labEventHandler := handlers.NewLabEventHandler(labEventSvc)
+	serviceabilityHandler := handlers.NewServiceabilityHandler(serviceabilitySvc)
+	appointmentHandler := handlers.NewAppointmentHandler(appointmentSvc)
+	sampleHandler := handlers.NewSampleHandler(sampleSvc)
+	reportHandler := handlers.NewReportHandler(reportSvc)
+	testResultHandler := handlers.NewTestResultHandler(testResultSvc)
+	fhirHandler := handlers.NewFHIRHandler(fhirSvc)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc)
+
+	// Run queued CSV lead imports
+	if dbReady && cfg.Leads.ImportPollIntervalSeconds > 0 {
+		go leadSvc.RunImportJobs(ctx, time.Duration(cfg.Leads.ImportPollIntervalSeconds)*time.Second)
+	}
+	// Push queued lab orders to lab LIS integrations
+	if dbReady && cfg.LabOrders.DispatchIntervalSeconds > 0 {
+		go labOrderSvc.Run(ctx, time.Duration(cfg.LabOrders.DispatchIntervalSeconds)*time.Second)
+	}
+	// Hand outbox domain events to their subscribers
+	if dbReady && cfg.Events.DispatchIntervalSeconds > 0 {
+		go eventDispatcher.Run(ctx, time.Duration(cfg.Events.DispatchIntervalSeconds)*time.Second)
+	}
+	// Feed new lead events to /leads/stream clients
+	if dbReady && cfg.LeadStream.PollIntervalMillis > 0 {
+		go leadStream.Run(ctx, time.Duration(cfg.LeadStream.PollIntervalMillis)*time.Millisecond)
+	}
+	// Deliver queued client webhook events
+	if dbReady && cfg.Webhooks.DispatchIntervalSeconds > 0 {
+		go webhookSvc.Run(ctx, time.Duration(cfg.Webhooks.DispatchIntervalSeconds)*time.Second)
+	}
 
 	// Initialize Gin
 	r := gin.Default()
+	// Only the front proxy may set the client IP that login attempt limits key on; with none
+	// configured X-Forwarded-For is ignored and the peer address is used.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
 	r.RedirectTrailingSlash = false // allow both /path and /path/ without 301 redirect (e.g. for FE clients that use trailing slash)
 	registerMiddleware(r, dbReady)
 
-	registerRoutes(r, cfg.JWT.Secret, routeDeps{
+	registerRoutes(r, cfg.JWT.Secret, loginSvc, routeDeps{
 		packageHandler: packageHandler,
 		loginHandler:   loginHandler,
 		clientHandler:         clientHandler,
@@ -85,7 +272,20 @@ func Run() error {
 		employeeHandler:       employeeHandler,
 		labHandler:            labHandler,
 		leadHandler:    leadHandler,
+		leadStreamHandler: leadStreamHandler,
 		testHandler:   testHandler,
+		auditHandler:          auditHandler,
+		patientHandler:        patientHandler,
+		labRoutingHandler:     labRoutingHandler,
+		serviceabilityHandler: serviceabilityHandler,
+		appointmentHandler:    appointmentHandler,
+		sampleHandler:         sampleHandler,
+		reportHandler:         reportHandler,
+		testResultHandler:     testResultHandler,
+		fhirHandler:           fhirHandler,
+		labOrderHandler:       labOrderHandler,
+		labEventHandler:       labEventHandler,
+		webhookHandler:        webhookHandler,
 	})
 
 	// Azure App Service and cloud platforms set PORT env; default 8080
@@ -94,6 +294,23 @@ func Run() error {
 		port = "8080"
 	}
 
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
 	log.Printf("Server running on port %s", port)
-	return r.Run(":" + port)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	log.Printf("Shutting down server")
+	shutdownCtx, done := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer done()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down server: %w", err)
+	}
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 
 	"b2b-diagnostic-aggregator/apis/internal/handlers"
 	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,9 +32,9 @@ func registerMiddleware(r *gin.Engine, dbReady bool) {
 	}
 }
 
-func registerRoutes(r *gin.Engine, jwtSecret string, deps routeDeps) {
+func registerRoutes(r *gin.Engine, jwtSecret string, sessions middleware.SessionValidator, deps routeDeps) {
 	registerPublicRoutes(r, deps)
-	registerProtectedRoutes(r, jwtSecret, deps)
+	registerProtectedRoutes(r, jwtSecret, sessions, deps)
 }
 
 type routeDeps struct {
@@ -44,7 +45,20 @@ type routeDeps struct {
 	employeeHandler       *handlers.EmployeeHandler
 	labHandler            *handlers.LabHandler
 	leadHandler           *handlers.LeadHandler
+	leadStreamHandler     *handlers.LeadStreamHandler
 	testHandler           *handlers.TestHandler
+	auditHandler          *handlers.AuditHandler
+	patientHandler        *handlers.PatientHandler
+	labRoutingHandler     *handlers.LabRoutingHandler
+	serviceabilityHandler *handlers.ServiceabilityHandler
+	appointmentHandler    *handlers.AppointmentHandler
+	sampleHandler         *handlers.SampleHandler
+	reportHandler         *handlers.ReportHandler
+	testResultHandler     *handlers.TestResultHandler
+	fhirHandler           *handlers.FHIRHandler
+	labOrderHandler       *handlers.LabOrderHandler
+	labEventHandler       *handlers.LabEventHandler
+	webhookHandler        *handlers.WebhookHandler
 }
 
 func registerPublicRoutes(r *gin.Engine, deps routeDeps) {
@@ -52,127 +66,464 @@ func registerPublicRoutes(r *gin.Engine, deps routeDeps) {
 	login := v1.Group("/login")
 	{
 		login.POST("", deps.loginHandler.Login)
+		login.POST("/refresh", deps.loginHandler.RefreshToken)
 		login.POST("/forgot-password", deps.loginHandler.ForgotPasswordReset)
 		login.POST("/forgot-password-key", deps.loginHandler.CreateForgotPasswordKey)
-		login.GET("/forgot-password-key", deps.loginHandler.GetForgotPasswordKey)
 		login.POST("/change-password", deps.loginHandler.ChangePassword)
 		login.GET("/profile", deps.loginHandler.GetProfile) // public with X-Domain + userId or mobileNumber
 	}
+	v1.GET("/reports/:id/download", deps.reportHandler.Download)              // authorized by the signed URL
+	v1.POST("/integrations/labs/:labId/events", deps.labEventHandler.Receive) // authorized by the lab's HMAC signature
 	r.GET("/ping", handlers.Ping)
 }
 
-func registerProtectedRoutes(r *gin.Engine, jwtSecret string, deps routeDeps) {
+func registerProtectedRoutes(r *gin.Engine, jwtSecret string, sessions middleware.SessionValidator, deps routeDeps) {
 	v1 := r.Group("/api/v1")
 	api := v1.Group("")
-	api.Use(middleware.AuthMiddleware(jwtSecret))
+	api.Use(middleware.AuthMiddleware(jwtSecret, sessions))
 	{
+		api.POST("/logout", deps.loginHandler.Logout)
+		api.POST("/login/unlock", middleware.RequirePermission(loginAdminPermissions, middleware.ActionUpdate), deps.loginHandler.UnlockAccount)
 		registerPackageRoutes(api, deps.packageHandler)
 		registerClientRoutes(api, deps.clientHandler)
 		registerClientLocationRoutes(api, deps.clientLocationHandler)
 		registerEmployeeRoutes(api, deps.employeeHandler)
 		registerLabRoutes(api, deps.labHandler)
 		registerLeadRoutes(api, deps.leadHandler)
+		registerLeadStreamRoutes(api, deps.leadStreamHandler)
+		registerLabRoutingRoutes(api, deps.labRoutingHandler)
+		registerLabOrderRoutes(api, deps.labOrderHandler)
+		registerLabEventRoutes(api, deps.labEventHandler)
 		registerTestRoutes(api, deps.testHandler)
+		registerPatientRoutes(api, deps.patientHandler)
+		registerAppointmentRoutes(api, deps.appointmentHandler)
+		registerSampleRoutes(api, deps.sampleHandler)
+		registerReportRoutes(api, deps.reportHandler)
+		registerTestResultRoutes(api, deps.testResultHandler)
+		registerFHIRRoutes(api, deps.fhirHandler)
+		registerWebhookRoutes(api, deps.webhookHandler)
+		api.GET("/serviceability", middleware.RequirePermission(serviceabilityPermissions, middleware.ActionRead), deps.serviceabilityHandler.Get)
+		api.GET("/audit", middleware.RequirePermission(auditPermissions, middleware.ActionRead), deps.auditHandler.GetAll)
 	}
 }
 
+// Permission matrices per route group: which user types may perform each action.
+var (
+	employeeOnly      = []int{utils.UserTypeEmployee}
+	employeeAndClient = []int{utils.UserTypeEmployee, utils.UserTypeClient}
+	employeeAndLab    = []int{utils.UserTypeEmployee, utils.UserTypeLab}
+	allUserTypes      = []int{utils.UserTypeEmployee, utils.UserTypeClient, utils.UserTypeLab}
+
+	loginAdminPermissions = middleware.PermissionMatrix{
+		middleware.ActionUpdate: employeeOnly,
+	}
+	auditPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead: employeeOnly,
+	}
+	packagePermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+		middleware.ActionDelete: employeeOnly,
+	}
+	packageClientMappingPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeAndClient,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+	packageLabMappingPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeAndLab,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+	clientPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeOnly,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+		middleware.ActionDelete: employeeOnly,
+	}
+	clientLocationPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeAndClient,
+		middleware.ActionCreate: employeeAndClient,
+		middleware.ActionUpdate: employeeAndClient,
+		middleware.ActionDelete: employeeAndClient,
+	}
+	employeePermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeOnly,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+		middleware.ActionDelete: employeeOnly,
+	}
+	labPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeOnly,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+		middleware.ActionDelete: employeeOnly,
+	}
+	leadPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeAndClient,
+		middleware.ActionUpdate: employeeAndLab,
+		middleware.ActionDelete: employeeOnly,
+		middleware.ActionBulk:   employeeOnly,
+	}
+	patientPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead: employeeAndClient,
+	}
+	labRoutingPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+	labIntegrationPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+	labOrderPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeAndLab,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+	webhookPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   employeeAndClient,
+		middleware.ActionCreate: employeeAndClient,
+		middleware.ActionUpdate: employeeAndClient,
+		middleware.ActionDelete: employeeAndClient,
+	}
+	labSlotPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+	labAppointmentPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead: employeeAndLab,
+	}
+	appointmentPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeAndClient,
+		middleware.ActionUpdate: allUserTypes,
+	}
+	samplePermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeAndLab,
+		middleware.ActionUpdate: employeeAndLab,
+	}
+	reportPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeAndLab,
+		middleware.ActionUpdate: employeeAndLab,
+	}
+	resultPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeAndLab,
+	}
+	fhirPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead: allUserTypes,
+	}
+	serviceabilityPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead: employeeOnly,
+	}
+	testPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead: allUserTypes,
+	}
+	testParameterPermissions = middleware.PermissionMatrix{
+		middleware.ActionRead:   allUserTypes,
+		middleware.ActionCreate: employeeOnly,
+		middleware.ActionUpdate: employeeOnly,
+	}
+)
+
 func registerPackageRoutes(api *gin.RouterGroup, handler *handlers.PackageHandler) {
 	packages := api.Group("/packages")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(packagePermissions, action)
+	}
+	canClientMapping := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(packageClientMappingPermissions, action)
+	}
+	canLabMapping := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(packageLabMappingPermissions, action)
+	}
 	{
-		packages.GET("", handler.GetAll)
-		packages.GET("/", handler.GetAll)
-		packages.GET("/with-tests-details", handler.GetAllWithTestsDetails)
-		packages.GET("/:id", handler.GetByID)
-		packages.POST("", handler.Create)
-		packages.POST("/", handler.Create)
-		packages.POST("/with-tests", handler.CreateWithTests)
-		packages.PUT("/:id", handler.UpdatePackageStatus)
-		packages.DELETE("/:id", handler.Delete)
-		packages.POST("/client-mapping", handler.CreatePackageClientMapping)
-		packages.GET("/client-mapping", handler.GetAllPackageClientMappings)
-		packages.PUT("/client-mapping/:id", handler.UpdatePackageClientMappingStatus)
-		packages.POST("/lab-mapping", handler.CreatePackageLabMapping)
-		packages.GET("/lab-mapping", handler.GetAllPackageLabMappings)
-		packages.PUT("/lab-mapping/:id", handler.UpdatePackageLabMappingStatus)
+		packages.GET("", can(middleware.ActionRead), handler.GetAll)
+		packages.GET("/", can(middleware.ActionRead), handler.GetAll)
+		packages.GET("/with-tests-details", can(middleware.ActionRead), handler.GetAllWithTestsDetails)
+		packages.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		packages.POST("", can(middleware.ActionCreate), handler.Create)
+		packages.POST("/", can(middleware.ActionCreate), handler.Create)
+		packages.POST("/with-tests", can(middleware.ActionCreate), handler.CreateWithTests)
+		packages.PUT("/:id", can(middleware.ActionUpdate), handler.UpdatePackageStatus)
+		packages.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
+		packages.POST("/client-mapping", canClientMapping(middleware.ActionCreate), handler.CreatePackageClientMapping)
+		packages.GET("/client-mapping", canClientMapping(middleware.ActionRead), handler.GetAllPackageClientMappings)
+		packages.PUT("/client-mapping/:id", canClientMapping(middleware.ActionUpdate), handler.UpdatePackageClientMappingStatus)
+		packages.POST("/lab-mapping", canLabMapping(middleware.ActionCreate), handler.CreatePackageLabMapping)
+		packages.GET("/lab-mapping", canLabMapping(middleware.ActionRead), handler.GetAllPackageLabMappings)
+		packages.PUT("/lab-mapping/:id", canLabMapping(middleware.ActionUpdate), handler.UpdatePackageLabMappingStatus)
 	}
 }
 
 func registerClientRoutes(api *gin.RouterGroup, handler *handlers.ClientHandler) {
 	clients := api.Group("/clients")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(clientPermissions, action)
+	}
 	{
-		clients.GET("", handler.GetAll)
-		clients.GET("/", handler.GetAll)
-		clients.GET("/:id", handler.GetByID)
-		clients.GET("/contact", handler.GetByContactNumber)
-		clients.POST("", handler.Create)
-		clients.POST("/", handler.Create)
-		clients.PUT("/:id", handler.Update)
-		clients.DELETE("/:id", handler.Delete)
+		clients.GET("", can(middleware.ActionRead), handler.GetAll)
+		clients.GET("/", can(middleware.ActionRead), handler.GetAll)
+		clients.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		clients.GET("/contact", can(middleware.ActionRead), handler.GetByContactNumber)
+		clients.POST("", can(middleware.ActionCreate), handler.Create)
+		clients.POST("/", can(middleware.ActionCreate), handler.Create)
+		clients.PUT("/:id", can(middleware.ActionUpdate), handler.Update)
+		clients.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
 	}
 }
 
 func registerClientLocationRoutes(api *gin.RouterGroup, handler *handlers.ClientLocationHandler) {
 	loc := api.Group("/client/:client_id/locations")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(clientLocationPermissions, action)
+	}
 	{
-		loc.GET("", handler.GetAllByClientID)
-		loc.GET("/", handler.GetAllByClientID)
-		loc.GET("/:id", handler.GetByID)
-		loc.POST("", handler.Create)
-		loc.POST("/", handler.Create)
-		loc.PUT("/:id", handler.Update)
-		loc.DELETE("/:id", handler.Delete)
+		loc.GET("", can(middleware.ActionRead), handler.GetAllByClientID)
+		loc.GET("/", can(middleware.ActionRead), handler.GetAllByClientID)
+		loc.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		loc.POST("", can(middleware.ActionCreate), handler.Create)
+		loc.POST("/", can(middleware.ActionCreate), handler.Create)
+		loc.PUT("/:id", can(middleware.ActionUpdate), handler.Update)
+		loc.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
 	}
 }
 
 func registerEmployeeRoutes(api *gin.RouterGroup, handler *handlers.EmployeeHandler) {
 	employees := api.Group("/employees")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(employeePermissions, action)
+	}
 	{
-		employees.GET("", handler.GetAll)
-		employees.GET("/", handler.GetAll)
-		employees.GET("/search", handler.GetByContactNumber)
-		employees.GET("/:id", handler.GetByID)
-		employees.POST("", handler.Create)
-		employees.POST("/", handler.Create)
-		employees.PUT("/:id", handler.Update)
-		employees.DELETE("/:id", handler.Delete)
+		employees.GET("", can(middleware.ActionRead), handler.GetAll)
+		employees.GET("/", can(middleware.ActionRead), handler.GetAll)
+		employees.GET("/search", can(middleware.ActionRead), handler.GetByContactNumber)
+		employees.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		employees.POST("", can(middleware.ActionCreate), handler.Create)
+		employees.POST("/", can(middleware.ActionCreate), handler.Create)
+		employees.PUT("/:id", can(middleware.ActionUpdate), handler.Update)
+		employees.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
 	}
 }
 
 func registerLabRoutes(api *gin.RouterGroup, handler *handlers.LabHandler) {
 	labs := api.Group("/labs")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(labPermissions, action)
+	}
 	{
-		labs.GET("", handler.GetAll)
-		labs.GET("/", handler.GetAll)
-		labs.GET("/:id", handler.GetByID)
-		labs.GET("/contact", handler.GetByContactNumber)
-		labs.POST("", handler.Create)
-		labs.POST("/", handler.Create)
-		labs.PUT("/:id", handler.Update)
-		labs.DELETE("/:id", handler.Delete)
+		labs.GET("", can(middleware.ActionRead), handler.GetAll)
+		labs.GET("/", can(middleware.ActionRead), handler.GetAll)
+		labs.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		labs.GET("/contact", can(middleware.ActionRead), handler.GetByContactNumber)
+		labs.POST("", can(middleware.ActionCreate), handler.Create)
+		labs.POST("/", can(middleware.ActionCreate), handler.Create)
+		labs.PUT("/:id", can(middleware.ActionUpdate), handler.Update)
+		labs.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
 	}
 }
 
 func registerLeadRoutes(api *gin.RouterGroup, handler *handlers.LeadHandler) {
 	leads := api.Group("/leads")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(leadPermissions, action)
+	}
+	{
+		leads.GET("", can(middleware.ActionRead), handler.GetAll)
+		leads.GET("/", can(middleware.ActionRead), handler.GetAll)
+		leads.GET("/statuses", can(middleware.ActionRead), handler.GetStatuses)
+		leads.GET("/duplicates", can(middleware.ActionRead), handler.GetDuplicates)
+		leads.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		leads.GET("/:id/history", can(middleware.ActionRead), handler.GetHistory)
+		leads.POST("", can(middleware.ActionCreate), handler.Create)
+		leads.POST("/", can(middleware.ActionCreate), handler.Create)
+		leads.PUT("/:id", can(middleware.ActionUpdate), handler.Update)
+		leads.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
+		leads.POST("/:id/merge", can(middleware.ActionDelete), handler.Merge)
+		leads.POST("/bulk-status", can(middleware.ActionBulk), handler.BulkUpdateStatus)
+		leads.POST("/bulk-csv", can(middleware.ActionBulk), handler.BulkImportCsv)
+		leads.GET("/import-jobs/:id", can(middleware.ActionBulk), handler.GetImportJob)
+		leads.GET("/import-jobs/:id/errors.csv", can(middleware.ActionBulk), handler.GetImportJobErrors)
+	}
+}
+
+// registerLeadStreamRoutes adds the server-sent lead event stream; tenant filtering happens per event.
+func registerLeadStreamRoutes(api *gin.RouterGroup, handler *handlers.LeadStreamHandler) {
+	api.GET("/leads/stream", middleware.RequirePermission(leadPermissions, middleware.ActionRead), handler.Stream)
+}
+
+// registerLabRoutingRoutes adds lab selection under /leads; lab prices are internal, so employees only.
+func registerLabRoutingRoutes(api *gin.RouterGroup, handler *handlers.LabRoutingHandler) {
+	leads := api.Group("/leads")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(labRoutingPermissions, action)
+	}
+	{
+		leads.GET("/:id/lab-options", can(middleware.ActionRead), handler.GetLabOptions)
+		leads.POST("/:id/assign-lab", can(middleware.ActionUpdate), handler.AssignLab)
+	}
+}
+
+// registerLabOrderRoutes adds per-lab LIS integration settings under /labs and the lead's lab order
+// deliveries under /leads.
+func registerLabOrderRoutes(api *gin.RouterGroup, handler *handlers.LabOrderHandler) {
+	labs := api.Group("/labs")
+	leads := api.Group("/leads")
+	canIntegration := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(labIntegrationPermissions, action)
+	}
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(labOrderPermissions, action)
+	}
 	{
-		leads.GET("", handler.GetAll)
-		leads.GET("/", handler.GetAll)
-		leads.GET("/:id", handler.GetByID)
-		leads.POST("", handler.Create)
-		leads.POST("/", handler.Create)
-		leads.PUT("/:id", handler.Update)
-		leads.DELETE("/:id", handler.Delete)
-		leads.POST("/bulk-status", handler.BulkUpdateStatus)
-		leads.POST("/bulk-csv", handler.BulkImportCsv)
+		labs.GET("/:id/integration", canIntegration(middleware.ActionRead), handler.GetIntegration)
+		labs.PUT("/:id/integration", canIntegration(middleware.ActionUpdate), handler.SaveIntegration)
+		leads.GET("/:id/lab-orders", can(middleware.ActionRead), handler.GetLeadOrders)
+		leads.POST("/:id/lab-orders", can(middleware.ActionCreate), handler.Resend)
+		leads.POST("/:id/lab-orders/:deliveryId/retry", can(middleware.ActionUpdate), handler.Retry)
+	}
+}
+
+// registerLabEventRoutes adds rotation of the secret labs sign their status events with.
+func registerLabEventRoutes(api *gin.RouterGroup, handler *handlers.LabEventHandler) {
+	labs := api.Group("/labs")
+	{
+		labs.POST("/:id/integration/event-secret", middleware.RequirePermission(labIntegrationPermissions, middleware.ActionUpdate), handler.RotateSecret)
+	}
+}
+
+// registerWebhookRoutes adds client webhook subscriptions, the dead-letter list and redelivery.
+func registerWebhookRoutes(api *gin.RouterGroup, handler *handlers.WebhookHandler) {
+	webhooks := api.Group("/webhooks")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(webhookPermissions, action)
+	}
+	{
+		webhooks.GET("", can(middleware.ActionRead), handler.GetAll)
+		webhooks.POST("", can(middleware.ActionCreate), handler.Create)
+		webhooks.GET("/dead-letters", can(middleware.ActionRead), handler.GetDeadLetters)
+		webhooks.POST("/deliveries/:deliveryId/redeliver", can(middleware.ActionUpdate), handler.Redeliver)
+		webhooks.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		webhooks.PUT("/:id", can(middleware.ActionUpdate), handler.Update)
+		webhooks.DELETE("/:id", can(middleware.ActionDelete), handler.Delete)
 	}
 }
 
 func registerTestRoutes(api *gin.RouterGroup, handler *handlers.TestHandler) {
 	tests := api.Group("/tests")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(testPermissions, action)
+	}
+	{
+		tests.GET("", can(middleware.ActionRead), handler.GetAll)
+		tests.GET("/", can(middleware.ActionRead), handler.GetAll)
+		tests.GET("/active", can(middleware.ActionRead), handler.GetActive)
+		tests.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+	}
+}
+
+func registerPatientRoutes(api *gin.RouterGroup, handler *handlers.PatientHandler) {
+	patients := api.Group("/patients")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(patientPermissions, action)
+	}
+	{
+		patients.GET("/:id", can(middleware.ActionRead), handler.GetByID)
+		patients.GET("/:id/leads", can(middleware.ActionRead), handler.GetLeads)
+	}
+}
+
+// registerAppointmentRoutes adds lab slot configuration and availability under /labs, booking under
+// /leads and reschedule/cancel under /appointments. Lab users only see their own lab's day list.
+func registerAppointmentRoutes(api *gin.RouterGroup, handler *handlers.AppointmentHandler) {
+	labs := api.Group("/labs")
+	leads := api.Group("/leads")
+	appointments := api.Group("/appointments")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(appointmentPermissions, action)
+	}
+	canSlot := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(labSlotPermissions, action)
+	}
+	canLabList := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(labAppointmentPermissions, action)
+	}
+	{
+		labs.GET("/:id/slots", canSlot(middleware.ActionRead), handler.GetLabSlots)
+		labs.POST("/:id/slots", canSlot(middleware.ActionCreate), handler.CreateLabSlot)
+		labs.PUT("/:id/slots/:slotId", canSlot(middleware.ActionUpdate), handler.UpdateLabSlot)
+		labs.GET("/:id/appointments", canLabList(middleware.ActionRead), handler.GetLabAppointments)
+		leads.GET("/:id/appointments", can(middleware.ActionRead), handler.GetLeadAppointments)
+		leads.POST("/:id/appointments", can(middleware.ActionCreate), handler.Book)
+		appointments.PUT("/:id", can(middleware.ActionUpdate), handler.Reschedule)
+		appointments.POST("/:id/cancel", can(middleware.ActionUpdate), handler.Cancel)
+	}
+}
+
+// registerSampleRoutes adds sample collection under /leads and the scan and barcode endpoints labs
+// use to track tubes under /samples.
+func registerSampleRoutes(api *gin.RouterGroup, handler *handlers.SampleHandler) {
+	leads := api.Group("/leads")
+	samples := api.Group("/samples")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(samplePermissions, action)
+	}
+	{
+		leads.GET("/:id/samples", can(middleware.ActionRead), handler.GetLeadSamples)
+		leads.POST("/:id/samples", can(middleware.ActionCreate), handler.Collect)
+		samples.POST("/scan", can(middleware.ActionUpdate), handler.Scan)
+		samples.GET("/:id/barcode", can(middleware.ActionRead), handler.GetBarcode)
+	}
+}
+
+// registerReportRoutes adds report upload, release and signed download links under /leads.
+func registerReportRoutes(api *gin.RouterGroup, handler *handlers.ReportHandler) {
+	leads := api.Group("/leads")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(reportPermissions, action)
+	}
+	{
+		leads.GET("/:id/reports", can(middleware.ActionRead), handler.GetReports)
+		leads.POST("/:id/reports", can(middleware.ActionCreate), handler.Upload)
+		leads.POST("/:id/reports/:reportId/release", can(middleware.ActionUpdate), handler.Release)
+		leads.GET("/:id/reports/:reportId/download-url", can(middleware.ActionRead), handler.GetDownloadURL)
+	}
+}
+
+// registerTestResultRoutes adds test parameter definitions under /tests and structured results,
+// posted by labs, under /leads.
+func registerTestResultRoutes(api *gin.RouterGroup, handler *handlers.TestResultHandler) {
+	tests := api.Group("/tests")
+	leads := api.Group("/leads")
+	canParameter := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(testParameterPermissions, action)
+	}
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(resultPermissions, action)
+	}
+	{
+		tests.GET("/:id/parameters", canParameter(middleware.ActionRead), handler.GetParameters)
+		tests.POST("/:id/parameters", canParameter(middleware.ActionCreate), handler.CreateParameter)
+		tests.PUT("/:id/parameters/:parameterId", canParameter(middleware.ActionUpdate), handler.UpdateParameter)
+		leads.GET("/:id/results", can(middleware.ActionRead), handler.GetResults)
+		leads.POST("/:id/results", can(middleware.ActionCreate), handler.RecordResults)
+	}
+}
+
+// registerFHIRRoutes adds the read-only FHIR R4 export of lead orders and results under /fhir.
+func registerFHIRRoutes(api *gin.RouterGroup, handler *handlers.FHIRHandler) {
+	fhir := api.Group("/fhir")
+	can := func(action middleware.Action) gin.HandlerFunc {
+		return middleware.RequirePermission(fhirPermissions, action)
+	}
 	{
-		tests.GET("", handler.GetAll)
-		tests.GET("/", handler.GetAll)
-		tests.GET("/active", handler.GetActive)
-		tests.GET("/:id", handler.GetByID)
+		fhir.GET("/ServiceRequest/:leadId", can(middleware.ActionRead), handler.GetServiceRequest)
+		fhir.GET("/DiagnosticReport/:leadId", can(middleware.ActionRead), handler.GetDiagnosticReport)
 	}
 }
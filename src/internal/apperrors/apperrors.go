@@ -7,8 +7,10 @@ type Kind string
 const (
 	KindBadRequest  Kind = "bad_request"
 	KindUnauthorized     = "unauthorized"
+	KindForbidden        = "forbidden"
 	KindNotFound         = "not_found"
 	KindConflict         = "conflict"
+	KindTooManyRequests  = "too_many_requests"
 	KindInternal         = "internal"
 )
 
@@ -48,6 +50,10 @@ func NewUnauthorized(message string, err error) *AppError {
 	return &AppError{Kind: KindUnauthorized, Message: message, Err: err}
 }
 
+func NewForbidden(message string, err error) *AppError {
+	return &AppError{Kind: KindForbidden, Message: message, Err: err}
+}
+
 func NewBadRequest(message string, err error) *AppError {
 	return &AppError{Kind: KindBadRequest, Message: message, Err: err}
 }
@@ -56,6 +62,10 @@ func NewConflict(message string, err error) *AppError {
 	return &AppError{Kind: KindConflict, Message: message, Err: err}
 }
 
+func NewTooManyRequests(message string, err error) *AppError {
+	return &AppError{Kind: KindTooManyRequests, Message: message, Err: err}
+}
+
 func NewInternal(message string, err error) *AppError {
 	return &AppError{Kind: KindInternal, Message: message, Err: err}
 }
@@ -0,0 +1,143 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// code128Patterns are the bar/space widths of Code 128 symbols 0-105; each sums to 11 modules.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = "2331112"
+	quietModules  = 10
+)
+
+// Options controls the rendered size. Zero values fall back to 2px modules and an 80px bar height.
+type Options struct {
+	ModuleWidth int
+	Height      int
+}
+
+func (o Options) withDefaults() Options {
+	if o.ModuleWidth <= 0 {
+		o.ModuleWidth = 2
+	}
+	if o.Height <= 0 {
+		o.Height = 80
+	}
+	return o
+}
+
+// Code128 encodes data (printable ASCII) with code set B and returns the symbol as modules, true
+// for a bar, including the check symbol and stop pattern but not the quiet zones.
+func Code128(data string) ([]bool, error) {
+	if data == "" {
+		return nil, fmt.Errorf("barcode: empty data")
+	}
+	values := []int{code128StartB}
+	checksum := code128StartB
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		if ch < 32 || ch > 126 {
+			return nil, fmt.Errorf("barcode: character %q cannot be encoded", ch)
+		}
+		v := int(ch) - 32
+		values = append(values, v)
+		checksum += v * (i + 1)
+	}
+	values = append(values, checksum%103)
+
+	var modules []bool
+	appendPattern := func(pattern string) {
+		for i, w := range pattern {
+			for n := 0; n < int(w-'0'); n++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	for _, v := range values {
+		appendPattern(code128Patterns[v])
+	}
+	appendPattern(code128Stop)
+	return modules, nil
+}
+
+// PNG renders data as a Code 128 barcode PNG with quiet zones on both sides.
+func PNG(data string, opts Options) ([]byte, error) {
+	modules, err := Code128(data)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	width := (len(modules) + 2*quietModules) * opts.ModuleWidth
+	img := image.NewGray(image.Rect(0, 0, width, opts.Height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for i, bar := range modules {
+		if !bar {
+			continue
+		}
+		x0 := (quietModules + i) * opts.ModuleWidth
+		for x := x0; x < x0+opts.ModuleWidth; x++ {
+			for y := 0; y < opts.Height; y++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders data as a Code 128 barcode SVG with the human-readable text under the bars.
+func SVG(data string, opts Options) ([]byte, error) {
+	modules, err := Code128(data)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	width := (len(modules) + 2*quietModules) * opts.ModuleWidth
+	textSize := 14
+	height := opts.Height + textSize + 6
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		fmt.Fprintf(&buf, `<rect x="%d" y="0" width="%d" height="%d" fill="#000"/>`,
+			(quietModules+start)*opts.ModuleWidth, (i-start)*opts.ModuleWidth, opts.Height)
+	}
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="monospace" font-size="%d" text-anchor="middle">%s</text>`,
+		width/2, opts.Height+textSize+2, textSize, html.EscapeString(data))
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
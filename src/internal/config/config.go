@@ -3,18 +3,30 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Environment string
-	Port        int
-	Domain      string
-	DB          DBConfig
-	JWT         JWTConfig
-	Log         LogConfig
-	Domains     DomainURLs
+	Environment    string
+	Port           int
+	Domain         string
+	TrustedProxies []string // proxy addresses/CIDRs whose X-Forwarded-For is trusted for the client IP
+	DB             DBConfig
+	JWT            JWTConfig
+	Log            LogConfig
+	Domains        DomainURLs
+	Notification   NotificationConfig
+	Lockout        LockoutConfig
+	Leads          LeadConfig
+	Storage        StorageConfig
+	Reports        ReportConfig
+	LabOrders      LabOrderConfig
+	LabEvents      LabEventConfig
+	Webhooks       WebhookConfig
+	Events         EventOutboxConfig
+	LeadStream     LeadStreamConfig
 }
 
 type DBConfig struct {
@@ -32,6 +44,7 @@ type DBConfig struct {
 
 type JWTConfig struct {
 	Secret           string
+	RefreshSecret    string // signs refresh tokens; falls back to Secret when empty
 	ExpiresIn        string
 	RefreshExpiresIn string
 }
@@ -41,6 +54,82 @@ type LogConfig struct {
 	RetentionHours int
 }
 
+type NotificationConfig struct {
+	Driver   string // "log" (default) or "file"
+	FilePath string // output file for the file driver
+}
+
+// LockoutConfig limits failed password attempts per user and per client IP.
+type LockoutConfig struct {
+	MaxFailures   int // failures per user before the account is locked
+	IPMaxFailures int // failures per client IP before the IP is locked
+	WindowMinutes int // failures older than this are forgotten
+	LockMinutes   int // lock duration before automatic unlock
+	DelayAfter    int // failures after which attempts are progressively delayed
+	BaseDelayMs   int // first delay; doubles with each further failure
+	MaxDelayMs    int
+}
+
+type LeadConfig struct {
+	StatusWorkflowFile        string // JSON status catalog + transition graph; empty = built-in workflow
+	DuplicateWindowDays       int    // leads matching an earlier one within this many days are duplicates; 0 disables
+	ImportPollIntervalSeconds int    // how often the import worker looks for queued CSV imports; 0 disables the worker
+	ImportStaleMinutes        int    // a running import with no progress for this long is failed as interrupted
+}
+
+// StorageConfig selects where uploaded files (lab reports) are kept.
+type StorageConfig struct {
+	Driver      string // "local" (default) or "s3"
+	LocalDir    string // root directory for the local driver
+	S3Endpoint  string // S3-compatible endpoint, e.g. http://localhost:9000 for a MinIO emulator
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool // bucket in the URL path instead of the host name (most emulators)
+}
+
+type ReportConfig struct {
+	MaxUploadMB           int    // largest accepted report PDF
+	SigningSecret         string // signs report download URLs; derived from the JWT secret when empty
+	DownloadURLTTLMinutes int    // how long a signed download URL stays valid
+}
+
+// LabOrderConfig drives the queue that pushes lead orders to lab LIS integrations.
+type LabOrderConfig struct {
+	DispatchIntervalSeconds int // how often due deliveries are sent; 0 disables the dispatcher
+	BatchSize               int // deliveries sent per dispatch run
+	MaxAttempts             int // attempts per delivery when the lab's integration sets none
+}
+
+// LabEventConfig covers signed status events labs push to the public events endpoint.
+type LabEventConfig struct {
+	ToleranceSeconds int // how far a request's X-Lab-Timestamp may be from server time
+}
+
+// WebhookConfig drives delivery of client webhook events.
+type WebhookConfig struct {
+	DispatchIntervalSeconds int // how often due deliveries are sent; 0 disables the dispatcher
+	BatchSize               int // deliveries sent per dispatch run
+	MaxAttempts             int // attempts before a delivery is dead-lettered
+	TimeoutSeconds          int // per request
+}
+
+// EventOutboxConfig drives dispatch of outbox domain events to in-process subscribers.
+type EventOutboxConfig struct {
+	DispatchIntervalSeconds int // how often due events are dispatched; 0 disables the dispatcher
+	BatchSize               int // events dispatched per run
+	MaxAttempts             int // dispatches before an event is marked failed
+}
+
+// LeadStreamConfig drives GET /leads/stream.
+type LeadStreamConfig struct {
+	PollIntervalMillis int // how often the outbox is read for new lead events
+	HeartbeatSeconds   int // idle time before a heartbeat comment is sent
+	BufferSize         int // events held per client before a slow client is disconnected
+	ReplayLimit        int // most events replayed to a resuming client before it is told to reload
+}
+
 type DomainURLs struct {
 	Client   string
 	Employee string
@@ -55,9 +144,10 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
-		Port:        getEnvAsInt("PORT", 8080),
-		Domain:      getEnv("DOMAIN", ""),
+		Environment:    getEnv("ENVIRONMENT", "development"),
+		Port:           getEnvAsInt("PORT", 8080),
+		Domain:         getEnv("DOMAIN", ""),
+		TrustedProxies: getEnvAsList("TRUSTED_PROXIES"),
 		DB: DBConfig{
 			Server:                 getEnv("DB_SERVER", ""),
 			User:                   getEnv("DB_USER", ""),
@@ -72,6 +162,7 @@ func LoadConfig() *Config {
 		},
 		JWT: JWTConfig{
 			Secret:           getEnv("JWT_SECRET", ""),
+			RefreshSecret:    getEnv("JWT_REFRESH_SECRET", ""),
 			ExpiresIn:        getEnv("JWT_EXPIRES_IN", "24h"),
 			RefreshExpiresIn: getEnv("JWT_REFRESH_EXPIRES_IN", "7d"),
 		},
@@ -84,6 +175,65 @@ func LoadConfig() *Config {
 			Employee: getEnv("EMPLOYEE_DOMAIN_URL", ""),
 			Lab:      getEnv("LAB_DOMAIN_URL", ""),
 		},
+		Notification: NotificationConfig{
+			Driver:   getEnv("NOTIFIER_DRIVER", "log"),
+			FilePath: getEnv("NOTIFIER_FILE_PATH", "logs/notifications.log"),
+		},
+		Lockout: LockoutConfig{
+			MaxFailures:   getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+			IPMaxFailures: getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+			WindowMinutes: getEnvAsInt("LOGIN_FAILURE_WINDOW_MINUTES", 15),
+			LockMinutes:   getEnvAsInt("LOGIN_LOCK_MINUTES", 15),
+			DelayAfter:    getEnvAsInt("LOGIN_DELAY_AFTER", 2),
+			BaseDelayMs:   getEnvAsInt("LOGIN_BASE_DELAY_MS", 1000),
+			MaxDelayMs:    getEnvAsInt("LOGIN_MAX_DELAY_MS", 30000),
+		},
+		Leads: LeadConfig{
+			StatusWorkflowFile:        getEnv("LEAD_STATUS_WORKFLOW_FILE", ""),
+			DuplicateWindowDays:       getEnvAsInt("LEAD_DUPLICATE_WINDOW_DAYS", 30),
+			ImportPollIntervalSeconds: getEnvAsInt("LEAD_IMPORT_POLL_INTERVAL_SECONDS", 5),
+			ImportStaleMinutes:        getEnvAsInt("LEAD_IMPORT_STALE_MINUTES", 5),
+		},
+		Storage: StorageConfig{
+			Driver:      getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:    getEnv("STORAGE_LOCAL_DIR", "storage"),
+			S3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvAsBool("STORAGE_S3_PATH_STYLE", true),
+		},
+		Reports: ReportConfig{
+			MaxUploadMB:           getEnvAsInt("REPORT_MAX_UPLOAD_MB", 20),
+			SigningSecret:         getEnv("REPORT_SIGNING_SECRET", ""),
+			DownloadURLTTLMinutes: getEnvAsInt("REPORT_DOWNLOAD_URL_TTL_MINUTES", 15),
+		},
+		LabOrders: LabOrderConfig{
+			DispatchIntervalSeconds: getEnvAsInt("LAB_ORDER_DISPATCH_INTERVAL_SECONDS", 30),
+			BatchSize:               getEnvAsInt("LAB_ORDER_BATCH_SIZE", 20),
+			MaxAttempts:             getEnvAsInt("LAB_ORDER_MAX_ATTEMPTS", 5),
+		},
+		LabEvents: LabEventConfig{
+			ToleranceSeconds: getEnvAsInt("LAB_EVENT_TOLERANCE_SECONDS", 300),
+		},
+		Webhooks: WebhookConfig{
+			DispatchIntervalSeconds: getEnvAsInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 10),
+			BatchSize:               getEnvAsInt("WEBHOOK_BATCH_SIZE", 50),
+			MaxAttempts:             getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			TimeoutSeconds:          getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+		},
+		Events: EventOutboxConfig{
+			DispatchIntervalSeconds: getEnvAsInt("EVENT_DISPATCH_INTERVAL_SECONDS", 2),
+			BatchSize:               getEnvAsInt("EVENT_BATCH_SIZE", 100),
+			MaxAttempts:             getEnvAsInt("EVENT_MAX_ATTEMPTS", 10),
+		},
+		LeadStream: LeadStreamConfig{
+			PollIntervalMillis: getEnvAsInt("LEAD_STREAM_POLL_INTERVAL_MS", 1000),
+			HeartbeatSeconds:   getEnvAsInt("LEAD_STREAM_HEARTBEAT_SECONDS", 15),
+			BufferSize:         getEnvAsInt("LEAD_STREAM_BUFFER_SIZE", 64),
+			ReplayLimit:        getEnvAsInt("LEAD_STREAM_REPLAY_LIMIT", 1000),
+		},
 	}
 }
 
@@ -109,3 +259,14 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvAsList splits a comma-separated value, dropping empty entries; nil when unset.
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Actor is the authenticated user performing a change (UserType 1=employee, 2=client, 3=lab).
+// RequestID is the X-Request-Id of the HTTP request, recorded in the audit trail.
+type Actor struct {
+	UserID    int64
+	UserType  int
+	RequestID string
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// AppointmentDateLayout is the date format used for appointment dates in requests and queries.
+const AppointmentDateLayout = "2006-01-02"
+
+// Appointment statuses stored in tbl_Appointments.Status.
+const (
+	AppointmentStatusBooked    = "BOOKED"
+	AppointmentStatusCancelled = "CANCELLED"
+)
+
+// LabSlot is a daily collection window offered by a lab. A slot with a Pincode is reserved for that
+// pincode; a slot without one serves every pincode the lab collects from. Capacity is the number of
+// appointments the slot takes per day.
+type LabSlot struct {
+	SlotID        int64
+	LabID         int64
+	Pincode       string
+	StartTime     string // HH:MM
+	EndTime       string // HH:MM
+	Capacity      int
+	IsActive      bool
+	CreatedBy     int64
+	CreatedOn     time.Time
+	LastUpdatedBy int64
+	LastUpdatedOn time.Time
+}
+
+// Serves reports whether the slot can be booked for a collection at pincode.
+func (s LabSlot) Serves(pincode string) bool {
+	return s.Pincode == "" || s.Pincode == pincode
+}
+
+// Window formats the slot's time window, e.g. "09:00-11:00".
+func (s LabSlot) Window() string {
+	return s.StartTime + "-" + s.EndTime
+}
+
+// Appointment is a home collection booked for a lead in a lab slot on a given date. The slot's
+// window and the lead's pincode are copied so the appointment reads correctly after the slot changes.
+type Appointment struct {
+	AppointmentID   int64
+	LeadID          int64
+	LabID           int64
+	SlotID          int64
+	AppointmentDate time.Time
+	StartTime       string
+	EndTime         string
+	Pincode         string
+	Status          string
+	CancelReason    string
+	CreatedBy       int64
+	CreatedOn       time.Time
+	LastUpdatedBy   int64
+	LastUpdatedOn   time.Time
+}
+
+// Describe formats the appointment's date and window for history entries.
+func (a Appointment) Describe() string {
+	return fmt.Sprintf("%s %s-%s", a.AppointmentDate.Format(AppointmentDateLayout), a.StartTime, a.EndTime)
+}
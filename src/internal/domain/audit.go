@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// AuditEntry records one create/update/delete of a master entity. Before and After hold the entity
+// as JSON ("" when there is no before/after state, e.g. for create/delete).
+type AuditEntry struct {
+	AuditID    int64
+	EntityType string
+	EntityID   int64
+	Action     string
+	ActorID    int64
+	ActorType  int
+	RequestID  string
+	Before     string
+	After      string
+	CreatedOn  time.Time
+}
+
+// Audited entity types.
+const (
+	AuditEntityClient               = "CLIENT"
+	AuditEntityClientLocation       = "CLIENT_LOCATION"
+	AuditEntityEmployee             = "EMPLOYEE"
+	AuditEntityLab                  = "LAB"
+	AuditEntityLabIntegration       = "LAB_INTEGRATION"
+	AuditEntityLabSlot              = "LAB_SLOT"
+	AuditEntityPackage              = "PACKAGE"
+	AuditEntityPackageClientMapping = "PACKAGE_CLIENT_MAPPING"
+	AuditEntityPackageLabMapping    = "PACKAGE_LAB_MAPPING"
+	AuditEntityTestParameter        = "TEST_PARAMETER"
+	AuditEntityWebhookSubscription  = "WEBHOOK_SUBSCRIPTION"
+)
+
+// Audited actions.
+const (
+	AuditActionCreate = "CREATE"
+	AuditActionUpdate = "UPDATE"
+	AuditActionDelete = "DELETE"
+)
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types. Services write them to the outbox in the transaction that makes the change;
+// the event dispatcher hands them to in-process subscribers afterwards.
+const (
+	EventLeadCreated        = "LeadCreated"
+	EventLeadUpdated        = "LeadUpdated"
+	EventLeadStatusChanged  = "LeadStatusChanged"
+	EventLeadDeleted        = "LeadDeleted"
+	EventReportReleased     = "ReportReleased"
+	EventPackageDeactivated = "PackageDeactivated"
+	EventPriceChanged       = "PriceChanged"
+)
+
+// Aggregates domain events belong to.
+const (
+	AggregateLead    = "LEAD"
+	AggregatePackage = "PACKAGE"
+)
+
+// Outbox statuses stored in tbl_OutboxEvents.Status. A pending event is dispatched until every
+// subscriber has handled it (processed) or its attempts run out (failed).
+const (
+	OutboxStatusPending   = "PENDING"
+	OutboxStatusProcessed = "PROCESSED"
+	OutboxStatusFailed    = "FAILED"
+)
+
+// DomainEvent is an outbox entry. EventID is the outbox sequence number, assigned when the event is
+// written; Payload is the JSON of the event's data (LeadCreated, LeadStatusChanged, ...).
+type DomainEvent struct {
+	EventID       int64
+	Type          string
+	AggregateType string
+	AggregateID   int64
+	Payload       string
+	OccurredOn    time.Time
+	Status        string
+	Attempts      int
+	NextAttemptOn *time.Time
+	LastError     string
+	ProcessedOn   *time.Time
+}
+
+// NewDomainEvent builds a pending event carrying data as its payload.
+func NewDomainEvent(eventType, aggregateType string, aggregateID int64, data interface{}) (DomainEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return DomainEvent{}, err
+	}
+	now := time.Now()
+	return DomainEvent{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(payload),
+		OccurredOn:    now,
+		Status:        OutboxStatusPending,
+		NextAttemptOn: &now,
+	}, nil
+}
+
+// Decode unmarshals the payload into the event's data struct.
+func (e DomainEvent) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(e.Payload), v)
+}
+
+// OutboxRetryDelay is the wait before dispatching an event again after attempt failures: ten seconds,
+// doubling per attempt, at most an hour.
+func OutboxRetryDelay(attempt int) time.Duration {
+	delay := 10 * time.Second
+	for i := 1; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// LeadCreated is the data of EventLeadCreated.
+type LeadCreated struct {
+	LeadID      int64  `json:"leadId"`
+	ClientID    int64  `json:"clientId"`
+	LabID       *int64 `json:"labId,omitempty"`
+	PackageID   int    `json:"packageId"`
+	PatientCode string `json:"patientCode,omitempty"`
+	StatusID    int8   `json:"statusId"`
+}
+
+// LeadUpdated is the data of EventLeadUpdated: fields other than the status changed. Fields names the
+// changed fields without their values.
+type LeadUpdated struct {
+	LeadID      int64    `json:"leadId"`
+	ClientID    int64    `json:"clientId"`
+	LabID       *int64   `json:"labId,omitempty"`
+	PackageID   int      `json:"packageId"`
+	PatientCode string   `json:"patientCode,omitempty"`
+	StatusID    int8     `json:"statusId"`
+	Fields      []string `json:"fields"`
+}
+
+// LeadStatusChanged is the data of EventLeadStatusChanged.
+type LeadStatusChanged struct {
+	LeadID       int64  `json:"leadId"`
+	ClientID     int64  `json:"clientId"`
+	LabID        *int64 `json:"labId,omitempty"`
+	PackageID    int    `json:"packageId"`
+	PatientCode  string `json:"patientCode,omitempty"`
+	FromStatusID int8   `json:"fromStatusId"`
+	ToStatusID   int8   `json:"toStatusId"`
+}
+
+// LeadDeleted is the data of EventLeadDeleted: the lead was deleted, or merged into MergedIntoLeadID
+// as a duplicate.
+type LeadDeleted struct {
+	LeadID           int64  `json:"leadId"`
+	ClientID         int64  `json:"clientId"`
+	LabID            *int64 `json:"labId,omitempty"`
+	PackageID        int    `json:"packageId"`
+	PatientCode      string `json:"patientCode,omitempty"`
+	StatusID         int8   `json:"statusId"`
+	MergedIntoLeadID *int64 `json:"mergedIntoLeadId,omitempty"`
+}
+
+// ReportReleased is the data of EventReportReleased.
+type ReportReleased struct {
+	LeadID     int64      `json:"leadId"`
+	ClientID   int64      `json:"clientId"`
+	ReportID   int64      `json:"reportId"`
+	Version    int        `json:"version"`
+	ReleasedOn *time.Time `json:"releasedOn"`
+}
+
+// PackageDeactivated is the data of EventPackageDeactivated.
+type PackageDeactivated struct {
+	PackageID      int    `json:"packageId"`
+	PackageName    string `json:"packageName"`
+	ClientMappings int    `json:"clientMappings"` // client prices deactivated with the package
+	LabMappings    int    `json:"labMappings"`    // lab prices deactivated with the package
+}
+
+// PriceChanged is the data of EventPriceChanged: the package's price for one client or one lab was
+// set. PreviousPrice is nil when there was no price before.
+type PriceChanged struct {
+	PackageID     int      `json:"packageId"`
+	ClientID      *int64   `json:"clientId,omitempty"`
+	LabID         *int64   `json:"labId,omitempty"`
+	PreviousPrice *float64 `json:"previousPrice"`
+	Price         float64  `json:"price"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// Lab event types labs push to POST /integrations/labs/:labId/events.
+const (
+	LabEventSampleReceived = "SAMPLE_RECEIVED"
+	LabEventReportReady    = "REPORT_READY"
+)
+
+// IsLabEventType reports whether eventType is an event labs may push.
+func IsLabEventType(eventType string) bool {
+	return eventType == LabEventSampleReceived || eventType == LabEventReportReady
+}
+
+// Outcomes of a lab event: applied when it changed the lead or a sample, ignored when the lead had
+// already moved past the event.
+const (
+	LabEventOutcomeApplied = "APPLIED"
+	LabEventOutcomeIgnored = "IGNORED"
+)
+
+// LabEvent is an event a lab pushed for one of its leads. ExternalEventID is the lab's own event ID;
+// a lab sending the same ID again gets the first outcome back without the event being re-applied.
+type LabEvent struct {
+	EventID         int64
+	LabID           int64
+	ExternalEventID string
+	EventType       string
+	LeadID          int64
+	AccessionNumber string
+	OccurredOn      *time.Time
+	Outcome         string
+	Note            string
+	ReceivedOn      time.Time
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lab integration adapters stored in tbl_LabIntegrations.Adapter. Labs without a configuration are
+// manual: ops staff key the order into the lab's LIS themselves.
+const (
+	LabAdapterHTTPJSON = "HTTP_JSON"
+	LabAdapterHL7MLLP  = "HL7_MLLP"
+	LabAdapterManual   = "MANUAL"
+)
+
+// IsLabAdapter reports whether adapter is a known lab integration adapter.
+func IsLabAdapter(adapter string) bool {
+	switch adapter {
+	case LabAdapterHTTPJSON, LabAdapterHL7MLLP, LabAdapterManual:
+		return true
+	}
+	return false
+}
+
+// LabIntegration is how orders for a lab reach its LIS. Endpoint is a URL for HTTP_JSON and
+// host:port for HL7_MLLP; ReceivingApplication and ReceivingFacility fill MSH-5/MSH-6 of HL7 messages.
+// EventSecret signs the status events the lab pushes back to us.
+type LabIntegration struct {
+	LabID                int64
+	Adapter              string
+	Endpoint             string
+	AuthToken            string `json:"-"` // bearer token sent by the HTTP_JSON adapter
+	ReceivingApplication string
+	ReceivingFacility    string
+	EventSecret          string `json:"-"`
+	TimeoutSeconds       int
+	MaxAttempts          int
+	IsActive             bool
+	CreatedBy            int64
+	CreatedOn            time.Time
+	LastUpdatedBy        int64
+	LastUpdatedOn        time.Time
+}
+
+// Pushes reports whether orders for the lab are sent by an adapter rather than entered by hand.
+func (i *LabIntegration) Pushes() bool {
+	return i != nil && i.IsActive && i.Adapter != LabAdapterManual
+}
+
+// Lab order delivery statuses stored in tbl_LabOrderDeliveries.Status. A pending delivery is retried
+// with backoff until it is delivered or runs out of attempts (failed); manual deliveries are never
+// sent. Reassigning the lead to another lab supersedes its open deliveries.
+const (
+	LabOrderStatusPending    = "PENDING"
+	LabOrderStatusDelivered  = "DELIVERED"
+	LabOrderStatusFailed     = "FAILED"
+	LabOrderStatusManual     = "MANUAL"
+	LabOrderStatusSuperseded = "SUPERSEDED"
+)
+
+// LabOrderDelivery is one push of a lead's order to its assigned lab.
+type LabOrderDelivery struct {
+	DeliveryID    int64
+	LeadID        int64
+	LabID         int64
+	Adapter       string
+	Status        string
+	Attempts      int
+	MaxAttempts   int
+	NextAttemptOn *time.Time
+	LastError     string
+	ExternalRef   string // the LIS's order or message reference, when it returns one
+	CreatedBy     int64
+	CreatedOn     time.Time
+	DeliveredOn   *time.Time
+	LastUpdatedOn time.Time
+	AttemptLog    []LabOrderAttempt
+}
+
+// Reference is the order number sent to the lab (HL7 placer order number, HTTP idempotency key),
+// e.g. L00001234-17.
+func (d LabOrderDelivery) Reference() string {
+	return fmt.Sprintf("L%08d-%d", d.LeadID, d.DeliveryID)
+}
+
+// LabOrderAttempt records one delivery attempt and what the LIS answered.
+type LabOrderAttempt struct {
+	AttemptID     int64
+	DeliveryID    int64
+	AttemptNumber int
+	AttemptedOn   time.Time
+	DurationMs    int64
+	Success       bool
+	ResponseCode  string // HTTP status or HL7 acknowledgment code
+	Response      string // response body, truncated
+	Error         string
+}
+
+// LabOrderRetryDelay is the wait before the next attempt after attempt failures: one minute,
+// doubling per attempt, at most an hour.
+func LabOrderRetryDelay(attempt int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// LabOrder is the content pushed to a lab's LIS for a lead.
+type LabOrder struct {
+	Reference     string
+	LeadID        int64
+	LabID         int64
+	LabName       string
+	PatientID     int64
+	PatientName   string
+	Age           int8
+	Gender        string
+	ContactNumber string
+	Address       string
+	Pincode       string
+	PackageID     int
+	PackageName   string
+	Tests         []Test
+	OrderedOn     time.Time
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// LabOption is one lab evaluated for a lead. Eligible labs are ranked from 1 by lowest price;
+// ineligible labs have Rank 0 and list why in Reasons.
+type LabOption struct {
+	LabID    int64    `json:"labId"`
+	LabName  string   `json:"labName"`
+	Price    *float64 `json:"price,omitempty"` // lab price for the lead's package; nil when the lab does not offer it
+	Eligible bool     `json:"eligible"`
+	Rank     int      `json:"rank,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// LabRoutingCriteria is what a lab must satisfy to take a lead.
+type LabRoutingCriteria struct {
+	Pincode        string
+	CollectionType string // optional; when set the lab must offer this collection type
+	On             time.Time
+}
+
+// EvaluateLab checks a lab against the criteria. price is the lab's active price for the lead's
+// package, or nil when the lab does not offer the package.
+func EvaluateLab(lab Lab, price *float64, c LabRoutingCriteria) LabOption {
+	opt := LabOption{LabID: lab.LabID, LabName: lab.LabName, Price: price}
+	if lab.IsActive == nil || !*lab.IsActive {
+		opt.Reasons = append(opt.Reasons, "lab is inactive")
+	}
+	if !lab.CollectsFrom(c.Pincode) {
+		opt.Reasons = append(opt.Reasons, "does not collect from pincode "+c.Pincode)
+	}
+	if c.CollectionType != "" && !containsListValue(lab.CollectionTypes, c.CollectionType) {
+		opt.Reasons = append(opt.Reasons, "does not offer collection type "+c.CollectionType)
+	}
+	if price == nil {
+		opt.Reasons = append(opt.Reasons, "does not offer the package")
+	}
+	switch {
+	case lab.MOUEndDate == nil:
+		opt.Reasons = append(opt.Reasons, "no MOU on file")
+	case lab.MOUStartDate != nil && c.On.Before(*lab.MOUStartDate):
+		opt.Reasons = append(opt.Reasons, "MOU starts "+lab.MOUStartDate.Format("2006-01-02"))
+	case c.On.After(endOfDay(*lab.MOUEndDate)):
+		opt.Reasons = append(opt.Reasons, "MOU expired "+lab.MOUEndDate.Format("2006-01-02"))
+	}
+	if lab.AccreditationID == nil || *lab.AccreditationID <= 0 {
+		opt.Reasons = append(opt.Reasons, "not accredited")
+	}
+	opt.Eligible = len(opt.Reasons) == 0
+	return opt
+}
+
+// RankLabOptions orders eligible labs by lowest price (then LabID) and numbers them from 1,
+// followed by the ineligible labs.
+func RankLabOptions(options []LabOption) {
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Eligible && *a.Price != *b.Price {
+			return *a.Price < *b.Price
+		}
+		return a.LabID < b.LabID
+	})
+	for i := range options {
+		options[i].Rank = 0
+		if options[i].Eligible {
+			options[i].Rank = i + 1
+		}
+	}
+}
+
+// CollectsFrom reports whether the lab lists pincode among its collection pincodes.
+func (l Lab) CollectsFrom(pincode string) bool {
+	return containsListValue(l.CollectionPincodes, pincode)
+}
+
+// containsListValue reports whether a comma-separated list (as stored for lab pincodes and
+// collection types) contains value.
+func containsListValue(list *string, value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+	for _, item := range SplitList(list) {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitList splits a comma-separated lab list (pincodes, collection types) into trimmed, non-empty,
+// de-duplicated values.
+func SplitList(list *string) []string {
+	if list == nil {
+		return nil
+	}
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(*list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// IsPincode reports whether s is a 6-digit Indian postal code.
+func IsPincode(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
@@ -1,11 +1,15 @@
 package domain
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type Lead struct {
 	LeadID        int64
 	ClientID      int64
-	PatientID     string
+	PatientID     int64  // tbl_Patients key; 0 for leads created before patients existed
+	PatientCode   string // legacy display code: patient initials + contact number
 	PatientName   string
 	Age           int8
 	Gender        string
@@ -17,6 +21,7 @@ type Lead struct {
 	StateID       int8
 	Pincode       string
 	LeadStatusID  int8
+	LabID         *int64
 	CreatedBy     int64
 	CreatedOn     time.Time
 	LastUpdatedBy int64
@@ -26,16 +31,28 @@ type Lead struct {
 // LeadDetail is lead with resolved ClientName and PackageName for API response.
 type LeadDetail struct {
 	Lead
-	ClientName  string `json:"clientName,omitempty"`
-	PackageName string `json:"packageName,omitempty"`
+	ClientName   string `json:"clientName,omitempty"`
+	PackageName  string `json:"packageName,omitempty"`
+	NextStatuses []int8 `json:"nextStatuses"` // statuses the lead may move to next
 }
 
 type LeadHistory struct {
-	UID       int64
-	LeadID    int64
-	Action    string
-	CreatedBy int64
-	CreatedOn time.Time
+	UID           int64
+	LeadID        int64
+	Action        string
+	Reason        string // required for some status changes (e.g. cancellation)
+	CreatedBy     int64
+	CreatedByType int // user type of CreatedBy; 0 for entries recorded before it was tracked
+	CreatedOn     time.Time
+	Changes       []LeadFieldChange
+}
+
+// LeadFieldChange is the old and new value of one lead field in a history entry. Values are
+// formatted as strings; an empty value means the field was unset.
+type LeadFieldChange struct {
+	Field    string
+	OldValue string
+	NewValue string
 }
 
 const (
@@ -44,4 +61,61 @@ const (
 	LeadActionDelete       = "DELETE"
 	LeadActionStatusUpdate = "STATUS_UPDATE"
 	LeadActionCsvImport    = "CSV_IMPORT"
+	LeadActionMerge        = "MERGE"
+	LeadActionLabAssign    = "LAB_ASSIGN"
+
+	LeadActionAppointmentBook       = "APPOINTMENT_BOOK"
+	LeadActionAppointmentReschedule = "APPOINTMENT_RESCHEDULE"
+	LeadActionAppointmentCancel     = "APPOINTMENT_CANCEL"
+
+	LeadActionSampleCollect = "SAMPLE_COLLECT"
+	LeadActionSampleScan    = "SAMPLE_SCAN"
+
+	LeadActionReportUpload  = "REPORT_UPLOAD"
+	LeadActionReportRelease = "REPORT_RELEASE"
+	LeadActionResultsRecord = "RESULTS_RECORD"
+
+	LeadActionLabOrderPush = "LAB_ORDER_PUSH"
+	LeadActionLabEvent     = "LAB_EVENT"
 )
+
+// DiffLeads lists the fields that differ between before and after. Diffing against a zero Lead gives
+// the initial values of a new lead.
+func DiffLeads(before, after Lead) []LeadFieldChange {
+	var changes []LeadFieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, LeadFieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	add("ClientID", formatID(before.ClientID), formatID(after.ClientID))
+	add("PatientID", formatID(before.PatientID), formatID(after.PatientID))
+	add("PatientCode", before.PatientCode, after.PatientCode)
+	add("PatientName", before.PatientName, after.PatientName)
+	add("Age", formatID(int64(before.Age)), formatID(int64(after.Age)))
+	add("Gender", before.Gender, after.Gender)
+	add("PackageID", formatID(int64(before.PackageID)), formatID(int64(after.PackageID)))
+	add("ContactNumber", before.ContactNumber, after.ContactNumber)
+	add("Emailid", before.Emailid, after.Emailid)
+	add("Address", before.Address, after.Address)
+	add("CityID", formatID(int64(before.CityID)), formatID(int64(after.CityID)))
+	add("StateID", formatID(int64(before.StateID)), formatID(int64(after.StateID)))
+	add("Pincode", before.Pincode, after.Pincode)
+	add("LeadStatusID", formatID(int64(before.LeadStatusID)), formatID(int64(after.LeadStatusID)))
+	add("LabID", formatOptionalID(before.LabID), formatOptionalID(after.LabID))
+	return changes
+}
+
+func formatID(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func formatOptionalID(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LeadDuplicatePolicy decides when two leads look like the same order: same client, package,
+// contact number and normalized patient name, created within Window of each other. A zero Window
+// disables duplicate detection.
+type LeadDuplicatePolicy struct {
+	Window time.Duration
+}
+
+func (p LeadDuplicatePolicy) Enabled() bool {
+	return p.Window > 0
+}
+
+// IsDuplicate reports whether a and b match on the duplicate key and were created within the window.
+func (p LeadDuplicatePolicy) IsDuplicate(a, b Lead) bool {
+	if !p.Enabled() || LeadDuplicateKey(a) != LeadDuplicateKey(b) {
+		return false
+	}
+	gap := a.CreatedOn.Sub(b.CreatedOn)
+	if gap < 0 {
+		gap = -gap
+	}
+	return gap <= p.Window
+}
+
+// Group clusters leads into duplicate groups of two or more, ordered by creation time within a
+// group. Leads with the same key belong to one group while each is within the window of the
+// previous one.
+func (p LeadDuplicatePolicy) Group(leads []Lead) [][]Lead {
+	if !p.Enabled() {
+		return nil
+	}
+	sorted := append([]Lead(nil), leads...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := LeadDuplicateKey(sorted[i]), LeadDuplicateKey(sorted[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return sorted[i].CreatedOn.Before(sorted[j].CreatedOn)
+	})
+	var groups [][]Lead
+	var current []Lead
+	flush := func() {
+		if len(current) > 1 {
+			groups = append(groups, current)
+		}
+		current = nil
+	}
+	for _, lead := range sorted {
+		if len(current) > 0 && !p.IsDuplicate(current[len(current)-1], lead) {
+			flush()
+		}
+		current = append(current, lead)
+	}
+	flush()
+	return groups
+}
+
+// LeadDuplicateKey is the client, package, contact number and normalized patient name of a lead.
+func LeadDuplicateKey(l Lead) string {
+	return fmt.Sprintf("%d|%d|%s|%s", l.ClientID, l.PackageID, strings.TrimSpace(l.ContactNumber), NormalizePatientName(l.PatientName))
+}
+
+// NormalizePatientName lower-cases the name and collapses whitespace so "Ravi  Kumar" and
+// "ravi kumar" compare equal.
+func NormalizePatientName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// LeadDependents counts the records attached to a lead other than its history. Leads with any of
+// them cannot be merged away.
+type LeadDependents struct {
+	Appointments int64
+	Samples      int64
+	Reports      int64
+	Results      int64
+	LabOrders    int64
+}
+
+// Describe lists the non-zero counts, e.g. ["1 appointment", "2 samples"].
+func (d LeadDependents) Describe() []string {
+	var out []string
+	add := func(n int64, noun string) {
+		switch {
+		case n == 1:
+			out = append(out, "1 "+noun)
+		case n > 1:
+			out = append(out, fmt.Sprintf("%d %ss", n, noun))
+		}
+	}
+	add(d.Appointments, "appointment")
+	add(d.Samples, "sample")
+	add(d.Reports, "report")
+	add(d.Results, "test result")
+	add(d.LabOrders, "lab order")
+	return out
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// Lead import job statuses.
+const (
+	LeadImportQueued    = "QUEUED"
+	LeadImportRunning   = "RUNNING"
+	LeadImportCompleted = "COMPLETED"
+	LeadImportFailed    = "FAILED"
+)
+
+// LeadImportJob is a background CSV lead import. In strict mode the import is all-or-nothing;
+// otherwise valid rows are inserted and invalid rows are skipped and reported.
+type LeadImportJob struct {
+	JobID         int64
+	ClientID      int64
+	PackageID     int
+	Strict        bool
+	Status        string
+	TotalRows     int
+	ProcessedRows int
+	InsertedRows  int
+	FailedRows    int
+	Message       string
+	CreatedBy     int64
+	CreatedByType int
+	CreatedOn     time.Time
+	StartedOn     *time.Time
+	CompletedOn   *time.Time
+	LastUpdatedOn *time.Time // last progress or heartbeat while running
+}
+
+// Finished reports whether the job has stopped running.
+func (j LeadImportJob) Finished() bool {
+	return j.Status == LeadImportCompleted || j.Status == LeadImportFailed
+}
+
+// LeadImportRowError is one problem found in a CSV row. RowNumber is the 1-based line in the file
+// (the header is row 1); Column is empty when the problem is not tied to one column.
+type LeadImportRowError struct {
+	JobID     int64
+	RowNumber int
+	Column    string
+	Reason    string
+}
@@ -0,0 +1,120 @@
+package domain
+
+// Lead status IDs stored in tbl_Leads.LeadStatusID. Legacy rows with 0 are treated as New.
+const (
+	LeadStatusNew             int8 = 1
+	LeadStatusContacted       int8 = 2
+	LeadStatusScheduled       int8 = 3
+	LeadStatusSampleCollected int8 = 4
+	LeadStatusSentToLab       int8 = 5
+	LeadStatusReportReady     int8 = 6
+	LeadStatusDelivered       int8 = 7
+	LeadStatusCancelled       int8 = 8
+)
+
+// LeadStatus is one entry of the lead status catalog.
+type LeadStatus struct {
+	ID             int8   `json:"id"`
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Terminal       bool   `json:"terminal"`
+	ReasonRequired bool   `json:"reasonRequired"` // moving into this status needs a reason
+}
+
+// LeadStatusWorkflow is the status catalog plus the allowed transitions between statuses.
+type LeadStatusWorkflow struct {
+	statuses    []LeadStatus
+	byID        map[int8]LeadStatus
+	transitions map[int8][]int8
+}
+
+// NewLeadStatusWorkflow builds a workflow from a catalog and a transition graph (from -> allowed to).
+// A status with no outgoing transitions is terminal.
+func NewLeadStatusWorkflow(statuses []LeadStatus, transitions map[int8][]int8) *LeadStatusWorkflow {
+	w := &LeadStatusWorkflow{
+		byID:        make(map[int8]LeadStatus, len(statuses)),
+		transitions: make(map[int8][]int8, len(transitions)),
+	}
+	for from, to := range transitions {
+		w.transitions[from] = append([]int8(nil), to...)
+	}
+	for _, st := range statuses {
+		st.Terminal = len(w.transitions[st.ID]) == 0
+		w.statuses = append(w.statuses, st)
+		w.byID[st.ID] = st
+	}
+	return w
+}
+
+// DefaultLeadStatusWorkflow is the standard collection-to-delivery flow. Any non-terminal lead can
+// be cancelled, with a reason.
+func DefaultLeadStatusWorkflow() *LeadStatusWorkflow {
+	return NewLeadStatusWorkflow(
+		[]LeadStatus{
+			{ID: LeadStatusNew, Code: "NEW", Name: "New"},
+			{ID: LeadStatusContacted, Code: "CONTACTED", Name: "Contacted"},
+			{ID: LeadStatusScheduled, Code: "SCHEDULED", Name: "Scheduled"},
+			{ID: LeadStatusSampleCollected, Code: "SAMPLE_COLLECTED", Name: "Sample Collected"},
+			{ID: LeadStatusSentToLab, Code: "SENT_TO_LAB", Name: "Sent to Lab"},
+			{ID: LeadStatusReportReady, Code: "REPORT_READY", Name: "Report Ready"},
+			{ID: LeadStatusDelivered, Code: "DELIVERED", Name: "Delivered"},
+			{ID: LeadStatusCancelled, Code: "CANCELLED", Name: "Cancelled", ReasonRequired: true},
+		},
+		map[int8][]int8{
+			LeadStatusNew:             {LeadStatusContacted, LeadStatusScheduled, LeadStatusCancelled},
+			LeadStatusContacted:       {LeadStatusScheduled, LeadStatusCancelled},
+			LeadStatusScheduled:       {LeadStatusSampleCollected, LeadStatusContacted, LeadStatusCancelled},
+			LeadStatusSampleCollected: {LeadStatusSentToLab, LeadStatusCancelled},
+			LeadStatusSentToLab:       {LeadStatusReportReady, LeadStatusCancelled},
+			LeadStatusReportReady:     {LeadStatusDelivered},
+		},
+	)
+}
+
+// Statuses returns the catalog in display order.
+func (w *LeadStatusWorkflow) Statuses() []LeadStatus {
+	return append([]LeadStatus(nil), w.statuses...)
+}
+
+// Status looks up a status by ID (0 resolves to New).
+func (w *LeadStatusWorkflow) Status(id int8) (LeadStatus, bool) {
+	st, ok := w.byID[w.Normalize(id)]
+	return st, ok
+}
+
+// Normalize maps the legacy 0 status to New.
+func (w *LeadStatusWorkflow) Normalize(id int8) int8 {
+	if id == 0 {
+		return LeadStatusNew
+	}
+	return id
+}
+
+// InitialStatus is the status new leads start in.
+func (w *LeadStatusWorkflow) InitialStatus() int8 {
+	return LeadStatusNew
+}
+
+// NextStatuses returns the statuses a lead in status from may move to.
+func (w *LeadStatusWorkflow) NextStatuses(from int8) []int8 {
+	return append([]int8(nil), w.transitions[w.Normalize(from)]...)
+}
+
+// CanTransition reports whether from -> to is an allowed move.
+func (w *LeadStatusWorkflow) CanTransition(from, to int8) bool {
+	for _, next := range w.transitions[w.Normalize(from)] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transitions returns a copy of the full transition graph.
+func (w *LeadStatusWorkflow) Transitions() map[int8][]int8 {
+	out := make(map[int8][]int8, len(w.transitions))
+	for from, to := range w.transitions {
+		out[from] = append([]int8(nil), to...)
+	}
+	return out
+}
@@ -5,13 +5,13 @@ import "time"
 type Login struct {
 	RecordID      int64
 	UserID        int64
-	Pwd           string
+	Pwd           string `json:"-"` // bcrypt hash (or legacy AES ciphertext until next login); never serialized
 	UserType      string
 	CreatedOn     time.Time
 	LastUpdatedOn time.Time
 }
 
-// ForgotPassword represents a forgot-password reset key record
+// ForgotPassword represents a forgot-password OTP record; ForgetPasswordKey is the OTP hash, never the OTP itself
 type ForgotPassword struct {
 	Uid                 int64
 	UserID              int64
@@ -21,4 +21,17 @@ type ForgotPassword struct {
 	ExpiryTimestamp     time.Time
 	IsPasswordChanged   bool
 	IsPasswordUpdatedOn *time.Time
+	FailedAttempts      int // verification attempts claimed against the OTP, including a successful one
+}
+
+// RefreshToken is a server-side record of an issued refresh token (jti) within a login session.
+type RefreshToken struct {
+	TokenID   string
+	SessionID string
+	UserID    int64
+	UserType  string
+	ExpiresAt time.Time
+	CreatedOn time.Time
+	UsedOn    *time.Time
+	RevokedOn *time.Time
 }
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Gender codes. Reference ranges store them; leads and patients hold whatever was captured ("M",
+// "Male", "female", ...), so compare through NormalizeGender.
+const (
+	GenderMale   = "M"
+	GenderFemale = "F"
+	GenderOther  = "O"
+)
+
+// NormalizeGender maps a gender code or word (M/MALE, F/FEMALE, O/OTHER, in any case) to its gender
+// code, and anything else to "".
+func NormalizeGender(gender string) string {
+	switch strings.ToUpper(strings.TrimSpace(gender)) {
+	case "M", "MALE":
+		return GenderMale
+	case "F", "FEMALE":
+		return GenderFemale
+	case "O", "OTHER":
+		return GenderOther
+	}
+	return ""
+}
+
+// Patient is the person a lead is for. Patients belong to a client; family members sharing one
+// contact number are separate patients.
+type Patient struct {
+	PatientID     int64
+	ClientID      int64
+	FullName      string
+	Age           int8
+	Gender        string
+	ContactNumber string
+	Emailid       string
+	Address       string
+	CityID        int8
+	StateID       int8
+	Pincode       string
+	CreatedBy     int64
+	CreatedOn     time.Time
+	LastUpdatedBy int64
+	LastUpdatedOn time.Time
+}
+
+// PatientFromLead builds a new patient from the patient details captured on a lead.
+func PatientFromLead(l Lead) Patient {
+	return Patient{
+		ClientID:      l.ClientID,
+		FullName:      l.PatientName,
+		Age:           l.Age,
+		Gender:        l.Gender,
+		ContactNumber: l.ContactNumber,
+		Emailid:       l.Emailid,
+		Address:       l.Address,
+		CityID:        l.CityID,
+		StateID:       l.StateID,
+		Pincode:       l.Pincode,
+	}
+}
+
+// ApplyTo copies the patient's details onto the lead.
+func (p Patient) ApplyTo(l *Lead) {
+	l.PatientID = p.PatientID
+	l.PatientName = p.FullName
+	l.Age = p.Age
+	l.Gender = p.Gender
+	l.ContactNumber = p.ContactNumber
+	l.Emailid = p.Emailid
+	l.Address = p.Address
+	l.CityID = p.CityID
+	l.StateID = p.StateID
+	l.Pincode = p.Pincode
+}
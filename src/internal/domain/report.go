@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// Report statuses stored in tbl_LabReports.Status. Uploading an amended report supersedes the
+// previous versions; only the latest version can be released.
+const (
+	ReportStatusUploaded   = "UPLOADED"
+	ReportStatusReleased   = "RELEASED"
+	ReportStatusSuperseded = "SUPERSEDED"
+)
+
+// LabReport is one version of a lead's report PDF. The file lives in the configured storage under
+// StorageKey; Checksum is its hex SHA-256.
+type LabReport struct {
+	ReportID        int64
+	LeadID          int64
+	LabID           *int64
+	Version         int
+	FileName        string
+	ContentType     string
+	SizeBytes       int64
+	Checksum        string
+	StorageKey      string `json:"-"`
+	Status          string
+	AmendmentReason string // why this version replaces the previous one; empty for version 1
+	UploadedBy      int64
+	UploadedByType  int
+	UploadedOn      time.Time
+	ReleasedBy      *int64
+	ReleasedOn      *time.Time
+}
+
+// ReportDownloadLink is a time-limited signed link to a released report.
+type ReportDownloadLink struct {
+	ReportID  int64
+	ExpiresAt time.Time
+	Signature string
+}
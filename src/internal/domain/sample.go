@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Sample statuses stored in tbl_Samples.Status.
+const (
+	SampleStatusCollected = "COLLECTED"
+	SampleStatusInTransit = "IN_TRANSIT"
+	SampleStatusReceived  = "RECEIVED"
+	SampleStatusRejected  = "REJECTED"
+)
+
+// sampleTransitions is the sample lifecycle: collected tubes travel to the lab (or are handed over
+// directly) and the lab may reject a tube at any point before or after receiving it.
+var sampleTransitions = map[string][]string{
+	SampleStatusCollected: {SampleStatusInTransit, SampleStatusReceived, SampleStatusRejected},
+	SampleStatusInTransit: {SampleStatusReceived, SampleStatusRejected},
+	SampleStatusReceived:  {SampleStatusRejected},
+}
+
+// Sample is one collected tube of a lead, identified by its accession number (printed as a barcode).
+type Sample struct {
+	SampleID        int64
+	LeadID          int64
+	LabID           *int64
+	TubeNumber      int
+	TubeType        string
+	AccessionNumber string
+	Status          string
+	RejectionReason string
+	CollectedBy     int64
+	CollectedOn     time.Time
+	ReceivedOn      *time.Time
+	LastUpdatedBy   int64
+	LastUpdatedOn   time.Time
+}
+
+// AccessionNumber builds the accession number of a lead's tube, e.g. S00000123-02 for tube 2 of lead
+// 123. The separator keeps the lead and tube parts apart whatever their length (tube numbers grow
+// past 99 over repeated collections), and lead IDs are never reused, so accession numbers are unique.
+func AccessionNumber(leadID int64, tubeNumber int) string {
+	return fmt.Sprintf("S%08d-%02d", leadID, tubeNumber)
+}
+
+// IsSampleStatus reports whether status is a known sample status.
+func IsSampleStatus(status string) bool {
+	_, ok := sampleTransitions[status]
+	return ok || status == SampleStatusRejected
+}
+
+// CanAdvanceSample reports whether a sample may move from one status to another.
+func CanAdvanceSample(from, to string) bool {
+	for _, next := range sampleTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
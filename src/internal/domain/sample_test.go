@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestAccessionNumber(t *testing.T) {
+	tests := []struct {
+		leadID int64
+		tube   int
+		want   string
+	}{
+		{123, 2, "S00000123-02"},
+		{12345678, 101, "S12345678-101"},
+		{123456781, 1, "S123456781-01"},
+	}
+	seen := make(map[string]bool, len(tests))
+	for _, tt := range tests {
+		got := AccessionNumber(tt.leadID, tt.tube)
+		if got != tt.want {
+			t.Errorf("AccessionNumber(%d, %d) = %q, want %q", tt.leadID, tt.tube, got, tt.want)
+		}
+		if seen[got] {
+			t.Errorf("AccessionNumber(%d, %d) = %q collides with another tube", tt.leadID, tt.tube, got)
+		}
+		seen[got] = true
+	}
+}
@@ -0,0 +1,21 @@
+package domain
+
+// TenantScope limits data access to a single client or lab, derived from the caller's JWT.
+// The zero value is unrestricted (employees).
+type TenantScope struct {
+	ClientID int64
+	LabID    int64
+}
+
+// IsUnrestricted reports whether the scope allows access to every tenant's data.
+func (s TenantScope) IsUnrestricted() bool {
+	return s.ClientID == 0 && s.LabID == 0
+}
+
+// AllowsClient reports whether data owned by clientID is visible within the scope.
+func (s TenantScope) AllowsClient(clientID int64) bool {
+	if s.LabID != 0 {
+		return false
+	}
+	return s.ClientID == 0 || s.ClientID == clientID
+}
@@ -0,0 +1,113 @@
+package domain
+
+import "time"
+
+// Result flags computed against a parameter's reference range. A result without a matching range
+// has no flag.
+const (
+	ResultFlagNormal       = "NORMAL"
+	ResultFlagLow          = "LOW"
+	ResultFlagHigh         = "HIGH"
+	ResultFlagCriticalLow  = "CRITICAL_LOW"
+	ResultFlagCriticalHigh = "CRITICAL_HIGH"
+)
+
+// TestParameter is one analyte measured by a test, e.g. Hemoglobin (g/dL) for a CBC.
+type TestParameter struct {
+	ParameterID   int64
+	TestID        int
+	Analyte       string
+	Unit          string
+	DisplayOrder  int
+	IsActive      bool
+	Ranges        []ReferenceRange
+	CreatedBy     int64
+	CreatedOn     time.Time
+	LastUpdatedBy int64
+	LastUpdatedOn time.Time
+}
+
+// ReferenceRange is the normal (Low-High) and critical band of a parameter for patients of a gender
+// ("" for any) within an age band in years (inclusive). Any bound may be open (nil).
+type ReferenceRange struct {
+	RangeID      int64
+	ParameterID  int64
+	Gender       string
+	MinAge       int
+	MaxAge       int
+	Low          *float64
+	High         *float64
+	CriticalLow  *float64
+	CriticalHigh *float64
+}
+
+// Matches reports whether the range applies to a patient of the given age and gender. The patient's
+// gender may be a code or a word ("F", "Female"); a gender-specific range never matches an unknown
+// gender.
+func (r ReferenceRange) Matches(age int, gender string) bool {
+	if age < r.MinAge || age > r.MaxAge {
+		return false
+	}
+	if r.Gender == "" {
+		return true
+	}
+	code := NormalizeGender(r.Gender)
+	return code != "" && code == NormalizeGender(gender)
+}
+
+// RangeFor picks the parameter's reference range for a patient: gender-specific ranges win over
+// ranges for any gender, then the narrowest age band. It returns nil when no range applies.
+func (p TestParameter) RangeFor(age int, gender string) *ReferenceRange {
+	var best *ReferenceRange
+	for i := range p.Ranges {
+		r := &p.Ranges[i]
+		if !r.Matches(age, gender) {
+			continue
+		}
+		switch {
+		case best == nil, best.Gender == "" && r.Gender != "":
+			best = r
+		case (best.Gender == "") == (r.Gender == "") && r.MaxAge-r.MinAge < best.MaxAge-best.MinAge:
+			best = r
+		}
+	}
+	return best
+}
+
+// Flag classifies value against the range. Critical bounds take precedence over the normal band.
+func (r ReferenceRange) Flag(value float64) string {
+	switch {
+	case r.CriticalLow != nil && value <= *r.CriticalLow:
+		return ResultFlagCriticalLow
+	case r.CriticalHigh != nil && value >= *r.CriticalHigh:
+		return ResultFlagCriticalHigh
+	case r.Low != nil && value < *r.Low:
+		return ResultFlagLow
+	case r.High != nil && value > *r.High:
+		return ResultFlagHigh
+	}
+	return ResultFlagNormal
+}
+
+// TestResult is the value a lab reported for one parameter of a lead's test. The reference range
+// and flag are fixed when the value is recorded, so later range changes do not rewrite results.
+type TestResult struct {
+	ResultID       int64
+	LeadID         int64
+	TestID         int
+	ParameterID    int64
+	Value          float64
+	Unit           string
+	ReferenceLow   *float64
+	ReferenceHigh  *float64
+	Flag           string
+	Comment        string
+	ReportedBy     int64
+	ReportedByType int
+	ReportedOn     time.Time
+}
+
+// IsAbnormal reports whether the result is flagged outside its normal range.
+func (r TestResult) IsAbnormal() bool {
+	return r.Flag != "" && r.Flag != ResultFlagNormal
+}
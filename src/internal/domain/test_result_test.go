@@ -0,0 +1,76 @@
+package domain
+
+import "testing"
+
+func float(v float64) *float64 { return &v }
+
+func TestRangeFor(t *testing.T) {
+	param := TestParameter{
+		Analyte: "Hemoglobin",
+		Ranges: []ReferenceRange{
+			{RangeID: 1, MinAge: 0, MaxAge: 120},
+			{RangeID: 2, Gender: "M", MinAge: 18, MaxAge: 120},
+			{RangeID: 3, Gender: "F", MinAge: 18, MaxAge: 120},
+			{RangeID: 4, Gender: "F", MinAge: 18, MaxAge: 45},
+			{RangeID: 5, MinAge: 0, MaxAge: 12},
+		},
+	}
+	tests := []struct {
+		name   string
+		age    int
+		gender string
+		want   int64 // RangeID; 0 for none
+	}{
+		{"male code", 30, "M", 2},
+		{"male word", 30, "Male", 2},
+		{"male lower case with spaces", 30, " male ", 2},
+		{"female word picks narrowest band", 30, "FEMALE", 4},
+		{"female outside narrow band", 60, "Female", 3},
+		{"unknown gender falls back to any-gender range", 30, "Unspecified", 1},
+		{"empty gender falls back to any-gender range", 30, "", 1},
+		{"child picks narrowest any-gender band", 8, "F", 5},
+		{"no range for age", 130, "M", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int64
+			if r := param.RangeFor(tt.age, tt.gender); r != nil {
+				got = r.RangeID
+			}
+			if got != tt.want {
+				t.Errorf("RangeFor(%d, %q) = range %d, want %d", tt.age, tt.gender, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferenceRangeFlag(t *testing.T) {
+	r := ReferenceRange{Low: float(13), High: float(17), CriticalLow: float(7), CriticalHigh: float(20)}
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{15, ResultFlagNormal},
+		{13, ResultFlagNormal},
+		{17, ResultFlagNormal},
+		{12.9, ResultFlagLow},
+		{17.1, ResultFlagHigh},
+		{7, ResultFlagCriticalLow},
+		{3, ResultFlagCriticalLow},
+		{20, ResultFlagCriticalHigh},
+		{25, ResultFlagCriticalHigh},
+	}
+	for _, tt := range tests {
+		if got := r.Flag(tt.value); got != tt.want {
+			t.Errorf("Flag(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	open := ReferenceRange{High: float(200)}
+	if got := open.Flag(-5); got != ResultFlagNormal {
+		t.Errorf("open low bound: Flag(-5) = %s, want %s", got, ResultFlagNormal)
+	}
+	if got := open.Flag(201); got != ResultFlagHigh {
+		t.Errorf("open low bound: Flag(201) = %s, want %s", got, ResultFlagHigh)
+	}
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook event types clients can subscribe to.
+const (
+	WebhookEventLeadCreated       = "lead.created"
+	WebhookEventLeadStatusChanged = "lead.status_changed"
+	WebhookEventReportReleased    = "report.released"
+)
+
+// WebhookEventTypes lists every event type a subscription may ask for.
+var WebhookEventTypes = []string{WebhookEventLeadCreated, WebhookEventLeadStatusChanged, WebhookEventReportReleased}
+
+// IsWebhookEventType reports whether eventType is a known webhook event type.
+func IsWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription is a client endpoint that receives lead lifecycle events. Every request is
+// signed with Secret.
+type WebhookSubscription struct {
+	SubscriptionID int64
+	ClientID       int64
+	URL            string
+	Secret         string `json:"-"`
+	EventTypes     []string
+	IsActive       bool
+	CreatedBy      int64
+	CreatedOn      time.Time
+	LastUpdatedBy  int64
+	LastUpdatedOn  time.Time
+}
+
+// Wants reports whether the subscription should receive events of eventType.
+func (s WebhookSubscription) Wants(eventType string) bool {
+	if !s.IsActive {
+		return false
+	}
+	for _, t := range s.EventTypes {
+		if strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses stored in tbl_WebhookDeliveries.Status. A pending delivery is retried
+// with backoff until the endpoint accepts it or its attempts run out; dead deliveries form the
+// dead-letter list and are only sent again when redelivered by hand.
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryDead      = "DEAD"
+)
+
+// WebhookEvent is a lead lifecycle event for a client, written to the outbox in the transaction that
+// caused it. Payload is the JSON body sent to subscribers; EventID is shared by all its deliveries so
+// receivers can drop duplicates.
+type WebhookEvent struct {
+	EventID    string
+	Type       string
+	ClientID   int64
+	LeadID     int64
+	OccurredOn time.Time
+	Payload    string
+}
+
+// WebhookDelivery is one event queued for one subscription.
+type WebhookDelivery struct {
+	DeliveryID       int64
+	SubscriptionID   int64
+	ClientID         int64
+	EventID          string
+	EventType        string
+	LeadID           int64
+	Payload          string
+	Status           string
+	Attempts         int
+	NextAttemptOn    *time.Time
+	LastResponseCode int
+	LastError        string
+	CreatedOn        time.Time
+	DeliveredOn      *time.Time
+	LastUpdatedOn    time.Time
+}
+
+// WebhookRetryDelay is the wait before the next attempt after attempt failures: thirty seconds,
+// doubling per attempt, at most six hours.
+func WebhookRetryDelay(attempt int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempt && delay < 6*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 6*time.Hour {
+		delay = 6 * time.Hour
+	}
+	return delay
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "b2b-diagnostic-aggregator/apis/internal/domain"
+
+type LabSlotIDParam struct {
+	ID     int64 `uri:"id" binding:"required"`
+	SlotID int64 `uri:"slotId" binding:"required"`
+}
+
+// LabSlotRequest configures a daily slot; without Pincode the slot serves every pincode the lab
+// collects from. Times are HH:MM.
+type LabSlotRequest struct {
+	Pincode   string `json:"pincode" binding:"omitempty,len=6,numeric"`
+	StartTime string `json:"startTime" binding:"required"`
+	EndTime   string `json:"endTime" binding:"required"`
+	Capacity  int    `json:"capacity" binding:"required,min=1"`
+	IsActive  *bool  `json:"isActive"`
+}
+
+// LabSlotsQuery lists a lab's slots; with Date only active slots are listed, with their remaining
+// capacity that day. Pincode keeps the slots that can serve it.
+type LabSlotsQuery struct {
+	Date    string `form:"date"`
+	Pincode string `form:"pincode" binding:"omitempty,len=6,numeric"`
+}
+
+// LabSlotAvailability is a slot with, when a date was requested, its bookings and free capacity.
+type LabSlotAvailability struct {
+	domain.LabSlot
+	Date      string `json:"date,omitempty"`
+	Booked    *int   `json:"booked,omitempty"`
+	Available *int   `json:"available,omitempty"`
+}
+
+// AppointmentSlotRequest books (or moves an appointment to) SlotID on Date (YYYY-MM-DD).
+type AppointmentSlotRequest struct {
+	SlotID int64  `json:"slotId" binding:"required,min=1"`
+	Date   string `json:"date" binding:"required"`
+}
+
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason" binding:"required,max=250"`
+}
+
+type LabAppointmentsQuery struct {
+	Date string `form:"date" binding:"required"`
+}
+
+// LabAppointment is an entry of a lab's day list: the appointment with the lead's collection details.
+type LabAppointment struct {
+	domain.Appointment
+	PatientName   string `json:"patientName"`
+	ContactNumber string `json:"contactNumber"`
+	Address       string `json:"address"`
+	PackageID     int    `json:"packageId"`
+	PackageName   string `json:"packageName,omitempty"`
+}
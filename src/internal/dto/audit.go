@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// AuditEntryResponse is one audit entry; before/after are the entity snapshots as JSON (null when absent)
+type AuditEntryResponse struct {
+	AuditID    int64           `json:"auditId"`
+	EntityType string          `json:"entityType"`
+	EntityID   int64           `json:"entityId"`
+	Action     string          `json:"action"`
+	ActorID    int64           `json:"actorId"`
+	ActorType  int             `json:"actorType"`
+	RequestID  string          `json:"requestId,omitempty"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedOn  time.Time       `json:"createdOn"`
+}
+
+func AuditEntryFromDomain(e domain.AuditEntry) AuditEntryResponse {
+	return AuditEntryResponse{
+		AuditID:    e.AuditID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		ActorID:    e.ActorID,
+		ActorType:  e.ActorType,
+		RequestID:  e.RequestID,
+		Before:     rawJSONOrNull(e.Before),
+		After:      rawJSONOrNull(e.After),
+		CreatedOn:  e.CreatedOn,
+	}
+}
+
+func rawJSONOrNull(s string) json.RawMessage {
+	if s == "" || !json.Valid([]byte(s)) {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
@@ -0,0 +1,53 @@
+package dto
+
+import "time"
+
+type LeadLabOrderParam struct {
+	ID         int64 `uri:"id" binding:"required"`
+	DeliveryID int64 `uri:"deliveryId" binding:"required"`
+}
+
+// LabIntegrationRequest configures how orders reach a lab's LIS. Endpoint is an http(s) URL for
+// HTTP_JSON and host:port for HL7_MLLP. Omitting authToken keeps the stored token; an empty string
+// clears it. Zero timeout and attempts use the defaults.
+type LabIntegrationRequest struct {
+	Adapter              string  `json:"adapter" binding:"required,oneof=HTTP_JSON HL7_MLLP MANUAL"`
+	Endpoint             string  `json:"endpoint" binding:"max=500"`
+	AuthToken            *string `json:"authToken" binding:"omitempty,max=500"`
+	ReceivingApplication string  `json:"receivingApplication" binding:"max=50"`
+	ReceivingFacility    string  `json:"receivingFacility" binding:"max=50"`
+	TimeoutSeconds       int     `json:"timeoutSeconds" binding:"min=0,max=300"`
+	MaxAttempts          int     `json:"maxAttempts" binding:"min=0,max=20"`
+	IsActive             *bool   `json:"isActive"`
+}
+
+type LabEventPathParam struct {
+	LabID int64 `uri:"labId" binding:"required"`
+}
+
+// LabEventRequest is an event a lab pushes for one of its leads. The lead is identified by leadId or
+// by the accessionNumber of one of its samples; eventId is the lab's own ID, used to drop duplicates.
+type LabEventRequest struct {
+	EventID         string     `json:"eventId" binding:"required,max=100"`
+	Type            string     `json:"type" binding:"required,oneof=SAMPLE_RECEIVED REPORT_READY"`
+	LeadID          int64      `json:"leadId" binding:"omitempty,min=1"`
+	AccessionNumber string     `json:"accessionNumber" binding:"max=20"`
+	OccurredAt      *time.Time `json:"occurredAt"`
+	Note            string     `json:"note" binding:"max=300"`
+}
+
+// LabEventResult is what happened to a lab event. Duplicate is true when the eventId was already
+// received; the outcome is then the original one.
+type LabEventResult struct {
+	EventID   string `json:"eventId"`
+	LeadID    int64  `json:"leadId"`
+	Outcome   string `json:"outcome"`
+	Note      string `json:"note,omitempty"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+// LabEventSecret is a newly generated event signing secret; it is only shown once.
+type LabEventSecret struct {
+	LabID  int64  `json:"labId"`
+	Secret string `json:"secret"`
+}
@@ -1,29 +1,36 @@
 package dto
 
 import (
+	"time"
+
 	"b2b-diagnostic-aggregator/apis/internal/domain"
 )
 
+// LeadRequest creates a lead. With PatientID set the patient details come from the patient record
+// and may be omitted; otherwise the lead is linked to (or creates) the client's patient with the same
+// contact number and name.
 type LeadRequest struct {
 	LeadID        int64     `binding:"omitempty"`
 	ClientID      int64     `binding:"required"`
-	PatientID     string    `binding:"omitempty"`
-	PatientName   string    `binding:"required"`
-	Age           int8      `binding:"required"`
-	Gender        string    `binding:"required"`
+	PatientID     int64     `binding:"omitempty,min=1"`
+	PatientName   string    `binding:"required_without=PatientID"`
+	Age           int8      `binding:"required_without=PatientID"`
+	Gender        string    `binding:"required_without=PatientID"`
 	PackageID     int       `binding:"required"`
-	ContactNumber string    `binding:"required"`
-	Emailid       string    `binding:"required"`
-	Address       string    `binding:"required"`
-	CityID        int8      `binding:"required"`
-	StateID       int8      `binding:"required"`
-	Pincode       string    `binding:"required"`
+	ContactNumber string    `binding:"required_without=PatientID"`
+	Emailid       string    `binding:"required_without=PatientID"`
+	Address       string    `binding:"required_without=PatientID"`
+	CityID        int8      `binding:"required_without=PatientID"`
+	StateID       int8      `binding:"required_without=PatientID"`
+	Pincode       string    `binding:"required_without=PatientID"`
 	LeadStatusID int8 // defaults to 0 when omitted in POST payload
+	AllowDuplicate bool // create even when the lead matches a recent one (see LEAD_DUPLICATE_WINDOW_DAYS)
 }
 
 type BulkUpdateLeadStatusRequest struct {
 	LeadIDs      []int64 `json:"leadIds" binding:"required"`
 	LeadStatusID int8    `json:"leadStatusId" binding:"required"`
+	Reason       string  `json:"reason"` // required when the target status needs a reason (e.g. cancellation)
 }
 
 // LeadUpdateRequest is for PUT; all fields optional. At least one must be set.
@@ -40,6 +47,7 @@ type LeadUpdateRequest struct {
 	StateID       *int8   `json:"StateID"`
 	Pincode       *string `json:"Pincode"`
 	LeadStatusID  *int8   `json:"LeadStatusID"`
+	StatusReason  *string `json:"StatusReason"` // reason for the status change; required for cancellation
 }
 
 func (r LeadUpdateRequest) HasAtLeastOneField() bool {
@@ -66,3 +74,96 @@ func (r LeadRequest) ToDomain() domain.Lead {
 		LeadStatusID:  r.LeadStatusID,
 	}
 }
+
+// LeadStatusesQuery optionally asks for the next allowed statuses of specific leads (comma-separated IDs)
+type LeadStatusesQuery struct {
+	LeadIDs string `form:"leadIds"`
+}
+
+// LeadStatusNode is a catalog status with its allowed next statuses
+type LeadStatusNode struct {
+	domain.LeadStatus
+	Next []int8 `json:"next"`
+}
+
+// LeadNextStatuses is the current status of one lead and where it may move next
+type LeadNextStatuses struct {
+	LeadID       int64  `json:"leadId"`
+	StatusID     int8   `json:"statusId"`
+	NextStatuses []int8 `json:"nextStatuses"`
+}
+
+// LeadStatusWorkflowResponse exposes the status graph and, when requested, per-lead next statuses
+type LeadStatusWorkflowResponse struct {
+	Statuses []LeadStatusNode   `json:"statuses"`
+	Leads    []LeadNextStatuses `json:"leads,omitempty"`
+}
+
+// LeadHistoryActor is the user who made a history entry, with the name resolved from employees,
+// clients or labs
+type LeadHistoryActor struct {
+	UserID   int64  `json:"userId"`
+	UserType int    `json:"userType"`
+	Name     string `json:"name"`
+}
+
+// LeadFieldChange is the old and new value of one field
+type LeadFieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// LeadHistoryEntry is one entry of GET /leads/:id/history
+type LeadHistoryEntry struct {
+	UID       int64             `json:"uid"`
+	Action    string            `json:"action"`
+	Reason    string            `json:"reason,omitempty"`
+	CreatedOn time.Time         `json:"createdOn"`
+	Actor     LeadHistoryActor  `json:"actor"`
+	Changes   []LeadFieldChange `json:"changes"`
+}
+
+// LeadStatusRejection is a lead whose requested status change the workflow does not allow.
+type LeadStatusRejection struct {
+	LeadID          int64  `json:"leadId"`
+	CurrentStatusID int8   `json:"currentStatusId"`
+	Reason          string `json:"reason"`
+}
+
+// BulkLeadStatusPreview is the dry-run result of a bulk status update. WouldCommit is false when any
+// lead is missing or not allowed to move, since the real update is all-or-nothing.
+type BulkLeadStatusPreview struct {
+	DryRun       bool                  `json:"dryRun"`
+	LeadStatusID int8                  `json:"leadStatusId"`
+	ToUpdate     []int64               `json:"toUpdate"`
+	NotFound     []int64               `json:"notFound"`
+	NotAllowed   []LeadStatusRejection `json:"notAllowed"`
+	WouldCommit  bool                  `json:"wouldCommit"`
+}
+
+// LeadDuplicateGroup is a set of leads that look like the same order, oldest first.
+type LeadDuplicateGroup struct {
+	ClientID      int64         `json:"clientId"`
+	PackageID     int           `json:"packageId"`
+	ContactNumber string        `json:"contactNumber"`
+	PatientName   string        `json:"patientName"` // normalized
+	Leads         []domain.Lead `json:"leads"`
+}
+
+// MergeLeadsRequest merges the listed leads into the lead in the URL.
+type MergeLeadsRequest struct {
+	DuplicateLeadIDs []int64 `json:"duplicateLeadIds" binding:"required,min=1"`
+	Reason           string  `json:"reason" binding:"max=250"`
+}
+
+// LabOptionsQuery optionally restricts lab options to labs offering a collection type
+type LabOptionsQuery struct {
+	CollectionType string `form:"collectionType"`
+}
+
+// AssignLabRequest assigns LabID to the lead; without LabID the top-ranked eligible lab is assigned.
+type AssignLabRequest struct {
+	LabID          *int64 `json:"labId" binding:"omitempty,min=1"`
+	CollectionType string `json:"collectionType"`
+}
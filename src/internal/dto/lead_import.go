@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+type LeadImportRowError struct {
+	RowNumber int    `json:"rowNumber"`
+	Column    string `json:"column,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// LeadImportJobResponse is the state of a CSV import job. Errors lists the rows that were skipped
+// (or, in strict mode, that rejected the import); ErrorReportURL downloads them as CSV.
+type LeadImportJobResponse struct {
+	JobID           int64                `json:"jobId"`
+	Status          string               `json:"status"`
+	Strict          bool                 `json:"strict"`
+	ClientID        int64                `json:"clientId"`
+	PackageID       int                  `json:"packageId"`
+	TotalRows       int                  `json:"totalRows"`
+	ProcessedRows   int                  `json:"processedRows"`
+	InsertedRows    int                  `json:"insertedRows"`
+	FailedRows      int                  `json:"failedRows"`
+	ProgressPercent int                  `json:"progressPercent"`
+	Message         string               `json:"message,omitempty"`
+	CreatedOn       time.Time            `json:"createdOn"`
+	StartedOn       *time.Time           `json:"startedOn,omitempty"`
+	CompletedOn     *time.Time           `json:"completedOn,omitempty"`
+	Errors          []LeadImportRowError `json:"errors"`
+	ErrorReportURL  string               `json:"errorReportUrl,omitempty"`
+}
+
+// ToLeadImportJobResponse builds the response; errorReportBase is the job URL the report path is
+// appended to.
+func ToLeadImportJobResponse(job domain.LeadImportJob, rowErrors []domain.LeadImportRowError, errorReportBase string) LeadImportJobResponse {
+	resp := LeadImportJobResponse{
+		JobID:         job.JobID,
+		Status:        job.Status,
+		Strict:        job.Strict,
+		ClientID:      job.ClientID,
+		PackageID:     job.PackageID,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		InsertedRows:  job.InsertedRows,
+		FailedRows:    job.FailedRows,
+		Message:       job.Message,
+		CreatedOn:     job.CreatedOn,
+		StartedOn:     job.StartedOn,
+		CompletedOn:   job.CompletedOn,
+		Errors:        make([]LeadImportRowError, len(rowErrors)),
+	}
+	if job.TotalRows > 0 {
+		resp.ProgressPercent = job.ProcessedRows * 100 / job.TotalRows
+	}
+	for i, e := range rowErrors {
+		resp.Errors[i] = LeadImportRowError{RowNumber: e.RowNumber, Column: e.Column, Reason: e.Reason}
+	}
+	if len(rowErrors) > 0 {
+		resp.ErrorReportURL = fmt.Sprintf("%s/errors.csv", errorReportBase)
+	}
+	return resp
+}
+
+// LeadImportPreviewRow is one CSV row in a dry-run import. A duplicate row matches an existing lead
+// (DuplicateOfLeadID) or an earlier row of the same file (DuplicateOfRow).
+type LeadImportPreviewRow struct {
+	RowNumber         int                  `json:"rowNumber"`
+	PatientName       string               `json:"patientName"`
+	ContactNumber     string               `json:"contactNumber"`
+	Errors            []LeadImportRowError `json:"errors,omitempty"`
+	DuplicateOfLeadID *int64               `json:"duplicateOfLeadId,omitempty"`
+	DuplicateOfRow    *int                 `json:"duplicateOfRow,omitempty"`
+}
+
+// LeadImportPreview is the dry-run result of a CSV import. Rows are split into those that would be
+// inserted, those that would be rejected as invalid and those that would be skipped as duplicates.
+// WouldCommit is false when a strict import would be rejected.
+type LeadImportPreview struct {
+	DryRun      bool                   `json:"dryRun"`
+	Strict      bool                   `json:"strict"`
+	TotalRows   int                    `json:"totalRows"`
+	WouldCommit bool                   `json:"wouldCommit"`
+	ToInsert    []LeadImportPreviewRow `json:"toInsert"`
+	Rejected    []LeadImportPreviewRow `json:"rejected"`
+	Duplicates  []LeadImportPreviewRow `json:"duplicates"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// LeadStreamEvent is one event of GET /leads/stream. ID is the outbox event ID, sent as the SSE id so
+// a reconnecting client resumes with Last-Event-ID.
+type LeadStreamEvent struct {
+	ID   int64
+	Type string // lead.created, lead.updated, lead.status_changed or lead.deleted
+	Data LeadStreamData
+}
+
+type LeadStreamStatus struct {
+	ID   int8   `json:"id"`
+	Name string `json:"name"`
+}
+
+// LeadStreamData is the JSON data of a lead stream event. Fields lists the changed fields of
+// lead.updated; PreviousStatus is set on lead.status_changed. MergedIntoLeadID is set on lead.deleted
+// when the lead was merged into another as a duplicate.
+type LeadStreamData struct {
+	LeadID           int64             `json:"leadId"`
+	ClientID         int64             `json:"clientId"`
+	LabID            *int64            `json:"labId,omitempty"`
+	PackageID        int               `json:"packageId"`
+	PatientCode      string            `json:"patientCode,omitempty"`
+	Status           LeadStreamStatus  `json:"status"`
+	PreviousStatus   *LeadStreamStatus `json:"previousStatus,omitempty"`
+	Fields           []string          `json:"fields,omitempty"`
+	MergedIntoLeadID *int64            `json:"mergedIntoLeadId,omitempty"`
+	OccurredAt       time.Time         `json:"occurredAt"`
+}
@@ -21,8 +21,24 @@ type LeadListQuery struct {
 	PackageID *int   `form:"packageId" binding:"omitempty,min=1"`
 }
 
+type LeadDuplicatesQuery struct {
+	ClientID *int64 `form:"clientId" binding:"omitempty,min=1"`
+}
+
 type PackageListQuery struct {
 	PaginationQuery
 	IsActive *bool  `form:"isActive" binding:"omitempty"`
 	Search   string `form:"search" binding:"omitempty"`
 }
+
+// AuditListQuery filters GET /audit; from/to are RFC3339 timestamps
+type AuditListQuery struct {
+	PaginationQuery
+	EntityType string `form:"entityType"`
+	EntityID   *int64 `form:"entityId" binding:"omitempty,min=1"`
+	Action     string `form:"action" binding:"omitempty,oneof=CREATE UPDATE DELETE"`
+	ActorID    *int64 `form:"actorId" binding:"omitempty,min=1"`
+	RequestID  string `form:"requestId"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+}
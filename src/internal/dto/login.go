@@ -3,6 +3,7 @@ package dto
 // LoginRequest supports domain + mobileNumber + password (Node-style) or legacy userId + password
 type LoginRequest struct {
 	Domain       string `json:"-"`            // from X-Domain header
+	ClientIP     string `json:"-"`            // from the request, for attempt limiting
 	MobileNumber string `json:"mobileNumber"` // required when using domain
 	Password     string `json:"Password" binding:"required"`
 	UserID       int64  `json:"userId"` // legacy: optional when domain+mobileNumber provided
@@ -15,27 +16,35 @@ type LoginResponse struct {
 	RefreshToken string      `json:"refreshToken"`
 }
 
-// CreateForgotPasswordKeyRequest creates a forgot-password key for a mobile number
+// RefreshTokenRequest exchanges a refresh token for a new token pair
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshTokenResponse returns the rotated token pair
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// CreateForgotPasswordKeyRequest requests a forgot-password OTP for a mobile number
 type CreateForgotPasswordKeyRequest struct {
 	Domain       string `json:"-"`
 	MobileNumber string `json:"mobileNumber" binding:"required"`
 }
 
-// GetForgotPasswordKeyRequest gets the latest forgot-password key (query params)
-type GetForgotPasswordKeyRequest struct {
-	Domain       string `form:"-"`
-	MobileNumber string `form:"mobileNumber" binding:"required"`
-}
-
-// ForgotPasswordResetRequest resets password using forgot-password key
+// ForgotPasswordResetRequest resets password using the OTP sent to the mobile number
 type ForgotPasswordResetRequest struct {
-	ForgetPasswordKey string `json:"forgetPasswordKey" binding:"required"`
-	Password          string `json:"Password" binding:"required"`
+	Domain       string `json:"-"`
+	MobileNumber string `json:"mobileNumber" binding:"required"`
+	Otp          string `json:"otp" binding:"required"`
+	Password     string `json:"Password" binding:"required"`
 }
 
 // ChangePasswordRequest changes password using old password
 type ChangePasswordRequest struct {
 	Domain       string `json:"-"`
+	ClientIP     string `json:"-"`
 	MobileNumber string `json:"mobileNumber" binding:"required"`
 	OldPassword  string `json:"OldPassword" binding:"required"`
 	NewPassword  string `json:"NewPassword" binding:"required"`
@@ -48,8 +57,9 @@ type GetProfileRequest struct {
 	MobileNumber string `form:"mobileNumber"`
 }
 
-// ForgotPasswordKeyResponse for get-forgot-password-key response
-type ForgotPasswordKeyResponse struct {
-	ForgetPasswordKey string `json:"forgetPasswordKey"`
-	Expiry            string `json:"expiry"` // RFC3339
+// UnlockAccountRequest clears failed-login lockouts for a user (userId + userType) and/or a client IP
+type UnlockAccountRequest struct {
+	UserID   int64  `json:"userId"`
+	UserType int    `json:"userType"`
+	IP       string `json:"ip"`
 }
@@ -23,3 +23,7 @@ type ClientIDPathParam struct {
 type ContactNumberQuery struct {
 	ContactNumber string `form:"contactNumber" binding:"required"`
 }
+
+type LeadIDPathParam struct {
+	LeadID int64 `uri:"leadId" binding:"required"`
+}
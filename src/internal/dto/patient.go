@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// PatientDetail is a patient with the other patients (family members) sharing their contact number.
+type PatientDetail struct {
+	domain.Patient
+	FamilyMembers []domain.Patient `json:"familyMembers"`
+}
+
+// PatientTimelineEntry is one lead in a patient's testing timeline.
+type PatientTimelineEntry struct {
+	LeadID        int64     `json:"leadId"`
+	PackageID     int       `json:"packageId"`
+	PackageName   string    `json:"packageName,omitempty"`
+	LeadStatusID  int8      `json:"leadStatusId"`
+	Status        string    `json:"status"`
+	LabID         *int64    `json:"labId,omitempty"`
+	CreatedOn     time.Time `json:"createdOn"`
+	LastUpdatedOn time.Time `json:"lastUpdatedOn"`
+}
+
+type PatientTimeline struct {
+	Patient domain.Patient         `json:"patient"`
+	Leads   []PatientTimelineEntry `json:"leads"`
+}
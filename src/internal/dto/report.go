@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type LeadReportParam struct {
+	ID       int64 `uri:"id" binding:"required"`
+	ReportID int64 `uri:"reportId" binding:"required"`
+}
+
+// ReportDownloadQuery carries the signature of a signed report download link.
+type ReportDownloadQuery struct {
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
+
+type ReportDownloadURL struct {
+	ReportID  int64     `json:"reportId"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// CollectSamplesRequest registers the tubes collected for a lead, one accession number per tube.
+type CollectSamplesRequest struct {
+	TubeTypes []string `json:"tubeTypes" binding:"required,min=1,max=20,dive,required,max=30"`
+}
+
+// SampleScanRequest advances the sample with AccessionNumber to Status (IN_TRANSIT, RECEIVED or
+// REJECTED); Reason is required for REJECTED.
+type SampleScanRequest struct {
+	AccessionNumber string `json:"accessionNumber" binding:"required,max=20"`
+	Status          string `json:"status" binding:"required,oneof=IN_TRANSIT RECEIVED REJECTED"`
+	Reason          string `json:"reason" binding:"max=250"`
+}
+
+type SampleBarcodeQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=png svg"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+type ServiceabilityQuery struct {
+	Pincode   string `form:"pincode" binding:"required"`
+	PackageID *int   `form:"packageId" binding:"omitempty,min=1"`
+}
+
+// ServiceableLab is an active lab that collects from the requested pincode. Price and OffersPackage
+// are only set when a package was requested.
+type ServiceableLab struct {
+	LabID           int64    `json:"labId"`
+	LabName         string   `json:"labName"`
+	CollectionTypes []string `json:"collectionTypes"`
+	OffersPackage   bool     `json:"offersPackage"`
+	Price           *float64 `json:"price,omitempty"`
+}
+
+// ServiceabilityResponse says whether a pincode is served (and, with a package, whether any serving
+// lab offers it).
+type ServiceabilityResponse struct {
+	Pincode     string           `json:"pincode"`
+	PackageID   *int             `json:"packageId,omitempty"`
+	PackageName string           `json:"packageName,omitempty"`
+	Serviceable bool             `json:"serviceable"`
+	Labs        []ServiceableLab `json:"labs"`
+}
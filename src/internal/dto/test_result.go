@@ -0,0 +1,71 @@
+package dto
+
+import "time"
+
+type TestParameterIDParam struct {
+	ID          int   `uri:"id" binding:"required"`
+	ParameterID int64 `uri:"parameterId" binding:"required"`
+}
+
+// ReferenceRangeRequest is one reference range of a parameter. Gender M or F restricts it to that
+// gender; the age band defaults to 0-150 years. Omitted bounds are open.
+type ReferenceRangeRequest struct {
+	Gender       string   `json:"gender" binding:"omitempty,oneof=M F"`
+	MinAge       *int     `json:"minAge" binding:"omitempty,min=0,max=150"`
+	MaxAge       *int     `json:"maxAge" binding:"omitempty,min=0,max=150"`
+	Low          *float64 `json:"low"`
+	High         *float64 `json:"high"`
+	CriticalLow  *float64 `json:"criticalLow"`
+	CriticalHigh *float64 `json:"criticalHigh"`
+}
+
+type TestParameterRequest struct {
+	Analyte      string                  `json:"analyte" binding:"required,max=100"`
+	Unit         string                  `json:"unit" binding:"max=30"`
+	DisplayOrder int                     `json:"displayOrder" binding:"min=0"`
+	IsActive     *bool                   `json:"isActive"`
+	Ranges       []ReferenceRangeRequest `json:"ranges" binding:"dive"`
+}
+
+type ResultValueRequest struct {
+	ParameterID int64    `json:"parameterId" binding:"required,min=1"`
+	Value       *float64 `json:"value" binding:"required"`
+	Comment     string   `json:"comment" binding:"max=500"`
+}
+
+// RecordResultsRequest posts parameter values for one test of the lead's package. A value for a
+// parameter that already has a result replaces it.
+type RecordResultsRequest struct {
+	TestID  int                  `json:"testId" binding:"required,min=1"`
+	Results []ResultValueRequest `json:"results" binding:"required,min=1,dive"`
+}
+
+// ResultEntry is one parameter value with the reference range and flag it was evaluated against.
+type ResultEntry struct {
+	ParameterID   int64     `json:"parameterId"`
+	Analyte       string    `json:"analyte"`
+	Unit          string    `json:"unit"`
+	Value         float64   `json:"value"`
+	ReferenceLow  *float64  `json:"referenceLow,omitempty"`
+	ReferenceHigh *float64  `json:"referenceHigh,omitempty"`
+	Flag          string    `json:"flag,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	ReportedOn    time.Time `json:"reportedOn"`
+}
+
+type TestResults struct {
+	TestID   int           `json:"testId"`
+	TestName string        `json:"testName"`
+	Results  []ResultEntry `json:"results"`
+}
+
+// LeadResults are a lead's structured results grouped by test.
+type LeadResults struct {
+	LeadID        int64         `json:"leadId"`
+	PatientID     int64         `json:"patientId,omitempty"`
+	Age           int8          `json:"age"`
+	Gender        string        `json:"gender"`
+	Tests         []TestResults `json:"tests"`
+	AbnormalCount int           `json:"abnormalCount"`
+	CriticalCount int           `json:"criticalCount"`
+}
@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// WebhookSubscriptionRequest creates or replaces a webhook subscription. Employees must name the
+// client; clients always subscribe for themselves. Omitting secret on create generates one; on update
+// it keeps the stored secret.
+type WebhookSubscriptionRequest struct {
+	ClientID   int64    `json:"clientId" binding:"omitempty,min=1"`
+	URL        string   `json:"url" binding:"required,url,max=500"`
+	Secret     *string  `json:"secret" binding:"omitempty,min=16,max=100"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1,dive,oneof=lead.created lead.status_changed report.released"`
+	IsActive   *bool    `json:"isActive"`
+}
+
+type WebhookSubscriptionListQuery struct {
+	ClientID *int64 `form:"clientId" binding:"omitempty,min=1"`
+}
+
+// WebhookSubscriptionSecret is a subscription together with its signing secret, returned only when
+// the secret is set.
+type WebhookSubscriptionSecret struct {
+	Subscription *domain.WebhookSubscription `json:"subscription"`
+	Secret       string                      `json:"secret"`
+}
+
+// WebhookDeadLetterQuery filters GET /webhooks/dead-letters.
+type WebhookDeadLetterQuery struct {
+	PaginationQuery
+	ClientID       *int64 `form:"clientId" binding:"omitempty,min=1"`
+	SubscriptionID *int64 `form:"subscriptionId" binding:"omitempty,min=1"`
+}
+
+type WebhookDeliveryParam struct {
+	DeliveryID int64 `uri:"deliveryId" binding:"required"`
+}
+
+// WebhookPayload is the JSON body POSTed to subscribers. ID is the event ID, the same across retries
+// and redeliveries; receivers should use it to drop duplicates.
+type WebhookPayload struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+type WebhookLeadStatus struct {
+	ID   int8   `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebhookLeadData is the data of lead.created and lead.status_changed events.
+type WebhookLeadData struct {
+	LeadID         int64              `json:"leadId"`
+	ClientID       int64              `json:"clientId"`
+	PatientCode    string             `json:"patientCode,omitempty"`
+	PackageID      int                `json:"packageId"`
+	Status         WebhookLeadStatus  `json:"status"`
+	PreviousStatus *WebhookLeadStatus `json:"previousStatus,omitempty"`
+}
+
+// WebhookReportData is the data of report.released events.
+type WebhookReportData struct {
+	LeadID     int64      `json:"leadId"`
+	ClientID   int64      `json:"clientId"`
+	ReportID   int64      `json:"reportId"`
+	Version    int        `json:"version"`
+	ReleasedAt *time.Time `json:"releasedAt"`
+}
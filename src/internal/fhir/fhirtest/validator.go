@@ -0,0 +1,375 @@
+// Package fhirtest validates FHIR JSON against the R4 StructureDefinitions bundled in testdata. It
+// checks element names, cardinality, choice types, primitive formats and required bindings, and that
+// references inside a bundle resolve; FHIRPath invariants are not evaluated.
+package fhirtest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed testdata/r4/*.json
+var definitions embed.FS
+
+type element struct {
+	Path string `json:"path"`
+	Min  int    `json:"min"`
+	Max  string `json:"max"`
+	Type []struct {
+		Code string `json:"code"`
+	} `json:"type"`
+	Binding *struct {
+		Strength string `json:"strength"`
+		ValueSet string `json:"valueSet"`
+	} `json:"binding"`
+}
+
+type structure struct {
+	Kind     string
+	children map[string][]element // parent path -> direct child elements
+}
+
+// Validator holds the loaded definitions.
+type Validator struct {
+	types     map[string]*structure
+	valueSets map[string]map[string]bool // canonical URL without version -> codes
+}
+
+// Load reads the bundled StructureDefinitions and ValueSets.
+func Load() (*Validator, error) {
+	v := &Validator{types: make(map[string]*structure), valueSets: make(map[string]map[string]bool)}
+	files, err := fs.Glob(definitions, "testdata/r4/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		raw, err := definitions.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var doc struct {
+			ResourceType string `json:"resourceType"`
+			URL          string `json:"url"`
+			Kind         string `json:"kind"`
+			Type         string `json:"type"`
+			Snapshot     struct {
+				Element []element `json:"element"`
+			} `json:"snapshot"`
+			Compose struct {
+				Include []struct {
+					Concept []struct {
+						Code string `json:"code"`
+					} `json:"concept"`
+				} `json:"include"`
+			} `json:"compose"`
+		}
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		switch doc.ResourceType {
+		case "StructureDefinition":
+			s := &structure{Kind: doc.Kind, children: make(map[string][]element)}
+			for _, e := range doc.Snapshot.Element {
+				if i := strings.LastIndex(e.Path, "."); i > 0 {
+					s.children[e.Path[:i]] = append(s.children[e.Path[:i]], e)
+				}
+			}
+			v.types[doc.Type] = s
+		case "ValueSet":
+			codes := make(map[string]bool)
+			for _, include := range doc.Compose.Include {
+				for _, c := range include.Concept {
+					codes[c.Code] = true
+				}
+			}
+			v.valueSets[doc.URL] = codes
+		}
+	}
+	return v, nil
+}
+
+// Validate checks one resource in FHIR JSON and returns every problem found. Bundles also have their
+// entries validated and their local references resolved.
+func (v *Validator) Validate(data []byte) []error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var resource map[string]interface{}
+	if err := dec.Decode(&resource); err != nil {
+		return []error{err}
+	}
+	run := &validation{v: v}
+	run.resource(resource, "")
+	if resource["resourceType"] == "Bundle" {
+		run.resolveBundleReferences(resource)
+	}
+	return run.errs
+}
+
+type validation struct {
+	v    *Validator
+	errs []error
+	refs []reference
+}
+
+type reference struct {
+	loc, value string
+}
+
+func (r *validation) fail(loc, format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Errorf("%s: %s", strings.TrimPrefix(loc, "."), fmt.Sprintf(format, args...)))
+}
+
+func (r *validation) resource(obj map[string]interface{}, loc string) {
+	resourceType, _ := obj["resourceType"].(string)
+	s, ok := r.v.types[resourceType]
+	if !ok || s.Kind != "resource" {
+		r.fail(loc, "unknown resourceType %q", resourceType)
+		return
+	}
+	if loc == "" {
+		loc = resourceType
+	}
+	r.object(s, resourceType, obj, loc, false)
+}
+
+// object validates the children of the element at path, defined in s.
+func (r *validation) object(s *structure, path string, obj map[string]interface{}, loc string, backbone bool) {
+	if len(obj) == 0 {
+		r.fail(loc, "empty object")
+		return
+	}
+	children := s.children[path]
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	present := make(map[string]bool)
+	for _, key := range keys {
+		if key == "resourceType" && s.Kind == "resource" && !strings.Contains(path, ".") {
+			continue
+		}
+		e, typeCode, ok := match(children, path, key)
+		if !ok && backbone {
+			e, typeCode, ok = implicitBackboneElement(path, key)
+		}
+		if !ok {
+			r.fail(loc, "unknown element %q", key)
+			continue
+		}
+		if present[e.Path] {
+			r.fail(loc, "more than one value for %s", e.Path)
+			continue
+		}
+		present[e.Path] = true
+		r.occurrences(s, e, typeCode, obj[key], loc+"."+key)
+	}
+	for _, e := range children {
+		if e.Min > 0 && !present[e.Path] {
+			r.fail(loc, "missing required element %s", strings.TrimPrefix(e.Path, path+"."))
+		}
+	}
+}
+
+// match finds the element a JSON key belongs to and, for choice elements, the chosen type.
+func match(children []element, path, key string) (element, string, bool) {
+	for _, e := range children {
+		name := strings.TrimPrefix(e.Path, path+".")
+		if name == key {
+			if len(e.Type) == 0 {
+				return e, "", true
+			}
+			return e, e.Type[0].Code, true
+		}
+		if base := strings.TrimSuffix(name, "[x]"); base != name && strings.HasPrefix(key, base) {
+			for _, t := range e.Type {
+				if key == base+strings.ToUpper(t.Code[:1])+t.Code[1:] {
+					return e, t.Code, true
+				}
+			}
+		}
+	}
+	return element{}, "", false
+}
+
+func implicitBackboneElement(path, key string) (element, string, bool) {
+	switch key {
+	case "id":
+		return element{Path: path + ".id", Max: "1"}, "string", true
+	case "extension", "modifierExtension":
+		return element{Path: path + "." + key, Max: "*"}, "Extension", true
+	}
+	return element{}, "", false
+}
+
+func (r *validation) occurrences(s *structure, e element, typeCode string, value interface{}, loc string) {
+	items, isArray := value.([]interface{})
+	switch {
+	case isArray && e.Max == "1":
+		r.fail(loc, "must be a single value, not an array")
+		return
+	case !isArray && e.Max != "1":
+		r.fail(loc, "must be an array")
+		return
+	case isArray && len(items) == 0:
+		r.fail(loc, "empty array")
+		return
+	}
+	if !isArray {
+		items = []interface{}{value}
+	}
+	for i, item := range items {
+		itemLoc := loc
+		if isArray {
+			itemLoc = fmt.Sprintf("%s[%d]", loc, i)
+		}
+		r.value(s, e, typeCode, item, itemLoc)
+	}
+}
+
+func (r *validation) value(s *structure, e element, typeCode string, value interface{}, loc string) {
+	if check, ok := primitives[typeCode]; ok {
+		if msg := check(value); msg != "" {
+			r.fail(loc, "%s %s", typeCode, msg)
+			return
+		}
+		if e.Binding != nil && e.Binding.Strength == "required" {
+			url := strings.SplitN(e.Binding.ValueSet, "|", 2)[0]
+			codes, ok := r.v.valueSets[url]
+			switch {
+			case !ok:
+				r.fail(loc, "value set %s is not bundled", url)
+			case !codes[fmt.Sprint(value)]:
+				r.fail(loc, "code %q is not in %s", value, url)
+			}
+		}
+		return
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		r.fail(loc, "%s must be an object", typeCode)
+		return
+	}
+	switch typeCode {
+	case "BackboneElement":
+		r.object(s, e.Path, obj, loc, true)
+	case "Resource":
+		r.resource(obj, loc)
+	default:
+		t, ok := r.v.types[typeCode]
+		if !ok {
+			r.fail(loc, "no definition bundled for type %s", typeCode)
+			return
+		}
+		r.object(t, typeCode, obj, loc, false)
+		if typeCode == "Reference" {
+			if ref, ok := obj["reference"].(string); ok {
+				r.refs = append(r.refs, reference{loc: loc, value: ref})
+			}
+		}
+	}
+}
+
+// resolveBundleReferences checks that entry fullUrls are unique and that relative references point at
+// a resource in the bundle.
+func (r *validation) resolveBundleReferences(bundle map[string]interface{}) {
+	entries, _ := bundle["entry"].([]interface{})
+	seen := make(map[string]bool)
+	resources := make(map[string]bool)
+	for i, raw := range entries {
+		entry, _ := raw.(map[string]interface{})
+		if fullURL, ok := entry["fullUrl"].(string); ok {
+			if seen[fullURL] {
+				r.fail(fmt.Sprintf("Bundle.entry[%d]", i), "duplicate fullUrl %s", fullURL)
+			}
+			seen[fullURL] = true
+		}
+		if resource, ok := entry["resource"].(map[string]interface{}); ok {
+			resources[fmt.Sprintf("%v/%v", resource["resourceType"], resource["id"])] = true
+		}
+	}
+	for _, ref := range r.refs {
+		if strings.Contains(ref.value, "://") || strings.HasPrefix(ref.value, "#") || strings.HasPrefix(ref.value, "urn:") {
+			continue
+		}
+		if !resources[ref.value] {
+			r.fail(ref.loc, "reference %s does not resolve inside the bundle", ref.value)
+		}
+	}
+}
+
+// Primitive formats from the R4 primitive type definitions.
+var (
+	reID       = regexp.MustCompile(`^[A-Za-z0-9\-\.]{1,64}$`)
+	reCode     = regexp.MustCompile(`^[^\s]+(\s[^\s]+)*$`)
+	reURI      = regexp.MustCompile(`^\S*$`)
+	reDate     = regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1]))?)?$`)
+	reDateTime = regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1])(T([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|(\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00)))?)?)?$`)
+	reInstant  = regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)-(0[1-9]|1[0-2])-(0[1-9]|[1-2][0-9]|3[0-1])T([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|(\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00))$`)
+	reTime     = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?$`)
+)
+
+var primitives = map[string]func(interface{}) string{
+	"boolean": func(v interface{}) string {
+		if _, ok := v.(bool); !ok {
+			return "must be true or false"
+		}
+		return ""
+	},
+	"decimal":     number(false, -1),
+	"integer":     number(true, -1<<31),
+	"unsignedInt": number(true, 0),
+	"positiveInt": number(true, 1),
+	"string":      text(nil),
+	"markdown":    text(nil),
+	"id":          text(reID),
+	"code":        text(reCode),
+	"uri":         text(reURI),
+	"canonical":   text(reURI),
+	"date":        text(reDate),
+	"dateTime":    text(reDateTime),
+	"instant":     text(reInstant),
+	"time":        text(reTime),
+}
+
+func text(pattern *regexp.Regexp) func(interface{}) string {
+	return func(v interface{}) string {
+		s, ok := v.(string)
+		switch {
+		case !ok:
+			return "must be a JSON string"
+		case strings.TrimSpace(s) == "":
+			return "must not be empty"
+		case pattern != nil && !pattern.MatchString(s):
+			return fmt.Sprintf("%q is not well formed", s)
+		}
+		return ""
+	}
+}
+
+func number(integer bool, min int64) func(interface{}) string {
+	return func(v interface{}) string {
+		n, ok := v.(json.Number)
+		if !ok {
+			return "must be a JSON number"
+		}
+		if !integer {
+			return ""
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return "must be an integer"
+		}
+		if min >= 0 && i < min {
+			return fmt.Sprintf("must be at least %d", min)
+		}
+		return ""
+	}
+}
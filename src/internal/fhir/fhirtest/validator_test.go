@@ -0,0 +1,90 @@
+package fhirtest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	v, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	tests := []struct {
+		name     string
+		resource string
+		wantErr  string // substring of one of the errors; "" for a valid resource
+	}{
+		{
+			name:     "valid service request",
+			resource: `{"resourceType":"ServiceRequest","id":"lead-1","status":"active","intent":"order","subject":{"reference":"Patient/p-1"},"authoredOn":"2024-05-01T10:00:00Z"}`,
+		},
+		{
+			name:     "missing required status",
+			resource: `{"resourceType":"ServiceRequest","intent":"order","subject":{"reference":"Patient/p-1"}}`,
+			wantErr:  "missing required element status",
+		},
+		{
+			name:     "code outside required binding",
+			resource: `{"resourceType":"ServiceRequest","status":"open","intent":"order","subject":{"reference":"Patient/p-1"}}`,
+			wantErr:  `code "open" is not in http://hl7.org/fhir/ValueSet/request-status`,
+		},
+		{
+			name:     "unknown element",
+			resource: `{"resourceType":"Patient","gender":"male","sex":"male"}`,
+			wantErr:  `unknown element "sex"`,
+		},
+		{
+			name:     "single value where an array is required",
+			resource: `{"resourceType":"Patient","name":{"text":"A B"}}`,
+			wantErr:  "must be an array",
+		},
+		{
+			name:     "array where a single value is required",
+			resource: `{"resourceType":"Patient","gender":["male"]}`,
+			wantErr:  "must be a single value",
+		},
+		{
+			name:     "malformed dateTime",
+			resource: `{"resourceType":"ServiceRequest","status":"active","intent":"order","subject":{"reference":"Patient/p-1"},"authoredOn":"01/05/2024"}`,
+			wantErr:  "dateTime",
+		},
+		{
+			name:     "choice type not allowed",
+			resource: `{"resourceType":"Observation","status":"final","code":{"text":"Hb"},"valueReference":{"reference":"Patient/p-1"}}`,
+			wantErr:  `unknown element "valueReference"`,
+		},
+		{
+			name:     "empty string",
+			resource: `{"resourceType":"Patient","name":[{"text":" "}]}`,
+			wantErr:  "must not be empty",
+		},
+		{
+			name:     "unresolved reference in bundle",
+			resource: `{"resourceType":"Bundle","type":"collection","entry":[{"fullUrl":"https://x/ServiceRequest/s-1","resource":{"resourceType":"ServiceRequest","id":"s-1","status":"active","intent":"order","subject":{"reference":"Patient/p-1"}}}]}`,
+			wantErr:  "reference Patient/p-1 does not resolve",
+		},
+		{
+			name:     "duplicate fullUrl",
+			resource: `{"resourceType":"Bundle","type":"collection","entry":[{"fullUrl":"https://x/Patient/p-1","resource":{"resourceType":"Patient","id":"p-1"}},{"fullUrl":"https://x/Patient/p-1","resource":{"resourceType":"Patient","id":"p-1"}}]}`,
+			wantErr:  "duplicate fullUrl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := v.Validate([]byte(tt.resource))
+			if tt.wantErr == "" {
+				for _, err := range errs {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tt.wantErr) {
+					return
+				}
+			}
+			t.Errorf("errors %v, want one containing %q", errs, tt.wantErr)
+		})
+	}
+}
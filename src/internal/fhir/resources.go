@@ -0,0 +1,243 @@
+// Package fhir holds the subset of FHIR R4 resources and data types the API exports. Field names
+// and cardinalities follow the R4 specification (http://hl7.org/fhir/R4); only elements we populate
+// are modelled, and empty ones are omitted from the JSON.
+package fhir
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// ContentType is the media type of FHIR JSON responses.
+const ContentType = "application/fhir+json"
+
+// Identifier systems for our own keys. FHIR only requires a URI, so URNs under our namespace are used.
+const (
+	SystemLead      = "urn:b2b-diagnostic-aggregator:lead"
+	SystemPatient   = "urn:b2b-diagnostic-aggregator:patient"
+	SystemClient    = "urn:b2b-diagnostic-aggregator:client"
+	SystemLab       = "urn:b2b-diagnostic-aggregator:lab"
+	SystemPackage   = "urn:b2b-diagnostic-aggregator:package"
+	SystemTest      = "urn:b2b-diagnostic-aggregator:test"
+	SystemParameter = "urn:b2b-diagnostic-aggregator:test-parameter"
+)
+
+// HL7 code systems used for categories and interpretations.
+const (
+	SystemObservationCategory       = "http://terminology.hl7.org/CodeSystem/observation-category"
+	SystemDiagnosticServiceSection  = "http://terminology.hl7.org/CodeSystem/v2-0074"
+	SystemObservationInterpretation = "http://terminology.hl7.org/CodeSystem/v3-ObservationInterpretation"
+)
+
+// Resource is a FHIR resource that can be placed in a bundle.
+type Resource interface {
+	// Ref is the resource's relative reference, e.g. "Patient/patient-12".
+	Ref() string
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	ID           string        `json:"id,omitempty"`
+	Type         string        `json:"type"`
+	Timestamp    *time.Time    `json:"timestamp,omitempty"`
+	Total        *int          `json:"total,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+type BundleEntry struct {
+	FullURL  string   `json:"fullUrl,omitempty"`
+	Resource Resource `json:"resource"`
+}
+
+// NewCollectionBundle bundles resources with absolute full URLs under base (e.g.
+// https://host/api/v1/fhir), so relative references between them resolve inside the bundle.
+func NewCollectionBundle(id, base string, resources ...Resource) *Bundle {
+	now := time.Now().UTC()
+	b := &Bundle{ResourceType: "Bundle", ID: id, Type: "collection", Timestamp: &now}
+	for _, r := range resources {
+		b.Entry = append(b.Entry, BundleEntry{FullURL: base + "/" + r.Ref(), Resource: r})
+	}
+	return b
+}
+
+// Data types
+
+type Meta struct {
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+}
+
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Reference points at a resource in the bundle (Reference) or, for resources we do not export,
+// identifies it logically by Type and Identifier.
+type Reference struct {
+	Reference  string      `json:"reference,omitempty"`
+	Type       string      `json:"type,omitempty"`
+	Identifier *Identifier `json:"identifier,omitempty"`
+	Display    string      `json:"display,omitempty"`
+}
+
+type Quantity struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+type HumanName struct {
+	Use  string `json:"use,omitempty"`
+	Text string `json:"text"`
+}
+
+type ContactPoint struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Address struct {
+	Use        string   `json:"use,omitempty"`
+	Line       []string `json:"line,omitempty"`
+	PostalCode string   `json:"postalCode,omitempty"`
+	Country    string   `json:"country,omitempty"`
+}
+
+type Annotation struct {
+	Text string `json:"text"`
+}
+
+// Resources
+
+type Patient struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Gender       string         `json:"gender,omitempty"`
+	Address      []Address      `json:"address,omitempty"`
+}
+
+func (p *Patient) Ref() string { return "Patient/" + p.ID }
+
+type ServiceRequest struct {
+	ResourceType string            `json:"resourceType"`
+	ID           string            `json:"id"`
+	Meta         *Meta             `json:"meta,omitempty"`
+	Identifier   []Identifier      `json:"identifier,omitempty"`
+	BasedOn      []Reference       `json:"basedOn,omitempty"`
+	Requisition  *Identifier       `json:"requisition,omitempty"`
+	Status       string            `json:"status"`
+	Intent       string            `json:"intent"`
+	Category     []CodeableConcept `json:"category,omitempty"`
+	Code         *CodeableConcept  `json:"code,omitempty"`
+	Subject      Reference         `json:"subject"`
+	AuthoredOn   *time.Time        `json:"authoredOn,omitempty"`
+	Requester    *Reference        `json:"requester,omitempty"`
+	Performer    []Reference       `json:"performer,omitempty"`
+}
+
+func (s *ServiceRequest) Ref() string { return "ServiceRequest/" + s.ID }
+
+type ObservationReferenceRange struct {
+	Low  *Quantity `json:"low,omitempty"`
+	High *Quantity `json:"high,omitempty"`
+}
+
+type Observation struct {
+	ResourceType      string                      `json:"resourceType"`
+	ID                string                      `json:"id"`
+	Identifier        []Identifier                `json:"identifier,omitempty"`
+	BasedOn           []Reference                 `json:"basedOn,omitempty"`
+	Status            string                      `json:"status"`
+	Category          []CodeableConcept           `json:"category,omitempty"`
+	Code              CodeableConcept             `json:"code"`
+	Subject           Reference                   `json:"subject"`
+	EffectiveDateTime *time.Time                  `json:"effectiveDateTime,omitempty"`
+	Issued            *time.Time                  `json:"issued,omitempty"`
+	Performer         []Reference                 `json:"performer,omitempty"`
+	ValueQuantity     *Quantity                   `json:"valueQuantity,omitempty"`
+	Interpretation    []CodeableConcept           `json:"interpretation,omitempty"`
+	Note              []Annotation                `json:"note,omitempty"`
+	ReferenceRange    []ObservationReferenceRange `json:"referenceRange,omitempty"`
+}
+
+func (o *Observation) Ref() string { return "Observation/" + o.ID }
+
+type DiagnosticReport struct {
+	ResourceType string            `json:"resourceType"`
+	ID           string            `json:"id"`
+	Meta         *Meta             `json:"meta,omitempty"`
+	Identifier   []Identifier      `json:"identifier,omitempty"`
+	BasedOn      []Reference       `json:"basedOn,omitempty"`
+	Status       string            `json:"status"`
+	Category     []CodeableConcept `json:"category,omitempty"`
+	Code         CodeableConcept   `json:"code"`
+	Subject      Reference         `json:"subject"`
+	Issued       *time.Time        `json:"issued,omitempty"`
+	Performer    []Reference       `json:"performer,omitempty"`
+	Result       []Reference       `json:"result,omitempty"`
+	Conclusion   string            `json:"conclusion,omitempty"`
+}
+
+func (d *DiagnosticReport) Ref() string { return "DiagnosticReport/" + d.ID }
+
+// LocalReference refers to a resource in the same bundle.
+func LocalReference(r Resource) Reference {
+	return Reference{Reference: r.Ref()}
+}
+
+// LogicalReference identifies a resource that is not exported, such as a client or lab organization.
+func LogicalReference(resourceType, system, value, display string) Reference {
+	return Reference{Type: resourceType, Identifier: &Identifier{System: system, Value: value}, Display: display}
+}
+
+// AdministrativeGender maps our gender codes (M, F, O or the spelled-out words) to the FHIR
+// administrative-gender value set.
+func AdministrativeGender(gender string) string {
+	switch domain.NormalizeGender(gender) {
+	case domain.GenderMale:
+		return "male"
+	case domain.GenderFemale:
+		return "female"
+	case domain.GenderOther:
+		return "other"
+	}
+	return "unknown"
+}
+
+// Interpretation maps a result flag to its v3 ObservationInterpretation code.
+func Interpretation(flag string) *CodeableConcept {
+	codes := map[string]Coding{
+		domain.ResultFlagNormal:       {System: SystemObservationInterpretation, Code: "N", Display: "Normal"},
+		domain.ResultFlagLow:          {System: SystemObservationInterpretation, Code: "L", Display: "Low"},
+		domain.ResultFlagHigh:         {System: SystemObservationInterpretation, Code: "H", Display: "High"},
+		domain.ResultFlagCriticalLow:  {System: SystemObservationInterpretation, Code: "LL", Display: "Critical low"},
+		domain.ResultFlagCriticalHigh: {System: SystemObservationInterpretation, Code: "HH", Display: "Critical high"},
+	}
+	coding, ok := codes[flag]
+	if !ok {
+		return nil
+	}
+	return &CodeableConcept{Coding: []Coding{coding}}
+}
+
+// LaboratoryCategory is the "laboratory" observation category.
+func LaboratoryCategory() CodeableConcept {
+	return CodeableConcept{Coding: []Coding{{System: SystemObservationCategory, Code: "laboratory", Display: "Laboratory"}}}
+}
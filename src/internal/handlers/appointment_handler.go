@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppointmentHandler struct {
+	svc service.AppointmentService
+}
+
+func NewAppointmentHandler(svc service.AppointmentService) *AppointmentHandler {
+	return &AppointmentHandler{svc: svc}
+}
+
+// GetLabSlots lists the lab's slots; with ?date= only active slots with their free capacity that day
+func (h *AppointmentHandler) GetLabSlots(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var query dto.LabSlotsQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	var date *time.Time
+	if query.Date != "" {
+		d, err := parseAppointmentDate(query.Date)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		date = &d
+	}
+	data, err := h.svc.ListSlots(params.ID, date, query.Pincode)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+// CreateLabSlot adds a daily collection slot to the lab
+func (h *AppointmentHandler) CreateLabSlot(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.LabSlotRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	slot, err := h.svc.CreateSlot(params.ID, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, slot, "Slot created successfully", nil)
+}
+
+// UpdateLabSlot replaces a slot's pincode, window, capacity and active flag
+func (h *AppointmentHandler) UpdateLabSlot(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.LabSlotIDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) || !middleware.RequirePositiveID(c, params.SlotID) {
+		return
+	}
+	var req dto.LabSlotRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	slot, err := h.svc.UpdateSlot(params.ID, params.SlotID, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, slot, "Slot updated successfully", nil)
+}
+
+// GetLabAppointments is the lab's collection list for ?date=
+func (h *AppointmentHandler) GetLabAppointments(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var query dto.LabAppointmentsQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	date, err := parseAppointmentDate(query.Date)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	data, err := h.svc.ListLabAppointments(params.ID, date, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data), "date": query.Date})
+}
+
+// GetLeadAppointments lists the lead's appointments, newest first, cancelled ones included
+func (h *AppointmentHandler) GetLeadAppointments(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.ListLeadAppointments(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+// Book books a slot for the lead and moves it to Scheduled
+func (h *AppointmentHandler) Book(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.AppointmentSlotRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	date, err := parseAppointmentDate(req.Date)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	appointment, err := h.svc.BookAppointment(params.ID, req.SlotID, date, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, appointment, "Appointment booked successfully", nil)
+}
+
+// Reschedule moves a booked appointment to another slot and/or date
+func (h *AppointmentHandler) Reschedule(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.AppointmentSlotRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	date, err := parseAppointmentDate(req.Date)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	appointment, err := h.svc.RescheduleAppointment(params.ID, req.SlotID, date, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, appointment, "Appointment rescheduled successfully", nil)
+}
+
+// Cancel cancels a booked appointment with a reason
+func (h *AppointmentHandler) Cancel(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.CancelAppointmentRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	appointment, err := h.svc.CancelAppointment(params.ID, req.Reason, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, appointment, "Appointment cancelled successfully", nil)
+}
+
+// parseAppointmentDate parses a YYYY-MM-DD date as midnight UTC, the form appointment dates are stored in.
+func parseAppointmentDate(raw string) (time.Time, error) {
+	date, err := time.Parse(domain.AppointmentDateLayout, raw)
+	if err != nil {
+		return time.Time{}, apperrors.NewBadRequest("date must be YYYY-MM-DD", err)
+	}
+	return date, nil
+}
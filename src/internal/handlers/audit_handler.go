@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// GetAll lists audit entries, newest first by default, filtered by entity, action, actor, request id and time range
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	var query dto.AuditListQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	if query.SortOrder == "" {
+		query.SortOrder = "desc"
+	}
+	page := query.PaginationQuery.Normalize("createdOn", 0)
+	filter := repository.AuditListFilter{
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		SortOrder:  page.SortOrder,
+		EntityType: strings.ToUpper(strings.TrimSpace(query.EntityType)),
+		EntityID:   query.EntityID,
+		Action:     query.Action,
+		ActorID:    query.ActorID,
+		RequestID:  strings.TrimSpace(query.RequestID),
+	}
+	var err error
+	if filter.From, err = parseOptionalTime(query.From); err != nil {
+		respondError(c, apperrors.NewBadRequest("from must be an RFC3339 timestamp", err))
+		return
+	}
+	if filter.To, err = parseOptionalTime(query.To); err != nil {
+		respondError(c, apperrors.NewBadRequest("to must be an RFC3339 timestamp", err))
+		return
+	}
+
+	data, total, err := h.svc.List(filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	entries := make([]dto.AuditEntryResponse, len(data))
+	for i, entry := range data {
+		entries[i] = dto.AuditEntryFromDomain(entry)
+	}
+	respondData(c, http.StatusOK, entries, "Success", gin.H{
+		"count":    len(data),
+		"page":     filter.Page,
+		"pageSize": filter.PageSize,
+		"total":    total,
+	})
+}
+
+func parseOptionalTime(raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -76,8 +76,8 @@ func (h *ClientHandler) GetByContactNumber(c *gin.Context) {
 }
 
 func (h *ClientHandler) Create(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -86,7 +86,7 @@ func (h *ClientHandler) Create(c *gin.Context) {
 		return
 	}
 	client := req.ToDomain()
-	if err := h.svc.CreateClient(&client, userID); err != nil {
+	if err := h.svc.CreateClient(&client, actor); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -94,8 +94,8 @@ func (h *ClientHandler) Create(c *gin.Context) {
 }
 
 func (h *ClientHandler) Update(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -114,7 +114,7 @@ func (h *ClientHandler) Update(c *gin.Context) {
 		respondError(c, apperrors.NewBadRequest("At least one field is required in the payload to update", nil))
 		return
 	}
-	client, err := h.svc.UpdateClient(params.ID, &req, userID)
+	client, err := h.svc.UpdateClient(params.ID, &req, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -130,7 +130,7 @@ func (h *ClientHandler) Delete(c *gin.Context) {
 	if !middleware.RequirePositiveID(c, params.ID) {
 		return
 	}
-	if err := h.svc.DeleteClient(params.ID); err != nil {
+	if err := h.svc.DeleteClient(params.ID, middleware.GetActor(c)); err != nil {
 		respondError(c, err)
 		return
 	}
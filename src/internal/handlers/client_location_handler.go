@@ -24,7 +24,7 @@ func (h *ClientLocationHandler) GetAllByClientID(c *gin.Context) {
 	if !middleware.BindUri(c, &params) {
 		return
 	}
-	data, err := h.svc.GetByClientID(params.ClientID)
+	data, err := h.svc.GetByClientID(params.ClientID, middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -37,7 +37,7 @@ func (h *ClientLocationHandler) GetByID(c *gin.Context) {
 	if !middleware.BindUri(c, &params) {
 		return
 	}
-	data, err := h.svc.GetByID(params.ID)
+	data, err := h.svc.GetByID(params.ID, middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -46,8 +46,8 @@ func (h *ClientLocationHandler) GetByID(c *gin.Context) {
 }
 
 func (h *ClientLocationHandler) Create(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -60,7 +60,7 @@ func (h *ClientLocationHandler) Create(c *gin.Context) {
 		return
 	}
 	loc := req.ToDomain(pathParams.ClientID)
-	if err := h.svc.Create(&loc, userID); err != nil {
+	if err := h.svc.Create(&loc, actor, middleware.GetTenantScope(c)); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -68,8 +68,8 @@ func (h *ClientLocationHandler) Create(c *gin.Context) {
 }
 
 func (h *ClientLocationHandler) Update(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -88,7 +88,7 @@ func (h *ClientLocationHandler) Update(c *gin.Context) {
 		respondError(c, apperrors.NewBadRequest("At least one field is required in the payload to update", nil))
 		return
 	}
-	loc, err := h.svc.Update(params.ID, &req, userID)
+	loc, err := h.svc.Update(params.ID, &req, actor, middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -104,7 +104,7 @@ func (h *ClientLocationHandler) Delete(c *gin.Context) {
 	if !middleware.RequirePositiveID(c, params.ID) {
 		return
 	}
-	if err := h.svc.Delete(params.ID); err != nil {
+	if err := h.svc.Delete(params.ID, middleware.GetActor(c), middleware.GetTenantScope(c)); err != nil {
 		respondError(c, err)
 		return
 	}
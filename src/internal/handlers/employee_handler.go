@@ -55,8 +55,8 @@ func (h *EmployeeHandler) GetByContactNumber(c *gin.Context) {
 }
 
 func (h *EmployeeHandler) Create(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -65,7 +65,7 @@ func (h *EmployeeHandler) Create(c *gin.Context) {
 		return
 	}
 	emp := req.ToDomain()
-	if err := h.svc.Create(&emp, userID); err != nil {
+	if err := h.svc.Create(&emp, actor); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -73,8 +73,8 @@ func (h *EmployeeHandler) Create(c *gin.Context) {
 }
 
 func (h *EmployeeHandler) Update(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -93,7 +93,7 @@ func (h *EmployeeHandler) Update(c *gin.Context) {
 		respondError(c, apperrors.NewBadRequest("At least one field is required in the payload to update", nil))
 		return
 	}
-	emp, err := h.svc.Update(params.ID, &req, userID)
+	emp, err := h.svc.Update(params.ID, &req, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -109,7 +109,7 @@ func (h *EmployeeHandler) Delete(c *gin.Context) {
 	if !middleware.RequirePositiveID(c, params.ID) {
 		return
 	}
-	if err := h.svc.Delete(params.ID); err != nil {
+	if err := h.svc.Delete(params.ID, middleware.GetActor(c)); err != nil {
 		respondError(c, err)
 		return
 	}
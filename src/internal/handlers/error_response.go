@@ -22,10 +22,14 @@ func respondError(c *gin.Context, err error) {
 			status = http.StatusBadRequest
 		case apperrors.KindUnauthorized:
 			status = http.StatusUnauthorized
+		case apperrors.KindForbidden:
+			status = http.StatusForbidden
 		case apperrors.KindNotFound:
 			status = http.StatusNotFound
 		case apperrors.KindConflict:
 			status = http.StatusConflict
+		case apperrors.KindTooManyRequests:
+			status = http.StatusTooManyRequests
 		case apperrors.KindInternal:
 			status = http.StatusInternalServerError
 		}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/fhir"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FHIRHandler struct {
+	svc service.FHIRService
+}
+
+func NewFHIRHandler(svc service.FHIRService) *FHIRHandler {
+	return &FHIRHandler{svc: svc}
+}
+
+// GetServiceRequest returns the lead as a FHIR R4 Bundle of Patient and ServiceRequests
+func (h *FHIRHandler) GetServiceRequest(c *gin.Context) {
+	var params dto.LeadIDPathParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.LeadID) {
+		return
+	}
+	bundle, err := h.svc.ServiceRequestBundle(params.LeadID, fhirBaseURL(c), middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondFHIR(c, bundle)
+}
+
+// GetDiagnosticReport returns the lead's results as a FHIR R4 Bundle of DiagnosticReport and Observations
+func (h *FHIRHandler) GetDiagnosticReport(c *gin.Context) {
+	var params dto.LeadIDPathParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.LeadID) {
+		return
+	}
+	bundle, err := h.svc.DiagnosticReportBundle(params.LeadID, fhirBaseURL(c), middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondFHIR(c, bundle)
+}
+
+// respondFHIR writes the resource as-is, without the API response envelope, as FHIR clients expect.
+func respondFHIR(c *gin.Context, resource interface{}) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, fhir.ContentType+"; charset=utf-8", body)
+}
+
+// fhirBaseURL is the absolute URL of the FHIR endpoints, e.g. https://host/api/v1/fhir.
+func fhirBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	path := c.Request.URL.Path
+	if i := strings.Index(path, "/fhir/"); i >= 0 {
+		path = path[:i]
+	}
+	return scheme + "://" + c.Request.Host + path + "/fhir"
+}
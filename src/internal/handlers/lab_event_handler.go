@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLabEventBytes caps the body read before the signature is checked.
+const maxLabEventBytes = 1 << 20
+
+type LabEventHandler struct {
+	svc service.LabEventService
+}
+
+func NewLabEventHandler(svc service.LabEventService) *LabEventHandler {
+	return &LabEventHandler{svc: svc}
+}
+
+// Receive accepts a signed status event from a lab (public; authorized by X-Lab-Signature)
+func (h *LabEventHandler) Receive(c *gin.Context) {
+	var params dto.LabEventPathParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.LabID) {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLabEventBytes+1))
+	if err != nil {
+		respondError(c, apperrors.NewBadRequest("Could not read request body", err))
+		return
+	}
+	if len(body) > maxLabEventBytes {
+		respondError(c, apperrors.NewBadRequest("Request body is too large", nil))
+		return
+	}
+	if err := h.svc.Authenticate(params.LabID, c.GetHeader("X-Lab-Timestamp"), c.GetHeader("X-Lab-Nonce"), c.GetHeader("X-Lab-Signature"), body); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	var req dto.LabEventRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	data, err := h.svc.Apply(params.LabID, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	message := "Lab event received"
+	if data.Duplicate {
+		message = "Lab event already received"
+	}
+	respondData(c, http.StatusOK, data, message, nil)
+}
+
+// RotateSecret issues a new event signing secret for the lab; the previous one stops working
+func (h *LabEventHandler) RotateSecret(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.RotateSecret(params.ID, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Lab event secret generated; store it now, it is not shown again", nil)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LabOrderHandler struct {
+	svc service.LabOrderService
+}
+
+func NewLabOrderHandler(svc service.LabOrderService) *LabOrderHandler {
+	return &LabOrderHandler{svc: svc}
+}
+
+// GetIntegration returns how orders reach the lab's LIS (MANUAL when never configured)
+func (h *LabOrderHandler) GetIntegration(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.GetIntegration(params.ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", nil)
+}
+
+// SaveIntegration creates or replaces the lab's LIS integration settings
+func (h *LabOrderHandler) SaveIntegration(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.LabIntegrationRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	data, err := h.svc.SaveIntegration(params.ID, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Lab integration saved successfully", nil)
+}
+
+// GetLeadOrders lists the lead's lab order deliveries, latest first, with their attempts
+func (h *LabOrderHandler) GetLeadOrders(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.ListDeliveries(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+// Resend queues the lead's order for its assigned lab again, superseding undelivered orders
+func (h *LabOrderHandler) Resend(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.Enqueue(params.ID, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, data, "Lab order queued successfully", nil)
+}
+
+// Retry requeues a failed lab order delivery
+func (h *LabOrderHandler) Retry(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.LeadLabOrderParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) || !middleware.RequirePositiveID(c, params.DeliveryID) {
+		return
+	}
+	data, err := h.svc.Retry(params.ID, params.DeliveryID, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Lab order requeued successfully", nil)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LabRoutingHandler struct {
+	svc service.LabRoutingService
+}
+
+func NewLabRoutingHandler(svc service.LabRoutingService) *LabRoutingHandler {
+	return &LabRoutingHandler{svc: svc}
+}
+
+// GetLabOptions ranks the labs that can take the lead (cheapest first) and lists excluded labs with reasons
+func (h *LabRoutingHandler) GetLabOptions(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var query dto.LabOptionsQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	data, err := h.svc.LabOptions(params.ID, query.CollectionType)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	eligible := 0
+	for _, opt := range data {
+		if opt.Eligible {
+			eligible++
+		}
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data), "eligibleCount": eligible})
+}
+
+// AssignLab stores the chosen (or top-ranked) lab on the lead
+func (h *LabRoutingHandler) AssignLab(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.AssignLabRequest
+	if c.Request.ContentLength != 0 && !middleware.BindJSON(c, &req) {
+		return
+	}
+	lead, err := h.svc.AssignLab(params.ID, req.LabID, req.CollectionType, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, lead, "Lab assigned successfully", nil)
+}
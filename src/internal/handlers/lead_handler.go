@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"b2b-diagnostic-aggregator/apis/internal/apperrors"
 	"b2b-diagnostic-aggregator/apis/internal/dto"
@@ -37,7 +42,7 @@ func (h *LeadHandler) GetAll(c *gin.Context) {
 		PackageID: query.PackageID,
 	}
 
-	data, total, err := h.svc.ListLeads(filter)
+	data, total, err := h.svc.ListLeads(filter, middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -55,7 +60,7 @@ func (h *LeadHandler) GetByID(c *gin.Context) {
 	if !middleware.BindUri(c, &params) {
 		return
 	}
-	data, err := h.svc.GetLeadByID(params.ID)
+	data, err := h.svc.GetLeadByID(params.ID, middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -64,8 +69,8 @@ func (h *LeadHandler) GetByID(c *gin.Context) {
 }
 
 func (h *LeadHandler) Create(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -74,7 +79,7 @@ func (h *LeadHandler) Create(c *gin.Context) {
 		return
 	}
 	lead := req.ToDomain()
-	if err := h.svc.CreateLead(&lead, userID); err != nil {
+	if err := h.svc.CreateLead(&lead, req.AllowDuplicate, actor, middleware.GetTenantScope(c)); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -82,8 +87,8 @@ func (h *LeadHandler) Create(c *gin.Context) {
 }
 
 func (h *LeadHandler) Update(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -102,7 +107,7 @@ func (h *LeadHandler) Update(c *gin.Context) {
 		respondError(c, apperrors.NewBadRequest("At least one field is required in the payload to update", nil))
 		return
 	}
-	lead, err := h.svc.UpdateLead(params.ID, &req, userID)
+	lead, err := h.svc.UpdateLead(params.ID, &req, actor, middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -118,11 +123,11 @@ func (h *LeadHandler) Delete(c *gin.Context) {
 	if !middleware.RequirePositiveID(c, params.ID) {
 		return
 	}
-	actorID, _ := middleware.GetUserID(c)
-	if actorID == 0 {
-		actorID = 1
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		actor.UserID = 1
 	}
-	if err := h.svc.DeleteLead(params.ID, actorID); err != nil {
+	if err := h.svc.DeleteLead(params.ID, actor); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -130,16 +135,29 @@ func (h *LeadHandler) Delete(c *gin.Context) {
 }
 
 func (h *LeadHandler) BulkUpdateStatus(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
+	dryRun, ok := dryRunRequested(c)
+	if !ok {
+		return
+	}
 	var req dto.BulkUpdateLeadStatusRequest
 	if !middleware.BindJSON(c, &req) {
 		return
 	}
-	count, err := h.svc.BulkUpdateLeadStatus(req.LeadIDs, req.LeadStatusID, userID)
+	if dryRun {
+		preview, err := h.svc.PreviewBulkLeadStatus(req.LeadIDs, req.LeadStatusID, req.Reason)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondData(c, http.StatusOK, preview, "Dry run: no leads were updated", nil)
+		return
+	}
+	count, err := h.svc.BulkUpdateLeadStatus(req.LeadIDs, req.LeadStatusID, req.Reason, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -147,7 +165,98 @@ func (h *LeadHandler) BulkUpdateStatus(c *gin.Context) {
 	respondData(c, http.StatusOK, gin.H{"updatedCount": count}, "Lead statuses updated successfully", nil)
 }
 
+// GetDuplicates lists groups of leads that look like the same order (optionally for one client)
+func (h *LeadHandler) GetDuplicates(c *gin.Context) {
+	var query dto.LeadDuplicatesQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	data, err := h.svc.ListDuplicates(query.ClientID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+// Merge folds the duplicate leads in the body into the lead in the URL
+func (h *LeadHandler) Merge(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.MergeLeadsRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	lead, err := h.svc.MergeLeads(params.ID, req.DuplicateLeadIDs, req.Reason, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, lead, "Leads merged successfully", nil)
+}
+
+// GetStatuses returns the lead status catalog and transition graph; with ?leadIds=1,2 it also returns
+// the next allowed statuses for each of those leads.
+func (h *LeadHandler) GetStatuses(c *gin.Context) {
+	var query dto.LeadStatusesQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	var leadIDs []int64
+	for _, part := range strings.Split(query.LeadIDs, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, apperrors.NewBadRequest("leadIds must be a comma-separated list of positive integers", err))
+			return
+		}
+		leadIDs = append(leadIDs, id)
+	}
+
+	data, err := h.svc.GetStatusWorkflow(leadIDs, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", nil)
+}
+
+// GetHistory returns the lead's change history with field-level diffs and actor names
+func (h *LeadHandler) GetHistory(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.GetLeadHistory(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
 func (h *LeadHandler) BulkImportCsv(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
 	file, err := c.FormFile("file")
 	if err != nil || file == nil {
 		respondError(c, apperrors.NewBadRequest("CSV file is required", err))
@@ -166,24 +275,114 @@ func (h *LeadHandler) BulkImportCsv(c *gin.Context) {
 		return
 	}
 
+	strict := false
+	if raw := c.PostForm("strict"); raw != "" {
+		if strict, err = strconv.ParseBool(raw); err != nil {
+			respondError(c, apperrors.NewBadRequest("strict must be true or false", err))
+			return
+		}
+	}
+
+	dryRun, ok := dryRunRequested(c)
+	if !ok {
+		return
+	}
+
 	f, err := file.Open()
 	if err != nil {
 		respondError(c, apperrors.NewBadRequest("Failed to read file", err))
 		return
 	}
 	defer f.Close()
-	buf := make([]byte, file.Size+1)
-	n, _ := f.Read(buf)
-	buf = buf[:n]
+	buf, err := io.ReadAll(f)
+	if err != nil {
+		respondError(c, apperrors.NewBadRequest("Failed to read file", err))
+		return
+	}
+
+	if dryRun {
+		preview, err := h.svc.PreviewCSVImport(buf, clientID, packageID, strict)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondData(c, http.StatusOK, preview, "Dry run: no leads were imported", nil)
+		return
+	}
+
+	job, err := h.svc.StartCSVImport(buf, clientID, packageID, strict, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusAccepted, dto.ToLeadImportJobResponse(*job, nil, importJobURL(c, job.JobID)), "Import job queued", nil)
+}
+
+// GetImportJob returns an import job's progress and row errors
+func (h *LeadHandler) GetImportJob(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	job, rowErrors, err := h.svc.GetImportJob(params.ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, dto.ToLeadImportJobResponse(*job, rowErrors, importJobURL(c, job.JobID)), "Success", nil)
+}
 
-	userID, _ := middleware.GetUserID(c)
-	if userID == 0 {
-		userID = 1
+// GetImportJobErrors downloads an import job's row errors as CSV (RowNumber, Column, Reason)
+func (h *LeadHandler) GetImportJobErrors(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
 	}
-	inserted, err := h.svc.BulkImportFromCSV(buf, clientID, packageID, userID)
+	_, rowErrors, err := h.svc.GetImportJob(params.ID)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
-	respondData(c, http.StatusCreated, gin.H{"insertedCount": inserted}, "Leads imported successfully", nil)
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	_ = w.Write([]string{"RowNumber", "Column", "Reason"})
+	for _, e := range rowErrors {
+		_ = w.Write([]string{strconv.Itoa(e.RowNumber), e.Column, e.Reason})
+	}
+	w.Flush()
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="lead-import-%d-errors.csv"`, params.ID))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", out.Bytes())
+}
+
+// dryRunRequested reads the dryRun flag from the query string or form body. It responds with 400 and
+// returns ok=false when the value is not a boolean.
+func dryRunRequested(c *gin.Context) (dryRun bool, ok bool) {
+	raw := c.Query("dryRun")
+	if raw == "" {
+		raw = c.PostForm("dryRun")
+	}
+	if raw == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		respondError(c, apperrors.NewBadRequest("dryRun must be true or false", err))
+		return false, false
+	}
+	return dryRun, true
+}
+
+// importJobURL is the path of an import job under the /leads group the request came through.
+func importJobURL(c *gin.Context, jobID int64) string {
+	path := c.Request.URL.Path
+	if i := strings.LastIndex(path, "/leads"); i >= 0 {
+		path = path[:i+len("/leads")]
+	}
+	return fmt.Sprintf("%s/import-jobs/%d", path, jobID)
 }
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+type LeadStreamHandler struct {
+	stream    service.LeadStream
+	heartbeat time.Duration
+}
+
+func NewLeadStreamHandler(stream service.LeadStream, heartbeat time.Duration) *LeadStreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &LeadStreamHandler{stream: stream, heartbeat: heartbeat}
+}
+
+// Stream sends lead events within the caller's tenant scope as server-sent events. Each event's id is
+// its outbox ID; a client resumes by sending it back as the Last-Event-ID header (or lastEventId query
+// parameter). A "reset" event means the gap was too long to replay and the client should reload.
+// Comment lines are sent as heartbeats while idle.
+func (h *LeadStreamHandler) Stream(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	lastEventID, err := lastEventIDFrom(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	sub, err := h.stream.Subscribe(middleware.GetTenantScope(c), lastEventID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	sent := lastEventID
+	write := func(event dto.LeadStreamEvent) bool {
+		if event.ID <= sent {
+			return true
+		}
+		sent = event.ID
+		return sse.Encode(c.Writer, sse.Event{Id: strconv.FormatInt(event.ID, 10), Event: event.Type, Data: event.Data}) == nil
+	}
+
+	if err := sse.Encode(c.Writer, sse.Event{Event: "ready", Retry: 3000, Data: gin.H{"lastEventId": sent}}); err != nil {
+		return
+	}
+	if sub.Reset {
+		sent = sub.ResumeID
+		data := gin.H{"reason": "Too many events were missed; reload leads"}
+		if err := sse.Encode(c.Writer, sse.Event{Id: strconv.FormatInt(sent, 10), Event: "reset", Data: data}); err != nil {
+			return
+		}
+	}
+	for _, event := range sub.Backlog {
+		if !write(event) {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind or the server is stopping; the client reconnects and resumes from sent
+				return
+			}
+			if !write(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(c.Writer, ": heartbeat %d\n\n", time.Now().Unix()); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func lastEventIDFrom(c *gin.Context) (int64, error) {
+	raw := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(c.Query("lastEventId"))
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, apperrors.NewBadRequest("Last-Event-ID must be a non-negative integer", err)
+	}
+	return id, nil
+}
@@ -45,6 +45,7 @@ func (h *LoginHandler) Login(c *gin.Context) {
 		return
 	}
 	req.Domain = domain
+	req.ClientIP = c.ClientIP()
 	req.MobileNumber = strings.TrimSpace(req.MobileNumber)
 	fmt.Printf("[LOGIN] Handler.Login: bound request domain=%q mobileNumber=%q userId=%d\n", req.Domain, req.MobileNumber, req.UserID)
 
@@ -71,7 +72,7 @@ func (h *LoginHandler) Login(c *gin.Context) {
 	respondData(c, http.StatusOK, resp, "Authenticated", nil)
 }
 
-// CreateForgotPasswordKey creates a forgot-password key for the given mobile number (X-Domain required)
+// CreateForgotPasswordKey sends a forgot-password OTP to the given mobile number (X-Domain required)
 func (h *LoginHandler) CreateForgotPasswordKey(c *gin.Context) {
 	domain := middleware.GetDomain(c)
 	if domain == "" {
@@ -99,11 +100,11 @@ func (h *LoginHandler) CreateForgotPasswordKey(c *gin.Context) {
 		return
 	}
 
-	respondData(c, http.StatusOK, n, "Forgot password key generated successfully", nil)
+	respondData(c, http.StatusOK, n, "If the mobile number is registered, an OTP has been sent", nil)
 }
 
-// GetForgotPasswordKey returns the latest non-expired forgot-password key (query: mobileNumber)
-func (h *LoginHandler) GetForgotPasswordKey(c *gin.Context) {
+// ForgotPasswordReset resets password using the OTP sent to mobileNumber and the new Password (X-Domain required)
+func (h *LoginHandler) ForgotPasswordReset(c *gin.Context) {
 	domain := middleware.GetDomain(c)
 	if domain == "" {
 		raw := c.GetHeader("X-Domain")
@@ -117,36 +118,20 @@ func (h *LoginHandler) GetForgotPasswordKey(c *gin.Context) {
 		return
 	}
 
-	var req dto.GetForgotPasswordKeyRequest
-	if !middleware.BindQuery(c, &req) {
-		return
-	}
-	req.Domain = domain
-	req.MobileNumber = strings.TrimSpace(req.MobileNumber)
-
-	result, err := h.svc.GetLatestForgotPasswordKey(req.Domain, req.MobileNumber)
-	if err != nil {
-		respondError(c, err)
-		return
-	}
-
-	respondData(c, http.StatusOK, result, "Forgot password key fetched successfully", nil)
-}
-
-// ForgotPasswordReset resets password using forgetPasswordKey and new Password
-func (h *LoginHandler) ForgotPasswordReset(c *gin.Context) {
 	var req dto.ForgotPasswordResetRequest
 	if !middleware.BindJSON(c, &req) {
 		return
 	}
+	req.Domain = domain
+	req.MobileNumber = strings.TrimSpace(req.MobileNumber)
 
-	ok, err := h.svc.ForgotPasswordReset(req.ForgetPasswordKey, req.Password)
+	ok, err := h.svc.ForgotPasswordReset(req.Domain, req.MobileNumber, strings.TrimSpace(req.Otp), req.Password)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
 	if !ok {
-		respondError(c, apperrors.NewBadRequest("Invalid or expired forgot password key", nil))
+		respondError(c, apperrors.NewBadRequest("Invalid or expired OTP", nil))
 		return
 	}
 
@@ -173,9 +158,10 @@ func (h *LoginHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 	req.Domain = domain
+	req.ClientIP = c.ClientIP()
 	req.MobileNumber = strings.TrimSpace(req.MobileNumber)
 
-	ok, err := h.svc.ChangePassword(req.Domain, req.MobileNumber, req.OldPassword, req.NewPassword)
+	ok, err := h.svc.ChangePassword(req.Domain, req.MobileNumber, req.OldPassword, req.NewPassword, req.ClientIP)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -188,6 +174,21 @@ func (h *LoginHandler) ChangePassword(c *gin.Context) {
 	respondData(c, http.StatusOK, 1, "Password updated successfully", nil)
 }
 
+// UnlockAccount clears failed-login lockouts for a user and/or client IP (employees only)
+func (h *LoginHandler) UnlockAccount(c *gin.Context) {
+	var req dto.UnlockAccountRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.svc.UnlockAccount(req.UserType, req.UserID, strings.TrimSpace(req.IP)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondMessage(c, http.StatusOK, "Account unlocked successfully")
+}
+
 // GetProfile returns user profile by userId or mobileNumber (query), X-Domain required
 func (h *LoginHandler) GetProfile(c *gin.Context) {
 	domain := middleware.GetDomain(c)
@@ -232,3 +233,29 @@ func (h *LoginHandler) GetProfile(c *gin.Context) {
 
 	respondData(c, http.StatusOK, result, "Profile fetched successfully", nil)
 }
+
+// RefreshToken exchanges a refresh token for a new access/refresh pair (the old refresh token is consumed)
+func (h *LoginHandler) RefreshToken(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.svc.RefreshToken(strings.TrimSpace(req.RefreshToken))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondData(c, http.StatusOK, result, "Token refreshed successfully", nil)
+}
+
+// Logout revokes the caller's session so its access and refresh tokens stop working
+func (h *LoginHandler) Logout(c *gin.Context) {
+	if err := h.svc.Logout(middleware.GetSessionID(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondMessage(c, http.StatusOK, "Logged out successfully")
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/config"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/lockout"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// noLogins has no login records, so every legacy userId login fails and counts against the IP.
+type noLogins struct {
+	repository.LoginRepository
+}
+
+func (noLogins) FindByUserID(int64) (*domain.Login, error) { return nil, gorm.ErrRecordNotFound }
+
+func TestLoginIPLimitUsesTrustedClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const peer = "10.0.0.5"
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantLastStatus int
+	}{
+		{
+			name:           "spoofed X-Forwarded-For from a direct client is ignored",
+			wantLastStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "X-Forwarded-For from the trusted proxy names the client",
+			trustedProxies: []string{peer},
+			wantLastStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userGuard := lockout.NewGuard(lockout.NewMemoryStore(time.Hour), lockout.Policy{})
+			ipGuard := lockout.NewGuard(lockout.NewMemoryStore(time.Hour), lockout.Policy{MaxFailures: 3, LockDuration: time.Hour})
+			svc := service.NewLoginService(noLogins{}, nil, nil, nil, nil, nil, nil, userGuard, ipGuard, config.JWTConfig{})
+
+			r := gin.New()
+			if err := r.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatal(err)
+			}
+			r.POST("/login", NewLoginHandler(svc).Login)
+
+			var status int
+			for i := 1; i <= 4; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"userId":99,"Password":"guess"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i))
+				req.RemoteAddr = peer + ":40000"
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				status = w.Code
+			}
+			if status != tt.wantLastStatus {
+				t.Fatalf("fourth login status = %d, want %d", status, tt.wantLastStatus)
+			}
+		})
+	}
+}
@@ -64,8 +64,8 @@ func (h *PackageHandler) GetByID(c *gin.Context) {
 }
 
 func (h *PackageHandler) Create(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -74,7 +74,7 @@ func (h *PackageHandler) Create(c *gin.Context) {
 		return
 	}
 	pkg := req.ToDomain()
-	if err := h.svc.CreatePackage(&pkg, userID); err != nil {
+	if err := h.svc.CreatePackage(&pkg, actor); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -87,7 +87,7 @@ func (h *PackageHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.DeletePackage(params.ID); err != nil {
+	if err := h.svc.DeletePackage(params.ID, middleware.GetActor(c)); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -95,8 +95,8 @@ func (h *PackageHandler) Delete(c *gin.Context) {
 }
 
 func (h *PackageHandler) CreateWithTests(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -105,7 +105,7 @@ func (h *PackageHandler) CreateWithTests(c *gin.Context) {
 		return
 	}
 	pkg := req.PackageRequest.ToDomain()
-	result, err := h.svc.CreatePackageWithTests(&pkg, req.TestIDs, userID)
+	result, err := h.svc.CreatePackageWithTests(&pkg, req.TestIDs, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -152,8 +152,8 @@ func (h *PackageHandler) GetAllWithTestsDetails(c *gin.Context) {
 }
 
 func (h *PackageHandler) UpdatePackageStatus(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -168,7 +168,7 @@ func (h *PackageHandler) UpdatePackageStatus(c *gin.Context) {
 	if !middleware.BindJSON(c, &req) {
 		return
 	}
-	result, err := h.svc.UpdatePackageStatus(params.ID, req.IsActive, userID)
+	result, err := h.svc.UpdatePackageStatus(params.ID, req.IsActive, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -186,8 +186,8 @@ func formatInt(n int) string {
 }
 
 func (h *PackageHandler) CreatePackageClientMapping(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -195,7 +195,7 @@ func (h *PackageHandler) CreatePackageClientMapping(c *gin.Context) {
 	if !middleware.BindJSON(c, &req) {
 		return
 	}
-	result, err := h.svc.CreatePackageClientMapping(req.PackageID, req.ClientID, req.Price, userID, userID)
+	result, err := h.svc.CreatePackageClientMapping(req.PackageID, req.ClientID, req.Price, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -208,7 +208,7 @@ func (h *PackageHandler) CreatePackageClientMapping(c *gin.Context) {
 }
 
 func (h *PackageHandler) GetAllPackageClientMappings(c *gin.Context) {
-	data, err := h.svc.GetAllPackageClientMappings()
+	data, err := h.svc.GetAllPackageClientMappings(middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -217,8 +217,8 @@ func (h *PackageHandler) GetAllPackageClientMappings(c *gin.Context) {
 }
 
 func (h *PackageHandler) UpdatePackageClientMappingStatus(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -237,7 +237,7 @@ func (h *PackageHandler) UpdatePackageClientMappingStatus(c *gin.Context) {
 		respondError(c, apperrors.NewBadRequest("IsActive is required", nil))
 		return
 	}
-	result, err := h.svc.UpdatePackageClientMappingStatus(params.ID, *req.IsActive, userID)
+	result, err := h.svc.UpdatePackageClientMappingStatus(params.ID, *req.IsActive, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -246,8 +246,8 @@ func (h *PackageHandler) UpdatePackageClientMappingStatus(c *gin.Context) {
 }
 
 func (h *PackageHandler) CreatePackageLabMapping(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -255,7 +255,7 @@ func (h *PackageHandler) CreatePackageLabMapping(c *gin.Context) {
 	if !middleware.BindJSON(c, &req) {
 		return
 	}
-	result, err := h.svc.CreatePackageLabMapping(req.PackageID, req.LabID, req.Price, userID, userID)
+	result, err := h.svc.CreatePackageLabMapping(req.PackageID, req.LabID, req.Price, actor)
 	if err != nil {
 		respondError(c, err)
 		return
@@ -268,7 +268,7 @@ func (h *PackageHandler) CreatePackageLabMapping(c *gin.Context) {
 }
 
 func (h *PackageHandler) GetAllPackageLabMappings(c *gin.Context) {
-	data, err := h.svc.GetAllPackageLabMappings()
+	data, err := h.svc.GetAllPackageLabMappings(middleware.GetTenantScope(c))
 	if err != nil {
 		respondError(c, err)
 		return
@@ -277,8 +277,8 @@ func (h *PackageHandler) GetAllPackageLabMappings(c *gin.Context) {
 }
 
 func (h *PackageHandler) UpdatePackageLabMappingStatus(c *gin.Context) {
-	userID, ok := middleware.GetUserID(c)
-	if !ok {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
 		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
 		return
 	}
@@ -297,7 +297,7 @@ func (h *PackageHandler) UpdatePackageLabMappingStatus(c *gin.Context) {
 		respondError(c, apperrors.NewBadRequest("IsActive is required", nil))
 		return
 	}
-	result, err := h.svc.UpdatePackageLabMappingStatus(params.ID, *req.IsActive, userID)
+	result, err := h.svc.UpdatePackageLabMappingStatus(params.ID, *req.IsActive, actor)
 	if err != nil {
 		respondError(c, err)
 		return
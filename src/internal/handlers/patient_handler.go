@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PatientHandler struct {
+	svc service.PatientService
+}
+
+func NewPatientHandler(svc service.PatientService) *PatientHandler {
+	return &PatientHandler{svc: svc}
+}
+
+// GetByID returns the patient and their family members (patients sharing the contact number)
+func (h *PatientHandler) GetByID(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.GetPatient(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", nil)
+}
+
+// GetLeads returns the patient's testing timeline: all their leads, oldest first
+func (h *PatientHandler) GetLeads(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.GetTimeline(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data.Leads)})
+}
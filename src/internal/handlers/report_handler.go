@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	svc service.ReportService
+}
+
+func NewReportHandler(svc service.ReportService) *ReportHandler {
+	return &ReportHandler{svc: svc}
+}
+
+// Upload stores a report PDF (multipart "file") as the lead's next report version. Optional form
+// fields: checksum (hex SHA-256 to verify) and amendmentReason (required after the first version).
+func (h *ReportHandler) Upload(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil || file == nil {
+		respondError(c, apperrors.NewBadRequest("Report file is required", err))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		respondError(c, apperrors.NewBadRequest("Failed to read file", err))
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		respondError(c, apperrors.NewBadRequest("Failed to read file", err))
+		return
+	}
+	report, err := h.svc.Upload(params.ID, file.Filename, data, c.PostForm("checksum"), c.PostForm("amendmentReason"), actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, report, "Report uploaded successfully", nil)
+}
+
+// GetReports lists the lead's report versions, latest first (clients see released reports only)
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.ListReports(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+// Release makes the latest report version downloadable by the owning client
+func (h *ReportHandler) Release(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.LeadReportParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) || !middleware.RequirePositiveID(c, params.ReportID) {
+		return
+	}
+	report, err := h.svc.Release(params.ID, params.ReportID, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, report, "Report released successfully", nil)
+}
+
+// GetDownloadURL returns a time-limited signed URL for the report
+func (h *ReportHandler) GetDownloadURL(c *gin.Context) {
+	var params dto.LeadReportParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) || !middleware.RequirePositiveID(c, params.ReportID) {
+		return
+	}
+	link, err := h.svc.DownloadLink(params.ID, params.ReportID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, dto.ReportDownloadURL{
+		ReportID:  link.ReportID,
+		URL:       fmt.Sprintf("%s/reports/%d/download?expires=%d&signature=%s", apiBasePath(c), link.ReportID, link.ExpiresAt.Unix(), link.Signature),
+		ExpiresAt: link.ExpiresAt,
+	}, "Success", nil)
+}
+
+// Download serves a report through a signed URL; the signature stands in for authentication
+func (h *ReportHandler) Download(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var query dto.ReportDownloadQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	report, data, err := h.svc.Download(params.ID, query.Expires, query.Signature)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename=%q`, report.FileName))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, report.ContentType, data)
+}
+
+// apiBasePath is the request path up to the API version prefix, e.g. /api/v1.
+func apiBasePath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	if i := strings.Index(path, "/leads"); i >= 0 {
+		return path[:i]
+	}
+	return "/api/v1"
+}
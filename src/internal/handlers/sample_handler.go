@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SampleHandler struct {
+	svc service.SampleService
+}
+
+func NewSampleHandler(svc service.SampleService) *SampleHandler {
+	return &SampleHandler{svc: svc}
+}
+
+// Collect registers the lead's collected tubes and returns them with their accession numbers
+func (h *SampleHandler) Collect(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.CollectSamplesRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	samples, err := h.svc.CollectSamples(params.ID, req.TubeTypes, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, samples, "Samples collected successfully", gin.H{"count": len(samples)})
+}
+
+// GetLeadSamples lists the lead's samples in tube order
+func (h *SampleHandler) GetLeadSamples(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.ListLeadSamples(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+// Scan advances a sample by accession number (in transit, received at lab, rejected with reason)
+func (h *SampleHandler) Scan(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var req dto.SampleScanRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	sample, err := h.svc.ScanSample(req.AccessionNumber, req.Status, req.Reason, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, sample, "Sample updated successfully", nil)
+}
+
+// GetBarcode returns the sample's accession barcode as PNG or, with ?format=svg, SVG
+func (h *SampleHandler) GetBarcode(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var query dto.SampleBarcodeQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	img, contentType, err := h.svc.Barcode(params.ID, query.Format, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, img)
+}
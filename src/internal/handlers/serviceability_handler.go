@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceabilityHandler struct {
+	svc service.ServiceabilityService
+}
+
+func NewServiceabilityHandler(svc service.ServiceabilityService) *ServiceabilityHandler {
+	return &ServiceabilityHandler{svc: svc}
+}
+
+// Get lists the active labs collecting in ?pincode= with their collection types and, with ?packageId=, the lab price
+func (h *ServiceabilityHandler) Get(c *gin.Context) {
+	var query dto.ServiceabilityQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	data, err := h.svc.Lookup(query.Pincode, query.PackageID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data.Labs)})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TestResultHandler struct {
+	svc service.TestResultService
+}
+
+func NewTestResultHandler(svc service.TestResultService) *TestResultHandler {
+	return &TestResultHandler{svc: svc}
+}
+
+// GetParameters lists the test's parameters with their reference ranges
+func (h *TestResultHandler) GetParameters(c *gin.Context) {
+	var params dto.TestIDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, int64(params.ID)) {
+		return
+	}
+	data, err := h.svc.ListParameters(params.ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+func (h *TestResultHandler) CreateParameter(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.TestIDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, int64(params.ID)) {
+		return
+	}
+	var req dto.TestParameterRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	param, err := h.svc.CreateParameter(params.ID, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, param, "Test parameter created successfully", nil)
+}
+
+// UpdateParameter replaces the parameter's definition and reference ranges
+func (h *TestResultHandler) UpdateParameter(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.TestParameterIDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, int64(params.ID)) || !middleware.RequirePositiveID(c, params.ParameterID) {
+		return
+	}
+	var req dto.TestParameterRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	param, err := h.svc.UpdateParameter(params.ID, params.ParameterID, req, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, param, "Test parameter updated successfully", nil)
+}
+
+// RecordResults stores parameter values for one test of the lead and returns them flagged
+func (h *TestResultHandler) RecordResults(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.RecordResultsRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	results, err := h.svc.RecordResults(params.ID, req, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, results, "Results recorded successfully", gin.H{"count": len(results)})
+}
+
+// GetResults returns the lead's structured results grouped by test
+func (h *TestResultHandler) GetResults(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.GetResults(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", nil)
+}
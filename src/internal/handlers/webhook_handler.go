@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/middleware"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+	"b2b-diagnostic-aggregator/apis/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	svc service.WebhookService
+}
+
+func NewWebhookHandler(svc service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+// GetAll lists webhook subscriptions; clients see their own, employees can filter by clientId
+func (h *WebhookHandler) GetAll(c *gin.Context) {
+	var query dto.WebhookSubscriptionListQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	data, err := h.svc.ListSubscriptions(query.ClientID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{"count": len(data)})
+}
+
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	data, err := h.svc.GetSubscription(params.ID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", nil)
+}
+
+// Create subscribes a URL to lead events; the signing secret is only returned here
+func (h *WebhookHandler) Create(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var req dto.WebhookSubscriptionRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	data, err := h.svc.CreateSubscription(req, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusCreated, data, "Webhook subscription created; store the secret now, it is not shown again", nil)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	actor := middleware.GetActor(c)
+	if actor.UserID == 0 {
+		respondError(c, apperrors.NewUnauthorized("Authentication required", nil))
+		return
+	}
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	var req dto.WebhookSubscriptionRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	data, err := h.svc.UpdateSubscription(params.ID, req, actor, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Webhook subscription updated successfully", nil)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	var params dto.IDParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.ID) {
+		return
+	}
+	if err := h.svc.DeleteSubscription(params.ID, middleware.GetActor(c), middleware.GetTenantScope(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondMessage(c, http.StatusOK, "Webhook subscription deleted successfully")
+}
+
+// GetDeadLetters lists deliveries that ran out of attempts, newest first
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	var query dto.WebhookDeadLetterQuery
+	if !middleware.BindQuery(c, &query) {
+		return
+	}
+	page := query.PaginationQuery.Normalize("deliveryId", 0)
+	filter := repository.WebhookDeliveryListFilter{
+		Page:           page.Page,
+		PageSize:       page.PageSize,
+		SubscriptionID: query.SubscriptionID,
+	}
+	if query.ClientID != nil {
+		filter.ClientID = *query.ClientID
+	}
+	data, total, err := h.svc.ListDeadLetters(filter, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusOK, data, "Success", gin.H{
+		"count":    len(data),
+		"page":     filter.Page,
+		"pageSize": filter.PageSize,
+		"total":    total,
+	})
+}
+
+// Redeliver queues a delivery again with a fresh set of attempts
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	var params dto.WebhookDeliveryParam
+	if !middleware.BindUri(c, &params) {
+		return
+	}
+	if !middleware.RequirePositiveID(c, params.DeliveryID) {
+		return
+	}
+	data, err := h.svc.Redeliver(params.DeliveryID, middleware.GetTenantScope(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondData(c, http.StatusAccepted, data, "Webhook delivery queued", nil)
+}
@@ -0,0 +1,218 @@
+package labintegration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// MLLP block framing: <VT> message <FS><CR>.
+const (
+	mllpStart = 0x0b
+	mllpEnd   = 0x1c
+	mllpCR    = 0x0d
+)
+
+// HL7 identification of this system in MSH-3/MSH-4.
+const (
+	hl7SendingApplication = "B2BDIAG"
+	hl7SendingFacility    = "AGGREGATOR"
+	hl7Version            = "2.3.1"
+	hl7TimeLayout         = "20060102150405"
+)
+
+// controlSeq numbers outgoing messages (MSH-10); seeded from the clock so IDs stay unique across restarts.
+var controlSeq = time.Now().UnixNano() / int64(time.Millisecond)
+
+// HL7MLLP sends orders as HL7 v2 ORM^O01 messages over an MLLP connection and waits for the
+// acknowledgment. AA (or CA) is a successful delivery; AE and AR are rejections.
+type HL7MLLP struct {
+	address              string
+	receivingApplication string
+	receivingFacility    string
+	timeout              time.Duration
+}
+
+func NewHL7MLLP(address, receivingApplication, receivingFacility string, timeout time.Duration) *HL7MLLP {
+	return &HL7MLLP{
+		address:              strings.TrimPrefix(address, "mllp://"),
+		receivingApplication: receivingApplication,
+		receivingFacility:    receivingFacility,
+		timeout:              timeout,
+	}
+}
+
+func (h *HL7MLLP) Send(ctx context.Context, order domain.LabOrder) (Result, error) {
+	controlID := strconv.FormatInt(atomic.AddInt64(&controlSeq, 1), 10)
+	message := BuildORM(order, controlID, h.receivingApplication, h.receivingFacility, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", h.address)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	frame := make([]byte, 0, len(message)+3)
+	frame = append(frame, mllpStart)
+	frame = append(frame, message...)
+	frame = append(frame, mllpEnd, mllpCR)
+	if _, err := conn.Write(frame); err != nil {
+		return Result{}, err
+	}
+	ack, err := ReadMLLP(bufio.NewReader(conn))
+	if err != nil {
+		return Result{}, fmt.Errorf("reading acknowledgment: %w", err)
+	}
+
+	result := Result{Response: truncate(ack)}
+	code, ackControlID, text, filler := parseACK(ack)
+	result.Code = code
+	result.ExternalRef = filler
+	switch {
+	case code == "":
+		return result, fmt.Errorf("acknowledgment has no MSA segment")
+	case ackControlID != controlID:
+		return result, fmt.Errorf("acknowledgment is for message %s, sent %s", ackControlID, controlID)
+	case code != "AA" && code != "CA":
+		if text == "" {
+			text = "order rejected"
+		}
+		return result, fmt.Errorf("lab acknowledged with %s: %s", code, text)
+	}
+	return result, nil
+}
+
+// BuildORM renders a new-order ORM^O01 message with one OBR per test. Segments end with <CR>.
+func BuildORM(order domain.LabOrder, controlID, receivingApplication, receivingFacility string, now time.Time) string {
+	ts := now.Format(hl7TimeLayout)
+	orderedOn := order.OrderedOn.Format(hl7TimeLayout)
+	patientID := strconv.FormatInt(order.PatientID, 10)
+	if order.PatientID == 0 {
+		patientID = "L" + strconv.FormatInt(order.LeadID, 10)
+	}
+	placer := hl7Escape(order.Reference)
+
+	segments := []string{
+		strings.Join([]string{"MSH", `^~\&`, hl7SendingApplication, hl7SendingFacility,
+			hl7Escape(receivingApplication), hl7Escape(receivingFacility), ts, "", "ORM^O01", controlID, "P", hl7Version}, "|"),
+		strings.Join([]string{"PID", "1", "", patientID + "^^^" + hl7SendingApplication, "",
+			hl7Escape(order.PatientName), "", "", hl7Gender(order.Gender), "", "",
+			hl7Escape(order.Address) + "^^^^" + hl7Escape(order.Pincode), "", hl7Escape(order.ContactNumber)}, "|"),
+		strings.Join([]string{"ORC", "NW", placer, "", "", "", "", "", "", orderedOn}, "|"),
+	}
+	for i, t := range order.Tests {
+		segments = append(segments, strings.Join([]string{"OBR", strconv.Itoa(i + 1), placer, "",
+			strconv.Itoa(t.TestID) + "^" + hl7Escape(t.TestName) + "^L", "", "", orderedOn}, "|"))
+	}
+	return strings.Join(segments, "\r") + "\r"
+}
+
+// ReadMLLP reads one MLLP-framed message and returns it without the framing.
+func ReadMLLP(r *bufio.Reader) (string, error) {
+	if _, err := r.ReadBytes(mllpStart); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for {
+		chunk, err := r.ReadBytes(mllpEnd)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(chunk[:len(chunk)-1])
+		next, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if next == mllpCR {
+			return buf.String(), nil
+		}
+		// An <FS> inside the message; next may itself start the end block.
+		buf.WriteByte(mllpEnd)
+		_ = r.UnreadByte()
+	}
+}
+
+// BuildACK renders the acknowledgment for a received message: MSA-1 code, MSA-2 the message's
+// control ID (MSH-10) and MSA-3 text.
+func BuildACK(message, code, text string) string {
+	var controlID, sendingApp, sendingFacility string
+	for _, segment := range splitSegments(message) {
+		fields := strings.Split(segment, "|")
+		if fields[0] == "MSH" && len(fields) > 9 {
+			sendingApp, sendingFacility, controlID = fields[2], fields[3], fields[9]
+		}
+	}
+	ts := time.Now().Format(hl7TimeLayout)
+	msh := strings.Join([]string{"MSH", `^~\&`, "LIS", "LAB", sendingApp, sendingFacility, ts, "", "ACK^O01", "ACK" + controlID, "P", hl7Version}, "|")
+	msa := strings.Join([]string{"MSA", code, controlID, hl7Escape(text)}, "|")
+	return msh + "\r" + msa + "\r"
+}
+
+// parseACK extracts MSA-1, MSA-2 and MSA-3 from an acknowledgment, and ORC-3 (filler order
+// number) when the LIS answers with an order response.
+func parseACK(message string) (code, controlID, text, filler string) {
+	for _, segment := range splitSegments(message) {
+		fields := strings.Split(segment, "|")
+		switch fields[0] {
+		case "MSA":
+			code = field(fields, 1)
+			controlID = field(fields, 2)
+			text = field(fields, 3)
+		case "ORC":
+			filler = field(fields, 3)
+		}
+	}
+	return code, controlID, text, filler
+}
+
+func splitSegments(message string) []string {
+	message = strings.ReplaceAll(message, "\n", "\r")
+	var segments []string
+	for _, s := range strings.Split(message, "\r") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+func field(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+// hl7Escape escapes the HL7 delimiters in a field value and drops line breaks.
+func hl7Escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\E\`, "|", `\F\`, "^", `\S\`, "&", `\T\`, "~", `\R\`, "\r", " ", "\n", " ",
+	).Replace(strings.TrimSpace(s))
+}
+
+// hl7Gender maps our gender codes to HL7 table 0001.
+func hl7Gender(gender string) string {
+	switch strings.ToUpper(strings.TrimSpace(gender)) {
+	case "M", "MALE":
+		return "M"
+	case "F", "FEMALE":
+		return "F"
+	case "O", "OTHER":
+		return "O"
+	}
+	return "U"
+}
@@ -0,0 +1,302 @@
+package labintegration
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+func testOrder() domain.LabOrder {
+	return domain.LabOrder{
+		Reference:     "LO-12-3",
+		LeadID:        12,
+		LabID:         3,
+		PatientID:     42,
+		PatientName:   "Asha Rao",
+		Age:           34,
+		Gender:        "Female",
+		ContactNumber: "9876543210",
+		Address:       "12 MG Road",
+		Pincode:       "560001",
+		PackageID:     7,
+		PackageName:   "Thyroid Profile",
+		Tests: []domain.Test{
+			{TestID: 101, TestName: "TSH", Category: "Hormones"},
+			{TestID: 102, TestName: "Free T4", Category: "Hormones"},
+		},
+		OrderedOn: time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+// hl7Field returns field i of the n-th (0-based) segment named name. Other segments are indexed by
+// their field number; MSH is one lower because MSH-1 is the field separator itself.
+func hl7Field(message, name string, n, i int) string {
+	for _, segment := range splitSegments(message) {
+		fields := strings.Split(segment, "|")
+		if fields[0] != name {
+			continue
+		}
+		if n == 0 {
+			return field(fields, i)
+		}
+		n--
+	}
+	return ""
+}
+
+func TestBuildORM(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	message := BuildORM(testOrder(), "1001", "LISAPP", "CITYLAB", now)
+
+	tests := []struct {
+		name    string
+		segment string
+		n, i    int
+		want    string
+	}{
+		{"MSH-3 sending application", "MSH", 0, 2, hl7SendingApplication},
+		{"MSH-5 receiving application", "MSH", 0, 4, "LISAPP"},
+		{"MSH-6 receiving facility", "MSH", 0, 5, "CITYLAB"},
+		{"MSH-7 message time", "MSH", 0, 6, "20240305100000"},
+		{"MSH-9 message type", "MSH", 0, 8, "ORM^O01"},
+		{"MSH-10 control ID", "MSH", 0, 9, "1001"},
+		{"MSH-12 version", "MSH", 0, 11, hl7Version},
+		{"PID-3 patient ID", "PID", 0, 3, "42^^^" + hl7SendingApplication},
+		{"PID-5 patient name", "PID", 0, 5, "Asha Rao"},
+		{"PID-8 gender", "PID", 0, 8, "F"},
+		{"PID-11 address", "PID", 0, 11, "12 MG Road^^^^560001"},
+		{"PID-13 phone", "PID", 0, 13, "9876543210"},
+		{"ORC-1 order control", "ORC", 0, 1, "NW"},
+		{"ORC-2 placer order number", "ORC", 0, 2, "LO-12-3"},
+		{"ORC-9 transaction time", "ORC", 0, 9, "20240305093000"},
+		{"first OBR-1 set ID", "OBR", 0, 1, "1"},
+		{"first OBR-2 placer order number", "OBR", 0, 2, "LO-12-3"},
+		{"first OBR-4 service", "OBR", 0, 4, "101^TSH^L"},
+		{"second OBR-1 set ID", "OBR", 1, 1, "2"},
+		{"second OBR-4 service", "OBR", 1, 4, "102^Free T4^L"},
+		{"second OBR-7 observation time", "OBR", 1, 7, "20240305093000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hl7Field(message, tt.segment, tt.n, tt.i); got != tt.want {
+				t.Fatalf("got %q, want %q\n%s", got, tt.want, strings.ReplaceAll(message, "\r", "\n"))
+			}
+		})
+	}
+
+	if !strings.HasSuffix(message, "\r") || strings.Contains(message, "\n") {
+		t.Fatalf("segments must end with <CR> only: %q", message)
+	}
+}
+
+func TestBuildORMEscapesAndFallbacks(t *testing.T) {
+	order := testOrder()
+	order.PatientID = 0
+	order.PatientName = "Rao|Asha^K\nJr"
+	order.Gender = "x"
+	message := BuildORM(order, "1", "", "", time.Now())
+
+	if got, want := hl7Field(message, "PID", 0, 3), "L12^^^"+hl7SendingApplication; got != want {
+		t.Fatalf("PID-3 = %q, want %q", got, want)
+	}
+	if got, want := hl7Field(message, "PID", 0, 5), `Rao\F\Asha\S\K Jr`; got != want {
+		t.Fatalf("PID-5 = %q, want %q", got, want)
+	}
+	if got := hl7Field(message, "PID", 0, 8); got != "U" {
+		t.Fatalf("PID-8 = %q, want U", got)
+	}
+	if n := len(splitSegments(message)); n != 5 {
+		t.Fatalf("%d segments, want 5", n)
+	}
+}
+
+func TestReadMLLP(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		want    []string // messages read in order
+		wantErr bool     // after the messages in want
+	}{
+		{name: "one frame", stream: "\x0bMSH|a\rMSA|AA\r\x1c\r", want: []string{"MSH|a\rMSA|AA\r"}},
+		{name: "bytes before the start block are skipped", stream: "noise\x0bMSH|a\x1c\r", want: []string{"MSH|a"}},
+		{name: "embedded FS not followed by CR", stream: "\x0bA\x1cB\x1c\r", want: []string{"A\x1cB"}},
+		{name: "embedded FS right before the end block", stream: "\x0bA\x1c\x1c\r", want: []string{"A\x1c"}},
+		{name: "frames back to back", stream: "\x0bone\x1c\r\x0btwo\x1c\r", want: []string{"one", "two"}},
+		{name: "connection closed inside a frame", stream: "\x0bMSH|a", wantErr: true},
+		{name: "connection closed after FS", stream: "\x0bMSH|a\x1c", wantErr: true},
+		{name: "no frame", stream: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.stream))
+			for _, want := range tt.want {
+				got, err := ReadMLLP(r)
+				if err != nil {
+					t.Fatalf("ReadMLLP() error = %v", err)
+				}
+				if got != want {
+					t.Fatalf("ReadMLLP() = %q, want %q", got, want)
+				}
+			}
+			if tt.wantErr {
+				if got, err := ReadMLLP(r); err == nil {
+					t.Fatalf("ReadMLLP() = %q, want an error", got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseACK(t *testing.T) {
+	order := BuildORM(testOrder(), "77", "LIS", "LAB", time.Now())
+	tests := []struct {
+		name                          string
+		message                       string
+		code, controlID, text, filler string
+	}{
+		{name: "accepted", message: BuildACK(order, "AA", "Order accepted"), code: "AA", controlID: "77", text: "Order accepted"},
+		{name: "rejection text is kept escaped", message: BuildACK(order, "AE", "bad|field"), code: "AE", controlID: "77", text: `bad\F\field`},
+		{name: "order response carries the filler number", message: BuildACK(order, "AA", "") + "ORC|OK|LO-12-3|LIS-9\r", code: "AA", controlID: "77", filler: "LIS-9"},
+		{name: "newline separated", message: "MSH|^~\\&|LIS\nMSA|AR|77|down\n", code: "AR", controlID: "77", text: "down"},
+		{name: "no MSA", message: "MSH|^~\\&|LIS\r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, controlID, text, filler := parseACK(tt.message)
+			if code != tt.code || controlID != tt.controlID || text != tt.text || filler != tt.filler {
+				t.Fatalf("parseACK() = %q, %q, %q, %q; want %q, %q, %q, %q",
+					code, controlID, text, filler, tt.code, tt.controlID, tt.text, tt.filler)
+			}
+		})
+	}
+}
+
+// mllpStub accepts one connection, reads one order and answers with reply(order); an empty reply
+// closes the connection without acknowledging.
+func mllpStub(t *testing.T, reply func(order string) string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		order, err := ReadMLLP(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		received <- order
+		if ack := reply(order); ack != "" {
+			_, _ = conn.Write([]byte("\x0b" + ack + "\x1c\r"))
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestHL7MLLPSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    func(order string) string
+		wantCode string
+		wantRef  string
+		wantErr  string
+	}{
+		{
+			name:     "AA is delivered",
+			reply:    func(order string) string { return BuildACK(order, "AA", "Order accepted") },
+			wantCode: "AA",
+		},
+		{
+			name:     "CA is delivered",
+			reply:    func(order string) string { return BuildACK(order, "CA", "") },
+			wantCode: "CA",
+		},
+		{
+			name:     "filler order number becomes the external reference",
+			reply:    func(order string) string { return BuildACK(order, "AA", "") + "ORC|OK|LO-12-3|LIS-9\r" },
+			wantCode: "AA",
+			wantRef:  "LIS-9",
+		},
+		{
+			name:     "AE is a rejection with the lab's text",
+			reply:    func(order string) string { return BuildACK(order, "AE", "Unknown test 102") },
+			wantCode: "AE",
+			wantErr:  "lab acknowledged with AE: Unknown test 102",
+		},
+		{
+			name:     "AR without text",
+			reply:    func(order string) string { return BuildACK(order, "AR", "") },
+			wantCode: "AR",
+			wantErr:  "lab acknowledged with AR: order rejected",
+		},
+		{
+			name: "ACK for another message",
+			reply: func(order string) string {
+				return BuildACK(strings.Replace(order, "|ORM^O01|", "|ORM^O01|other", 1), "AA", "")
+			},
+			wantCode: "AA",
+			wantErr:  "acknowledgment is for message other",
+		},
+		{
+			name:    "ACK without MSA",
+			reply:   func(string) string { return "MSH|^~\\&|LIS|LAB\r" },
+			wantErr: "no MSA segment",
+		},
+		{
+			name:    "connection closed without an ACK",
+			reply:   func(string) string { return "" },
+			wantErr: "reading acknowledgment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, received := mllpStub(t, tt.reply)
+			h := NewHL7MLLP("mllp://"+addr, "LISAPP", "CITYLAB", 5*time.Second)
+			result, err := h.Send(context.Background(), testOrder())
+
+			order := <-received
+			if hl7Field(order, "MSH", 0, 8) != "ORM^O01" || hl7Field(order, "MSH", 0, 4) != "LISAPP" {
+				t.Fatalf("stub received %q", order)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Send() error = %v, want %q", err, tt.wantErr)
+			}
+			if result.Code != tt.wantCode || result.ExternalRef != tt.wantRef {
+				t.Fatalf("Send() = code %q ref %q, want %q %q", result.Code, result.ExternalRef, tt.wantCode, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestHL7MLLPSendTimesOut(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second) // never acknowledges
+		}
+	}()
+	h := NewHL7MLLP(ln.Addr().String(), "", "", 100*time.Millisecond)
+	if _, err := h.Send(context.Background(), testOrder()); err == nil {
+		t.Fatal("Send() succeeded without an acknowledgment")
+	}
+}
@@ -0,0 +1,120 @@
+package labintegration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// HTTPJSON posts orders as JSON to the lab's endpoint. Any 2xx response is a successful delivery;
+// the order reference is sent as the Idempotency-Key so a retried order is not booked twice.
+type HTTPJSON struct {
+	endpoint  string
+	authToken string
+	client    *http.Client
+}
+
+func NewHTTPJSON(endpoint, authToken string, timeout time.Duration) *HTTPJSON {
+	return &HTTPJSON{endpoint: endpoint, authToken: authToken, client: &http.Client{Timeout: timeout}}
+}
+
+// orderPayload is the JSON body sent to HTTP_JSON labs.
+type orderPayload struct {
+	OrderReference string        `json:"orderReference"`
+	LeadID         int64         `json:"leadId"`
+	OrderedOn      time.Time     `json:"orderedOn"`
+	Patient        patientJSON   `json:"patient"`
+	Package        packageJSON   `json:"package"`
+	Tests          []testPayload `json:"tests"`
+}
+
+type patientJSON struct {
+	ID            int64  `json:"id,omitempty"`
+	Name          string `json:"name"`
+	Age           int8   `json:"age"`
+	Gender        string `json:"gender"`
+	ContactNumber string `json:"contactNumber"`
+	Address       string `json:"address,omitempty"`
+	Pincode       string `json:"pincode,omitempty"`
+}
+
+type packageJSON struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type testPayload struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+}
+
+// httpAck is the optional JSON answer of the LIS; the first reference field present is kept.
+type httpAck struct {
+	OrderID     string `json:"orderId"`
+	ExternalRef string `json:"externalRef"`
+	Reference   string `json:"reference"`
+}
+
+func (h *HTTPJSON) Send(ctx context.Context, order domain.LabOrder) (Result, error) {
+	payload := orderPayload{
+		OrderReference: order.Reference,
+		LeadID:         order.LeadID,
+		OrderedOn:      order.OrderedOn,
+		Patient: patientJSON{
+			ID:            order.PatientID,
+			Name:          order.PatientName,
+			Age:           order.Age,
+			Gender:        order.Gender,
+			ContactNumber: order.ContactNumber,
+			Address:       order.Address,
+			Pincode:       order.Pincode,
+		},
+		Package: packageJSON{ID: order.PackageID, Name: order.PackageName},
+		Tests:   make([]testPayload, len(order.Tests)),
+	}
+	for i, t := range order.Tests {
+		payload.Tests[i] = testPayload{ID: t.TestID, Name: t.TestName, Category: t.Category}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", order.Reference)
+	if h.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.authToken)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLen+1))
+
+	result := Result{Code: strconv.Itoa(resp.StatusCode), Response: truncate(string(raw))}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("lab endpoint returned %s", resp.Status)
+	}
+	var ack httpAck
+	if json.Unmarshal(raw, &ack) == nil {
+		for _, ref := range []string{ack.OrderID, ack.ExternalRef, ack.Reference} {
+			if ref != "" {
+				result.ExternalRef = ref
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package labintegration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPJSONSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+		wantRef  string
+		wantErr  bool
+	}{
+		{name: "200 with orderId", status: http.StatusOK, body: `{"orderId":"LIS-1"}`, wantCode: "200", wantRef: "LIS-1"},
+		{name: "201 with externalRef", status: http.StatusCreated, body: `{"externalRef":"LIS-2"}`, wantCode: "201", wantRef: "LIS-2"},
+		{name: "orderId wins over reference", status: http.StatusOK, body: `{"reference":"R","orderId":"O"}`, wantCode: "200", wantRef: "O"},
+		{name: "202 without a body", status: http.StatusAccepted, wantCode: "202"},
+		{name: "2xx with a non-JSON body", status: http.StatusOK, body: "queued", wantCode: "200"},
+		{name: "4xx is a rejection", status: http.StatusUnprocessableEntity, body: `{"error":"unknown test"}`, wantCode: "422", wantErr: true},
+		{name: "5xx is a failure", status: http.StatusServiceUnavailable, body: "down", wantCode: "503", wantErr: true},
+		{name: "3xx is not a delivery", status: http.StatusNotModified, wantCode: "304", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var payload orderPayload
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("decoding order: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			result, err := NewHTTPJSON(srv.URL+"/orders", "token-1", 5*time.Second).Send(context.Background(), testOrder())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, want error %v", err, tt.wantErr)
+			}
+			if result.Code != tt.wantCode || result.ExternalRef != tt.wantRef {
+				t.Fatalf("Send() = code %q ref %q, want %q %q", result.Code, result.ExternalRef, tt.wantCode, tt.wantRef)
+			}
+			if tt.status != http.StatusNotModified && result.Response != tt.body {
+				t.Fatalf("Response = %q, want %q", result.Response, tt.body)
+			}
+
+			if got.Method != http.MethodPost || got.URL.Path != "/orders" {
+				t.Fatalf("request %s %s", got.Method, got.URL.Path)
+			}
+			for header, want := range map[string]string{
+				"Content-Type":    "application/json",
+				"Idempotency-Key": "LO-12-3",
+				"Authorization":   "Bearer token-1",
+			} {
+				if v := got.Header.Get(header); v != want {
+					t.Fatalf("%s = %q, want %q", header, v, want)
+				}
+			}
+			if payload.OrderReference != "LO-12-3" || payload.Patient.Name != "Asha Rao" || len(payload.Tests) != 2 || payload.Tests[1].Name != "Free T4" {
+				t.Fatalf("payload = %+v", payload)
+			}
+		})
+	}
+}
+
+func TestHTTPJSONSendTruncatesResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(strings.Repeat("x", maxResponseLen*2)))
+	}))
+	defer srv.Close()
+
+	result, err := NewHTTPJSON(srv.URL, "", 5*time.Second).Send(context.Background(), testOrder())
+	if err == nil {
+		t.Fatal("Send() succeeded on a 500")
+	}
+	if len(result.Response) != maxResponseLen {
+		t.Fatalf("kept %d bytes of the response, want %d", len(result.Response), maxResponseLen)
+	}
+}
+
+func TestHTTPJSONSendWithoutToken(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTPJSON(srv.URL, "", 5*time.Second).Send(context.Background(), testOrder()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if auth != "" {
+		t.Fatalf("Authorization = %q, want none", auth)
+	}
+}
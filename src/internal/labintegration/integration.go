@@ -0,0 +1,59 @@
+// Package labintegration pushes lead orders to lab information systems (LIS). Each lab is
+// configured with an adapter: a generic HTTP JSON endpoint, HL7 v2 ORM^O01 messages over MLLP, or
+// manual entry by ops staff.
+package labintegration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// ErrManual is returned by New for labs whose orders are entered by hand.
+var ErrManual = errors.New("labintegration: lab orders are entered manually")
+
+// DefaultTimeout bounds one delivery attempt when the lab configures none.
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseLen caps the LIS response kept on an attempt record.
+const maxResponseLen = 2000
+
+// LabIntegration sends an order to one lab's LIS. Send returns the LIS's answer even when it
+// rejects the order, together with an error, so the attempt can be recorded either way.
+type LabIntegration interface {
+	Send(ctx context.Context, order domain.LabOrder) (Result, error)
+}
+
+// Result is what the LIS answered to one delivery attempt.
+type Result struct {
+	Code        string // HTTP status or HL7 acknowledgment code (AA, AE, AR)
+	Response    string // raw response, truncated
+	ExternalRef string // the LIS's reference for the order, when it returns one
+}
+
+// New builds the adapter configured for a lab.
+func New(cfg domain.LabIntegration) (LabIntegration, error) {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	switch cfg.Adapter {
+	case domain.LabAdapterHTTPJSON:
+		return NewHTTPJSON(cfg.Endpoint, cfg.AuthToken, timeout), nil
+	case domain.LabAdapterHL7MLLP:
+		return NewHL7MLLP(cfg.Endpoint, cfg.ReceivingApplication, cfg.ReceivingFacility, timeout), nil
+	case domain.LabAdapterManual:
+		return nil, ErrManual
+	}
+	return nil, fmt.Errorf("labintegration: unknown adapter %q", cfg.Adapter)
+}
+
+func truncate(s string) string {
+	if len(s) <= maxResponseLen {
+		return s
+	}
+	return s[:maxResponseLen]
+}
@@ -0,0 +1,200 @@
+package lockout
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the failed-attempt record for one key (a user or a client IP).
+type State struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store persists attempt state per key. The in-memory store is per process; a shared implementation
+// (Redis, SQL, ...) can be plugged in so that lockouts apply across instances.
+type Store interface {
+	Get(key string) (State, bool)
+	// Update applies fn to the state for key atomically and returns the result.
+	Update(key string, fn func(*State)) State
+	Delete(key string)
+}
+
+// Policy configures delays and lockout for one kind of key.
+type Policy struct {
+	MaxFailures  int           // failures within Window before the key is locked
+	Window       time.Duration // failures older than this are forgotten
+	LockDuration time.Duration // how long a lock lasts before it expires on its own
+	DelayAfter   int           // failures after which each further attempt must wait
+	BaseDelay    time.Duration // wait after DelayAfter failures; doubles with every further failure
+	MaxDelay     time.Duration
+}
+
+// LockedError is returned while a key is locked.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("locked until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// ThrottledError is returned when an attempt comes before the progressive delay has elapsed.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("retry after %s", e.RetryAfter)
+}
+
+// Guard applies a Policy to keys kept in a Store.
+type Guard struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy, now: time.Now}
+}
+
+// Check returns a *LockedError or *ThrottledError when key may not attempt right now, nil otherwise.
+// Expired locks and stale failures are cleared. An empty key is never limited.
+func (g *Guard) Check(key string) error {
+	if key == "" {
+		return nil
+	}
+	st, ok := g.store.Get(key)
+	if !ok {
+		return nil
+	}
+	now := g.now()
+	if !st.LockedUntil.IsZero() {
+		if now.Before(st.LockedUntil) {
+			return &LockedError{Until: st.LockedUntil}
+		}
+		g.store.Delete(key)
+		return nil
+	}
+	if g.policy.Window > 0 && now.Sub(st.LastFailure) > g.policy.Window {
+		g.store.Delete(key)
+		return nil
+	}
+	if wait := g.delay(st.Failures) - now.Sub(st.LastFailure); wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail records a failed attempt and returns a *LockedError when this failure locks the key.
+func (g *Guard) Fail(key string) error {
+	if key == "" {
+		return nil
+	}
+	now := g.now()
+	st := g.store.Update(key, func(st *State) {
+		if !st.LockedUntil.IsZero() && !now.Before(st.LockedUntil) {
+			*st = State{}
+		}
+		if g.policy.Window > 0 && !st.LastFailure.IsZero() && now.Sub(st.LastFailure) > g.policy.Window {
+			*st = State{}
+		}
+		st.Failures++
+		st.LastFailure = now
+		if g.policy.MaxFailures > 0 && st.Failures >= g.policy.MaxFailures && st.LockedUntil.IsZero() {
+			st.LockedUntil = now.Add(g.policy.LockDuration)
+		}
+	})
+	if !st.LockedUntil.IsZero() && now.Before(st.LockedUntil) {
+		return &LockedError{Until: st.LockedUntil}
+	}
+	return nil
+}
+
+// Reset clears failures and any lock for key (successful login or admin unlock).
+func (g *Guard) Reset(key string) {
+	g.store.Delete(key)
+}
+
+// Status returns the current state for key, if any.
+func (g *Guard) Status(key string) (State, bool) {
+	return g.store.Get(key)
+}
+
+func (g *Guard) delay(failures int) time.Duration {
+	if g.policy.BaseDelay <= 0 || failures < g.policy.DelayAfter {
+		return 0
+	}
+	d := g.policy.BaseDelay
+	for i := g.policy.DelayAfter; i < failures; i++ {
+		d *= 2
+		if g.policy.MaxDelay > 0 && d >= g.policy.MaxDelay {
+			return g.policy.MaxDelay
+		}
+	}
+	return d
+}
+
+// memorySweepEvery is how many writes the MemoryStore accepts between sweeps of stale entries.
+const memorySweepEvery = 1000
+
+// MemoryStore is the default in-process Store. Entries whose last failure and lock are both older
+// than ttl are swept periodically so sprayed keys (e.g. many IPs) do not accumulate.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]State
+	ttl     time.Duration
+	writes  int
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]State), ttl: ttl}
+}
+
+func (s *MemoryStore) Get(key string) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.entries[key]
+	return st, ok
+}
+
+func (s *MemoryStore) Update(key string, fn func(*State)) State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.entries[key]
+	fn(&st)
+	s.entries[key] = st
+	s.writes++
+	if s.ttl > 0 && s.writes >= memorySweepEvery {
+		s.writes = 0
+		cutoff := time.Now().Add(-s.ttl)
+		for k, e := range s.entries {
+			if e.LastFailure.Before(cutoff) && e.LockedUntil.Before(cutoff) {
+				delete(s.entries, k)
+			}
+		}
+	}
+	return st
+}
+
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// UserKey and IPKey build the store keys used for login attempts. IPKey returns "" for an unknown
+// IP, which Guard never limits.
+func UserKey(userType int, userID int64) string {
+	return fmt.Sprintf("user:%d:%d", userType, userID)
+}
+
+func IPKey(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
@@ -0,0 +1,123 @@
+package lockout
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	policy := Policy{
+		MaxFailures:  4,
+		Window:       15 * time.Minute,
+		LockDuration: 10 * time.Minute,
+		DelayAfter:   2,
+		BaseDelay:    time.Second,
+		MaxDelay:     3 * time.Second,
+	}
+
+	// Each step advances the clock by wait, then fails (fail=true) or checks the key.
+	type step struct {
+		wait       time.Duration
+		fail       bool
+		reset      bool
+		wantLocked bool
+		wantRetry  time.Duration // expected ThrottledError.RetryAfter; 0 means not throttled
+	}
+	tests := []struct {
+		name  string
+		key   string
+		steps []step
+	}{
+		{
+			name:  "failures below the delay threshold are not throttled",
+			key:   "user:1:1",
+			steps: []step{{fail: true}, {}},
+		},
+		{
+			name: "progressive delay doubles up to the maximum",
+			key:  "user:1:1",
+			steps: []step{
+				{fail: true}, {fail: true}, {wantRetry: time.Second},
+				{wait: time.Second}, {fail: true}, {wantRetry: 2 * time.Second},
+				{wait: 2 * time.Second},
+			},
+		},
+		{
+			name: "max failures lock the key",
+			key:  "user:1:1",
+			steps: []step{
+				{fail: true}, {fail: true}, {fail: true}, {fail: true, wantLocked: true},
+				{wantLocked: true}, {wait: 9 * time.Minute, wantLocked: true},
+			},
+		},
+		{
+			name: "lock expires on its own",
+			key:  "user:1:1",
+			steps: []step{
+				{fail: true}, {fail: true}, {fail: true}, {fail: true, wantLocked: true},
+				{wait: 10 * time.Minute}, {fail: true},
+			},
+		},
+		{
+			name: "failures outside the window are forgotten",
+			key:  "user:1:1",
+			steps: []step{
+				{fail: true}, {fail: true}, {fail: true},
+				{wait: 16 * time.Minute}, {fail: true}, {},
+			},
+		},
+		{
+			name: "reset clears a lock",
+			key:  "user:1:1",
+			steps: []step{
+				{fail: true}, {fail: true}, {fail: true}, {fail: true, wantLocked: true},
+				{reset: true}, {},
+			},
+		},
+		{
+			name: "empty key is never limited",
+			key:  "",
+			steps: []step{
+				{fail: true}, {fail: true}, {fail: true}, {fail: true}, {fail: true}, {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+			g := NewGuard(NewMemoryStore(time.Hour), policy)
+			g.now = func() time.Time { return now }
+			for i, s := range tt.steps {
+				now = now.Add(s.wait)
+				var err error
+				switch {
+				case s.reset:
+					g.Reset(tt.key)
+					continue
+				case s.fail:
+					err = g.Fail(tt.key)
+				default:
+					err = g.Check(tt.key)
+				}
+				var locked *LockedError
+				if got := errors.As(err, &locked); got != s.wantLocked {
+					t.Fatalf("step %d: err = %v, want locked %v", i, err, s.wantLocked)
+				}
+				if s.fail || s.wantLocked {
+					continue
+				}
+				var throttled *ThrottledError
+				var retry time.Duration
+				if errors.As(err, &throttled) {
+					retry = throttled.RetryAfter
+				} else if err != nil {
+					t.Fatalf("step %d: unexpected error %v", i, err)
+				}
+				if retry != s.wantRetry {
+					t.Fatalf("step %d: retry after %s, want %s", i, retry, s.wantRetry)
+				}
+			}
+		})
+	}
+}
@@ -10,7 +10,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(secret string) gin.HandlerFunc {
+// SessionValidator reports whether the login session an access token belongs to is still active
+// (not logged out or revoked after refresh-token reuse).
+type SessionValidator interface {
+	IsSessionActive(sessionID string) (bool, error)
+}
+
+func AuthMiddleware(secret string, sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -34,7 +40,9 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := utils.ValidateToken(parts[1], secret)
+		// Only access tokens are accepted; refresh tokens are signed with a different secret and
+		// carry tokenType=refresh, so they fail here.
+		claims, err := utils.ValidateTokenOfType(parts[1], secret, utils.TokenTypeAccess)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success":   false,
@@ -45,10 +53,24 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		if sessions != nil {
+			active, err := sessions.IsSessionActive(claims.SessionID)
+			if err != nil || !active {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"success":   false,
+					"message":   "Session has been revoked",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// Set user information in context (Node-compatible: userId, userType)
 		c.Set("userId", claims.UserID)
 		c.Set("userType", claims.UserType)
 		c.Set("role", claims.Role())
+		c.Set("sessionId", claims.SessionID)
 
 		c.Next()
 	}
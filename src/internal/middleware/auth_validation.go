@@ -5,6 +5,9 @@ import (
 	"strings"
 	"time"
 
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/pkg/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -55,3 +58,53 @@ func GetUserID(c *gin.Context) (int64, bool) {
 		return 0, false
 	}
 }
+
+// GetUserType returns the authenticated user type from context (1=employee, 2=client, 3=lab; set by AuthMiddleware).
+// Returns (0, false) if not found or invalid.
+func GetUserType(c *gin.Context) (int, bool) {
+	v, ok := c.Get("userType")
+	if !ok {
+		return 0, false
+	}
+	userType, ok := v.(int)
+	if !ok || userType == 0 {
+		return 0, false
+	}
+	return userType, true
+}
+
+// GetTenantScope returns the data scope for the authenticated user: clients are limited to their own
+// ClientID, labs to their own LabID, employees are unrestricted.
+func GetTenantScope(c *gin.Context) domain.TenantScope {
+	userID, _ := GetUserID(c)
+	userType, _ := GetUserType(c)
+	switch userType {
+	case utils.UserTypeClient:
+		return domain.TenantScope{ClientID: userID}
+	case utils.UserTypeLab:
+		return domain.TenantScope{LabID: userID}
+	default:
+		return domain.TenantScope{}
+	}
+}
+
+// GetActor returns the authenticated user and request id as a domain.Actor (UserID 0 when unauthenticated).
+func GetActor(c *gin.Context) domain.Actor {
+	userID, _ := GetUserID(c)
+	userType, _ := GetUserType(c)
+	return domain.Actor{UserID: userID, UserType: userType, RequestID: GetRequestID(c)}
+}
+
+// GetRequestID returns the request id assigned by LoggingMiddleware.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// GetSessionID returns the login session id of the authenticated access token (set by AuthMiddleware).
+func GetSessionID(c *gin.Context) string {
+	v, _ := c.Get("sessionId")
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Action is an operation a caller performs on a route group (read, create, update, ...).
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionBulk   Action = "bulk"
+)
+
+// PermissionMatrix maps each action on a route group to the user types (1=employee, 2=client, 3=lab)
+// allowed to perform it. Actions missing from the matrix are denied for everyone.
+type PermissionMatrix map[Action][]int
+
+// Allows reports whether userType may perform action.
+func (m PermissionMatrix) Allows(action Action, userType int) bool {
+	for _, allowed := range m[action] {
+		if allowed == userType {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission aborts with 403 unless the authenticated user type is allowed to perform action
+// according to matrix. Must be registered after AuthMiddleware.
+func RequirePermission(matrix PermissionMatrix, action Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userType, ok := GetUserType(c)
+		if !ok || !matrix.Allows(action, userType) {
+			appErr := apperrors.NewForbidden("You do not have permission to perform this action", nil)
+			c.JSON(http.StatusForbidden, gin.H{
+				"success":   false,
+				"message":   appErr.Message,
+				"timestamp": time.Now().UTC().Format(time.RFC3339),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
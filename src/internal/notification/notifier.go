@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Message is a single outbound notification (SMS, email, ...) to one recipient.
+type Message struct {
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Notifier delivers messages to users. Implementations must be safe for concurrent use.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+type Config struct {
+	Driver   string // "log" (default) or "file"
+	FilePath string // used by the file driver
+}
+
+// NewNotifier returns the notifier selected by cfg.Driver. Real SMS/email providers plug in here.
+func NewNotifier(cfg Config) (Notifier, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return &LogNotifier{}, nil
+	case "file":
+		return NewFileNotifier(cfg.FilePath)
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q", cfg.Driver)
+	}
+}
+
+// LogNotifier writes messages to the application log. Intended for development and tests.
+type LogNotifier struct{}
+
+func (n *LogNotifier) Send(msg Message) error {
+	log.Printf(`{"timestamp":"%s","level":"info","event":"notification","recipient":%q,"subject":%q,"body":%q}`,
+		time.Now().UTC().Format(time.RFC3339), msg.Recipient, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier appends messages to a file, one line per message, so tests can read them back.
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	if path == "" {
+		path = filepath.Join("logs", "notifications.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileNotifier{path: path}, nil
+}
+
+func (n *FileNotifier) Send(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), msg.Recipient, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type LabSlot struct {
+	SlotID        int64     `gorm:"primaryKey;column:SlotID;autoIncrement"`
+	LabID         int64     `gorm:"column:LabID;not null;index:IX_LabSlots_LabID"`
+	Pincode       *string   `gorm:"column:Pincode;type:varchar(6)"`
+	StartTime     string    `gorm:"column:StartTime;type:varchar(5);not null"`
+	EndTime       string    `gorm:"column:EndTime;type:varchar(5);not null"`
+	Capacity      int       `gorm:"column:Capacity;not null"`
+	IsActive      bool      `gorm:"column:IsActive;not null"`
+	CreatedBy     int64     `gorm:"column:CreatedBy;not null"`
+	CreatedOn     time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	LastUpdatedBy int64     `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn time.Time `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (LabSlot) TableName() string {
+	return "MediAdmin.tbl_LabSlots"
+}
+
+type Appointment struct {
+	AppointmentID   int64     `gorm:"primaryKey;column:AppointmentID;autoIncrement"`
+	LeadID          int64     `gorm:"column:LeadID;not null;index:IX_Appointments_LeadID"`
+	LabID           int64     `gorm:"column:LabID;not null;index:IX_Appointments_LabID_Date,priority:1"`
+	SlotID          int64     `gorm:"column:SlotID;not null"`
+	AppointmentDate time.Time `gorm:"column:AppointmentDate;type:date;not null;index:IX_Appointments_LabID_Date,priority:2"`
+	StartTime       string    `gorm:"column:StartTime;type:varchar(5);not null"`
+	EndTime         string    `gorm:"column:EndTime;type:varchar(5);not null"`
+	Pincode         string    `gorm:"column:Pincode;type:varchar(6);not null"`
+	Status          string    `gorm:"column:Status;type:varchar(20);not null"`
+	CancelReason    *string   `gorm:"column:CancelReason;type:nvarchar(500)"`
+	CreatedBy       int64     `gorm:"column:CreatedBy;not null"`
+	CreatedOn       time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	LastUpdatedBy   int64     `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn   time.Time `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (Appointment) TableName() string {
+	return "MediAdmin.tbl_Appointments"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type AuditLog struct {
+	AuditID    int64     `gorm:"primaryKey;column:AuditID;autoIncrement"`
+	EntityType string    `gorm:"column:EntityType;type:varchar(50);not null"`
+	EntityID   int64     `gorm:"column:EntityID;not null"`
+	Action     string    `gorm:"column:Action;type:varchar(25);not null"`
+	ActorID    int64     `gorm:"column:ActorID;not null"`
+	ActorType  int       `gorm:"column:ActorType;not null"`
+	RequestID  *string   `gorm:"column:RequestID;type:varchar(64)"`
+	BeforeJSON *string   `gorm:"column:BeforeJSON;type:nvarchar(max)"`
+	AfterJSON  *string   `gorm:"column:AfterJSON;type:nvarchar(max)"`
+	CreatedOn  time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+}
+
+func (AuditLog) TableName() string {
+	return "MediAdmin.tbl_AuditLog"
+}
@@ -2,7 +2,7 @@ package models
 
 import "time"
 
-// ForgotPassword maps to MediAdmin.ForgotPassword
+// ForgotPassword maps to MediAdmin.ForgotPassword. ForgetPasswordKey holds the bcrypt hash of the OTP.
 type ForgotPassword struct {
 	Uid                 int64      `gorm:"primaryKey;column:Uid;autoIncrement"`
 	UserID              int64      `gorm:"column:UserID;not null"`
@@ -12,6 +12,7 @@ type ForgotPassword struct {
 	ExpiryTimestamp     time.Time  `gorm:"column:ExpiryTimestamp;not null"`
 	IsPasswordChanged   bool       `gorm:"column:IsPasswordChanged;not null;default:false"`
 	IsPasswordUpdatedOn *time.Time `gorm:"column:IsPasswordUpdatedOn"`
+	FailedAttempts      int        `gorm:"column:FailedAttempts;not null;default:0"`
 }
 
 func (ForgotPassword) TableName() string {
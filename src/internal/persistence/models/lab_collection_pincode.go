@@ -0,0 +1,12 @@
+package models
+
+// LabCollectionPincode is one pincode a lab collects samples from: the normalized, indexed form of
+// tbl_Labs.CollectionPincodes, kept in sync by the lab repository.
+type LabCollectionPincode struct {
+	Pincode string `gorm:"primaryKey;column:Pincode;type:varchar(6)"`
+	LabID   int64  `gorm:"primaryKey;column:LabID;index:IX_LabCollectionPincodes_LabID"`
+}
+
+func (LabCollectionPincode) TableName() string {
+	return "MediAdmin.tbl_LabCollectionPincodes"
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+type LabIntegration struct {
+	LabID                int64     `gorm:"primaryKey;column:LabID;autoIncrement:false"`
+	Adapter              string    `gorm:"column:Adapter;type:varchar(20);not null"`
+	Endpoint             *string   `gorm:"column:Endpoint;type:varchar(500)"`
+	AuthToken            *string   `gorm:"column:AuthToken;type:varchar(500)"`
+	ReceivingApplication *string   `gorm:"column:ReceivingApplication;type:varchar(50)"`
+	ReceivingFacility    *string   `gorm:"column:ReceivingFacility;type:varchar(50)"`
+	EventSecret          *string   `gorm:"column:EventSecret;type:varchar(100)"`
+	TimeoutSeconds       int       `gorm:"column:TimeoutSeconds;not null"`
+	MaxAttempts          int       `gorm:"column:MaxAttempts;not null"`
+	IsActive             bool      `gorm:"column:IsActive;not null"`
+	CreatedBy            int64     `gorm:"column:CreatedBy;not null"`
+	CreatedOn            time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	LastUpdatedBy        int64     `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn        time.Time `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (LabIntegration) TableName() string {
+	return "MediAdmin.tbl_LabIntegrations"
+}
+
+type LabOrderDelivery struct {
+	DeliveryID    int64      `gorm:"primaryKey;column:DeliveryID;autoIncrement"`
+	LeadID        int64      `gorm:"column:LeadID;not null;index:IX_LabOrderDeliveries_LeadID"`
+	LabID         int64      `gorm:"column:LabID;not null"`
+	Adapter       string     `gorm:"column:Adapter;type:varchar(20);not null"`
+	Status        string     `gorm:"column:Status;type:varchar(20);not null;index:IX_LabOrderDeliveries_Status_NextAttemptOn,priority:1"`
+	Attempts      int        `gorm:"column:Attempts;not null"`
+	MaxAttempts   int        `gorm:"column:MaxAttempts;not null"`
+	NextAttemptOn *time.Time `gorm:"column:NextAttemptOn;index:IX_LabOrderDeliveries_Status_NextAttemptOn,priority:2"`
+	LastError     *string    `gorm:"column:LastError;type:nvarchar(1000)"`
+	ExternalRef   *string    `gorm:"column:ExternalRef;type:varchar(100)"`
+	CreatedBy     int64      `gorm:"column:CreatedBy;not null"`
+	CreatedOn     time.Time  `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	DeliveredOn   *time.Time `gorm:"column:DeliveredOn"`
+	LastUpdatedOn time.Time  `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (LabOrderDelivery) TableName() string {
+	return "MediAdmin.tbl_LabOrderDeliveries"
+}
+
+type LabOrderAttempt struct {
+	AttemptID     int64     `gorm:"primaryKey;column:AttemptID;autoIncrement"`
+	DeliveryID    int64     `gorm:"column:DeliveryID;not null;index:IX_LabOrderAttempts_DeliveryID"`
+	AttemptNumber int       `gorm:"column:AttemptNumber;not null"`
+	AttemptedOn   time.Time `gorm:"column:AttemptedOn;not null;default:GETDATE()"`
+	DurationMs    int64     `gorm:"column:DurationMs;not null"`
+	Success       bool      `gorm:"column:Success;not null"`
+	ResponseCode  *string   `gorm:"column:ResponseCode;type:varchar(20)"`
+	Response      *string   `gorm:"column:Response;type:nvarchar(2000)"`
+	Error         *string   `gorm:"column:Error;type:nvarchar(1000)"`
+}
+
+func (LabOrderAttempt) TableName() string {
+	return "MediAdmin.tbl_LabOrderAttempts"
+}
+
+type LabEvent struct {
+	EventID         int64      `gorm:"primaryKey;column:EventID;autoIncrement"`
+	LabID           int64      `gorm:"column:LabID;not null;uniqueIndex:UX_LabEvents_LabID_ExternalEventID,priority:1"`
+	ExternalEventID string     `gorm:"column:ExternalEventID;type:varchar(100);not null;uniqueIndex:UX_LabEvents_LabID_ExternalEventID,priority:2"`
+	EventType       string     `gorm:"column:EventType;type:varchar(30);not null"`
+	LeadID          int64      `gorm:"column:LeadID;not null"`
+	AccessionNumber *string    `gorm:"column:AccessionNumber;type:varchar(20)"`
+	OccurredOn      *time.Time `gorm:"column:OccurredOn"`
+	Outcome         string     `gorm:"column:Outcome;type:varchar(20);not null"`
+	Note            *string    `gorm:"column:Note;type:nvarchar(500)"`
+	ReceivedOn      time.Time  `gorm:"column:ReceivedOn;not null;default:GETDATE()"`
+}
+
+func (LabEvent) TableName() string {
+	return "MediAdmin.tbl_LabEvents"
+}
+
+// LabEventNonce is a request nonce seen from a lab, kept for the signature window to reject replays.
+type LabEventNonce struct {
+	LabID      int64     `gorm:"primaryKey;column:LabID;autoIncrement:false"`
+	Nonce      string    `gorm:"primaryKey;column:Nonce;type:varchar(100)"`
+	ReceivedOn time.Time `gorm:"column:ReceivedOn;not null;default:GETDATE();index:IX_LabEventNonces_ReceivedOn"`
+}
+
+func (LabEventNonce) TableName() string {
+	return "MediAdmin.tbl_LabEventNonces"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type LabReport struct {
+	ReportID        int64      `gorm:"primaryKey;column:ReportID;autoIncrement"`
+	LeadID          int64      `gorm:"column:LeadID;not null;uniqueIndex:UX_LabReports_LeadID_Version,priority:1"`
+	LabID           *int64     `gorm:"column:LabID"`
+	Version         int        `gorm:"column:Version;not null;uniqueIndex:UX_LabReports_LeadID_Version,priority:2"`
+	FileName        string     `gorm:"column:FileName;type:nvarchar(255);not null"`
+	ContentType     string     `gorm:"column:ContentType;type:varchar(100);not null"`
+	SizeBytes       int64      `gorm:"column:SizeBytes;not null"`
+	Checksum        string     `gorm:"column:Checksum;type:char(64);not null"`
+	StorageKey      string     `gorm:"column:StorageKey;type:varchar(300);not null"`
+	Status          string     `gorm:"column:Status;type:varchar(20);not null"`
+	AmendmentReason *string    `gorm:"column:AmendmentReason;type:nvarchar(500)"`
+	UploadedBy      int64      `gorm:"column:UploadedBy;not null"`
+	UploadedByType  int        `gorm:"column:UploadedByType;not null"`
+	UploadedOn      time.Time  `gorm:"column:UploadedOn;not null;default:GETDATE()"`
+	ReleasedBy      *int64     `gorm:"column:ReleasedBy"`
+	ReleasedOn      *time.Time `gorm:"column:ReleasedOn"`
+}
+
+func (LabReport) TableName() string {
+	return "MediAdmin.tbl_LabReports"
+}
@@ -5,7 +5,8 @@ import "time"
 type Lead struct {
 	LeadID        int64     `gorm:"primaryKey;column:LeadID;autoIncrement"`
 	ClientID      int64     `gorm:"column:ClientID;not null"`
-	PatientID     string    `gorm:"column:PatientID;type:varchar(20);not null"`
+	PatientID     string    `gorm:"column:PatientID;type:varchar(20);not null"` // legacy code (initials + contact number)
+	PatientRefID  *int64    `gorm:"column:PatientRefID"`                        // tbl_Patients.PatientID; NULL for leads created before patients existed
 	PatientName   string    `gorm:"column:PatientName;type:varchar(100);not null"`
 	Age           int8      `gorm:"column:Age;not null"`
 	Gender        string    `gorm:"column:Gender;type:varchar(1);not null"`
@@ -17,6 +18,7 @@ type Lead struct {
 	StateID       int8      `gorm:"column:StateID;not null"`
 	Pincode       string    `gorm:"column:Pincode;type:varchar(6);not null"`
 	LeadStatusID  int8      `gorm:"column:LeadStatusID;not null"`
+	LabID         *int64    `gorm:"column:LabID"`
 	CreatedBy     int64     `gorm:"column:CreatedBy;not null"`
 	CreatedOn     time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
 	LastUpdatedBy int64     `gorm:"column:LastUpdatedBy;not null"`
@@ -28,13 +30,29 @@ func (Lead) TableName() string {
 }
 
 type LeadHistory struct {
-	UID       int64     `gorm:"primaryKey;column:UID;autoIncrement"`
-	LeadID    int64     `gorm:"column:LeadID;not null"`
-	Action    string    `gorm:"column:Action;type:varchar(25);not null"`
-	CreatedBy int64     `gorm:"column:CreatedBy;not null"`
-	CreatedOn time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	UID           int64     `gorm:"primaryKey;column:UID;autoIncrement"`
+	LeadID        int64     `gorm:"column:LeadID;not null"`
+	Action        string    `gorm:"column:Action;type:varchar(25);not null"`
+	Reason        *string   `gorm:"column:Reason;type:varchar(250)"`
+	CreatedBy     int64     `gorm:"column:CreatedBy;not null"`
+	CreatedByType *int      `gorm:"column:CreatedByType"`
+	CreatedOn     time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
 }
 
 func (LeadHistory) TableName() string {
 	return "MediAdmin.tbl_LeadsHistory"
 }
+
+// LeadHistoryChange is one field's old/new value for a tbl_LeadsHistory entry.
+type LeadHistoryChange struct {
+	UID        int64   `gorm:"primaryKey;column:UID;autoIncrement"`
+	HistoryUID int64   `gorm:"column:HistoryUID;not null"`
+	LeadID     int64   `gorm:"column:LeadID;not null"`
+	FieldName  string  `gorm:"column:FieldName;type:varchar(50);not null"`
+	OldValue   *string `gorm:"column:OldValue;type:nvarchar(500)"`
+	NewValue   *string `gorm:"column:NewValue;type:nvarchar(500)"`
+}
+
+func (LeadHistoryChange) TableName() string {
+	return "MediAdmin.tbl_LeadsHistoryChanges"
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type LeadImportJob struct {
+	JobID         int64      `gorm:"primaryKey;column:JobID;autoIncrement"`
+	ClientID      int64      `gorm:"column:ClientID;not null"`
+	PackageID     int        `gorm:"column:PackageID;not null"`
+	Strict        bool       `gorm:"column:Strict;not null"`
+	Status        string     `gorm:"column:Status;type:varchar(20);not null"`
+	TotalRows     int        `gorm:"column:TotalRows;not null"`
+	ProcessedRows int        `gorm:"column:ProcessedRows;not null"`
+	InsertedRows  int        `gorm:"column:InsertedRows;not null"`
+	FailedRows    int        `gorm:"column:FailedRows;not null"`
+	Message       *string    `gorm:"column:Message;type:nvarchar(500)"`
+	CreatedBy     int64      `gorm:"column:CreatedBy;not null"`
+	CreatedByType int        `gorm:"column:CreatedByType;not null"`
+	CreatedOn     time.Time  `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	StartedOn     *time.Time `gorm:"column:StartedOn"`
+	CompletedOn   *time.Time `gorm:"column:CompletedOn"`
+	LastUpdatedOn *time.Time `gorm:"column:LastUpdatedOn"`                  // progress or heartbeat of a running job
+	CsvContent    []byte     `gorm:"column:CsvContent;type:varbinary(max)"` // uploaded file; cleared when the job finishes
+}
+
+func (LeadImportJob) TableName() string {
+	return "MediAdmin.tbl_LeadImportJobs"
+}
+
+// LeadImportJobError is one row-level problem reported by a lead import job.
+type LeadImportJobError struct {
+	UID        int64  `gorm:"primaryKey;column:UID;autoIncrement"`
+	JobID      int64  `gorm:"column:JobID;not null"`
+	RowNumber  int    `gorm:"column:RowNumber;not null"`
+	ColumnName string `gorm:"column:ColumnName;type:varchar(50);not null"`
+	Reason     string `gorm:"column:Reason;type:nvarchar(500);not null"`
+}
+
+func (LeadImportJobError) TableName() string {
+	return "MediAdmin.tbl_LeadImportJobErrors"
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type OutboxEvent struct {
+	EventID       int64      `gorm:"primaryKey;column:EventID;autoIncrement"`
+	EventType     string     `gorm:"column:EventType;type:varchar(50);not null"`
+	AggregateType string     `gorm:"column:AggregateType;type:varchar(30);not null"`
+	AggregateID   int64      `gorm:"column:AggregateID;not null"`
+	Payload       string     `gorm:"column:Payload;type:nvarchar(max);not null"`
+	OccurredOn    time.Time  `gorm:"column:OccurredOn;not null;default:GETDATE()"`
+	Status        string     `gorm:"column:Status;type:varchar(20);not null;index:IX_OutboxEvents_Status_NextAttemptOn,priority:1"`
+	Attempts      int        `gorm:"column:Attempts;not null"`
+	NextAttemptOn *time.Time `gorm:"column:NextAttemptOn;index:IX_OutboxEvents_Status_NextAttemptOn,priority:2"`
+	LastError     *string    `gorm:"column:LastError;type:nvarchar(1000)"`
+	ProcessedOn   *time.Time `gorm:"column:ProcessedOn"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "MediAdmin.tbl_OutboxEvents"
+}
+
+// OutboxEventHandled records that a subscriber handled an event, so a retried event skips it.
+type OutboxEventHandled struct {
+	EventID    int64     `gorm:"primaryKey;column:EventID;autoIncrement:false"`
+	Subscriber string    `gorm:"primaryKey;column:Subscriber;type:varchar(50)"`
+	HandledOn  time.Time `gorm:"column:HandledOn;not null;default:GETDATE()"`
+}
+
+func (OutboxEventHandled) TableName() string {
+	return "MediAdmin.tbl_OutboxEventsHandled"
+}
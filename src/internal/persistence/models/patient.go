@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Patient struct {
+	PatientID     int64     `gorm:"primaryKey;column:PatientID;autoIncrement"`
+	ClientID      int64     `gorm:"column:ClientID;not null"`
+	FullName      string    `gorm:"column:FullName;type:varchar(100);not null"`
+	Age           int8      `gorm:"column:Age;not null"`
+	Gender        string    `gorm:"column:Gender;type:varchar(1);not null"`
+	ContactNumber string    `gorm:"column:ContactNumber;type:varchar(10);not null"`
+	Emailid       string    `gorm:"column:Emailid;type:varchar(75);not null"`
+	Address       string    `gorm:"column:Address;type:varchar(150);not null"`
+	CityID        int8      `gorm:"column:CityID;not null"`
+	StateID       int8      `gorm:"column:StateID;not null"`
+	Pincode       string    `gorm:"column:Pincode;type:varchar(6);not null"`
+	CreatedBy     int64     `gorm:"column:CreatedBy;not null"`
+	CreatedOn     time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	LastUpdatedBy int64     `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn time.Time `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (Patient) TableName() string {
+	return "MediAdmin.tbl_Patients"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RefreshToken maps to MediAdmin.tbl_RefreshTokens. One row per issued refresh token (jti); all
+// tokens issued for one login share a SessionID so the whole session can be revoked together.
+type RefreshToken struct {
+	TokenID   string     `gorm:"primaryKey;column:TokenID;type:varchar(64)"`
+	SessionID string     `gorm:"column:SessionID;type:varchar(64);not null;index"`
+	UserID    int64      `gorm:"column:UserID;not null"`
+	UserType  string     `gorm:"column:UserType;type:varchar(10);not null"`
+	ExpiresAt time.Time  `gorm:"column:ExpiresAt;not null"`
+	CreatedOn time.Time  `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	UsedOn    *time.Time `gorm:"column:UsedOn"`
+	RevokedOn *time.Time `gorm:"column:RevokedOn"`
+}
+
+func (RefreshToken) TableName() string {
+	return "MediAdmin.tbl_RefreshTokens"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Sample struct {
+	SampleID        int64      `gorm:"primaryKey;column:SampleID;autoIncrement"`
+	LeadID          int64      `gorm:"column:LeadID;not null;index:IX_Samples_LeadID"`
+	LabID           *int64     `gorm:"column:LabID"`
+	TubeNumber      int        `gorm:"column:TubeNumber;not null"`
+	TubeType        string     `gorm:"column:TubeType;type:varchar(30);not null"`
+	AccessionNumber string     `gorm:"column:AccessionNumber;type:varchar(20);not null;uniqueIndex:UX_Samples_AccessionNumber"`
+	Status          string     `gorm:"column:Status;type:varchar(20);not null"`
+	RejectionReason *string    `gorm:"column:RejectionReason;type:nvarchar(250)"`
+	CollectedBy     int64      `gorm:"column:CollectedBy;not null"`
+	CollectedOn     time.Time  `gorm:"column:CollectedOn;not null;default:GETDATE()"`
+	ReceivedOn      *time.Time `gorm:"column:ReceivedOn"`
+	LastUpdatedBy   int64      `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn   time.Time  `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (Sample) TableName() string {
+	return "MediAdmin.tbl_Samples"
+}
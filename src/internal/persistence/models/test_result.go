@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+type TestParameter struct {
+	ParameterID   int64     `gorm:"primaryKey;column:ParameterID;autoIncrement"`
+	TestID        int       `gorm:"column:TestID;not null;index:IX_TestParameters_TestID"`
+	Analyte       string    `gorm:"column:Analyte;type:nvarchar(100);not null"`
+	Unit          string    `gorm:"column:Unit;type:nvarchar(30);not null"`
+	DisplayOrder  int       `gorm:"column:DisplayOrder;not null"`
+	IsActive      bool      `gorm:"column:IsActive;not null;default:true"`
+	CreatedBy     int64     `gorm:"column:CreatedBy;not null"`
+	CreatedOn     time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	LastUpdatedBy int64     `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn time.Time `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (TestParameter) TableName() string {
+	return "MediAdmin.tbl_TestParameters"
+}
+
+type TestReferenceRange struct {
+	RangeID      int64    `gorm:"primaryKey;column:RangeID;autoIncrement"`
+	ParameterID  int64    `gorm:"column:ParameterID;not null;index:IX_TestReferenceRanges_ParameterID"`
+	Gender       *string  `gorm:"column:Gender;type:varchar(1)"`
+	MinAge       int      `gorm:"column:MinAge;not null"`
+	MaxAge       int      `gorm:"column:MaxAge;not null"`
+	Low          *float64 `gorm:"column:Low"`
+	High         *float64 `gorm:"column:High"`
+	CriticalLow  *float64 `gorm:"column:CriticalLow"`
+	CriticalHigh *float64 `gorm:"column:CriticalHigh"`
+}
+
+func (TestReferenceRange) TableName() string {
+	return "MediAdmin.tbl_TestReferenceRanges"
+}
+
+type TestResult struct {
+	ResultID       int64     `gorm:"primaryKey;column:ResultID;autoIncrement"`
+	LeadID         int64     `gorm:"column:LeadID;not null;uniqueIndex:UX_TestResults_LeadID_ParameterID,priority:1"`
+	TestID         int       `gorm:"column:TestID;not null"`
+	ParameterID    int64     `gorm:"column:ParameterID;not null;uniqueIndex:UX_TestResults_LeadID_ParameterID,priority:2"`
+	Value          float64   `gorm:"column:Value;not null"`
+	Unit           string    `gorm:"column:Unit;type:nvarchar(30);not null"`
+	ReferenceLow   *float64  `gorm:"column:ReferenceLow"`
+	ReferenceHigh  *float64  `gorm:"column:ReferenceHigh"`
+	Flag           *string   `gorm:"column:Flag;type:varchar(20)"`
+	Comment        *string   `gorm:"column:Comment;type:nvarchar(500)"`
+	ReportedBy     int64     `gorm:"column:ReportedBy;not null"`
+	ReportedByType int       `gorm:"column:ReportedByType;not null"`
+	ReportedOn     time.Time `gorm:"column:ReportedOn;not null;default:GETDATE()"`
+}
+
+func (TestResult) TableName() string {
+	return "MediAdmin.tbl_TestResults"
+}
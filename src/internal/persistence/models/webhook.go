@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type WebhookSubscription struct {
+	SubscriptionID int64     `gorm:"primaryKey;column:SubscriptionID;autoIncrement"`
+	ClientID       int64     `gorm:"column:ClientID;not null;index:IX_WebhookSubscriptions_ClientID"`
+	URL            string    `gorm:"column:URL;type:varchar(500);not null"`
+	Secret         string    `gorm:"column:Secret;type:varchar(100);not null"`
+	EventTypes     string    `gorm:"column:EventTypes;type:varchar(200);not null"` // comma-separated
+	IsActive       bool      `gorm:"column:IsActive;not null"`
+	CreatedBy      int64     `gorm:"column:CreatedBy;not null"`
+	CreatedOn      time.Time `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	LastUpdatedBy  int64     `gorm:"column:LastUpdatedBy;not null"`
+	LastUpdatedOn  time.Time `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "MediAdmin.tbl_WebhookSubscriptions"
+}
+
+type WebhookDelivery struct {
+	DeliveryID       int64      `gorm:"primaryKey;column:DeliveryID;autoIncrement"`
+	SubscriptionID   int64      `gorm:"column:SubscriptionID;not null;index:IX_WebhookDeliveries_SubscriptionID"`
+	ClientID         int64      `gorm:"column:ClientID;not null;index:IX_WebhookDeliveries_ClientID_Status,priority:1"`
+	EventID          string     `gorm:"column:EventID;type:varchar(40);not null;index:IX_WebhookDeliveries_EventID"`
+	EventType        string     `gorm:"column:EventType;type:varchar(50);not null"`
+	LeadID           int64      `gorm:"column:LeadID;not null"`
+	Payload          string     `gorm:"column:Payload;type:nvarchar(max);not null"`
+	Status           string     `gorm:"column:Status;type:varchar(20);not null;index:IX_WebhookDeliveries_Status_NextAttemptOn,priority:1;index:IX_WebhookDeliveries_ClientID_Status,priority:2"`
+	Attempts         int        `gorm:"column:Attempts;not null"`
+	NextAttemptOn    *time.Time `gorm:"column:NextAttemptOn;index:IX_WebhookDeliveries_Status_NextAttemptOn,priority:2"`
+	LastResponseCode *int       `gorm:"column:LastResponseCode"`
+	LastError        *string    `gorm:"column:LastError;type:nvarchar(1000)"`
+	CreatedOn        time.Time  `gorm:"column:CreatedOn;not null;default:GETDATE()"`
+	DeliveredOn      *time.Time `gorm:"column:DeliveredOn"`
+	LastUpdatedOn    time.Time  `gorm:"column:LastUpdatedOn;not null;default:GETDATE()"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "MediAdmin.tbl_WebhookDeliveries"
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type LabSlotRepository interface {
+	FindByID(id int64) (*domain.LabSlot, error)
+	FindByLabID(labID int64) ([]domain.LabSlot, error)
+	Create(s *domain.LabSlot) error
+	Update(s *domain.LabSlot) error
+}
+
+type labSlotRepository struct {
+	db *gorm.DB
+}
+
+func NewLabSlotRepository(db *gorm.DB) LabSlotRepository {
+	return &labSlotRepository{db: db}
+}
+
+func (r *labSlotRepository) FindByID(id int64) (*domain.LabSlot, error) {
+	var s persistencemodels.LabSlot
+	if err := r.db.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	slot := mapLabSlotToDomain(s)
+	return &slot, nil
+}
+
+// FindByLabID returns the lab's slots ordered by start time.
+func (r *labSlotRepository) FindByLabID(labID int64) ([]domain.LabSlot, error) {
+	var rows []persistencemodels.LabSlot
+	if err := r.db.Where("LabID = ?", labID).Order("StartTime ASC, SlotID ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	slots := make([]domain.LabSlot, len(rows))
+	for i, row := range rows {
+		slots[i] = mapLabSlotToDomain(row)
+	}
+	return slots, nil
+}
+
+func (r *labSlotRepository) Create(s *domain.LabSlot) error {
+	persist := mapLabSlotToPersistence(*s)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*s = mapLabSlotToDomain(persist)
+	return nil
+}
+
+func (r *labSlotRepository) Update(s *domain.LabSlot) error {
+	persist := mapLabSlotToPersistence(*s)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*s = mapLabSlotToDomain(persist)
+	return nil
+}
+
+type AppointmentRepository interface {
+	FindByID(id int64) (*domain.Appointment, error)
+	FindByLeadID(leadID int64) ([]domain.Appointment, error)
+	FindBookedByLeadID(leadID int64) (*domain.Appointment, error)
+	FindByLabAndDate(labID int64, date time.Time) ([]domain.Appointment, error)
+	CountBookedBySlot(labID int64, date time.Time) (map[int64]int, error)
+	LockSlot(slotID int64) error
+	CountBooked(slotID int64, date time.Time) (int, error)
+	Create(a *domain.Appointment) error
+	Update(a *domain.Appointment) error
+}
+
+type appointmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAppointmentRepository(db *gorm.DB) AppointmentRepository {
+	return &appointmentRepository{db: db}
+}
+
+func (r *appointmentRepository) FindByID(id int64) (*domain.Appointment, error) {
+	var a persistencemodels.Appointment
+	if err := r.db.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	appointment := mapAppointmentToDomain(a)
+	return &appointment, nil
+}
+
+// FindByLeadID returns every appointment of the lead, newest first, cancelled ones included.
+func (r *appointmentRepository) FindByLeadID(leadID int64) ([]domain.Appointment, error) {
+	var rows []persistencemodels.Appointment
+	if err := r.db.Where("LeadID = ?", leadID).Order("AppointmentDate DESC, AppointmentID DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return mapAppointmentsToDomain(rows), nil
+}
+
+// FindBookedByLeadID returns the lead's current booked appointment, or gorm.ErrRecordNotFound. Inside
+// a transaction the lead's appointment range stays locked until commit, so a concurrent booking for
+// the same lead waits and then finds this one.
+func (r *appointmentRepository) FindBookedByLeadID(leadID int64) (*domain.Appointment, error) {
+	var a persistencemodels.Appointment
+	err := r.db.Raw("SELECT TOP 1 * FROM MediAdmin.tbl_Appointments WITH (UPDLOCK, HOLDLOCK) WHERE LeadID = ? AND Status = ? ORDER BY AppointmentID",
+		leadID, domain.AppointmentStatusBooked).Scan(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	if a.AppointmentID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	appointment := mapAppointmentToDomain(a)
+	return &appointment, nil
+}
+
+// FindByLabAndDate returns the lab's booked appointments on date in time order.
+func (r *appointmentRepository) FindByLabAndDate(labID int64, date time.Time) ([]domain.Appointment, error) {
+	var rows []persistencemodels.Appointment
+	err := r.db.Where("LabID = ? AND AppointmentDate = ? AND Status = ?", labID, date, domain.AppointmentStatusBooked).
+		Order("StartTime ASC, AppointmentID ASC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return mapAppointmentsToDomain(rows), nil
+}
+
+// CountBookedBySlot returns the number of booked appointments per slot of the lab on date.
+func (r *appointmentRepository) CountBookedBySlot(labID int64, date time.Time) (map[int64]int, error) {
+	var rows []struct {
+		SlotID int64
+		Booked int
+	}
+	err := r.db.Model(&persistencemodels.Appointment{}).
+		Select("SlotID, COUNT(*) AS Booked").
+		Where("LabID = ? AND AppointmentDate = ? AND Status = ?", labID, date, domain.AppointmentStatusBooked).
+		Group("SlotID").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int, len(rows))
+	for _, row := range rows {
+		counts[row.SlotID] = row.Booked
+	}
+	return counts, nil
+}
+
+// LockSlot takes an update lock on the slot row until the transaction ends, so concurrent bookings
+// of the same slot are counted one at a time. Call inside AppointmentUnitOfWork.
+func (r *appointmentRepository) LockSlot(slotID int64) error {
+	var id int64
+	return r.db.Raw("SELECT SlotID FROM MediAdmin.tbl_LabSlots WITH (UPDLOCK, ROWLOCK) WHERE SlotID = ?", slotID).Scan(&id).Error
+}
+
+func (r *appointmentRepository) CountBooked(slotID int64, date time.Time) (int, error) {
+	var count int64
+	err := r.db.Model(&persistencemodels.Appointment{}).
+		Where("SlotID = ? AND AppointmentDate = ? AND Status = ?", slotID, date, domain.AppointmentStatusBooked).
+		Count(&count).Error
+	return int(count), err
+}
+
+func (r *appointmentRepository) Create(a *domain.Appointment) error {
+	persist := mapAppointmentToPersistence(*a)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*a = mapAppointmentToDomain(persist)
+	return nil
+}
+
+func (r *appointmentRepository) Update(a *domain.Appointment) error {
+	persist := mapAppointmentToPersistence(*a)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*a = mapAppointmentToDomain(persist)
+	return nil
+}
+
+// AppointmentUnitOfWork runs appointment changes and the matching lead update, history entry and
+// domain events in one transaction.
+type AppointmentUnitOfWork interface {
+	WithinTransaction(func(AppointmentRepository, LeadRepository, LeadHistoryRepository, OutboxRepository) error) error
+}
+
+type appointmentUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewAppointmentUnitOfWork(db *gorm.DB) AppointmentUnitOfWork {
+	return &appointmentUnitOfWork{db: db}
+}
+
+func (u *appointmentUnitOfWork) WithinTransaction(fn func(AppointmentRepository, LeadRepository, LeadHistoryRepository, OutboxRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewAppointmentRepository(tx), NewLeadRepository(tx), NewLeadHistoryRepository(tx), NewOutboxRepository(tx))
+	})
+}
+
+func mapLabSlotToDomain(p persistencemodels.LabSlot) domain.LabSlot {
+	return domain.LabSlot{
+		SlotID:        p.SlotID,
+		LabID:         p.LabID,
+		Pincode:       derefString(p.Pincode),
+		StartTime:     p.StartTime,
+		EndTime:       p.EndTime,
+		Capacity:      p.Capacity,
+		IsActive:      p.IsActive,
+		CreatedBy:     p.CreatedBy,
+		CreatedOn:     p.CreatedOn,
+		LastUpdatedBy: p.LastUpdatedBy,
+		LastUpdatedOn: p.LastUpdatedOn,
+	}
+}
+
+func mapLabSlotToPersistence(d domain.LabSlot) persistencemodels.LabSlot {
+	return persistencemodels.LabSlot{
+		SlotID:        d.SlotID,
+		LabID:         d.LabID,
+		Pincode:       optionalString(d.Pincode),
+		StartTime:     d.StartTime,
+		EndTime:       d.EndTime,
+		Capacity:      d.Capacity,
+		IsActive:      d.IsActive,
+		CreatedBy:     d.CreatedBy,
+		CreatedOn:     d.CreatedOn,
+		LastUpdatedBy: d.LastUpdatedBy,
+		LastUpdatedOn: d.LastUpdatedOn,
+	}
+}
+
+func mapAppointmentToDomain(p persistencemodels.Appointment) domain.Appointment {
+	return domain.Appointment{
+		AppointmentID:   p.AppointmentID,
+		LeadID:          p.LeadID,
+		LabID:           p.LabID,
+		SlotID:          p.SlotID,
+		AppointmentDate: p.AppointmentDate,
+		StartTime:       p.StartTime,
+		EndTime:         p.EndTime,
+		Pincode:         p.Pincode,
+		Status:          p.Status,
+		CancelReason:    derefString(p.CancelReason),
+		CreatedBy:       p.CreatedBy,
+		CreatedOn:       p.CreatedOn,
+		LastUpdatedBy:   p.LastUpdatedBy,
+		LastUpdatedOn:   p.LastUpdatedOn,
+	}
+}
+
+func mapAppointmentToPersistence(d domain.Appointment) persistencemodels.Appointment {
+	return persistencemodels.Appointment{
+		AppointmentID:   d.AppointmentID,
+		LeadID:          d.LeadID,
+		LabID:           d.LabID,
+		SlotID:          d.SlotID,
+		AppointmentDate: d.AppointmentDate,
+		StartTime:       d.StartTime,
+		EndTime:         d.EndTime,
+		Pincode:         d.Pincode,
+		Status:          d.Status,
+		CancelReason:    optionalString(d.CancelReason),
+		CreatedBy:       d.CreatedBy,
+		CreatedOn:       d.CreatedOn,
+		LastUpdatedBy:   d.LastUpdatedBy,
+		LastUpdatedOn:   d.LastUpdatedOn,
+	}
+}
+
+func mapAppointmentsToDomain(rows []persistencemodels.Appointment) []domain.Appointment {
+	appointments := make([]domain.Appointment, len(rows))
+	for i, row := range rows {
+		appointments[i] = mapAppointmentToDomain(row)
+	}
+	return appointments
+}
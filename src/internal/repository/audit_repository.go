@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepository interface {
+	Create(entry *domain.AuditEntry) error
+	List(filter AuditListFilter) ([]domain.AuditEntry, int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(entry *domain.AuditEntry) error {
+	p := mapAuditEntryToPersistence(*entry)
+	if err := r.db.Create(&p).Error; err != nil {
+		return err
+	}
+	*entry = mapAuditEntryToDomain(p)
+	return nil
+}
+
+func (r *auditRepository) List(filter AuditListFilter) ([]domain.AuditEntry, int64, error) {
+	query := r.db.Model(&persistencemodels.AuditLog{})
+	if filter.EntityType != "" {
+		query = query.Where("EntityType = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("EntityID = ?", *filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("Action = ?", filter.Action)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("ActorID = ?", *filter.ActorID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("RequestID = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("CreatedOn >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("CreatedOn <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := normalizeSortOrder(filter.SortOrder)
+	offset := (filter.Page - 1) * filter.PageSize
+
+	var rows []persistencemodels.AuditLog
+	err := query.Order("CreatedOn " + order + ", AuditID " + order).Limit(filter.PageSize).Offset(offset).Find(&rows).Error
+	entries := make([]domain.AuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = mapAuditEntryToDomain(row)
+	}
+	return entries, total, err
+}
+
+func mapAuditEntryToPersistence(d domain.AuditEntry) persistencemodels.AuditLog {
+	return persistencemodels.AuditLog{
+		AuditID:    d.AuditID,
+		EntityType: d.EntityType,
+		EntityID:   d.EntityID,
+		Action:     d.Action,
+		ActorID:    d.ActorID,
+		ActorType:  d.ActorType,
+		RequestID:  optionalString(d.RequestID),
+		BeforeJSON: optionalString(d.Before),
+		AfterJSON:  optionalString(d.After),
+		CreatedOn:  d.CreatedOn,
+	}
+}
+
+func mapAuditEntryToDomain(p persistencemodels.AuditLog) domain.AuditEntry {
+	d := domain.AuditEntry{
+		AuditID:    p.AuditID,
+		EntityType: p.EntityType,
+		EntityID:   p.EntityID,
+		Action:     p.Action,
+		ActorID:    p.ActorID,
+		ActorType:  p.ActorType,
+		CreatedOn:  p.CreatedOn,
+	}
+	if p.RequestID != nil {
+		d.RequestID = *p.RequestID
+	}
+	if p.BeforeJSON != nil {
+		d.Before = *p.BeforeJSON
+	}
+	if p.AfterJSON != nil {
+		d.After = *p.AfterJSON
+	}
+	return d
+}
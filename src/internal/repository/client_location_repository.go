@@ -8,6 +8,7 @@ import (
 )
 
 type ClientLocationRepository interface {
+	WithScope(scope domain.TenantScope) ClientLocationRepository
 	FindByClientID(clientID int64) ([]domain.ClientLocation, error)
 	FindByID(id int64) (*domain.ClientLocation, error)
 	ExistsByID(id int64) (bool, error)
@@ -17,16 +18,26 @@ type ClientLocationRepository interface {
 }
 
 type clientLocationRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	scope domain.TenantScope
 }
 
 func NewClientLocationRepository(db *gorm.DB) ClientLocationRepository {
 	return &clientLocationRepository{db: db}
 }
 
+// WithScope returns a repository limited to the scope's client; labs own no client locations.
+func (r *clientLocationRepository) WithScope(scope domain.TenantScope) ClientLocationRepository {
+	return &clientLocationRepository{db: r.db, scope: scope}
+}
+
+func (r *clientLocationRepository) scoped() *gorm.DB {
+	return r.db.Scopes(tenantScope(r.scope, "ClientID", ""))
+}
+
 func (r *clientLocationRepository) FindByClientID(clientID int64) ([]domain.ClientLocation, error) {
 	var list []persistencemodels.ClientLocation
-	if err := r.db.Where("ClientID = ?", clientID).Find(&list).Error; err != nil {
+	if err := r.scoped().Where("ClientID = ?", clientID).Find(&list).Error; err != nil {
 		return nil, err
 	}
 	return mapClientLocationsToDomain(list), nil
@@ -34,7 +45,7 @@ func (r *clientLocationRepository) FindByClientID(clientID int64) ([]domain.Clie
 
 func (r *clientLocationRepository) FindByID(id int64) (*domain.ClientLocation, error) {
 	var m persistencemodels.ClientLocation
-	if err := r.db.First(&m, id).Error; err != nil {
+	if err := r.scoped().First(&m, id).Error; err != nil {
 		return nil, err
 	}
 	d := mapClientLocationToDomain(m)
@@ -43,7 +54,7 @@ func (r *clientLocationRepository) FindByID(id int64) (*domain.ClientLocation, e
 
 func (r *clientLocationRepository) ExistsByID(id int64) (bool, error) {
 	var count int64
-	if err := r.db.Model(&persistencemodels.ClientLocation{}).Where("ClientLocationID = ?", id).Limit(1).Count(&count).Error; err != nil {
+	if err := r.scoped().Model(&persistencemodels.ClientLocation{}).Where("ClientLocationID = ?", id).Limit(1).Count(&count).Error; err != nil {
 		return false, err
 	}
 	return count > 0, nil
@@ -68,7 +79,7 @@ func (r *clientLocationRepository) Update(l *domain.ClientLocation) error {
 }
 
 func (r *clientLocationRepository) Delete(id int64) error {
-	return r.db.Delete(&persistencemodels.ClientLocation{}, id).Error
+	return r.scoped().Delete(&persistencemodels.ClientLocation{}, id).Error
 }
 
 func mapClientLocationToDomain(p persistencemodels.ClientLocation) domain.ClientLocation {
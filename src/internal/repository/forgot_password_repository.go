@@ -12,8 +12,9 @@ import (
 type ForgotPasswordRepository interface {
 	Create(data *domain.ForgotPassword) error
 	FindLatestValidKey(userID int64, userType string) (*domain.ForgotPassword, error)
-	FindByKey(forgetPasswordKey string, userID int64, userType string) (*domain.ForgotPassword, error)
 	MarkAsUsed(record *domain.ForgotPassword) error
+	ClaimAttempt(record *domain.ForgotPassword, maxAttempts int) (bool, error)
+	InvalidateActiveKeys(userID int64, userType string) error
 }
 
 type forgotPasswordRepository struct {
@@ -47,19 +48,6 @@ func (r *forgotPasswordRepository) FindLatestValidKey(userID int64, userType str
 	return &d, nil
 }
 
-func (r *forgotPasswordRepository) FindByKey(forgetPasswordKey string, userID int64, userType string) (*domain.ForgotPassword, error) {
-	var p persistencemodels.ForgotPassword
-	now := time.Now().UTC()
-	err := r.db.Where("UserID = ? AND UserType = ? AND ForgetPasswordKey = ? AND ExpiryTimestamp > ? AND IsPasswordChanged = ?",
-		userID, userType, forgetPasswordKey, now, false).
-		First(&p).Error
-	if err != nil {
-		return nil, err
-	}
-	d := mapForgotPasswordToDomain(p)
-	return &d, nil
-}
-
 func (r *forgotPasswordRepository) MarkAsUsed(record *domain.ForgotPassword) error {
 	now := time.Now().UTC()
 	return r.db.Model(&persistencemodels.ForgotPassword{}).
@@ -70,6 +58,24 @@ func (r *forgotPasswordRepository) MarkAsUsed(record *domain.ForgotPassword) err
 		}).Error
 }
 
+// ClaimAttempt counts one verification attempt against the OTP record in a single conditional
+// update. It reports false when the record has no attempts left, so concurrent requests cannot make
+// more than maxAttempts guesses between them.
+func (r *forgotPasswordRepository) ClaimAttempt(record *domain.ForgotPassword, maxAttempts int) (bool, error) {
+	res := r.db.Model(&persistencemodels.ForgotPassword{}).
+		Where("Uid = ? AND FailedAttempts < ?", record.Uid, maxAttempts).
+		Update("FailedAttempts", gorm.Expr("FailedAttempts + 1"))
+	return res.RowsAffected > 0, res.Error
+}
+
+// InvalidateActiveKeys expires every outstanding OTP for the user so only the newest one can be used.
+func (r *forgotPasswordRepository) InvalidateActiveKeys(userID int64, userType string) error {
+	now := time.Now().UTC()
+	return r.db.Model(&persistencemodels.ForgotPassword{}).
+		Where("UserID = ? AND UserType = ? AND ExpiryTimestamp > ? AND IsPasswordChanged = ?", userID, userType, now, false).
+		Update("ExpiryTimestamp", now).Error
+}
+
 func mapForgotPasswordToPersistence(d domain.ForgotPassword) persistencemodels.ForgotPassword {
 	return persistencemodels.ForgotPassword{
 		Uid:                 d.Uid,
@@ -80,6 +86,7 @@ func mapForgotPasswordToPersistence(d domain.ForgotPassword) persistencemodels.F
 		ExpiryTimestamp:     d.ExpiryTimestamp,
 		IsPasswordChanged:   d.IsPasswordChanged,
 		IsPasswordUpdatedOn: d.IsPasswordUpdatedOn,
+		FailedAttempts:      d.FailedAttempts,
 	}
 }
 
@@ -93,5 +100,6 @@ func mapForgotPasswordToDomain(p persistencemodels.ForgotPassword) domain.Forgot
 		ExpiryTimestamp:     p.ExpiryTimestamp,
 		IsPasswordChanged:   p.IsPasswordChanged,
 		IsPasswordUpdatedOn: p.IsPasswordUpdatedOn,
+		FailedAttempts:      p.FailedAttempts,
 	}
 }
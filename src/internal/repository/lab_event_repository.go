@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type LabEventRepository interface {
+	FindByExternalID(labID int64, externalEventID string) (*domain.LabEvent, error)
+	Create(e *domain.LabEvent) error
+	ClaimNonce(labID int64, nonce string, now time.Time) (bool, error)
+	PruneNonces(before time.Time) error
+}
+
+type labEventRepository struct {
+	db *gorm.DB
+}
+
+func NewLabEventRepository(db *gorm.DB) LabEventRepository {
+	return &labEventRepository{db: db}
+}
+
+// FindByExternalID looks up the lab's event by its own ID. Inside a transaction the key range stays
+// locked until commit, so a concurrent duplicate waits and then finds this event.
+func (r *labEventRepository) FindByExternalID(labID int64, externalEventID string) (*domain.LabEvent, error) {
+	var p persistencemodels.LabEvent
+	err := r.db.Raw("SELECT * FROM MediAdmin.tbl_LabEvents WITH (UPDLOCK, HOLDLOCK) WHERE LabID = ? AND ExternalEventID = ?", labID, externalEventID).
+		Scan(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.EventID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	event := mapLabEventToDomain(p)
+	return &event, nil
+}
+
+func (r *labEventRepository) Create(e *domain.LabEvent) error {
+	persist := mapLabEventToPersistence(*e)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*e = mapLabEventToDomain(persist)
+	return nil
+}
+
+// ClaimNonce records a request nonce for the lab. It reports false when the nonce was already used.
+func (r *labEventRepository) ClaimNonce(labID int64, nonce string, now time.Time) (bool, error) {
+	var count int64
+	err := r.db.Raw("SELECT COUNT(1) FROM MediAdmin.tbl_LabEventNonces WITH (UPDLOCK, HOLDLOCK) WHERE LabID = ? AND Nonce = ?", labID, nonce).
+		Scan(&count).Error
+	if err != nil || count > 0 {
+		return false, err
+	}
+	return true, r.db.Create(&persistencemodels.LabEventNonce{LabID: labID, Nonce: nonce, ReceivedOn: now}).Error
+}
+
+// PruneNonces forgets nonces received before the given time; requests that old fail the timestamp
+// check anyway.
+func (r *labEventRepository) PruneNonces(before time.Time) error {
+	return r.db.Where("ReceivedOn < ?", before).Delete(&persistencemodels.LabEventNonce{}).Error
+}
+
+// LabEventUnitOfWork applies a lab event, the lead and sample changes it causes, the history entry and
+// domain events in one transaction.
+type LabEventUnitOfWork interface {
+	WithinTransaction(func(LabEventRepository, LeadRepository, SampleRepository, LeadHistoryRepository, OutboxRepository) error) error
+}
+
+type labEventUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewLabEventUnitOfWork(db *gorm.DB) LabEventUnitOfWork {
+	return &labEventUnitOfWork{db: db}
+}
+
+func (u *labEventUnitOfWork) WithinTransaction(fn func(LabEventRepository, LeadRepository, SampleRepository, LeadHistoryRepository, OutboxRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewLabEventRepository(tx), NewLeadRepository(tx), NewSampleRepository(tx), NewLeadHistoryRepository(tx), NewOutboxRepository(tx))
+	})
+}
+
+func mapLabEventToDomain(p persistencemodels.LabEvent) domain.LabEvent {
+	return domain.LabEvent{
+		EventID:         p.EventID,
+		LabID:           p.LabID,
+		ExternalEventID: p.ExternalEventID,
+		EventType:       p.EventType,
+		LeadID:          p.LeadID,
+		AccessionNumber: derefString(p.AccessionNumber),
+		OccurredOn:      p.OccurredOn,
+		Outcome:         p.Outcome,
+		Note:            derefString(p.Note),
+		ReceivedOn:      p.ReceivedOn,
+	}
+}
+
+func mapLabEventToPersistence(d domain.LabEvent) persistencemodels.LabEvent {
+	return persistencemodels.LabEvent{
+		EventID:         d.EventID,
+		LabID:           d.LabID,
+		ExternalEventID: d.ExternalEventID,
+		EventType:       d.EventType,
+		LeadID:          d.LeadID,
+		AccessionNumber: optionalString(d.AccessionNumber),
+		OccurredOn:      d.OccurredOn,
+		Outcome:         d.Outcome,
+		Note:            optionalString(d.Note),
+		ReceivedOn:      d.ReceivedOn,
+	}
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type LabIntegrationRepository interface {
+	FindByLabID(labID int64) (*domain.LabIntegration, error)
+	Create(i *domain.LabIntegration) error
+	Update(i *domain.LabIntegration) error
+}
+
+type labIntegrationRepository struct {
+	db *gorm.DB
+}
+
+func NewLabIntegrationRepository(db *gorm.DB) LabIntegrationRepository {
+	return &labIntegrationRepository{db: db}
+}
+
+func (r *labIntegrationRepository) FindByLabID(labID int64) (*domain.LabIntegration, error) {
+	var p persistencemodels.LabIntegration
+	if err := r.db.Where("LabID = ?", labID).First(&p).Error; err != nil {
+		return nil, err
+	}
+	integration := mapLabIntegrationToDomain(p)
+	return &integration, nil
+}
+
+func (r *labIntegrationRepository) Create(i *domain.LabIntegration) error {
+	persist := mapLabIntegrationToPersistence(*i)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*i = mapLabIntegrationToDomain(persist)
+	return nil
+}
+
+func (r *labIntegrationRepository) Update(i *domain.LabIntegration) error {
+	persist := mapLabIntegrationToPersistence(*i)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*i = mapLabIntegrationToDomain(persist)
+	return nil
+}
+
+type LabOrderDeliveryRepository interface {
+	FindByID(id int64) (*domain.LabOrderDelivery, error)
+	FindByLeadID(leadID int64) ([]domain.LabOrderDelivery, error)
+	FindDue(now time.Time, limit int) ([]domain.LabOrderDelivery, error)
+	Claim(id int64, now, leaseUntil time.Time) (bool, error)
+	Create(d *domain.LabOrderDelivery) error
+	Update(d *domain.LabOrderDelivery) error
+	CompleteAttempt(d *domain.LabOrderDelivery, leaseUntil time.Time) (bool, error)
+	SupersedeOpen(leadID int64) (int64, error)
+	CreateAttempt(a *domain.LabOrderAttempt) error
+}
+
+type labOrderDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewLabOrderDeliveryRepository(db *gorm.DB) LabOrderDeliveryRepository {
+	return &labOrderDeliveryRepository{db: db}
+}
+
+func (r *labOrderDeliveryRepository) FindByID(id int64) (*domain.LabOrderDelivery, error) {
+	var p persistencemodels.LabOrderDelivery
+	if err := r.db.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	delivery := mapLabOrderDeliveryToDomain(p)
+	return &delivery, nil
+}
+
+// FindByLeadID returns the lead's deliveries, latest first, each with its attempts in order.
+func (r *labOrderDeliveryRepository) FindByLeadID(leadID int64) ([]domain.LabOrderDelivery, error) {
+	var rows []persistencemodels.LabOrderDelivery
+	if err := r.db.Where("LeadID = ?", leadID).Order("DeliveryID DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []domain.LabOrderDelivery{}, nil
+	}
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.DeliveryID
+	}
+	var attempts []persistencemodels.LabOrderAttempt
+	if err := r.db.Where("DeliveryID IN ?", ids).Order("DeliveryID, AttemptNumber").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	byDelivery := make(map[int64][]domain.LabOrderAttempt, len(rows))
+	for _, a := range attempts {
+		byDelivery[a.DeliveryID] = append(byDelivery[a.DeliveryID], mapLabOrderAttemptToDomain(a))
+	}
+	deliveries := make([]domain.LabOrderDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = mapLabOrderDeliveryToDomain(row)
+		deliveries[i].AttemptLog = byDelivery[row.DeliveryID]
+	}
+	return deliveries, nil
+}
+
+// FindDue returns pending deliveries whose next attempt is due, oldest first.
+func (r *labOrderDeliveryRepository) FindDue(now time.Time, limit int) ([]domain.LabOrderDelivery, error) {
+	var rows []persistencemodels.LabOrderDelivery
+	err := r.db.Where("Status = ? AND NextAttemptOn <= ?", domain.LabOrderStatusPending, now).
+		Order("NextAttemptOn").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]domain.LabOrderDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = mapLabOrderDeliveryToDomain(row)
+	}
+	return deliveries, nil
+}
+
+// Claim takes a due delivery for one attempt by pushing its next attempt to leaseUntil. It reports
+// false when another dispatcher claimed it first, so each attempt is made once across instances.
+func (r *labOrderDeliveryRepository) Claim(id int64, now, leaseUntil time.Time) (bool, error) {
+	res := r.db.Model(&persistencemodels.LabOrderDelivery{}).
+		Where("DeliveryID = ? AND Status = ? AND NextAttemptOn <= ?", id, domain.LabOrderStatusPending, now).
+		Update("NextAttemptOn", leaseUntil)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *labOrderDeliveryRepository) Create(d *domain.LabOrderDelivery) error {
+	persist := mapLabOrderDeliveryToPersistence(*d)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*d = mapLabOrderDeliveryToDomain(persist)
+	return nil
+}
+
+func (r *labOrderDeliveryRepository) Update(d *domain.LabOrderDelivery) error {
+	persist := mapLabOrderDeliveryToPersistence(*d)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	attempts := d.AttemptLog
+	*d = mapLabOrderDeliveryToDomain(persist)
+	d.AttemptLog = attempts
+	return nil
+}
+
+// CompleteAttempt writes the outcome of an attempt made under the Claim lease ending at leaseUntil. It
+// reports false, writing nothing, when the delivery changed meanwhile (superseded, retried by hand,
+// or claimed again after the lease ran out).
+func (r *labOrderDeliveryRepository) CompleteAttempt(d *domain.LabOrderDelivery, leaseUntil time.Time) (bool, error) {
+	persist := mapLabOrderDeliveryToPersistence(*d)
+	res := r.db.Model(&persistencemodels.LabOrderDelivery{}).
+		Where("DeliveryID = ? AND Status = ? AND NextAttemptOn = ?", d.DeliveryID, domain.LabOrderStatusPending, leaseUntil).
+		Updates(map[string]interface{}{
+			"Status":        persist.Status,
+			"Attempts":      persist.Attempts,
+			"NextAttemptOn": persist.NextAttemptOn,
+			"LastError":     persist.LastError,
+			"ExternalRef":   persist.ExternalRef,
+			"DeliveredOn":   persist.DeliveredOn,
+			"LastUpdatedOn": persist.LastUpdatedOn,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// SupersedeOpen marks the lead's undelivered deliveries as superseded, e.g. when the lead moves to
+// another lab.
+func (r *labOrderDeliveryRepository) SupersedeOpen(leadID int64) (int64, error) {
+	res := r.db.Model(&persistencemodels.LabOrderDelivery{}).
+		Where("LeadID = ? AND Status IN ?", leadID, []string{domain.LabOrderStatusPending, domain.LabOrderStatusFailed, domain.LabOrderStatusManual}).
+		Updates(map[string]interface{}{
+			"Status":        domain.LabOrderStatusSuperseded,
+			"NextAttemptOn": nil,
+			"LastUpdatedOn": time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+func (r *labOrderDeliveryRepository) CreateAttempt(a *domain.LabOrderAttempt) error {
+	persist := mapLabOrderAttemptToPersistence(*a)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*a = mapLabOrderAttemptToDomain(persist)
+	return nil
+}
+
+// LabOrderUnitOfWork records a delivery change and the matching lead history entry in one transaction.
+type LabOrderUnitOfWork interface {
+	WithinTransaction(func(LabOrderDeliveryRepository, LeadHistoryRepository) error) error
+}
+
+type labOrderUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewLabOrderUnitOfWork(db *gorm.DB) LabOrderUnitOfWork {
+	return &labOrderUnitOfWork{db: db}
+}
+
+func (u *labOrderUnitOfWork) WithinTransaction(fn func(LabOrderDeliveryRepository, LeadHistoryRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewLabOrderDeliveryRepository(tx), NewLeadHistoryRepository(tx))
+	})
+}
+
+func mapLabIntegrationToDomain(p persistencemodels.LabIntegration) domain.LabIntegration {
+	return domain.LabIntegration{
+		LabID:                p.LabID,
+		Adapter:              p.Adapter,
+		Endpoint:             derefString(p.Endpoint),
+		AuthToken:            derefString(p.AuthToken),
+		ReceivingApplication: derefString(p.ReceivingApplication),
+		ReceivingFacility:    derefString(p.ReceivingFacility),
+		EventSecret:          derefString(p.EventSecret),
+		TimeoutSeconds:       p.TimeoutSeconds,
+		MaxAttempts:          p.MaxAttempts,
+		IsActive:             p.IsActive,
+		CreatedBy:            p.CreatedBy,
+		CreatedOn:            p.CreatedOn,
+		LastUpdatedBy:        p.LastUpdatedBy,
+		LastUpdatedOn:        p.LastUpdatedOn,
+	}
+}
+
+func mapLabIntegrationToPersistence(d domain.LabIntegration) persistencemodels.LabIntegration {
+	return persistencemodels.LabIntegration{
+		LabID:                d.LabID,
+		Adapter:              d.Adapter,
+		Endpoint:             optionalString(d.Endpoint),
+		AuthToken:            optionalString(d.AuthToken),
+		ReceivingApplication: optionalString(d.ReceivingApplication),
+		ReceivingFacility:    optionalString(d.ReceivingFacility),
+		EventSecret:          optionalString(d.EventSecret),
+		TimeoutSeconds:       d.TimeoutSeconds,
+		MaxAttempts:          d.MaxAttempts,
+		IsActive:             d.IsActive,
+		CreatedBy:            d.CreatedBy,
+		CreatedOn:            d.CreatedOn,
+		LastUpdatedBy:        d.LastUpdatedBy,
+		LastUpdatedOn:        d.LastUpdatedOn,
+	}
+}
+
+func mapLabOrderDeliveryToDomain(p persistencemodels.LabOrderDelivery) domain.LabOrderDelivery {
+	return domain.LabOrderDelivery{
+		DeliveryID:    p.DeliveryID,
+		LeadID:        p.LeadID,
+		LabID:         p.LabID,
+		Adapter:       p.Adapter,
+		Status:        p.Status,
+		Attempts:      p.Attempts,
+		MaxAttempts:   p.MaxAttempts,
+		NextAttemptOn: p.NextAttemptOn,
+		LastError:     derefString(p.LastError),
+		ExternalRef:   derefString(p.ExternalRef),
+		CreatedBy:     p.CreatedBy,
+		CreatedOn:     p.CreatedOn,
+		DeliveredOn:   p.DeliveredOn,
+		LastUpdatedOn: p.LastUpdatedOn,
+	}
+}
+
+func mapLabOrderDeliveryToPersistence(d domain.LabOrderDelivery) persistencemodels.LabOrderDelivery {
+	return persistencemodels.LabOrderDelivery{
+		DeliveryID:    d.DeliveryID,
+		LeadID:        d.LeadID,
+		LabID:         d.LabID,
+		Adapter:       d.Adapter,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		MaxAttempts:   d.MaxAttempts,
+		NextAttemptOn: d.NextAttemptOn,
+		LastError:     optionalString(d.LastError),
+		ExternalRef:   optionalString(d.ExternalRef),
+		CreatedBy:     d.CreatedBy,
+		CreatedOn:     d.CreatedOn,
+		DeliveredOn:   d.DeliveredOn,
+		LastUpdatedOn: d.LastUpdatedOn,
+	}
+}
+
+func mapLabOrderAttemptToDomain(p persistencemodels.LabOrderAttempt) domain.LabOrderAttempt {
+	return domain.LabOrderAttempt{
+		AttemptID:     p.AttemptID,
+		DeliveryID:    p.DeliveryID,
+		AttemptNumber: p.AttemptNumber,
+		AttemptedOn:   p.AttemptedOn,
+		DurationMs:    p.DurationMs,
+		Success:       p.Success,
+		ResponseCode:  derefString(p.ResponseCode),
+		Response:      derefString(p.Response),
+		Error:         derefString(p.Error),
+	}
+}
+
+func mapLabOrderAttemptToPersistence(d domain.LabOrderAttempt) persistencemodels.LabOrderAttempt {
+	return persistencemodels.LabOrderAttempt{
+		AttemptID:     d.AttemptID,
+		DeliveryID:    d.DeliveryID,
+		AttemptNumber: d.AttemptNumber,
+		AttemptedOn:   d.AttemptedOn,
+		DurationMs:    d.DurationMs,
+		Success:       d.Success,
+		ResponseCode:  optionalString(d.ResponseCode),
+		Response:      optionalString(d.Response),
+		Error:         optionalString(d.Error),
+	}
+}
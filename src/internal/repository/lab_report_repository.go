@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type LabReportRepository interface {
+	FindByID(id int64) (*domain.LabReport, error)
+	FindByLeadID(leadID int64) ([]domain.LabReport, error)
+	FindLatestForUpdate(leadID int64) (*domain.LabReport, error)
+	Create(r *domain.LabReport) error
+	Update(r *domain.LabReport) error
+	SupersedeVersions(leadID int64, beforeVersion int) error
+}
+
+type labReportRepository struct {
+	db *gorm.DB
+}
+
+func NewLabReportRepository(db *gorm.DB) LabReportRepository {
+	return &labReportRepository{db: db}
+}
+
+func (r *labReportRepository) FindByID(id int64) (*domain.LabReport, error) {
+	var p persistencemodels.LabReport
+	if err := r.db.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	report := mapLabReportToDomain(p)
+	return &report, nil
+}
+
+// FindByLeadID returns the lead's report versions, latest first.
+func (r *labReportRepository) FindByLeadID(leadID int64) ([]domain.LabReport, error) {
+	var rows []persistencemodels.LabReport
+	if err := r.db.Where("LeadID = ?", leadID).Order("Version DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	reports := make([]domain.LabReport, len(rows))
+	for i, row := range rows {
+		reports[i] = mapLabReportToDomain(row)
+	}
+	return reports, nil
+}
+
+// FindLatestForUpdate returns the lead's latest report version, or gorm.ErrRecordNotFound. Inside a
+// transaction the lead's versions stay locked until commit, so concurrent uploads number their
+// versions one after the other.
+func (r *labReportRepository) FindLatestForUpdate(leadID int64) (*domain.LabReport, error) {
+	var p persistencemodels.LabReport
+	err := r.db.Raw("SELECT TOP 1 * FROM MediAdmin.tbl_LabReports WITH (UPDLOCK, HOLDLOCK) WHERE LeadID = ? ORDER BY Version DESC", leadID).
+		Scan(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.ReportID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	report := mapLabReportToDomain(p)
+	return &report, nil
+}
+
+func (r *labReportRepository) Create(report *domain.LabReport) error {
+	persist := mapLabReportToPersistence(*report)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*report = mapLabReportToDomain(persist)
+	return nil
+}
+
+func (r *labReportRepository) Update(report *domain.LabReport) error {
+	persist := mapLabReportToPersistence(*report)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*report = mapLabReportToDomain(persist)
+	return nil
+}
+
+// SupersedeVersions marks the lead's report versions older than beforeVersion as superseded.
+func (r *labReportRepository) SupersedeVersions(leadID int64, beforeVersion int) error {
+	return r.db.Model(&persistencemodels.LabReport{}).
+		Where("LeadID = ? AND Version < ? AND Status <> ?", leadID, beforeVersion, domain.ReportStatusSuperseded).
+		Update("Status", domain.ReportStatusSuperseded).Error
+}
+
+// LabReportUnitOfWork runs report changes and the matching lead update, history entry and domain
+// events in one transaction.
+type LabReportUnitOfWork interface {
+	WithinTransaction(func(LabReportRepository, LeadRepository, LeadHistoryRepository, OutboxRepository) error) error
+}
+
+type labReportUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewLabReportUnitOfWork(db *gorm.DB) LabReportUnitOfWork {
+	return &labReportUnitOfWork{db: db}
+}
+
+func (u *labReportUnitOfWork) WithinTransaction(fn func(LabReportRepository, LeadRepository, LeadHistoryRepository, OutboxRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewLabReportRepository(tx), NewLeadRepository(tx), NewLeadHistoryRepository(tx), NewOutboxRepository(tx))
+	})
+}
+
+func mapLabReportToDomain(p persistencemodels.LabReport) domain.LabReport {
+	return domain.LabReport{
+		ReportID:        p.ReportID,
+		LeadID:          p.LeadID,
+		LabID:           p.LabID,
+		Version:         p.Version,
+		FileName:        p.FileName,
+		ContentType:     p.ContentType,
+		SizeBytes:       p.SizeBytes,
+		Checksum:        p.Checksum,
+		StorageKey:      p.StorageKey,
+		Status:          p.Status,
+		AmendmentReason: derefString(p.AmendmentReason),
+		UploadedBy:      p.UploadedBy,
+		UploadedByType:  p.UploadedByType,
+		UploadedOn:      p.UploadedOn,
+		ReleasedBy:      p.ReleasedBy,
+		ReleasedOn:      p.ReleasedOn,
+	}
+}
+
+func mapLabReportToPersistence(d domain.LabReport) persistencemodels.LabReport {
+	return persistencemodels.LabReport{
+		ReportID:        d.ReportID,
+		LeadID:          d.LeadID,
+		LabID:           d.LabID,
+		Version:         d.Version,
+		FileName:        d.FileName,
+		ContentType:     d.ContentType,
+		SizeBytes:       d.SizeBytes,
+		Checksum:        d.Checksum,
+		StorageKey:      d.StorageKey,
+		Status:          d.Status,
+		AmendmentReason: optionalString(d.AmendmentReason),
+		UploadedBy:      d.UploadedBy,
+		UploadedByType:  d.UploadedByType,
+		UploadedOn:      d.UploadedOn,
+		ReleasedBy:      d.ReleasedBy,
+		ReleasedOn:      d.ReleasedOn,
+	}
+}
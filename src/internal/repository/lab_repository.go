@@ -21,6 +21,8 @@ type LabRepository interface {
 	FindByContactNumber(contactNumber string) (*domain.Lab, error)
 	FindByCity(cityID int8) ([]domain.Lab, error)
 	FindByState(stateID int8) ([]domain.Lab, error)
+	FindActiveByCollectionPincode(pincode string) ([]domain.Lab, error)
+	BackfillCollectionPincodes() (int, error)
 }
 
 type labRepository struct {
@@ -98,7 +100,13 @@ func (r *labRepository) ExistsByID(id int64) (bool, error) {
 
 func (r *labRepository) Create(l *domain.Lab) error {
 	persist := mapLabToPersistence(*l)
-	if err := r.db.Create(&persist).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&persist).Error; err != nil {
+			return err
+		}
+		return replaceCollectionPincodes(tx, persist.LabID, persist.CollectionPincodes)
+	})
+	if err != nil {
 		return err
 	}
 	*l = mapLabToDomain(persist)
@@ -107,7 +115,13 @@ func (r *labRepository) Create(l *domain.Lab) error {
 
 func (r *labRepository) Update(l *domain.Lab) error {
 	persist := mapLabToPersistence(*l)
-	if err := r.db.Save(&persist).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&persist).Error; err != nil {
+			return err
+		}
+		return replaceCollectionPincodes(tx, persist.LabID, persist.CollectionPincodes)
+	})
+	if err != nil {
 		return err
 	}
 	*l = mapLabToDomain(persist)
@@ -115,7 +129,67 @@ func (r *labRepository) Update(l *domain.Lab) error {
 }
 
 func (r *labRepository) Delete(id int64) error {
-	return r.db.Delete(&persistencemodels.Lab{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("LabID = ?", id).Delete(&persistencemodels.LabCollectionPincode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&persistencemodels.Lab{}, id).Error
+	})
+}
+
+// FindActiveByCollectionPincode returns the active labs that collect from pincode, using the
+// tbl_LabCollectionPincodes index.
+func (r *labRepository) FindActiveByCollectionPincode(pincode string) ([]domain.Lab, error) {
+	var labs []persistencemodels.Lab
+	sub := r.db.Model(&persistencemodels.LabCollectionPincode{}).Select("LabID").Where("Pincode = ?", pincode)
+	err := r.db.Where("IsActive = ? AND LabID IN (?)", true, sub).Order("LabName").Find(&labs).Error
+	return mapLabsToDomain(labs), err
+}
+
+// BackfillCollectionPincodes fills tbl_LabCollectionPincodes from tbl_Labs.CollectionPincodes when
+// the table is empty (labs saved before it existed). It returns the number of labs indexed.
+func (r *labRepository) BackfillCollectionPincodes() (int, error) {
+	var existing int64
+	if err := r.db.Model(&persistencemodels.LabCollectionPincode{}).Limit(1).Count(&existing).Error; err != nil {
+		return 0, err
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+	var labs []persistencemodels.Lab
+	if err := r.db.Select("LabID", "CollectionPincodes").Where("CollectionPincodes IS NOT NULL AND CollectionPincodes <> ''").Find(&labs).Error; err != nil {
+		return 0, err
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, lab := range labs {
+			if err := replaceCollectionPincodes(tx, lab.LabID, lab.CollectionPincodes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(labs), nil
+}
+
+// replaceCollectionPincodes rewrites a lab's rows in tbl_LabCollectionPincodes from its
+// comma-separated CollectionPincodes value. Values that are not 6-digit pincodes are not indexed.
+func replaceCollectionPincodes(tx *gorm.DB, labID int64, list *string) error {
+	if err := tx.Where("LabID = ?", labID).Delete(&persistencemodels.LabCollectionPincode{}).Error; err != nil {
+		return err
+	}
+	var rows []persistencemodels.LabCollectionPincode
+	for _, pincode := range domain.SplitList(list) {
+		if domain.IsPincode(pincode) {
+			rows = append(rows, persistencemodels.LabCollectionPincode{Pincode: pincode, LabID: labID})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&rows, 500).Error
 }
 
 func (r *labRepository) FindAllActive() ([]domain.Lab, error) {
@@ -11,6 +11,7 @@ type LeadHistoryRepository interface {
 	LogAction(history *domain.LeadHistory) error
 	BulkLogActions(histories []domain.LeadHistory) error
 	FindByLeadID(leadID int64) ([]domain.LeadHistory, error)
+	ReassignLead(fromLeadID, toLeadID int64) error
 }
 
 type leadHistoryRepository struct {
@@ -21,22 +22,72 @@ func NewLeadHistoryRepository(db *gorm.DB) LeadHistoryRepository {
 	return &leadHistoryRepository{db: db}
 }
 
+// LogAction stores the history entry and its field changes. Call inside a transaction (LeadUnitOfWork)
+// so the entry and its changes are written together.
 func (r *leadHistoryRepository) LogAction(history *domain.LeadHistory) error {
 	persist := mapLeadHistoryToPersistence(*history)
 	if err := r.db.Create(&persist).Error; err != nil {
 		return err
 	}
+	if len(history.Changes) > 0 {
+		changes := mapLeadHistoryChangesToPersistence(persist.UID, persist.LeadID, history.Changes)
+		if err := r.db.Create(&changes).Error; err != nil {
+			return err
+		}
+	}
+	changes := history.Changes
 	*history = mapLeadHistoryToDomain(persist)
+	history.Changes = changes
 	return nil
 }
 
 func (r *leadHistoryRepository) BulkLogActions(histories []domain.LeadHistory) error {
 	persist := mapLeadHistoriesToPersistence(histories)
-	return r.db.Create(&persist).Error
+	if len(persist) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	var changes []persistencemodels.LeadHistoryChange
+	for i, history := range histories {
+		changes = append(changes, mapLeadHistoryChangesToPersistence(persist[i].UID, persist[i].LeadID, history.Changes)...)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return r.db.Create(&changes).Error
 }
 
+// FindByLeadID returns the lead's history, newest first, with field changes attached.
 func (r *leadHistoryRepository) FindByLeadID(leadID int64) ([]domain.LeadHistory, error) {
 	var histories []persistencemodels.LeadHistory
-	err := r.db.Where("LeadID = ?", leadID).Order("CreatedOn DESC").Find(&histories).Error
-	return mapLeadHistoriesToDomain(histories), err
+	if err := r.db.Where("LeadID = ?", leadID).Order("CreatedOn DESC, UID DESC").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	result := mapLeadHistoriesToDomain(histories)
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	var changes []persistencemodels.LeadHistoryChange
+	if err := r.db.Where("LeadID = ?", leadID).Order("UID ASC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	byHistory := make(map[int64][]domain.LeadFieldChange)
+	for _, change := range changes {
+		byHistory[change.HistoryUID] = append(byHistory[change.HistoryUID], mapLeadHistoryChangeToDomain(change))
+	}
+	for i := range result {
+		result[i].Changes = byHistory[result[i].UID]
+	}
+	return result, nil
+}
+
+// ReassignLead moves every history entry and field change of fromLeadID to toLeadID (lead merge).
+func (r *leadHistoryRepository) ReassignLead(fromLeadID, toLeadID int64) error {
+	if err := r.db.Model(&persistencemodels.LeadHistory{}).Where("LeadID = ?", fromLeadID).Update("LeadID", toLeadID).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&persistencemodels.LeadHistoryChange{}).Where("LeadID = ?", fromLeadID).Update("LeadID", toLeadID).Error
 }
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type LeadImportJobRepository interface {
+	Create(job *domain.LeadImportJob, content []byte) error
+	Update(job *domain.LeadImportJob) error
+	FindByID(id int64) (*domain.LeadImportJob, error)
+	FindContent(id int64) ([]byte, error)
+	FindQueued(limit int) ([]domain.LeadImportJob, error)
+	Claim(id int64, now time.Time) (bool, error)
+	Touch(id int64, now time.Time) error
+	FailStale(before time.Time, message string) (int64, error)
+	AddErrors(rowErrors []domain.LeadImportRowError) error
+	FindErrors(jobID int64) ([]domain.LeadImportRowError, error)
+}
+
+type leadImportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewLeadImportJobRepository(db *gorm.DB) LeadImportJobRepository {
+	return &leadImportJobRepository{db: db}
+}
+
+// Create stores the job with the uploaded CSV, which the import worker reads back when it runs the job.
+func (r *leadImportJobRepository) Create(job *domain.LeadImportJob, content []byte) error {
+	p := mapLeadImportJobToPersistence(*job)
+	p.CsvContent = content
+	if err := r.db.Create(&p).Error; err != nil {
+		return err
+	}
+	*job = mapLeadImportJobToDomain(p)
+	return nil
+}
+
+// Update saves the job's status, counters and timestamps. A finished job's CSV content is dropped.
+func (r *leadImportJobRepository) Update(job *domain.LeadImportJob) error {
+	p := mapLeadImportJobToPersistence(*job)
+	fields := map[string]interface{}{
+		"Status":        p.Status,
+		"TotalRows":     p.TotalRows,
+		"ProcessedRows": p.ProcessedRows,
+		"InsertedRows":  p.InsertedRows,
+		"FailedRows":    p.FailedRows,
+		"Message":       p.Message,
+		"StartedOn":     p.StartedOn,
+		"CompletedOn":   p.CompletedOn,
+		"LastUpdatedOn": time.Now(),
+	}
+	if job.Finished() {
+		fields["CsvContent"] = nil
+	}
+	return r.db.Model(&persistencemodels.LeadImportJob{}).Where("JobID = ?", job.JobID).Updates(fields).Error
+}
+
+func (r *leadImportJobRepository) FindByID(id int64) (*domain.LeadImportJob, error) {
+	var p persistencemodels.LeadImportJob
+	if err := r.db.Omit("CsvContent").First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	job := mapLeadImportJobToDomain(p)
+	return &job, nil
+}
+
+// FindContent returns the CSV uploaded for the job; it is empty once the job has finished.
+func (r *leadImportJobRepository) FindContent(id int64) ([]byte, error) {
+	var p persistencemodels.LeadImportJob
+	if err := r.db.Select("JobID", "CsvContent").First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return p.CsvContent, nil
+}
+
+// FindQueued returns the oldest queued jobs.
+func (r *leadImportJobRepository) FindQueued(limit int) ([]domain.LeadImportJob, error) {
+	var rows []persistencemodels.LeadImportJob
+	err := r.db.Omit("CsvContent").Where("Status = ?", domain.LeadImportQueued).Order("JobID ASC").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]domain.LeadImportJob, len(rows))
+	for i, row := range rows {
+		jobs[i] = mapLeadImportJobToDomain(row)
+	}
+	return jobs, nil
+}
+
+// Claim moves a queued job to running. It reports false when another worker claimed it first, so each
+// job runs once across instances.
+func (r *leadImportJobRepository) Claim(id int64, now time.Time) (bool, error) {
+	res := r.db.Model(&persistencemodels.LeadImportJob{}).
+		Where("JobID = ? AND Status = ?", id, domain.LeadImportQueued).
+		Updates(map[string]interface{}{"Status": domain.LeadImportRunning, "StartedOn": now, "LastUpdatedOn": now})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Touch records that a running job is still being worked on.
+func (r *leadImportJobRepository) Touch(id int64, now time.Time) error {
+	return r.db.Model(&persistencemodels.LeadImportJob{}).
+		Where("JobID = ? AND Status = ?", id, domain.LeadImportRunning).
+		Update("LastUpdatedOn", now).Error
+}
+
+// FailStale fails running jobs with no progress or heartbeat since before: their worker stopped. It
+// returns how many jobs it failed.
+func (r *leadImportJobRepository) FailStale(before time.Time, message string) (int64, error) {
+	res := r.db.Model(&persistencemodels.LeadImportJob{}).
+		Where("Status = ? AND COALESCE(LastUpdatedOn, StartedOn, CreatedOn) < ?", domain.LeadImportRunning, before).
+		Updates(map[string]interface{}{
+			"Status":      domain.LeadImportFailed,
+			"Message":     message,
+			"CompletedOn": time.Now(),
+			"CsvContent":  nil,
+		})
+	return res.RowsAffected, res.Error
+}
+
+func (r *leadImportJobRepository) AddErrors(rowErrors []domain.LeadImportRowError) error {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+	rows := make([]persistencemodels.LeadImportJobError, len(rowErrors))
+	for i, e := range rowErrors {
+		rows[i] = persistencemodels.LeadImportJobError{
+			JobID:      e.JobID,
+			RowNumber:  e.RowNumber,
+			ColumnName: e.Column,
+			Reason:     e.Reason,
+		}
+	}
+	return r.db.CreateInBatches(&rows, 200).Error
+}
+
+// FindErrors returns the job's row errors ordered by row number.
+func (r *leadImportJobRepository) FindErrors(jobID int64) ([]domain.LeadImportRowError, error) {
+	var rows []persistencemodels.LeadImportJobError
+	if err := r.db.Where("JobID = ?", jobID).Order("RowNumber ASC, UID ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.LeadImportRowError, len(rows))
+	for i, row := range rows {
+		result[i] = domain.LeadImportRowError{
+			JobID:     row.JobID,
+			RowNumber: row.RowNumber,
+			Column:    row.ColumnName,
+			Reason:    row.Reason,
+		}
+	}
+	return result, nil
+}
+
+func mapLeadImportJobToPersistence(d domain.LeadImportJob) persistencemodels.LeadImportJob {
+	return persistencemodels.LeadImportJob{
+		JobID:         d.JobID,
+		ClientID:      d.ClientID,
+		PackageID:     d.PackageID,
+		Strict:        d.Strict,
+		Status:        d.Status,
+		TotalRows:     d.TotalRows,
+		ProcessedRows: d.ProcessedRows,
+		InsertedRows:  d.InsertedRows,
+		FailedRows:    d.FailedRows,
+		Message:       optionalString(d.Message),
+		CreatedBy:     d.CreatedBy,
+		CreatedByType: d.CreatedByType,
+		CreatedOn:     d.CreatedOn,
+		StartedOn:     d.StartedOn,
+		CompletedOn:   d.CompletedOn,
+		LastUpdatedOn: d.LastUpdatedOn,
+	}
+}
+
+func mapLeadImportJobToDomain(p persistencemodels.LeadImportJob) domain.LeadImportJob {
+	d := domain.LeadImportJob{
+		JobID:         p.JobID,
+		ClientID:      p.ClientID,
+		PackageID:     p.PackageID,
+		Strict:        p.Strict,
+		Status:        p.Status,
+		TotalRows:     p.TotalRows,
+		ProcessedRows: p.ProcessedRows,
+		InsertedRows:  p.InsertedRows,
+		FailedRows:    p.FailedRows,
+		CreatedBy:     p.CreatedBy,
+		CreatedByType: p.CreatedByType,
+		CreatedOn:     p.CreatedOn,
+		StartedOn:     p.StartedOn,
+		CompletedOn:   p.CompletedOn,
+		LastUpdatedOn: p.LastUpdatedOn,
+	}
+	if p.Message != nil {
+		d.Message = *p.Message
+	}
+	return d
+}
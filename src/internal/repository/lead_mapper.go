@@ -9,7 +9,8 @@ func mapLeadToDomain(p persistencemodels.Lead) domain.Lead {
 	return domain.Lead{
 		LeadID:        p.LeadID,
 		ClientID:      p.ClientID,
-		PatientID:     p.PatientID,
+		PatientID:     derefInt64(p.PatientRefID),
+		PatientCode:   p.PatientID,
 		PatientName:   p.PatientName,
 		Age:           p.Age,
 		Gender:        p.Gender,
@@ -21,6 +22,7 @@ func mapLeadToDomain(p persistencemodels.Lead) domain.Lead {
 		StateID:       p.StateID,
 		Pincode:       p.Pincode,
 		LeadStatusID:  p.LeadStatusID,
+		LabID:         p.LabID,
 		CreatedBy:     p.CreatedBy,
 		CreatedOn:     p.CreatedOn,
 		LastUpdatedBy: p.LastUpdatedBy,
@@ -32,7 +34,8 @@ func mapLeadToPersistence(d domain.Lead) persistencemodels.Lead {
 	return persistencemodels.Lead{
 		LeadID:        d.LeadID,
 		ClientID:      d.ClientID,
-		PatientID:     d.PatientID,
+		PatientID:     d.PatientCode,
+		PatientRefID:  optionalInt64(d.PatientID),
 		PatientName:   d.PatientName,
 		Age:           d.Age,
 		Gender:        d.Gender,
@@ -44,6 +47,7 @@ func mapLeadToPersistence(d domain.Lead) persistencemodels.Lead {
 		StateID:       d.StateID,
 		Pincode:       d.Pincode,
 		LeadStatusID:  d.LeadStatusID,
+		LabID:         d.LabID,
 		CreatedBy:     d.CreatedBy,
 		CreatedOn:     d.CreatedOn,
 		LastUpdatedBy: d.LastUpdatedBy,
@@ -63,23 +67,92 @@ func mapLeadsToDomain(leads []persistencemodels.Lead) []domain.Lead {
 }
 
 func mapLeadHistoryToDomain(p persistencemodels.LeadHistory) domain.LeadHistory {
-	return domain.LeadHistory{
+	d := domain.LeadHistory{
 		UID:       p.UID,
 		LeadID:    p.LeadID,
 		Action:    p.Action,
 		CreatedBy: p.CreatedBy,
 		CreatedOn: p.CreatedOn,
 	}
+	if p.Reason != nil {
+		d.Reason = *p.Reason
+	}
+	if p.CreatedByType != nil {
+		d.CreatedByType = *p.CreatedByType
+	}
+	return d
 }
 
 func mapLeadHistoryToPersistence(d domain.LeadHistory) persistencemodels.LeadHistory {
-	return persistencemodels.LeadHistory{
+	p := persistencemodels.LeadHistory{
 		UID:       d.UID,
 		LeadID:    d.LeadID,
 		Action:    d.Action,
 		CreatedBy: d.CreatedBy,
 		CreatedOn: d.CreatedOn,
 	}
+	if d.Reason != "" {
+		reason := d.Reason
+		p.Reason = &reason
+	}
+	if d.CreatedByType != 0 {
+		actorType := d.CreatedByType
+		p.CreatedByType = &actorType
+	}
+	return p
+}
+
+func mapLeadHistoryChangesToPersistence(historyUID, leadID int64, changes []domain.LeadFieldChange) []persistencemodels.LeadHistoryChange {
+	mapped := make([]persistencemodels.LeadHistoryChange, len(changes))
+	for i, change := range changes {
+		mapped[i] = persistencemodels.LeadHistoryChange{
+			HistoryUID: historyUID,
+			LeadID:     leadID,
+			FieldName:  change.Field,
+			OldValue:   optionalString(change.OldValue),
+			NewValue:   optionalString(change.NewValue),
+		}
+	}
+	return mapped
+}
+
+func mapLeadHistoryChangeToDomain(p persistencemodels.LeadHistoryChange) domain.LeadFieldChange {
+	d := domain.LeadFieldChange{Field: p.FieldName}
+	if p.OldValue != nil {
+		d.OldValue = *p.OldValue
+	}
+	if p.NewValue != nil {
+		d.NewValue = *p.NewValue
+	}
+	return d
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalInt64(v int64) *int64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func derefInt64(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
 }
 
 func mapLeadHistoriesToDomain(histories []persistencemodels.LeadHistory) []domain.LeadHistory {
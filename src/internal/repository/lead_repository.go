@@ -10,9 +10,11 @@ import (
 )
 
 type LeadRepository interface {
+	WithScope(scope domain.TenantScope) LeadRepository
 	FindAll() ([]domain.Lead, error)
 	List(filter LeadListFilter) ([]domain.Lead, int64, error)
 	FindByID(id int64) (*domain.Lead, error)
+	FindByIDs(ids []int64) ([]domain.Lead, error)
 	ExistsByID(id int64) (bool, error)
 	Create(l *domain.Lead) error
 	Update(l *domain.Lead) error
@@ -21,27 +23,41 @@ type LeadRepository interface {
 	FindByClientID(clientID int64) ([]domain.Lead, error)
 	FindByStatus(statusID int8) ([]domain.Lead, error)
 	FindByPackage(packageID int) ([]domain.Lead, error)
-	FindByPatientID(patientID string) (*domain.Lead, error)
+	FindByPatientID(patientID int64) ([]domain.Lead, error)
 	FindByContactNumber(contactNumber string) ([]domain.Lead, error)
+	FindByClientAndContactNumbers(clientID int64, contactNumbers []string) ([]domain.Lead, error)
+	FindDuplicateCandidates(clientID *int64, window time.Duration) ([]domain.Lead, error)
 	FindByEmail(email string) ([]domain.Lead, error)
+	CountDependents(id int64) (domain.LeadDependents, error)
 }
 
 type leadRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	scope domain.TenantScope
 }
 
 func NewLeadRepository(db *gorm.DB) LeadRepository {
 	return &leadRepository{db: db}
 }
 
+// WithScope returns a repository whose reads, updates and deletes only touch leads owned by the
+// scope's client (ClientID) or lab (LabID).
+func (r *leadRepository) WithScope(scope domain.TenantScope) LeadRepository {
+	return &leadRepository{db: r.db, scope: scope}
+}
+
+func (r *leadRepository) scoped() *gorm.DB {
+	return r.db.Scopes(tenantScope(r.scope, "ClientID", "LabID"))
+}
+
 func (r *leadRepository) FindAll() ([]domain.Lead, error) {
 	var leads []persistencemodels.Lead
-	err := r.db.Find(&leads).Error
+	err := r.scoped().Find(&leads).Error
 	return mapLeadsToDomain(leads), err
 }
 
 func (r *leadRepository) List(filter LeadListFilter) ([]domain.Lead, int64, error) {
-	query := r.db.Model(&persistencemodels.Lead{})
+	query := r.scoped().Model(&persistencemodels.Lead{})
 	if filter.ClientID != nil {
 		query = query.Where("ClientID = ?", *filter.ClientID)
 	}
@@ -83,7 +99,7 @@ func mapLeadSortColumn(sortBy string) string {
 
 func (r *leadRepository) FindByID(id int64) (*domain.Lead, error) {
 	var l persistencemodels.Lead
-	err := r.db.First(&l, id).Error
+	err := r.scoped().First(&l, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -91,9 +107,15 @@ func (r *leadRepository) FindByID(id int64) (*domain.Lead, error) {
 	return &domainLead, nil
 }
 
+func (r *leadRepository) FindByIDs(ids []int64) ([]domain.Lead, error) {
+	var leads []persistencemodels.Lead
+	err := r.scoped().Where("LeadID IN ?", ids).Find(&leads).Error
+	return mapLeadsToDomain(leads), err
+}
+
 func (r *leadRepository) ExistsByID(id int64) (bool, error) {
 	var count int64
-	if err := r.db.Model(&persistencemodels.Lead{}).Where("LeadID = ?", id).Limit(1).Count(&count).Error; err != nil {
+	if err := r.scoped().Model(&persistencemodels.Lead{}).Where("LeadID = ?", id).Limit(1).Count(&count).Error; err != nil {
 		return false, err
 	}
 	return count > 0, nil
@@ -118,11 +140,11 @@ func (r *leadRepository) Update(l *domain.Lead) error {
 }
 
 func (r *leadRepository) Delete(id int64) error {
-	return r.db.Delete(&persistencemodels.Lead{}, id).Error
+	return r.scoped().Delete(&persistencemodels.Lead{}, id).Error
 }
 
 func (r *leadRepository) UpdateStatusForIDs(leadIDs []int64, statusID int8, lastUpdatedBy int64) (int64, error) {
-	result := r.db.Model(&persistencemodels.Lead{}).Where("LeadID IN ?", leadIDs).Updates(map[string]interface{}{
+	result := r.scoped().Model(&persistencemodels.Lead{}).Where("LeadID IN ?", leadIDs).Updates(map[string]interface{}{
 		"LeadStatusID":   statusID,
 		"LastUpdatedBy":  lastUpdatedBy,
 		"LastUpdatedOn":  time.Now(),
@@ -132,40 +154,93 @@ func (r *leadRepository) UpdateStatusForIDs(leadIDs []int64, statusID int8, last
 
 func (r *leadRepository) FindByClientID(clientID int64) ([]domain.Lead, error) {
 	var leads []persistencemodels.Lead
-	err := r.db.Where("ClientID = ?", clientID).Find(&leads).Error
+	err := r.scoped().Where("ClientID = ?", clientID).Find(&leads).Error
 	return mapLeadsToDomain(leads), err
 }
 
 func (r *leadRepository) FindByStatus(statusID int8) ([]domain.Lead, error) {
 	var leads []persistencemodels.Lead
-	err := r.db.Where("LeadStatusID = ?", statusID).Find(&leads).Error
+	err := r.scoped().Where("LeadStatusID = ?", statusID).Find(&leads).Error
 	return mapLeadsToDomain(leads), err
 }
 
 func (r *leadRepository) FindByPackage(packageID int) ([]domain.Lead, error) {
 	var leads []persistencemodels.Lead
-	err := r.db.Where("PackageID = ?", packageID).Find(&leads).Error
+	err := r.scoped().Where("PackageID = ?", packageID).Find(&leads).Error
 	return mapLeadsToDomain(leads), err
 }
 
-func (r *leadRepository) FindByPatientID(patientID string) (*domain.Lead, error) {
-	var l persistencemodels.Lead
-	err := r.db.Where("PatientID = ?", patientID).First(&l).Error
-	if err != nil {
-		return nil, err
-	}
-	domainLead := mapLeadToDomain(l)
-	return &domainLead, nil
+// FindByPatientID returns the patient's leads, oldest first.
+func (r *leadRepository) FindByPatientID(patientID int64) ([]domain.Lead, error) {
+	var leads []persistencemodels.Lead
+	err := r.scoped().Where("PatientRefID = ?", patientID).Order("CreatedOn ASC, LeadID ASC").Find(&leads).Error
+	return mapLeadsToDomain(leads), err
 }
 
 func (r *leadRepository) FindByContactNumber(contactNumber string) ([]domain.Lead, error) {
 	var leads []persistencemodels.Lead
-	err := r.db.Where("ContactNumber = ?", contactNumber).Find(&leads).Error
+	err := r.scoped().Where("ContactNumber = ?", contactNumber).Find(&leads).Error
 	return mapLeadsToDomain(leads), err
 }
 
+// FindByClientAndContactNumbers returns the client's leads with any of the contact numbers. The
+// numbers are queried in chunks to stay under SQL Server's parameter limit.
+func (r *leadRepository) FindByClientAndContactNumbers(clientID int64, contactNumbers []string) ([]domain.Lead, error) {
+	const chunkSize = 1000
+	var result []domain.Lead
+	for start := 0; start < len(contactNumbers); start += chunkSize {
+		end := start + chunkSize
+		if end > len(contactNumbers) {
+			end = len(contactNumbers)
+		}
+		var leads []persistencemodels.Lead
+		if err := r.scoped().Where("ClientID = ? AND ContactNumber IN ?", clientID, contactNumbers[start:end]).Find(&leads).Error; err != nil {
+			return nil, err
+		}
+		result = append(result, mapLeadsToDomain(leads)...)
+	}
+	return result, nil
+}
+
+// FindDuplicateCandidates returns leads that share client, package and contact number with another
+// lead created within window of them. Patient names are compared by the caller.
+func (r *leadRepository) FindDuplicateCandidates(clientID *int64, window time.Duration) ([]domain.Lead, error) {
+	leads := persistencemodels.Lead{}.TableName()
+	query := r.scoped().Where(`EXISTS (SELECT 1 FROM `+leads+` d WHERE d.ClientID = `+leads+`.ClientID
+		AND d.PackageID = `+leads+`.PackageID AND d.ContactNumber = `+leads+`.ContactNumber
+		AND d.LeadID <> `+leads+`.LeadID AND ABS(DATEDIFF(MINUTE, d.CreatedOn, `+leads+`.CreatedOn)) <= ?)`, int64(window/time.Minute))
+	if clientID != nil {
+		query = query.Where("ClientID = ?", *clientID)
+	}
+	var rows []persistencemodels.Lead
+	err := query.Order("ClientID, PackageID, ContactNumber, CreatedOn").Find(&rows).Error
+	return mapLeadsToDomain(rows), err
+}
+
 func (r *leadRepository) FindByEmail(email string) ([]domain.Lead, error) {
 	var leads []persistencemodels.Lead
-	err := r.db.Where("Emailid = ?", email).Find(&leads).Error
+	err := r.scoped().Where("Emailid = ?", email).Find(&leads).Error
 	return mapLeadsToDomain(leads), err
 }
+
+// CountDependents counts the appointments, samples, reports, test results and lab orders recorded
+// for the lead.
+func (r *leadRepository) CountDependents(id int64) (domain.LeadDependents, error) {
+	var d domain.LeadDependents
+	counts := []struct {
+		model interface{}
+		n     *int64
+	}{
+		{&persistencemodels.Appointment{}, &d.Appointments},
+		{&persistencemodels.Sample{}, &d.Samples},
+		{&persistencemodels.LabReport{}, &d.Reports},
+		{&persistencemodels.TestResult{}, &d.Results},
+		{&persistencemodels.LabOrderDelivery{}, &d.LabOrders},
+	}
+	for _, c := range counts {
+		if err := r.db.Model(c.model).Where("LeadID = ?", id).Count(c.n).Error; err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
@@ -2,8 +2,10 @@ package repository
 
 import "gorm.io/gorm"
 
+// LeadUnitOfWork runs lead changes, the patients they link to, their history entries and the domain
+// events they raise in one transaction.
 type LeadUnitOfWork interface {
-	WithinTransaction(func(LeadRepository, LeadHistoryRepository) error) error
+	WithinTransaction(func(LeadRepository, LeadHistoryRepository, PatientRepository, OutboxRepository) error) error
 }
 
 type leadUnitOfWork struct {
@@ -14,10 +16,12 @@ func NewLeadUnitOfWork(db *gorm.DB) LeadUnitOfWork {
 	return &leadUnitOfWork{db: db}
 }
 
-func (u *leadUnitOfWork) WithinTransaction(fn func(LeadRepository, LeadHistoryRepository) error) error {
+func (u *leadUnitOfWork) WithinTransaction(fn func(LeadRepository, LeadHistoryRepository, PatientRepository, OutboxRepository) error) error {
 	return u.db.Transaction(func(tx *gorm.DB) error {
 		leadRepo := NewLeadRepository(tx)
 		historyRepo := NewLeadHistoryRepository(tx)
-		return fn(leadRepo, historyRepo)
+		patientRepo := NewPatientRepository(tx)
+		outbox := NewOutboxRepository(tx)
+		return fn(leadRepo, historyRepo, patientRepo, outbox)
 	})
 }
@@ -1,5 +1,7 @@
 package repository
 
+import "time"
+
 type ClientListFilter struct {
 	Page      int
 	PageSize  int
@@ -38,3 +40,25 @@ type PackageListFilter struct {
 	IsActive  *bool
 	Search    string
 }
+
+type AuditListFilter struct {
+	Page       int
+	PageSize   int
+	SortOrder  string
+	EntityType string
+	EntityID   *int64
+	Action     string
+	ActorID    *int64
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// WebhookDeliveryListFilter selects deliveries for GET /webhooks/dead-letters; ClientID 0 means any client.
+type WebhookDeliveryListFilter struct {
+	Page           int
+	PageSize       int
+	ClientID       int64
+	SubscriptionID *int64
+	Status         string
+}
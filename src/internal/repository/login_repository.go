@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"b2b-diagnostic-aggregator/apis/internal/domain"
 	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
@@ -11,9 +12,8 @@ import (
 
 type LoginRepository interface {
 	FindByUserID(userID int64) (*domain.Login, error)
-	Authenticate(userID int64, encryptedPassword, userType string) (bool, error)
-	UpdatePassword(userID int64, newPassword string) error
-	ChangePassword(userID int64, oldEncryptedPassword, newEncryptedPassword string) (int64, error)
+	FindByUserIDAndType(userID int64, userType string) (*domain.Login, error)
+	UpdatePassword(userID int64, newPasswordHash string) error
 }
 
 type loginRepository struct {
@@ -37,24 +37,19 @@ func (r *loginRepository) FindByUserID(userID int64) (*domain.Login, error) {
 	return &domainLogin, nil
 }
 
-func (r *loginRepository) Authenticate(userID int64, encryptedPassword, userType string) (bool, error) {
-	fmt.Printf("[LOGIN] Repository.Authenticate: entry userId=%d userType=%s\n", userID, userType)
-	var count int64
-	err := r.db.Model(&persistencemodels.Login{}).
-		Where("UserID = ? AND Pwd = ? AND UserType = ?", userID, encryptedPassword, userType).
-		Count(&count).Error
-	ok := count > 0
-	fmt.Printf("[LOGIN] Repository.Authenticate: result ok=%v err=%v\n", ok, err)
-	return ok, err
-}
-
-func (r *loginRepository) UpdatePassword(userID int64, newPassword string) error {
-	return r.db.Model(&persistencemodels.Login{}).Where("UserID = ?", userID).Update("Pwd", newPassword).Error
+func (r *loginRepository) FindByUserIDAndType(userID int64, userType string) (*domain.Login, error) {
+	var login persistencemodels.Login
+	err := r.db.Where("UserID = ? AND UserType = ?", userID, userType).First(&login).Error
+	if err != nil {
+		return nil, err
+	}
+	domainLogin := mapLoginToDomain(login)
+	return &domainLogin, nil
 }
 
-func (r *loginRepository) ChangePassword(userID int64, oldEncryptedPassword, newEncryptedPassword string) (int64, error) {
-	res := r.db.Model(&persistencemodels.Login{}).
-		Where("UserID = ? AND Pwd = ?", userID, oldEncryptedPassword).
-		Update("Pwd", newEncryptedPassword)
-	return res.RowsAffected, res.Error
+func (r *loginRepository) UpdatePassword(userID int64, newPasswordHash string) error {
+	return r.db.Model(&persistencemodels.Login{}).Where("UserID = ?", userID).Updates(map[string]interface{}{
+		"Pwd":           newPasswordHash,
+		"LastUpdatedOn": time.Now(),
+	}).Error
 }
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository stores domain events. Add is called inside the transaction that makes the change,
+// so an event exists exactly when its change commits; the event dispatcher reads the rest.
+type OutboxRepository interface {
+	Add(event *domain.DomainEvent) error
+	FindDue(now time.Time, limit int) ([]domain.DomainEvent, error)
+	FindAfter(afterID int64, limit int) ([]domain.DomainEvent, error)
+	LatestID() (int64, error)
+	Claim(id int64, now, leaseUntil time.Time) (bool, error)
+	Update(event *domain.DomainEvent) error
+	HandledBy(id int64) ([]string, error)
+	MarkHandled(id int64, subscriber string) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Add(event *domain.DomainEvent) error {
+	persist := mapOutboxEventToPersistence(*event)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*event = mapOutboxEventToDomain(persist)
+	return nil
+}
+
+// FindDue returns pending events whose next attempt is due, in the order they were written.
+func (r *outboxRepository) FindDue(now time.Time, limit int) ([]domain.DomainEvent, error) {
+	var rows []persistencemodels.OutboxEvent
+	err := r.db.Where("Status = ? AND NextAttemptOn <= ?", domain.OutboxStatusPending, now).
+		Order("EventID").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	events := make([]domain.DomainEvent, len(rows))
+	for i, row := range rows {
+		events[i] = mapOutboxEventToDomain(row)
+	}
+	return events, nil
+}
+
+// FindAfter returns events written after afterID whatever their dispatch status, in outbox order.
+func (r *outboxRepository) FindAfter(afterID int64, limit int) ([]domain.DomainEvent, error) {
+	var rows []persistencemodels.OutboxEvent
+	if err := r.db.Where("EventID > ?", afterID).Order("EventID").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	events := make([]domain.DomainEvent, len(rows))
+	for i, row := range rows {
+		events[i] = mapOutboxEventToDomain(row)
+	}
+	return events, nil
+}
+
+// LatestID returns the newest event's ID, or 0 when the outbox is empty.
+func (r *outboxRepository) LatestID() (int64, error) {
+	var id *int64
+	if err := r.db.Model(&persistencemodels.OutboxEvent{}).Select("MAX(EventID)").Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return derefInt64(id), nil
+}
+
+// Claim takes a due event for one dispatch by pushing its next attempt to leaseUntil. It reports
+// false when another dispatcher claimed it first.
+func (r *outboxRepository) Claim(id int64, now, leaseUntil time.Time) (bool, error) {
+	res := r.db.Model(&persistencemodels.OutboxEvent{}).
+		Where("EventID = ? AND Status = ? AND NextAttemptOn <= ?", id, domain.OutboxStatusPending, now).
+		Update("NextAttemptOn", leaseUntil)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *outboxRepository) Update(event *domain.DomainEvent) error {
+	persist := mapOutboxEventToPersistence(*event)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*event = mapOutboxEventToDomain(persist)
+	return nil
+}
+
+// HandledBy returns the subscribers that already handled the event.
+func (r *outboxRepository) HandledBy(id int64) ([]string, error) {
+	var subscribers []string
+	err := r.db.Model(&persistencemodels.OutboxEventHandled{}).Where("EventID = ?", id).Pluck("Subscriber", &subscribers).Error
+	return subscribers, err
+}
+
+func (r *outboxRepository) MarkHandled(id int64, subscriber string) error {
+	return r.db.Create(&persistencemodels.OutboxEventHandled{EventID: id, Subscriber: subscriber, HandledOn: time.Now()}).Error
+}
+
+func mapOutboxEventToDomain(p persistencemodels.OutboxEvent) domain.DomainEvent {
+	return domain.DomainEvent{
+		EventID:       p.EventID,
+		Type:          p.EventType,
+		AggregateType: p.AggregateType,
+		AggregateID:   p.AggregateID,
+		Payload:       p.Payload,
+		OccurredOn:    p.OccurredOn,
+		Status:        p.Status,
+		Attempts:      p.Attempts,
+		NextAttemptOn: p.NextAttemptOn,
+		LastError:     derefString(p.LastError),
+		ProcessedOn:   p.ProcessedOn,
+	}
+}
+
+func mapOutboxEventToPersistence(d domain.DomainEvent) persistencemodels.OutboxEvent {
+	return persistencemodels.OutboxEvent{
+		EventID:       d.EventID,
+		EventType:     d.Type,
+		AggregateType: d.AggregateType,
+		AggregateID:   d.AggregateID,
+		Payload:       d.Payload,
+		OccurredOn:    d.OccurredOn,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptOn: d.NextAttemptOn,
+		LastError:     optionalString(d.LastError),
+		ProcessedOn:   d.ProcessedOn,
+	}
+}
@@ -1,12 +1,14 @@
 package repository
 
 import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
 	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
 
 	"gorm.io/gorm"
 )
 
 type PackageClientMappingRepository interface {
+	WithScope(scope domain.TenantScope) PackageClientMappingRepository
 	Create(m *persistencemodels.PackageClientMapping) error
 	FindByPackageAndClient(packageID int, clientID int64) (*persistencemodels.PackageClientMapping, error)
 	FindByID(id int) (*persistencemodels.PackageClientMapping, error)
@@ -16,20 +18,30 @@ type PackageClientMappingRepository interface {
 }
 
 type packageClientMappingRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	scope domain.TenantScope
 }
 
 func NewPackageClientMappingRepository(db *gorm.DB) PackageClientMappingRepository {
 	return &packageClientMappingRepository{db: db}
 }
 
+// WithScope returns a repository limited to mappings of the scope's client.
+func (r *packageClientMappingRepository) WithScope(scope domain.TenantScope) PackageClientMappingRepository {
+	return &packageClientMappingRepository{db: r.db, scope: scope}
+}
+
+func (r *packageClientMappingRepository) scoped() *gorm.DB {
+	return r.db.Scopes(tenantScope(r.scope, "ClientID", ""))
+}
+
 func (r *packageClientMappingRepository) Create(m *persistencemodels.PackageClientMapping) error {
 	return r.db.Create(m).Error
 }
 
 func (r *packageClientMappingRepository) FindByPackageAndClient(packageID int, clientID int64) (*persistencemodels.PackageClientMapping, error) {
 	var m persistencemodels.PackageClientMapping
-	err := r.db.Where("PackageID = ? AND ClientID = ? AND IsActive = ?", packageID, clientID, true).First(&m).Error
+	err := r.scoped().Where("PackageID = ? AND ClientID = ? AND IsActive = ?", packageID, clientID, true).First(&m).Error
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +50,7 @@ func (r *packageClientMappingRepository) FindByPackageAndClient(packageID int, c
 
 func (r *packageClientMappingRepository) FindByID(id int) (*persistencemodels.PackageClientMapping, error) {
 	var m persistencemodels.PackageClientMapping
-	err := r.db.First(&m, id).Error
+	err := r.scoped().First(&m, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +59,13 @@ func (r *packageClientMappingRepository) FindByID(id int) (*persistencemodels.Pa
 
 func (r *packageClientMappingRepository) FindAll() ([]persistencemodels.PackageClientMapping, error) {
 	var list []persistencemodels.PackageClientMapping
-	err := r.db.Find(&list).Error
+	err := r.scoped().Find(&list).Error
 	return list, err
 }
 
 func (r *packageClientMappingRepository) FindByPackageID(packageID int) ([]persistencemodels.PackageClientMapping, error) {
 	var list []persistencemodels.PackageClientMapping
-	err := r.db.Where("PackageID = ?", packageID).Find(&list).Error
+	err := r.scoped().Where("PackageID = ?", packageID).Find(&list).Error
 	return list, err
 }
 
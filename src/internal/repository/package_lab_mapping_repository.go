@@ -1,12 +1,14 @@
 package repository
 
 import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
 	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
 
 	"gorm.io/gorm"
 )
 
 type PackageLabMappingRepository interface {
+	WithScope(scope domain.TenantScope) PackageLabMappingRepository
 	Create(m *persistencemodels.PackageLabMapping) error
 	FindByPackageAndLab(packageID int, labID int64) (*persistencemodels.PackageLabMapping, error)
 	FindByID(id int) (*persistencemodels.PackageLabMapping, error)
@@ -16,20 +18,30 @@ type PackageLabMappingRepository interface {
 }
 
 type packageLabMappingRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	scope domain.TenantScope
 }
 
 func NewPackageLabMappingRepository(db *gorm.DB) PackageLabMappingRepository {
 	return &packageLabMappingRepository{db: db}
 }
 
+// WithScope returns a repository limited to mappings of the scope's lab.
+func (r *packageLabMappingRepository) WithScope(scope domain.TenantScope) PackageLabMappingRepository {
+	return &packageLabMappingRepository{db: r.db, scope: scope}
+}
+
+func (r *packageLabMappingRepository) scoped() *gorm.DB {
+	return r.db.Scopes(tenantScope(r.scope, "", "LabID"))
+}
+
 func (r *packageLabMappingRepository) Create(m *persistencemodels.PackageLabMapping) error {
 	return r.db.Create(m).Error
 }
 
 func (r *packageLabMappingRepository) FindByPackageAndLab(packageID int, labID int64) (*persistencemodels.PackageLabMapping, error) {
 	var m persistencemodels.PackageLabMapping
-	err := r.db.Where("PackageID = ? AND LabID = ? AND IsActive = ?", packageID, labID, true).First(&m).Error
+	err := r.scoped().Where("PackageID = ? AND LabID = ? AND IsActive = ?", packageID, labID, true).First(&m).Error
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +50,7 @@ func (r *packageLabMappingRepository) FindByPackageAndLab(packageID int, labID i
 
 func (r *packageLabMappingRepository) FindByID(id int) (*persistencemodels.PackageLabMapping, error) {
 	var m persistencemodels.PackageLabMapping
-	err := r.db.First(&m, id).Error
+	err := r.scoped().First(&m, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +59,13 @@ func (r *packageLabMappingRepository) FindByID(id int) (*persistencemodels.Packa
 
 func (r *packageLabMappingRepository) FindAll() ([]persistencemodels.PackageLabMapping, error) {
 	var list []persistencemodels.PackageLabMapping
-	err := r.db.Find(&list).Error
+	err := r.scoped().Find(&list).Error
 	return list, err
 }
 
 func (r *packageLabMappingRepository) FindByPackageID(packageID int) ([]persistencemodels.PackageLabMapping, error) {
 	var list []persistencemodels.PackageLabMapping
-	err := r.db.Where("PackageID = ?", packageID).Find(&list).Error
+	err := r.scoped().Where("PackageID = ?", packageID).Find(&list).Error
 	return list, err
 }
 
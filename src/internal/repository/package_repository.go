@@ -23,6 +23,7 @@ type PackageRepository interface {
 	CreateWithTests(p *domain.Package, testIDs []int) error
 	FindAllPackageTestMappings() ([]persistencemodels.PackageTestMapping, error)
 	FindPackagesByExactTestIds(testIDs []int) ([]int, error)
+	FindActiveTestIDs(packageID int) ([]int, error)
 	UpdatePackageStatusCascade(packageID int, isActive bool, lastUpdatedBy int64) (testCount, clientCount, labCount int, err error)
 }
 
@@ -171,6 +172,15 @@ func (r *packageRepository) FindAllPackageTestMappings() ([]persistencemodels.Pa
 	return out, err
 }
 
+// FindActiveTestIDs returns the IDs of the tests actively mapped to the package.
+func (r *packageRepository) FindActiveTestIDs(packageID int) ([]int, error) {
+	var ids []int
+	err := r.db.Model(&persistencemodels.PackageTestMapping{}).
+		Where("PackageID = ? AND IsActive = ?", packageID, true).
+		Order("TestID ASC").Pluck("TestID", &ids).Error
+	return ids, err
+}
+
 func (r *packageRepository) FindPackagesByExactTestIds(testIDs []int) ([]int, error) {
 	var mappings []persistencemodels.PackageTestMapping
 	if err := r.db.Where("IsActive = ?", true).Find(&mappings).Error; err != nil {
@@ -239,3 +249,23 @@ func (r *packageRepository) UpdatePackageStatusCascade(packageID int, isActive b
 	})
 	return testCount, clientCount, labCount, err
 }
+
+// PackageUnitOfWork runs package and price changes and the domain events they raise in one
+// transaction.
+type PackageUnitOfWork interface {
+	WithinTransaction(func(PackageRepository, PackageClientMappingRepository, PackageLabMappingRepository, OutboxRepository) error) error
+}
+
+type packageUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewPackageUnitOfWork(db *gorm.DB) PackageUnitOfWork {
+	return &packageUnitOfWork{db: db}
+}
+
+func (u *packageUnitOfWork) WithinTransaction(fn func(PackageRepository, PackageClientMappingRepository, PackageLabMappingRepository, OutboxRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewPackageRepository(tx), NewPackageClientMappingRepository(tx), NewPackageLabMappingRepository(tx), NewOutboxRepository(tx))
+	})
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type PatientRepository interface {
+	WithScope(scope domain.TenantScope) PatientRepository
+	FindByID(id int64) (*domain.Patient, error)
+	FindByContactNumber(clientID int64, contactNumber string) ([]domain.Patient, error)
+	Create(p *domain.Patient) error
+}
+
+type patientRepository struct {
+	db    *gorm.DB
+	scope domain.TenantScope
+}
+
+func NewPatientRepository(db *gorm.DB) PatientRepository {
+	return &patientRepository{db: db}
+}
+
+// WithScope restricts queries to the caller's client; labs own no patients.
+func (r *patientRepository) WithScope(scope domain.TenantScope) PatientRepository {
+	return &patientRepository{db: r.db, scope: scope}
+}
+
+func (r *patientRepository) scoped() *gorm.DB {
+	return r.db.Scopes(tenantScope(r.scope, "ClientID", ""))
+}
+
+func (r *patientRepository) FindByID(id int64) (*domain.Patient, error) {
+	var p persistencemodels.Patient
+	if err := r.scoped().First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	patient := mapPatientToDomain(p)
+	return &patient, nil
+}
+
+// FindByContactNumber returns the client's patients sharing a contact number (a family), oldest first.
+func (r *patientRepository) FindByContactNumber(clientID int64, contactNumber string) ([]domain.Patient, error) {
+	var rows []persistencemodels.Patient
+	if err := r.scoped().Where("ClientID = ? AND ContactNumber = ?", clientID, contactNumber).Order("PatientID ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	patients := make([]domain.Patient, len(rows))
+	for i, row := range rows {
+		patients[i] = mapPatientToDomain(row)
+	}
+	return patients, nil
+}
+
+func (r *patientRepository) Create(p *domain.Patient) error {
+	persist := mapPatientToPersistence(*p)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*p = mapPatientToDomain(persist)
+	return nil
+}
+
+func mapPatientToDomain(p persistencemodels.Patient) domain.Patient {
+	return domain.Patient{
+		PatientID:     p.PatientID,
+		ClientID:      p.ClientID,
+		FullName:      p.FullName,
+		Age:           p.Age,
+		Gender:        p.Gender,
+		ContactNumber: p.ContactNumber,
+		Emailid:       p.Emailid,
+		Address:       p.Address,
+		CityID:        p.CityID,
+		StateID:       p.StateID,
+		Pincode:       p.Pincode,
+		CreatedBy:     p.CreatedBy,
+		CreatedOn:     p.CreatedOn,
+		LastUpdatedBy: p.LastUpdatedBy,
+		LastUpdatedOn: p.LastUpdatedOn,
+	}
+}
+
+func mapPatientToPersistence(d domain.Patient) persistencemodels.Patient {
+	return persistencemodels.Patient{
+		PatientID:     d.PatientID,
+		ClientID:      d.ClientID,
+		FullName:      d.FullName,
+		Age:           d.Age,
+		Gender:        d.Gender,
+		ContactNumber: d.ContactNumber,
+		Emailid:       d.Emailid,
+		Address:       d.Address,
+		CityID:        d.CityID,
+		StateID:       d.StateID,
+		Pincode:       d.Pincode,
+		CreatedBy:     d.CreatedBy,
+		CreatedOn:     d.CreatedOn,
+		LastUpdatedBy: d.LastUpdatedBy,
+		LastUpdatedOn: d.LastUpdatedOn,
+	}
+}
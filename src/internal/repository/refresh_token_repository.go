@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *domain.RefreshToken) error
+	FindByTokenID(tokenID string) (*domain.RefreshToken, error)
+	MarkAsUsed(tokenID string) (bool, error)
+	RevokeSession(sessionID string) error
+	IsSessionActive(sessionID string) (bool, error)
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(token *domain.RefreshToken) error {
+	p := mapRefreshTokenToPersistence(*token)
+	if err := r.db.Create(&p).Error; err != nil {
+		return err
+	}
+	*token = mapRefreshTokenToDomain(p)
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByTokenID(tokenID string) (*domain.RefreshToken, error) {
+	var p persistencemodels.RefreshToken
+	if err := r.db.Where("TokenID = ?", tokenID).First(&p).Error; err != nil {
+		return nil, err
+	}
+	d := mapRefreshTokenToDomain(p)
+	return &d, nil
+}
+
+// MarkAsUsed flags the token as rotated. Returns false when it was already used or revoked, so two
+// concurrent refreshes with the same token cannot both succeed.
+func (r *refreshTokenRepository) MarkAsUsed(tokenID string) (bool, error) {
+	res := r.db.Model(&persistencemodels.RefreshToken{}).
+		Where("TokenID = ? AND UsedOn IS NULL AND RevokedOn IS NULL", tokenID).
+		Update("UsedOn", time.Now().UTC())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *refreshTokenRepository) RevokeSession(sessionID string) error {
+	return r.db.Model(&persistencemodels.RefreshToken{}).
+		Where("SessionID = ? AND RevokedOn IS NULL", sessionID).
+		Update("RevokedOn", time.Now().UTC()).Error
+}
+
+func (r *refreshTokenRepository) IsSessionActive(sessionID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&persistencemodels.RefreshToken{}).
+		Where("SessionID = ? AND RevokedOn IS NULL", sessionID).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func mapRefreshTokenToPersistence(d domain.RefreshToken) persistencemodels.RefreshToken {
+	return persistencemodels.RefreshToken{
+		TokenID:   d.TokenID,
+		SessionID: d.SessionID,
+		UserID:    d.UserID,
+		UserType:  d.UserType,
+		ExpiresAt: d.ExpiresAt,
+		CreatedOn: d.CreatedOn,
+		UsedOn:    d.UsedOn,
+		RevokedOn: d.RevokedOn,
+	}
+}
+
+func mapRefreshTokenToDomain(p persistencemodels.RefreshToken) domain.RefreshToken {
+	return domain.RefreshToken{
+		TokenID:   p.TokenID,
+		SessionID: p.SessionID,
+		UserID:    p.UserID,
+		UserType:  p.UserType,
+		ExpiresAt: p.ExpiresAt,
+		CreatedOn: p.CreatedOn,
+		UsedOn:    p.UsedOn,
+		RevokedOn: p.RevokedOn,
+	}
+}
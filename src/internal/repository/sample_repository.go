@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type SampleRepository interface {
+	FindByID(id int64) (*domain.Sample, error)
+	FindByAccessionNumber(accessionNumber string) (*domain.Sample, error)
+	FindByLeadID(leadID int64) ([]domain.Sample, error)
+	MaxTubeNumber(leadID int64) (int, error)
+	Create(s *domain.Sample) error
+	Update(s *domain.Sample) error
+}
+
+type sampleRepository struct {
+	db *gorm.DB
+}
+
+func NewSampleRepository(db *gorm.DB) SampleRepository {
+	return &sampleRepository{db: db}
+}
+
+func (r *sampleRepository) FindByID(id int64) (*domain.Sample, error) {
+	var s persistencemodels.Sample
+	if err := r.db.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	sample := mapSampleToDomain(s)
+	return &sample, nil
+}
+
+func (r *sampleRepository) FindByAccessionNumber(accessionNumber string) (*domain.Sample, error) {
+	var s persistencemodels.Sample
+	if err := r.db.Where("AccessionNumber = ?", accessionNumber).First(&s).Error; err != nil {
+		return nil, err
+	}
+	sample := mapSampleToDomain(s)
+	return &sample, nil
+}
+
+// FindByLeadID returns the lead's samples in tube order.
+func (r *sampleRepository) FindByLeadID(leadID int64) ([]domain.Sample, error) {
+	var rows []persistencemodels.Sample
+	if err := r.db.Where("LeadID = ?", leadID).Order("TubeNumber ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	samples := make([]domain.Sample, len(rows))
+	for i, row := range rows {
+		samples[i] = mapSampleToDomain(row)
+	}
+	return samples, nil
+}
+
+// MaxTubeNumber returns the highest tube number used for the lead, 0 when it has no samples.
+func (r *sampleRepository) MaxTubeNumber(leadID int64) (int, error) {
+	var max *int
+	err := r.db.Model(&persistencemodels.Sample{}).Where("LeadID = ?", leadID).Select("MAX(TubeNumber)").Scan(&max).Error
+	if err != nil || max == nil {
+		return 0, err
+	}
+	return *max, nil
+}
+
+func (r *sampleRepository) Create(s *domain.Sample) error {
+	persist := mapSampleToPersistence(*s)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*s = mapSampleToDomain(persist)
+	return nil
+}
+
+func (r *sampleRepository) Update(s *domain.Sample) error {
+	persist := mapSampleToPersistence(*s)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*s = mapSampleToDomain(persist)
+	return nil
+}
+
+// SampleUnitOfWork runs sample changes and the matching lead update, history entry and domain events
+// in one transaction.
+type SampleUnitOfWork interface {
+	WithinTransaction(func(SampleRepository, LeadRepository, LeadHistoryRepository, OutboxRepository) error) error
+}
+
+type sampleUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewSampleUnitOfWork(db *gorm.DB) SampleUnitOfWork {
+	return &sampleUnitOfWork{db: db}
+}
+
+func (u *sampleUnitOfWork) WithinTransaction(fn func(SampleRepository, LeadRepository, LeadHistoryRepository, OutboxRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewSampleRepository(tx), NewLeadRepository(tx), NewLeadHistoryRepository(tx), NewOutboxRepository(tx))
+	})
+}
+
+func mapSampleToDomain(p persistencemodels.Sample) domain.Sample {
+	return domain.Sample{
+		SampleID:        p.SampleID,
+		LeadID:          p.LeadID,
+		LabID:           p.LabID,
+		TubeNumber:      p.TubeNumber,
+		TubeType:        p.TubeType,
+		AccessionNumber: p.AccessionNumber,
+		Status:          p.Status,
+		RejectionReason: derefString(p.RejectionReason),
+		CollectedBy:     p.CollectedBy,
+		CollectedOn:     p.CollectedOn,
+		ReceivedOn:      p.ReceivedOn,
+		LastUpdatedBy:   p.LastUpdatedBy,
+		LastUpdatedOn:   p.LastUpdatedOn,
+	}
+}
+
+func mapSampleToPersistence(d domain.Sample) persistencemodels.Sample {
+	return persistencemodels.Sample{
+		SampleID:        d.SampleID,
+		LeadID:          d.LeadID,
+		LabID:           d.LabID,
+		TubeNumber:      d.TubeNumber,
+		TubeType:        d.TubeType,
+		AccessionNumber: d.AccessionNumber,
+		Status:          d.Status,
+		RejectionReason: optionalString(d.RejectionReason),
+		CollectedBy:     d.CollectedBy,
+		CollectedOn:     d.CollectedOn,
+		ReceivedOn:      d.ReceivedOn,
+		LastUpdatedBy:   d.LastUpdatedBy,
+		LastUpdatedOn:   d.LastUpdatedOn,
+	}
+}
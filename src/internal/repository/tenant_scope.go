@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// tenantScope returns a GORM scope that restricts rows to the caller's tenant.
+// clientColumn/labColumn name the owning column for each tenant type; an empty column means
+// that tenant type owns no rows in the table, so the query matches nothing.
+func tenantScope(scope domain.TenantScope, clientColumn, labColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if scope.ClientID != 0 {
+			if clientColumn == "" {
+				return db.Where("1 = 0")
+			}
+			db = db.Where(clientColumn+" = ?", scope.ClientID)
+		}
+		if scope.LabID != 0 {
+			if labColumn == "" {
+				return db.Where("1 = 0")
+			}
+			db = db.Where(labColumn+" = ?", scope.LabID)
+		}
+		return db
+	}
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"errors"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type TestParameterRepository interface {
+	FindByID(id int64) (*domain.TestParameter, error)
+	FindByTestIDs(testIDs []int) ([]domain.TestParameter, error)
+	Create(p *domain.TestParameter) error
+	Update(p *domain.TestParameter) error
+}
+
+type testParameterRepository struct {
+	db *gorm.DB
+}
+
+func NewTestParameterRepository(db *gorm.DB) TestParameterRepository {
+	return &testParameterRepository{db: db}
+}
+
+func (r *testParameterRepository) FindByID(id int64) (*domain.TestParameter, error) {
+	var p persistencemodels.TestParameter
+	if err := r.db.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	params, err := r.withRanges([]persistencemodels.TestParameter{p})
+	if err != nil {
+		return nil, err
+	}
+	return &params[0], nil
+}
+
+// FindByTestIDs returns the tests' parameters with their reference ranges, in display order.
+func (r *testParameterRepository) FindByTestIDs(testIDs []int) ([]domain.TestParameter, error) {
+	if len(testIDs) == 0 {
+		return nil, nil
+	}
+	var rows []persistencemodels.TestParameter
+	if err := r.db.Where("TestID IN ?", testIDs).Order("TestID ASC, DisplayOrder ASC, ParameterID ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return r.withRanges(rows)
+}
+
+// Create stores the parameter and its reference ranges together.
+func (r *testParameterRepository) Create(p *domain.TestParameter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		persist := mapTestParameterToPersistence(*p)
+		if err := tx.Create(&persist).Error; err != nil {
+			return err
+		}
+		ranges, err := replaceReferenceRanges(tx, persist.ParameterID, p.Ranges)
+		if err != nil {
+			return err
+		}
+		*p = mapTestParameterToDomain(persist, ranges)
+		return nil
+	})
+}
+
+// Update saves the parameter and replaces its reference ranges.
+func (r *testParameterRepository) Update(p *domain.TestParameter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		persist := mapTestParameterToPersistence(*p)
+		if err := tx.Save(&persist).Error; err != nil {
+			return err
+		}
+		ranges, err := replaceReferenceRanges(tx, persist.ParameterID, p.Ranges)
+		if err != nil {
+			return err
+		}
+		*p = mapTestParameterToDomain(persist, ranges)
+		return nil
+	})
+}
+
+func (r *testParameterRepository) withRanges(rows []persistencemodels.TestParameter) ([]domain.TestParameter, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ParameterID
+	}
+	var ranges []persistencemodels.TestReferenceRange
+	if err := r.db.Where("ParameterID IN ?", ids).Order("RangeID ASC").Find(&ranges).Error; err != nil {
+		return nil, err
+	}
+	byParameter := make(map[int64][]persistencemodels.TestReferenceRange)
+	for _, rr := range ranges {
+		byParameter[rr.ParameterID] = append(byParameter[rr.ParameterID], rr)
+	}
+	params := make([]domain.TestParameter, len(rows))
+	for i, row := range rows {
+		params[i] = mapTestParameterToDomain(row, byParameter[row.ParameterID])
+	}
+	return params, nil
+}
+
+func replaceReferenceRanges(tx *gorm.DB, parameterID int64, ranges []domain.ReferenceRange) ([]persistencemodels.TestReferenceRange, error) {
+	if err := tx.Where("ParameterID = ?", parameterID).Delete(&persistencemodels.TestReferenceRange{}).Error; err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	rows := make([]persistencemodels.TestReferenceRange, len(ranges))
+	for i, rr := range ranges {
+		rows[i] = persistencemodels.TestReferenceRange{
+			ParameterID:  parameterID,
+			Gender:       optionalString(rr.Gender),
+			MinAge:       rr.MinAge,
+			MaxAge:       rr.MaxAge,
+			Low:          rr.Low,
+			High:         rr.High,
+			CriticalLow:  rr.CriticalLow,
+			CriticalHigh: rr.CriticalHigh,
+		}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+type TestResultRepository interface {
+	FindByLeadID(leadID int64) ([]domain.TestResult, error)
+	Upsert(results []domain.TestResult) error
+}
+
+type testResultRepository struct {
+	db *gorm.DB
+}
+
+func NewTestResultRepository(db *gorm.DB) TestResultRepository {
+	return &testResultRepository{db: db}
+}
+
+func (r *testResultRepository) FindByLeadID(leadID int64) ([]domain.TestResult, error) {
+	var rows []persistencemodels.TestResult
+	if err := r.db.Where("LeadID = ?", leadID).Order("TestID ASC, ResultID ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	results := make([]domain.TestResult, len(rows))
+	for i, row := range rows {
+		results[i] = mapTestResultToDomain(row)
+	}
+	return results, nil
+}
+
+// Upsert stores each result, replacing the lead's earlier value for the same parameter (amended
+// results). Call inside TestResultUnitOfWork.
+func (r *testResultRepository) Upsert(results []domain.TestResult) error {
+	for i := range results {
+		persist := mapTestResultToPersistence(results[i])
+		var existing persistencemodels.TestResult
+		err := r.db.Where("LeadID = ? AND ParameterID = ?", persist.LeadID, persist.ParameterID).First(&existing).Error
+		switch {
+		case err == nil:
+			persist.ResultID = existing.ResultID
+			err = r.db.Save(&persist).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = r.db.Create(&persist).Error
+		}
+		if err != nil {
+			return err
+		}
+		results[i] = mapTestResultToDomain(persist)
+	}
+	return nil
+}
+
+// TestResultUnitOfWork records results and the lead history entry in one transaction.
+type TestResultUnitOfWork interface {
+	WithinTransaction(func(TestResultRepository, LeadHistoryRepository) error) error
+}
+
+type testResultUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewTestResultUnitOfWork(db *gorm.DB) TestResultUnitOfWork {
+	return &testResultUnitOfWork{db: db}
+}
+
+func (u *testResultUnitOfWork) WithinTransaction(fn func(TestResultRepository, LeadHistoryRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewTestResultRepository(tx), NewLeadHistoryRepository(tx))
+	})
+}
+
+func mapTestParameterToDomain(p persistencemodels.TestParameter, ranges []persistencemodels.TestReferenceRange) domain.TestParameter {
+	param := domain.TestParameter{
+		ParameterID:   p.ParameterID,
+		TestID:        p.TestID,
+		Analyte:       p.Analyte,
+		Unit:          p.Unit,
+		DisplayOrder:  p.DisplayOrder,
+		IsActive:      p.IsActive,
+		Ranges:        make([]domain.ReferenceRange, len(ranges)),
+		CreatedBy:     p.CreatedBy,
+		CreatedOn:     p.CreatedOn,
+		LastUpdatedBy: p.LastUpdatedBy,
+		LastUpdatedOn: p.LastUpdatedOn,
+	}
+	for i, rr := range ranges {
+		param.Ranges[i] = domain.ReferenceRange{
+			RangeID:      rr.RangeID,
+			ParameterID:  rr.ParameterID,
+			Gender:       derefString(rr.Gender),
+			MinAge:       rr.MinAge,
+			MaxAge:       rr.MaxAge,
+			Low:          rr.Low,
+			High:         rr.High,
+			CriticalLow:  rr.CriticalLow,
+			CriticalHigh: rr.CriticalHigh,
+		}
+	}
+	return param
+}
+
+func mapTestParameterToPersistence(d domain.TestParameter) persistencemodels.TestParameter {
+	return persistencemodels.TestParameter{
+		ParameterID:   d.ParameterID,
+		TestID:        d.TestID,
+		Analyte:       d.Analyte,
+		Unit:          d.Unit,
+		DisplayOrder:  d.DisplayOrder,
+		IsActive:      d.IsActive,
+		CreatedBy:     d.CreatedBy,
+		CreatedOn:     d.CreatedOn,
+		LastUpdatedBy: d.LastUpdatedBy,
+		LastUpdatedOn: d.LastUpdatedOn,
+	}
+}
+
+func mapTestResultToDomain(p persistencemodels.TestResult) domain.TestResult {
+	return domain.TestResult{
+		ResultID:       p.ResultID,
+		LeadID:         p.LeadID,
+		TestID:         p.TestID,
+		ParameterID:    p.ParameterID,
+		Value:          p.Value,
+		Unit:           p.Unit,
+		ReferenceLow:   p.ReferenceLow,
+		ReferenceHigh:  p.ReferenceHigh,
+		Flag:           derefString(p.Flag),
+		Comment:        derefString(p.Comment),
+		ReportedBy:     p.ReportedBy,
+		ReportedByType: p.ReportedByType,
+		ReportedOn:     p.ReportedOn,
+	}
+}
+
+func mapTestResultToPersistence(d domain.TestResult) persistencemodels.TestResult {
+	return persistencemodels.TestResult{
+		ResultID:       d.ResultID,
+		LeadID:         d.LeadID,
+		TestID:         d.TestID,
+		ParameterID:    d.ParameterID,
+		Value:          d.Value,
+		Unit:           d.Unit,
+		ReferenceLow:   d.ReferenceLow,
+		ReferenceHigh:  d.ReferenceHigh,
+		Flag:           optionalString(d.Flag),
+		Comment:        optionalString(d.Comment),
+		ReportedBy:     d.ReportedBy,
+		ReportedByType: d.ReportedByType,
+		ReportedOn:     d.ReportedOn,
+	}
+}
@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	persistencemodels "b2b-diagnostic-aggregator/apis/internal/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type WebhookSubscriptionRepository interface {
+	FindByID(id int64) (*domain.WebhookSubscription, error)
+	FindByClientID(clientID int64) ([]domain.WebhookSubscription, error)
+	FindAll() ([]domain.WebhookSubscription, error)
+	Create(s *domain.WebhookSubscription) error
+	Update(s *domain.WebhookSubscription) error
+	Delete(id int64) error
+}
+
+type webhookSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookSubscriptionRepository(db *gorm.DB) WebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{db: db}
+}
+
+func (r *webhookSubscriptionRepository) FindByID(id int64) (*domain.WebhookSubscription, error) {
+	var p persistencemodels.WebhookSubscription
+	if err := r.db.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	subscription := mapWebhookSubscriptionToDomain(p)
+	return &subscription, nil
+}
+
+func (r *webhookSubscriptionRepository) FindByClientID(clientID int64) ([]domain.WebhookSubscription, error) {
+	var rows []persistencemodels.WebhookSubscription
+	if err := r.db.Where("ClientID = ?", clientID).Order("SubscriptionID").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return mapWebhookSubscriptionsToDomain(rows), nil
+}
+
+func (r *webhookSubscriptionRepository) FindAll() ([]domain.WebhookSubscription, error) {
+	var rows []persistencemodels.WebhookSubscription
+	if err := r.db.Order("ClientID, SubscriptionID").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return mapWebhookSubscriptionsToDomain(rows), nil
+}
+
+func (r *webhookSubscriptionRepository) Create(s *domain.WebhookSubscription) error {
+	persist := mapWebhookSubscriptionToPersistence(*s)
+	if err := r.db.Create(&persist).Error; err != nil {
+		return err
+	}
+	*s = mapWebhookSubscriptionToDomain(persist)
+	return nil
+}
+
+func (r *webhookSubscriptionRepository) Update(s *domain.WebhookSubscription) error {
+	persist := mapWebhookSubscriptionToPersistence(*s)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*s = mapWebhookSubscriptionToDomain(persist)
+	return nil
+}
+
+// Delete removes the subscription and every delivery queued for it.
+func (r *webhookSubscriptionRepository) Delete(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("SubscriptionID = ?", id).Delete(&persistencemodels.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&persistencemodels.WebhookSubscription{}, id).Error
+	})
+}
+
+// WebhookDeliveryRepository stores webhook deliveries. Enqueue is called by the domain event
+// dispatcher, which may hand it the same event more than once.
+type WebhookDeliveryRepository interface {
+	Enqueue(event domain.WebhookEvent) (int, error)
+	FindByID(id int64) (*domain.WebhookDelivery, error)
+	List(filter WebhookDeliveryListFilter) ([]domain.WebhookDelivery, int64, error)
+	FindDue(now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	Claim(id int64, now, leaseUntil time.Time) (bool, error)
+	Update(d *domain.WebhookDelivery) error
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+// Enqueue queues the event for each of the client's active subscriptions that wants it and does not
+// have it queued yet, and returns how many deliveries were queued.
+func (r *webhookDeliveryRepository) Enqueue(event domain.WebhookEvent) (int, error) {
+	var rows []persistencemodels.WebhookSubscription
+	if err := r.db.Where("ClientID = ? AND IsActive = ?", event.ClientID, true).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	var queued []int64
+	if err := r.db.Model(&persistencemodels.WebhookDelivery{}).Where("EventID = ?", event.EventID).
+		Pluck("SubscriptionID", &queued).Error; err != nil {
+		return 0, err
+	}
+	skip := make(map[int64]bool, len(queued))
+	for _, id := range queued {
+		skip[id] = true
+	}
+	var deliveries []persistencemodels.WebhookDelivery
+	for _, row := range rows {
+		if skip[row.SubscriptionID] || !mapWebhookSubscriptionToDomain(row).Wants(event.Type) {
+			continue
+		}
+		next := event.OccurredOn
+		deliveries = append(deliveries, persistencemodels.WebhookDelivery{
+			SubscriptionID: row.SubscriptionID,
+			ClientID:       event.ClientID,
+			EventID:        event.EventID,
+			EventType:      event.Type,
+			LeadID:         event.LeadID,
+			Payload:        event.Payload,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptOn:  &next,
+			CreatedOn:      event.OccurredOn,
+			LastUpdatedOn:  event.OccurredOn,
+		})
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	return len(deliveries), r.db.Create(&deliveries).Error
+}
+
+func (r *webhookDeliveryRepository) FindByID(id int64) (*domain.WebhookDelivery, error) {
+	var p persistencemodels.WebhookDelivery
+	if err := r.db.First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	delivery := mapWebhookDeliveryToDomain(p)
+	return &delivery, nil
+}
+
+// List returns matching deliveries, latest first, with the total count.
+func (r *webhookDeliveryRepository) List(filter WebhookDeliveryListFilter) ([]domain.WebhookDelivery, int64, error) {
+	query := r.db.Model(&persistencemodels.WebhookDelivery{})
+	if filter.ClientID != 0 {
+		query = query.Where("ClientID = ?", filter.ClientID)
+	}
+	if filter.SubscriptionID != nil {
+		query = query.Where("SubscriptionID = ?", *filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("Status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+
+	var rows []persistencemodels.WebhookDelivery
+	err := query.Order("DeliveryID DESC").Limit(filter.PageSize).Offset(offset).Find(&rows).Error
+	deliveries := make([]domain.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = mapWebhookDeliveryToDomain(row)
+	}
+	return deliveries, total, err
+}
+
+// FindDue returns pending deliveries whose next attempt is due, oldest first.
+func (r *webhookDeliveryRepository) FindDue(now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var rows []persistencemodels.WebhookDelivery
+	err := r.db.Where("Status = ? AND NextAttemptOn <= ?", domain.WebhookDeliveryPending, now).
+		Order("NextAttemptOn, DeliveryID").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]domain.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = mapWebhookDeliveryToDomain(row)
+	}
+	return deliveries, nil
+}
+
+// Claim takes a due delivery for one attempt by pushing its next attempt to leaseUntil. It reports
+// false when another dispatcher claimed it first.
+func (r *webhookDeliveryRepository) Claim(id int64, now, leaseUntil time.Time) (bool, error) {
+	res := r.db.Model(&persistencemodels.WebhookDelivery{}).
+		Where("DeliveryID = ? AND Status = ? AND NextAttemptOn <= ?", id, domain.WebhookDeliveryPending, now).
+		Update("NextAttemptOn", leaseUntil)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *webhookDeliveryRepository) Update(d *domain.WebhookDelivery) error {
+	persist := mapWebhookDeliveryToPersistence(*d)
+	if err := r.db.Save(&persist).Error; err != nil {
+		return err
+	}
+	*d = mapWebhookDeliveryToDomain(persist)
+	return nil
+}
+
+func mapWebhookSubscriptionToDomain(p persistencemodels.WebhookSubscription) domain.WebhookSubscription {
+	var eventTypes []string
+	for _, t := range strings.Split(p.EventTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			eventTypes = append(eventTypes, t)
+		}
+	}
+	return domain.WebhookSubscription{
+		SubscriptionID: p.SubscriptionID,
+		ClientID:       p.ClientID,
+		URL:            p.URL,
+		Secret:         p.Secret,
+		EventTypes:     eventTypes,
+		IsActive:       p.IsActive,
+		CreatedBy:      p.CreatedBy,
+		CreatedOn:      p.CreatedOn,
+		LastUpdatedBy:  p.LastUpdatedBy,
+		LastUpdatedOn:  p.LastUpdatedOn,
+	}
+}
+
+func mapWebhookSubscriptionsToDomain(rows []persistencemodels.WebhookSubscription) []domain.WebhookSubscription {
+	subscriptions := make([]domain.WebhookSubscription, len(rows))
+	for i, row := range rows {
+		subscriptions[i] = mapWebhookSubscriptionToDomain(row)
+	}
+	return subscriptions
+}
+
+func mapWebhookSubscriptionToPersistence(d domain.WebhookSubscription) persistencemodels.WebhookSubscription {
+	return persistencemodels.WebhookSubscription{
+		SubscriptionID: d.SubscriptionID,
+		ClientID:       d.ClientID,
+		URL:            d.URL,
+		Secret:         d.Secret,
+		EventTypes:     strings.Join(d.EventTypes, ","),
+		IsActive:       d.IsActive,
+		CreatedBy:      d.CreatedBy,
+		CreatedOn:      d.CreatedOn,
+		LastUpdatedBy:  d.LastUpdatedBy,
+		LastUpdatedOn:  d.LastUpdatedOn,
+	}
+}
+
+func mapWebhookDeliveryToDomain(p persistencemodels.WebhookDelivery) domain.WebhookDelivery {
+	d := domain.WebhookDelivery{
+		DeliveryID:     p.DeliveryID,
+		SubscriptionID: p.SubscriptionID,
+		ClientID:       p.ClientID,
+		EventID:        p.EventID,
+		EventType:      p.EventType,
+		LeadID:         p.LeadID,
+		Payload:        p.Payload,
+		Status:         p.Status,
+		Attempts:       p.Attempts,
+		NextAttemptOn:  p.NextAttemptOn,
+		LastError:      derefString(p.LastError),
+		CreatedOn:      p.CreatedOn,
+		DeliveredOn:    p.DeliveredOn,
+		LastUpdatedOn:  p.LastUpdatedOn,
+	}
+	if p.LastResponseCode != nil {
+		d.LastResponseCode = *p.LastResponseCode
+	}
+	return d
+}
+
+func mapWebhookDeliveryToPersistence(d domain.WebhookDelivery) persistencemodels.WebhookDelivery {
+	p := persistencemodels.WebhookDelivery{
+		DeliveryID:     d.DeliveryID,
+		SubscriptionID: d.SubscriptionID,
+		ClientID:       d.ClientID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		LeadID:         d.LeadID,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptOn:  d.NextAttemptOn,
+		LastError:      optionalString(d.LastError),
+		CreatedOn:      d.CreatedOn,
+		DeliveredOn:    d.DeliveredOn,
+		LastUpdatedOn:  d.LastUpdatedOn,
+	}
+	if d.LastResponseCode != 0 {
+		code := d.LastResponseCode
+		p.LastResponseCode = &code
+	}
+	return p
+}
@@ -0,0 +1,521 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// slotTimeLayout is the HH:MM format of slot start and end times.
+const slotTimeLayout = "15:04"
+
+// AppointmentService manages lab collection slots and the home collection appointments booked in
+// them. Booking moves the lead to Scheduled; cancelling moves a Scheduled lead back to Contacted.
+type AppointmentService interface {
+	ListSlots(labID int64, date *time.Time, pincode string) ([]dto.LabSlotAvailability, error)
+	CreateSlot(labID int64, req dto.LabSlotRequest, actor domain.Actor) (*domain.LabSlot, error)
+	UpdateSlot(labID, slotID int64, req dto.LabSlotRequest, actor domain.Actor) (*domain.LabSlot, error)
+	BookAppointment(leadID, slotID int64, date time.Time, actor domain.Actor, scope domain.TenantScope) (*domain.Appointment, error)
+	ListLeadAppointments(leadID int64, scope domain.TenantScope) ([]domain.Appointment, error)
+	RescheduleAppointment(id, slotID int64, date time.Time, actor domain.Actor, scope domain.TenantScope) (*domain.Appointment, error)
+	CancelAppointment(id int64, reason string, actor domain.Actor, scope domain.TenantScope) (*domain.Appointment, error)
+	ListLabAppointments(labID int64, date time.Time, scope domain.TenantScope) ([]dto.LabAppointment, error)
+}
+
+type appointmentService struct {
+	repo        repository.AppointmentRepository
+	slotRepo    repository.LabSlotRepository
+	leadRepo    repository.LeadRepository
+	labRepo     repository.LabRepository
+	packageRepo repository.PackageRepository
+	uow         repository.AppointmentUnitOfWork
+	routing     LabRoutingService
+	workflow    *domain.LeadStatusWorkflow
+	audit       AuditService
+}
+
+func NewAppointmentService(
+	repo repository.AppointmentRepository,
+	slotRepo repository.LabSlotRepository,
+	leadRepo repository.LeadRepository,
+	labRepo repository.LabRepository,
+	packageRepo repository.PackageRepository,
+	uow repository.AppointmentUnitOfWork,
+	routing LabRoutingService,
+	workflow *domain.LeadStatusWorkflow,
+	audit AuditService,
+) AppointmentService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	return &appointmentService{
+		repo:        repo,
+		slotRepo:    slotRepo,
+		leadRepo:    leadRepo,
+		labRepo:     labRepo,
+		packageRepo: packageRepo,
+		uow:         uow,
+		routing:     routing,
+		workflow:    workflow,
+		audit:       audit,
+	}
+}
+
+// ListSlots returns the lab's slots. With a date only active slots are listed, each with its
+// bookings and free capacity that day; with a pincode only slots that can serve it are listed.
+func (s *appointmentService) ListSlots(labID int64, date *time.Time, pincode string) ([]dto.LabSlotAvailability, error) {
+	lab, err := s.findLab(labID)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.slotRepo.FindByLabID(labID)
+	if err != nil {
+		return nil, err
+	}
+	var booked map[int64]int
+	if date != nil {
+		if booked, err = s.repo.CountBookedBySlot(labID, *date); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]dto.LabSlotAvailability, 0, len(slots))
+	for _, slot := range slots {
+		if pincode != "" && (!slot.Serves(pincode) || !lab.CollectsFrom(pincode)) {
+			continue
+		}
+		entry := dto.LabSlotAvailability{LabSlot: slot}
+		if date != nil {
+			if !slot.IsActive {
+				continue
+			}
+			count := booked[slot.SlotID]
+			available := slot.Capacity - count
+			if available < 0 {
+				available = 0
+			}
+			entry.Date = date.Format(domain.AppointmentDateLayout)
+			entry.Booked = &count
+			entry.Available = &available
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func (s *appointmentService) CreateSlot(labID int64, req dto.LabSlotRequest, actor domain.Actor) (*domain.LabSlot, error) {
+	lab, err := s.findLab(labID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSlot(*lab, req); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	slot := &domain.LabSlot{
+		LabID:         labID,
+		Pincode:       req.Pincode,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		Capacity:      req.Capacity,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+		CreatedBy:     actor.UserID,
+		CreatedOn:     now,
+		LastUpdatedBy: actor.UserID,
+		LastUpdatedOn: now,
+	}
+	if err := s.slotRepo.Create(slot); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityLabSlot, slot.SlotID, domain.AuditActionCreate, actor, nil, slot)
+	return slot, nil
+}
+
+// UpdateSlot replaces the slot's configuration. Appointments already booked keep their window; a
+// lower capacity only limits new bookings.
+func (s *appointmentService) UpdateSlot(labID, slotID int64, req dto.LabSlotRequest, actor domain.Actor) (*domain.LabSlot, error) {
+	lab, err := s.findLab(labID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.slotRepo.FindByID(slotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && existing.LabID != labID) {
+		return nil, apperrors.NewNotFound("Slot not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := validateSlot(*lab, req); err != nil {
+		return nil, err
+	}
+	updated := *existing
+	updated.Pincode = req.Pincode
+	updated.StartTime = req.StartTime
+	updated.EndTime = req.EndTime
+	updated.Capacity = req.Capacity
+	if req.IsActive != nil {
+		updated.IsActive = *req.IsActive
+	}
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = time.Now()
+	if err := s.slotRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityLabSlot, slotID, domain.AuditActionUpdate, actor, existing, &updated)
+	return &updated, nil
+}
+
+// BookAppointment books the lead into slotID on date and moves it to Scheduled. A lead without a lab
+// is assigned the slot's lab in the same transaction, so the lab must be eligible for the lead; its
+// order is queued once the booking has committed. A lead has at most one booked appointment; use
+// reschedule to move it.
+func (s *appointmentService) BookAppointment(leadID, slotID int64, date time.Time, actor domain.Actor, scope domain.TenantScope) (*domain.Appointment, error) {
+	lead, err := s.findLead(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	status := s.workflow.Normalize(lead.LeadStatusID)
+	if status != domain.LeadStatusScheduled && !s.workflow.CanTransition(status, domain.LeadStatusScheduled) {
+		current, _ := s.workflow.Status(status)
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("A lead in status %s cannot be scheduled", current.Name), nil)
+	}
+	slot, err := s.bookableSlot(lead, slotID, date)
+	if err != nil {
+		return nil, err
+	}
+	var lab *domain.LabOption
+	if lead.LabID == nil {
+		if lab, err = s.routing.ChooseLab(lead, &slot.LabID, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	appointment := &domain.Appointment{
+		LeadID:          leadID,
+		LabID:           slot.LabID,
+		SlotID:          slot.SlotID,
+		AppointmentDate: date,
+		StartTime:       slot.StartTime,
+		EndTime:         slot.EndTime,
+		Pincode:         lead.Pincode,
+		Status:          domain.AppointmentStatusBooked,
+		CreatedBy:       actor.UserID,
+		CreatedOn:       now,
+		LastUpdatedBy:   actor.UserID,
+		LastUpdatedOn:   now,
+	}
+
+	err = s.uow.WithinTransaction(func(repo repository.AppointmentRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		if current, err := repo.FindBookedByLeadID(leadID); err == nil {
+			return apperrors.NewConflict(fmt.Sprintf("Lead %d already has appointment %d on %s; reschedule it instead",
+				leadID, current.AppointmentID, current.Describe()), nil)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := reserveSlot(repo, *slot, date); err != nil {
+			return err
+		}
+		assigned := lead
+		if lab != nil {
+			var err error
+			if assigned, err = writeLabAssignment(leadRepo, historyRepo, outbox, s.workflow, lead, *lab, actor); err != nil {
+				return err
+			}
+		}
+		if err := repo.Create(appointment); err != nil {
+			return err
+		}
+		updated := *assigned
+		updated.LeadStatusID = domain.LeadStatusScheduled
+		updated.LastUpdatedBy = actor.UserID
+		updated.LastUpdatedOn = now
+		if err := leadRepo.Update(&updated); err != nil {
+			return err
+		}
+		err := historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionAppointmentBook,
+			Reason:        fmt.Sprintf("Appointment %d booked for %s", appointment.AppointmentID, appointment.Describe()),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       domain.DiffLeads(*assigned, updated),
+		})
+		if err != nil {
+			return err
+		}
+		return recordLeadEvents(outbox, s.workflow, assigned, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if lab != nil {
+		s.routing.QueueOrder(leadID, actor)
+	}
+	return appointment, nil
+}
+
+func (s *appointmentService) ListLeadAppointments(leadID int64, scope domain.TenantScope) ([]domain.Appointment, error) {
+	if _, err := s.findLead(leadID, scope); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByLeadID(leadID)
+}
+
+// RescheduleAppointment moves a booked appointment to slotID on date, subject to the new slot's
+// capacity. The lead stays Scheduled.
+func (s *appointmentService) RescheduleAppointment(id, slotID int64, date time.Time, actor domain.Actor, scope domain.TenantScope) (*domain.Appointment, error) {
+	appointment, lead, err := s.findBookedAppointment(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	if appointment.SlotID == slotID && appointment.AppointmentDate.Equal(date) {
+		return nil, apperrors.NewBadRequest("The appointment is already booked in that slot", nil)
+	}
+	slot, err := s.bookableSlot(lead, slotID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := appointment.Describe()
+	updated := *appointment
+	updated.SlotID = slot.SlotID
+	updated.AppointmentDate = date
+	updated.StartTime = slot.StartTime
+	updated.EndTime = slot.EndTime
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = time.Now()
+
+	err = s.uow.WithinTransaction(func(repo repository.AppointmentRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, _ repository.OutboxRepository) error {
+		if err := reserveSlot(repo, *slot, date); err != nil {
+			return err
+		}
+		if err := repo.Update(&updated); err != nil {
+			return err
+		}
+		return historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        lead.LeadID,
+			Action:        domain.LeadActionAppointmentReschedule,
+			Reason:        fmt.Sprintf("Appointment %d moved from %s to %s", id, previous, updated.Describe()),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// CancelAppointment cancels a booked appointment and, when the workflow allows it, moves the
+// Scheduled lead back to Contacted so it can be booked again.
+func (s *appointmentService) CancelAppointment(id int64, reason string, actor domain.Actor, scope domain.TenantScope) (*domain.Appointment, error) {
+	appointment, lead, err := s.findBookedAppointment(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	updated := *appointment
+	updated.Status = domain.AppointmentStatusCancelled
+	updated.CancelReason = reason
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = now
+
+	updatedLead := *lead
+	if s.workflow.Normalize(lead.LeadStatusID) == domain.LeadStatusScheduled &&
+		s.workflow.CanTransition(domain.LeadStatusScheduled, domain.LeadStatusContacted) {
+		updatedLead.LeadStatusID = domain.LeadStatusContacted
+		updatedLead.LastUpdatedBy = actor.UserID
+		updatedLead.LastUpdatedOn = now
+	}
+	changes := domain.DiffLeads(*lead, updatedLead)
+
+	err = s.uow.WithinTransaction(func(repo repository.AppointmentRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		if err := repo.Update(&updated); err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := leadRepo.Update(&updatedLead); err != nil {
+				return err
+			}
+		}
+		err := historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        lead.LeadID,
+			Action:        domain.LeadActionAppointmentCancel,
+			Reason:        fmt.Sprintf("Appointment %d on %s cancelled: %s", id, appointment.Describe(), reason),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       changes,
+		})
+		if err != nil {
+			return err
+		}
+		return recordLeadEvents(outbox, s.workflow, lead, updatedLead)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ListLabAppointments is the lab's collection list for one day. Lab users only see their own lab.
+func (s *appointmentService) ListLabAppointments(labID int64, date time.Time, scope domain.TenantScope) ([]dto.LabAppointment, error) {
+	if scope.ClientID != 0 || (scope.LabID != 0 && scope.LabID != labID) {
+		return nil, apperrors.NewForbidden("You can only view your own lab's appointments", nil)
+	}
+	if _, err := s.findLab(labID); err != nil {
+		return nil, err
+	}
+	appointments, err := s.repo.FindByLabAndDate(labID, date)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]dto.LabAppointment, len(appointments))
+	if len(appointments) == 0 {
+		return result, nil
+	}
+	leadIDs := make([]int64, len(appointments))
+	for i, a := range appointments {
+		leadIDs[i] = a.LeadID
+	}
+	leads, err := s.leadRepo.FindByIDs(leadIDs)
+	if err != nil {
+		return nil, err
+	}
+	leadsByID := make(map[int64]domain.Lead, len(leads))
+	for _, l := range leads {
+		leadsByID[l.LeadID] = l
+	}
+	packageNames := make(map[int]string)
+	for i, a := range appointments {
+		entry := dto.LabAppointment{Appointment: a}
+		if l, ok := leadsByID[a.LeadID]; ok {
+			entry.PatientName = l.PatientName
+			entry.ContactNumber = l.ContactNumber
+			entry.Address = l.Address
+			entry.PackageID = l.PackageID
+			name, seen := packageNames[l.PackageID]
+			if !seen {
+				if pkg, _ := s.packageRepo.FindByID(l.PackageID); pkg != nil {
+					name = pkg.PackageName
+				}
+				packageNames[l.PackageID] = name
+			}
+			entry.PackageName = name
+		}
+		result[i] = entry
+	}
+	return result, nil
+}
+
+// bookableSlot loads slotID and checks it can take the lead on date: the slot is active, belongs to
+// the lead's lab (if one is assigned), serves the lead's pincode and the date is not in the past.
+func (s *appointmentService) bookableSlot(lead *domain.Lead, slotID int64, date time.Time) (*domain.LabSlot, error) {
+	today, _ := time.Parse(domain.AppointmentDateLayout, time.Now().Format(domain.AppointmentDateLayout))
+	if date.Before(today) {
+		return nil, apperrors.NewBadRequest("Appointments cannot be booked in the past", nil)
+	}
+	slot, err := s.slotRepo.FindByID(slotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Slot not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	if !slot.IsActive {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Slot %d is inactive", slotID), nil)
+	}
+	if lead.LabID != nil && *lead.LabID != slot.LabID {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Lead %d is assigned to lab %d; book one of that lab's slots", lead.LeadID, *lead.LabID), nil)
+	}
+	if !slot.Serves(lead.Pincode) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Slot %d is reserved for pincode %s", slotID, slot.Pincode), nil)
+	}
+	lab, err := s.findLab(slot.LabID)
+	if err != nil {
+		return nil, err
+	}
+	if lab.IsActive == nil || !*lab.IsActive {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Lab %d is inactive", lab.LabID), nil)
+	}
+	if !lab.CollectsFrom(lead.Pincode) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Lab %d does not collect from pincode %s", lab.LabID, lead.Pincode), nil)
+	}
+	return slot, nil
+}
+
+// reserveSlot locks the slot and checks it still has capacity on date. Call inside the transaction
+// that creates or moves the appointment.
+func reserveSlot(repo repository.AppointmentRepository, slot domain.LabSlot, date time.Time) error {
+	if err := repo.LockSlot(slot.SlotID); err != nil {
+		return err
+	}
+	booked, err := repo.CountBooked(slot.SlotID, date)
+	if err != nil {
+		return err
+	}
+	if booked >= slot.Capacity {
+		return apperrors.NewConflict(fmt.Sprintf("Slot %s on %s is full", slot.Window(), date.Format(domain.AppointmentDateLayout)), nil)
+	}
+	return nil
+}
+
+func validateSlot(lab domain.Lab, req dto.LabSlotRequest) error {
+	start, err := time.Parse(slotTimeLayout, req.StartTime)
+	if err != nil {
+		return apperrors.NewBadRequest("startTime must be HH:MM", err)
+	}
+	end, err := time.Parse(slotTimeLayout, req.EndTime)
+	if err != nil {
+		return apperrors.NewBadRequest("endTime must be HH:MM", err)
+	}
+	if !end.After(start) {
+		return apperrors.NewBadRequest("endTime must be after startTime", nil)
+	}
+	if req.Pincode != "" && !lab.CollectsFrom(req.Pincode) {
+		return apperrors.NewBadRequest(fmt.Sprintf("Lab %d does not collect from pincode %s", lab.LabID, req.Pincode), nil)
+	}
+	return nil
+}
+
+func (s *appointmentService) findLab(id int64) (*domain.Lab, error) {
+	lab, err := s.labRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lab not found", err)
+	}
+	return lab, err
+}
+
+func (s *appointmentService) findLead(id int64, scope domain.TenantScope) (*domain.Lead, error) {
+	lead, err := s.leadRepo.WithScope(scope).FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	}
+	return lead, err
+}
+
+// findBookedAppointment loads a booked appointment and its lead; appointments of leads outside the
+// caller's scope are reported as not found.
+func (s *appointmentService) findBookedAppointment(id int64, scope domain.TenantScope) (*domain.Appointment, *domain.Lead, error) {
+	appointment, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, apperrors.NewNotFound("Appointment not found", err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	lead, err := s.leadRepo.WithScope(scope).FindByID(appointment.LeadID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, apperrors.NewNotFound("Appointment not found", err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if appointment.Status != domain.AppointmentStatusBooked {
+		return nil, nil, apperrors.NewConflict(fmt.Sprintf("Appointment %d is %s", id, appointment.Status), nil)
+	}
+	return appointment, lead, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+type AuditService interface {
+	Record(entityType string, entityID int64, action string, actor domain.Actor, before, after interface{})
+	List(filter repository.AuditListFilter) ([]domain.AuditEntry, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// Record stores an audit entry with before/after snapshots (nil for none). Auditing is best-effort:
+// the change it describes has already been committed, so failures are logged rather than returned.
+func (s *auditService) Record(entityType string, entityID int64, action string, actor domain.Actor, before, after interface{}) {
+	entry := &domain.AuditEntry{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		ActorID:    actor.UserID,
+		ActorType:  actor.UserType,
+		RequestID:  actor.RequestID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+	}
+	if err := s.repo.Create(entry); err != nil {
+		log.Printf(`{"timestamp":"%s","level":"error","event":"audit_write_failed","entity_type":%q,"entity_id":%d,"action":%q,"request_id":%q,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339), entityType, entityID, action, actor.RequestID, err.Error())
+	}
+}
+
+func (s *auditService) List(filter repository.AuditListFilter) ([]domain.AuditEntry, int64, error) {
+	return s.repo.List(filter)
+}
+
+func auditSnapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
@@ -13,44 +13,52 @@ import (
 )
 
 type ClientLocationService interface {
-	GetByClientID(clientID int64) ([]domain.ClientLocation, error)
-	GetByID(id int64) (*domain.ClientLocation, error)
-	Create(l *domain.ClientLocation, createdBy int64) error
-	Update(id int64, update *dto.ClientLocationUpdateRequest, lastUpdatedBy int64) (*domain.ClientLocation, error)
-	Delete(id int64) error
+	GetByClientID(clientID int64, scope domain.TenantScope) ([]domain.ClientLocation, error)
+	GetByID(id int64, scope domain.TenantScope) (*domain.ClientLocation, error)
+	Create(l *domain.ClientLocation, actor domain.Actor, scope domain.TenantScope) error
+	Update(id int64, update *dto.ClientLocationUpdateRequest, actor domain.Actor, scope domain.TenantScope) (*domain.ClientLocation, error)
+	Delete(id int64, actor domain.Actor, scope domain.TenantScope) error
 }
 
 type clientLocationService struct {
-	repo repository.ClientLocationRepository
+	repo  repository.ClientLocationRepository
+	audit AuditService
 }
 
-func NewClientLocationService(repo repository.ClientLocationRepository) ClientLocationService {
-	return &clientLocationService{repo: repo}
+func NewClientLocationService(repo repository.ClientLocationRepository, audit AuditService) ClientLocationService {
+	return &clientLocationService{repo: repo, audit: audit}
 }
 
-func (s *clientLocationService) GetByClientID(clientID int64) ([]domain.ClientLocation, error) {
-	return s.repo.FindByClientID(clientID)
+func (s *clientLocationService) GetByClientID(clientID int64, scope domain.TenantScope) ([]domain.ClientLocation, error) {
+	return s.repo.WithScope(scope).FindByClientID(clientID)
 }
 
-func (s *clientLocationService) GetByID(id int64) (*domain.ClientLocation, error) {
-	loc, err := s.repo.FindByID(id)
+func (s *clientLocationService) GetByID(id int64, scope domain.TenantScope) (*domain.ClientLocation, error) {
+	loc, err := s.repo.WithScope(scope).FindByID(id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, apperrors.NewNotFound("Client location not found", err)
 	}
 	return loc, err
 }
 
-func (s *clientLocationService) Create(l *domain.ClientLocation, createdBy int64) error {
+func (s *clientLocationService) Create(l *domain.ClientLocation, actor domain.Actor, scope domain.TenantScope) error {
+	if !scope.AllowsClient(l.ClientID) {
+		return apperrors.NewForbidden("You can only manage locations of your own client account", nil)
+	}
 	now := time.Now()
-	l.CreatedBy = createdBy
+	l.CreatedBy = actor.UserID
 	l.CreatedOn = now
-	l.LastUpdatedBy = createdBy
+	l.LastUpdatedBy = actor.UserID
 	l.LastUpdatedOn = now
-	return s.repo.Create(l)
+	if err := s.repo.Create(l); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityClientLocation, l.ClientLocationID, domain.AuditActionCreate, actor, nil, l)
+	return nil
 }
 
-func (s *clientLocationService) Update(id int64, update *dto.ClientLocationUpdateRequest, lastUpdatedBy int64) (*domain.ClientLocation, error) {
-	existing, err := s.repo.FindByID(id)
+func (s *clientLocationService) Update(id int64, update *dto.ClientLocationUpdateRequest, actor domain.Actor, scope domain.TenantScope) (*domain.ClientLocation, error) {
+	existing, err := s.repo.WithScope(scope).FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperrors.NewNotFound("Client location not found", err)
@@ -74,21 +82,27 @@ func (s *clientLocationService) Update(id int64, update *dto.ClientLocationUpdat
 		l.IsActive = *update.IsActive
 	}
 	l.ClientLocationID = id
-	l.LastUpdatedBy = lastUpdatedBy
+	l.LastUpdatedBy = actor.UserID
 	l.LastUpdatedOn = time.Now()
 	if err := s.repo.Update(&l); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityClientLocation, id, domain.AuditActionUpdate, actor, existing, &l)
 	return &l, nil
 }
 
-func (s *clientLocationService) Delete(id int64) error {
-	exists, err := s.repo.ExistsByID(id)
+func (s *clientLocationService) Delete(id int64, actor domain.Actor, scope domain.TenantScope) error {
+	repo := s.repo.WithScope(scope)
+	existing, err := repo.FindByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NewNotFound("Client location not found", err)
+		}
 		return err
 	}
-	if !exists {
-		return apperrors.NewNotFound("Client location not found", gorm.ErrRecordNotFound)
+	if err := repo.Delete(id); err != nil {
+		return err
 	}
-	return s.repo.Delete(id)
+	s.audit.Record(domain.AuditEntityClientLocation, id, domain.AuditActionDelete, actor, existing, nil)
+	return nil
 }
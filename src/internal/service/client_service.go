@@ -16,20 +16,21 @@ type ClientService interface {
 	ListClients(filter repository.ClientListFilter) ([]domain.Client, int64, error)
 	GetClientByID(id int64) (*domain.Client, error)
 	GetClientByContactNumber(contactNumber string) (*domain.Client, error)
-	CreateClient(c *domain.Client, createdBy int64) error
-	UpdateClient(id int64, update *dto.ClientUpdateRequest, lastUpdatedBy int64) (*domain.Client, error)
-	DeleteClient(id int64) error
+	CreateClient(c *domain.Client, actor domain.Actor) error
+	UpdateClient(id int64, update *dto.ClientUpdateRequest, actor domain.Actor) (*domain.Client, error)
+	DeleteClient(id int64, actor domain.Actor) error
 	GetActiveClients() ([]domain.Client, error)
 	GetClientsByCity(cityID int8) ([]domain.Client, error)
 	GetClientsByState(stateID int8) ([]domain.Client, error)
 }
 
 type clientService struct {
-	repo repository.ClientRepository
+	repo  repository.ClientRepository
+	audit AuditService
 }
 
-func NewClientService(repo repository.ClientRepository) ClientService {
-	return &clientService{repo: repo}
+func NewClientService(repo repository.ClientRepository, audit AuditService) ClientService {
+	return &clientService{repo: repo, audit: audit}
 }
 
 func (s *clientService) ListClients(filter repository.ClientListFilter) ([]domain.Client, int64, error) {
@@ -52,16 +53,20 @@ func (s *clientService) GetClientByContactNumber(contactNumber string) (*domain.
 	return client, err
 }
 
-func (s *clientService) CreateClient(c *domain.Client, createdBy int64) error {
+func (s *clientService) CreateClient(c *domain.Client, actor domain.Actor) error {
 	now := time.Now()
-	c.CreatedBy = createdBy
+	c.CreatedBy = actor.UserID
 	c.CreatedOn = now
-	c.LastUpdatedBy = createdBy
+	c.LastUpdatedBy = actor.UserID
 	c.LastUpdatedOn = now
-	return s.repo.Create(c)
+	if err := s.repo.Create(c); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityClient, c.ClientID, domain.AuditActionCreate, actor, nil, c)
+	return nil
 }
 
-func (s *clientService) UpdateClient(id int64, update *dto.ClientUpdateRequest, lastUpdatedBy int64) (*domain.Client, error) {
+func (s *clientService) UpdateClient(id int64, update *dto.ClientUpdateRequest, actor domain.Actor) (*domain.Client, error) {
 	existing, err := s.repo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -136,23 +141,28 @@ func (s *clientService) UpdateClient(id int64, update *dto.ClientUpdateRequest,
 	}
 
 	c.ClientID = id
-	c.LastUpdatedBy = lastUpdatedBy
+	c.LastUpdatedBy = actor.UserID
 	c.LastUpdatedOn = time.Now()
 	if err := s.repo.Update(&c); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityClient, id, domain.AuditActionUpdate, actor, existing, &c)
 	return &c, nil
 }
 
-func (s *clientService) DeleteClient(id int64) error {
-	exists, err := s.repo.ExistsByID(id)
+func (s *clientService) DeleteClient(id int64, actor domain.Actor) error {
+	existing, err := s.repo.FindByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NewNotFound("Client not found", err)
+		}
 		return err
 	}
-	if !exists {
-		return apperrors.NewNotFound("Client not found", gorm.ErrRecordNotFound)
+	if err := s.repo.Delete(id); err != nil {
+		return err
 	}
-	return s.repo.Delete(id)
+	s.audit.Record(domain.AuditEntityClient, id, domain.AuditActionDelete, actor, existing, nil)
+	return nil
 }
 
 func (s *clientService) GetActiveClients() ([]domain.Client, error) {
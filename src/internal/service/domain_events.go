@@ -0,0 +1,89 @@
+package service
+
+import (
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+// recordLeadEvents writes the domain events a lead change raises to the outbox: LeadCreated for a new
+// lead (before is nil), LeadUpdated when other fields changed and LeadStatusChanged when the status
+// moved. Call it inside the transaction that saves the lead.
+func recordLeadEvents(outbox repository.OutboxRepository, workflow *domain.LeadStatusWorkflow, before *domain.Lead, after domain.Lead) error {
+	if before == nil {
+		return recordEvent(outbox, domain.EventLeadCreated, domain.AggregateLead, after.LeadID, domain.LeadCreated{
+			LeadID:      after.LeadID,
+			ClientID:    after.ClientID,
+			LabID:       after.LabID,
+			PackageID:   after.PackageID,
+			PatientCode: after.PatientCode,
+			StatusID:    workflow.Normalize(after.LeadStatusID),
+		})
+	}
+	from, to := workflow.Normalize(before.LeadStatusID), workflow.Normalize(after.LeadStatusID)
+	var fields []string
+	for _, change := range domain.DiffLeads(*before, after) {
+		if change.Field != "LeadStatusID" {
+			fields = append(fields, change.Field)
+		}
+	}
+	if len(fields) > 0 {
+		err := recordEvent(outbox, domain.EventLeadUpdated, domain.AggregateLead, after.LeadID, domain.LeadUpdated{
+			LeadID:      after.LeadID,
+			ClientID:    after.ClientID,
+			LabID:       after.LabID,
+			PackageID:   after.PackageID,
+			PatientCode: after.PatientCode,
+			StatusID:    to,
+			Fields:      fields,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if from == to {
+		return nil
+	}
+	return recordEvent(outbox, domain.EventLeadStatusChanged, domain.AggregateLead, after.LeadID, domain.LeadStatusChanged{
+		LeadID:       after.LeadID,
+		ClientID:     after.ClientID,
+		LabID:        after.LabID,
+		PackageID:    after.PackageID,
+		PatientCode:  after.PatientCode,
+		FromStatusID: from,
+		ToStatusID:   to,
+	})
+}
+
+// recordLeadDeleted writes LeadDeleted for lead, which was merged into mergedInto when that is set;
+// call it inside the transaction that deletes the lead.
+func recordLeadDeleted(outbox repository.OutboxRepository, workflow *domain.LeadStatusWorkflow, lead domain.Lead, mergedInto *int64) error {
+	return recordEvent(outbox, domain.EventLeadDeleted, domain.AggregateLead, lead.LeadID, domain.LeadDeleted{
+		LeadID:           lead.LeadID,
+		ClientID:         lead.ClientID,
+		LabID:            lead.LabID,
+		PackageID:        lead.PackageID,
+		PatientCode:      lead.PatientCode,
+		StatusID:         workflow.Normalize(lead.LeadStatusID),
+		MergedIntoLeadID: mergedInto,
+	})
+}
+
+// recordReportReleased writes ReportReleased to the outbox; call it inside the transaction that
+// releases the report.
+func recordReportReleased(outbox repository.OutboxRepository, lead domain.Lead, report domain.LabReport) error {
+	return recordEvent(outbox, domain.EventReportReleased, domain.AggregateLead, lead.LeadID, domain.ReportReleased{
+		LeadID:     lead.LeadID,
+		ClientID:   lead.ClientID,
+		ReportID:   report.ReportID,
+		Version:    report.Version,
+		ReleasedOn: report.ReleasedOn,
+	})
+}
+
+func recordEvent(outbox repository.OutboxRepository, eventType, aggregateType string, aggregateID int64, data interface{}) error {
+	event, err := domain.NewDomainEvent(eventType, aggregateType, aggregateID, data)
+	if err != nil {
+		return err
+	}
+	return outbox.Add(&event)
+}
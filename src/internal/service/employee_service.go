@@ -16,17 +16,18 @@ type EmployeeService interface {
 	GetAll() ([]domain.Employee, error)
 	GetByID(id int64) (*domain.Employee, error)
 	GetByContactNumber(contactNumber string) (*domain.Employee, error)
-	Create(e *domain.Employee, createdBy int64) error
-	Update(id int64, update *dto.EmployeeUpdateRequest, lastUpdatedBy int64) (*domain.Employee, error)
-	Delete(id int64) error
+	Create(e *domain.Employee, actor domain.Actor) error
+	Update(id int64, update *dto.EmployeeUpdateRequest, actor domain.Actor) (*domain.Employee, error)
+	Delete(id int64, actor domain.Actor) error
 }
 
 type employeeService struct {
-	repo repository.EmployeeRepository
+	repo  repository.EmployeeRepository
+	audit AuditService
 }
 
-func NewEmployeeService(repo repository.EmployeeRepository) EmployeeService {
-	return &employeeService{repo: repo}
+func NewEmployeeService(repo repository.EmployeeRepository, audit AuditService) EmployeeService {
+	return &employeeService{repo: repo, audit: audit}
 }
 
 func (s *employeeService) GetAll() ([]domain.Employee, error) {
@@ -49,16 +50,20 @@ func (s *employeeService) GetByContactNumber(contactNumber string) (*domain.Empl
 	return emp, err
 }
 
-func (s *employeeService) Create(e *domain.Employee, createdBy int64) error {
+func (s *employeeService) Create(e *domain.Employee, actor domain.Actor) error {
 	now := time.Now()
-	e.CreatedBy = createdBy
+	e.CreatedBy = actor.UserID
 	e.CreatedOn = now
-	e.LastUpdatedBy = createdBy
+	e.LastUpdatedBy = actor.UserID
 	e.LastUpdatedOn = now
-	return s.repo.Create(e)
+	if err := s.repo.Create(e); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityEmployee, e.UID, domain.AuditActionCreate, actor, nil, e)
+	return nil
 }
 
-func (s *employeeService) Update(id int64, update *dto.EmployeeUpdateRequest, lastUpdatedBy int64) (*domain.Employee, error) {
+func (s *employeeService) Update(id int64, update *dto.EmployeeUpdateRequest, actor domain.Actor) (*domain.Employee, error) {
 	existing, err := s.repo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -95,21 +100,26 @@ func (s *employeeService) Update(id int64, update *dto.EmployeeUpdateRequest, la
 		e.Department = *update.Department
 	}
 	e.UID = id
-	e.LastUpdatedBy = lastUpdatedBy
+	e.LastUpdatedBy = actor.UserID
 	e.LastUpdatedOn = time.Now()
 	if err := s.repo.Update(&e); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityEmployee, id, domain.AuditActionUpdate, actor, existing, &e)
 	return &e, nil
 }
 
-func (s *employeeService) Delete(id int64) error {
-	exists, err := s.repo.ExistsByID(id)
+func (s *employeeService) Delete(id int64, actor domain.Actor) error {
+	existing, err := s.repo.FindByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NewNotFound("Employee not found", err)
+		}
 		return err
 	}
-	if !exists {
-		return apperrors.NewNotFound("Employee not found", gorm.ErrRecordNotFound)
+	if err := s.repo.Delete(id); err != nil {
+		return err
 	}
-	return s.repo.Delete(id)
+	s.audit.Record(domain.AuditEntityEmployee, id, domain.AuditActionDelete, actor, existing, nil)
+	return nil
 }
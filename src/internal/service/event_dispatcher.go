@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+// EventDispatcherSettings tunes outbox dispatch.
+type EventDispatcherSettings struct {
+	MaxAttempts int           // dispatches before an event is marked failed
+	BatchSize   int           // events dispatched per run
+	Lease       time.Duration // how long a claimed event is hidden from other dispatchers
+}
+
+// EventHandler handles one domain event for a subscriber. Delivery is at least once: a handler that
+// fails is called again with the same event (same EventID) after a backoff, and a crash between
+// handling and recording the outcome repeats it too, so handlers must be idempotent.
+type EventHandler func(ctx context.Context, event domain.DomainEvent) error
+
+// EventDispatcher delivers outbox events to in-process subscribers. Each subscriber's success is
+// recorded per event, so a retry only calls the subscribers that have not handled it yet. An event is
+// processed once every interested subscriber handled it and failed when its attempts run out.
+type EventDispatcher interface {
+	// Subscribe registers handler for the given event types, or for every type when none are given.
+	// name identifies the subscriber in the outbox and must stay stable across releases.
+	Subscribe(name string, handler EventHandler, eventTypes ...string)
+	DispatchDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type eventSubscriber struct {
+	name       string
+	handler    EventHandler
+	eventTypes map[string]bool
+}
+
+func (s eventSubscriber) wants(eventType string) bool {
+	return len(s.eventTypes) == 0 || s.eventTypes[eventType]
+}
+
+type eventDispatcher struct {
+	outbox      repository.OutboxRepository
+	settings    EventDispatcherSettings
+	mu          sync.RWMutex
+	subscribers []eventSubscriber
+}
+
+func NewEventDispatcher(outbox repository.OutboxRepository, settings EventDispatcherSettings) EventDispatcher {
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 10
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 100
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = time.Minute
+	}
+	return &eventDispatcher{outbox: outbox, settings: settings}
+}
+
+func (d *eventDispatcher) Subscribe(name string, handler EventHandler, eventTypes ...string) {
+	types := make(map[string]bool, len(eventTypes))
+	for _, t := range eventTypes {
+		types[t] = true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, eventSubscriber{name: name, handler: handler, eventTypes: types})
+}
+
+// DispatchDue dispatches the events that are due, one attempt each, and returns how many it attempted.
+func (d *eventDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := d.outbox.FindDue(now, d.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	attempted := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		claimed, err := d.outbox.Claim(due[i].EventID, now, now.Add(d.settings.Lease))
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := d.dispatch(ctx, due[i]); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// Run dispatches due events every interval until ctx is cancelled.
+func (d *eventDispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DispatchDue(ctx); err != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"outbox_dispatch_failed","error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), err.Error())
+			}
+		}
+	}
+}
+
+// dispatch hands the event to the subscribers that still need it and records the outcome. Only
+// failures to read or record are returned; handler failures schedule a retry.
+func (d *eventDispatcher) dispatch(ctx context.Context, event domain.DomainEvent) error {
+	handled, err := d.outbox.HandledBy(event.EventID)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(handled))
+	for _, name := range handled {
+		done[name] = true
+	}
+
+	d.mu.RLock()
+	subscribers := d.subscribers
+	d.mu.RUnlock()
+
+	var failure error
+	for _, subscriber := range subscribers {
+		if done[subscriber.name] || !subscriber.wants(event.Type) {
+			continue
+		}
+		if err := callEventHandler(ctx, subscriber.handler, event); err != nil {
+			failure = errors.Join(failure, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		if err := d.outbox.MarkHandled(event.EventID, subscriber.name); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	event.Attempts++
+	switch {
+	case failure == nil:
+		event.Status = domain.OutboxStatusProcessed
+		event.NextAttemptOn = nil
+		event.ProcessedOn = &now
+		event.LastError = ""
+	case event.Attempts >= d.settings.MaxAttempts:
+		event.Status = domain.OutboxStatusFailed
+		event.NextAttemptOn = nil
+		event.LastError = truncateError(failure)
+		log.Printf(`{"timestamp":"%s","level":"warn","event":"outbox_event_failed","event_id":%d,"event_type":%q,"error":%q}`,
+			now.UTC().Format(time.RFC3339), event.EventID, event.Type, event.LastError)
+	default:
+		next := now.Add(domain.OutboxRetryDelay(event.Attempts))
+		event.NextAttemptOn = &next
+		event.LastError = truncateError(failure)
+	}
+	return d.outbox.Update(&event)
+}
+
+// callEventHandler runs handler, turning a panic into an error so one bad subscriber cannot stop
+// the dispatcher.
+func callEventHandler(ctx context.Context, handler EventHandler, event domain.DomainEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
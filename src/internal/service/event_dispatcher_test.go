@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+// memOutbox is an in-memory OutboxRepository with the same claim and status rules as the SQL one.
+type memOutbox struct {
+	repository.OutboxRepository
+	mu                  sync.Mutex
+	nextID              int64
+	events              map[int64]domain.DomainEvent
+	handled             map[int64][]string
+	markHandledFailures int // MarkHandled calls that fail, simulating a crash after a handler ran
+}
+
+func newMemOutbox() *memOutbox {
+	return &memOutbox{events: make(map[int64]domain.DomainEvent), handled: make(map[int64][]string)}
+}
+
+func (o *memOutbox) Add(event *domain.DomainEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.nextID++
+	event.EventID = o.nextID
+	o.events[event.EventID] = *event
+	return nil
+}
+
+func (o *memOutbox) FindDue(now time.Time, limit int) ([]domain.DomainEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var due []domain.DomainEvent
+	for _, e := range o.events {
+		if e.Status == domain.OutboxStatusPending && e.NextAttemptOn != nil && !e.NextAttemptOn.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].EventID < due[j].EventID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (o *memOutbox) Claim(id int64, now, leaseUntil time.Time) (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	e, ok := o.events[id]
+	if !ok || e.Status != domain.OutboxStatusPending || e.NextAttemptOn == nil || e.NextAttemptOn.After(now) {
+		return false, nil
+	}
+	e.NextAttemptOn = &leaseUntil
+	o.events[id] = e
+	return true, nil
+}
+
+func (o *memOutbox) Update(event *domain.DomainEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events[event.EventID] = *event
+	return nil
+}
+
+func (o *memOutbox) HandledBy(id int64) ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.handled[id]...), nil
+}
+
+func (o *memOutbox) MarkHandled(id int64, subscriber string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.markHandledFailures > 0 {
+		o.markHandledFailures--
+		return errors.New("connection reset")
+	}
+	o.handled[id] = append(o.handled[id], subscriber)
+	return nil
+}
+
+// elapse makes every pending event due again, as if its retry delay or lease had passed.
+func (o *memOutbox) elapse() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	past := time.Now().Add(-time.Second)
+	for id, e := range o.events {
+		if e.Status == domain.OutboxStatusPending {
+			e.NextAttemptOn = &past
+			o.events[id] = e
+		}
+	}
+}
+
+func TestEventDispatcherRedelivery(t *testing.T) {
+	type subscriber struct {
+		name      string
+		failTimes int  // calls that fail before the handler succeeds
+		panics    bool // failing calls panic instead of returning an error
+		types     []string
+	}
+	tests := []struct {
+		name                string
+		subscribers         []subscriber
+		maxAttempts         int
+		markHandledFailures int
+		rounds              int
+		wantCalls           map[string]int
+		wantStatus          string
+		wantAttempts        int
+	}{
+		{
+			name:         "handled first time",
+			subscribers:  []subscriber{{name: "a"}},
+			rounds:       3,
+			wantCalls:    map[string]int{"a": 1},
+			wantStatus:   domain.OutboxStatusProcessed,
+			wantAttempts: 1,
+		},
+		{
+			name:         "failed handler is called again with the same event",
+			subscribers:  []subscriber{{name: "a", failTimes: 2}},
+			rounds:       5,
+			wantCalls:    map[string]int{"a": 3},
+			wantStatus:   domain.OutboxStatusProcessed,
+			wantAttempts: 3,
+		},
+		{
+			name:         "retry skips subscribers that already handled the event",
+			subscribers:  []subscriber{{name: "a"}, {name: "b", failTimes: 1}},
+			rounds:       3,
+			wantCalls:    map[string]int{"a": 1, "b": 2},
+			wantStatus:   domain.OutboxStatusProcessed,
+			wantAttempts: 2,
+		},
+		{
+			name:         "panicking handler is retried",
+			subscribers:  []subscriber{{name: "a", failTimes: 1, panics: true}},
+			rounds:       3,
+			wantCalls:    map[string]int{"a": 2},
+			wantStatus:   domain.OutboxStatusProcessed,
+			wantAttempts: 2,
+		},
+		{
+			name:                "crash after handling redelivers the event",
+			subscribers:         []subscriber{{name: "a"}},
+			markHandledFailures: 1,
+			rounds:              3,
+			wantCalls:           map[string]int{"a": 2},
+			wantStatus:          domain.OutboxStatusProcessed,
+			wantAttempts:        1,
+		},
+		{
+			name:         "event fails after max attempts",
+			subscribers:  []subscriber{{name: "a", failTimes: 10}},
+			maxAttempts:  3,
+			rounds:       6,
+			wantCalls:    map[string]int{"a": 3},
+			wantStatus:   domain.OutboxStatusFailed,
+			wantAttempts: 3,
+		},
+		{
+			name:         "subscribers of other types are not called",
+			subscribers:  []subscriber{{name: "a"}, {name: "b", types: []string{domain.EventReportReleased}}},
+			rounds:       2,
+			wantCalls:    map[string]int{"a": 1},
+			wantStatus:   domain.OutboxStatusProcessed,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := newMemOutbox()
+			outbox.markHandledFailures = tt.markHandledFailures
+			dispatcher := NewEventDispatcher(outbox, EventDispatcherSettings{MaxAttempts: tt.maxAttempts})
+
+			calls := make(map[string]int)
+			var seen []int64
+			for _, sub := range tt.subscribers {
+				dispatcher.Subscribe(sub.name, func(_ context.Context, event domain.DomainEvent) error {
+					calls[sub.name]++
+					seen = append(seen, event.EventID)
+					if calls[sub.name] <= sub.failTimes {
+						if sub.panics {
+							panic("handler bug")
+						}
+						return errors.New("endpoint unavailable")
+					}
+					return nil
+				}, sub.types...)
+			}
+
+			event, err := domain.NewDomainEvent(domain.EventLeadCreated, domain.AggregateLead, 42, domain.LeadCreated{LeadID: 42})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := outbox.Add(&event); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.rounds; i++ {
+				_, _ = dispatcher.DispatchDue(context.Background())
+				outbox.elapse()
+			}
+
+			for name, want := range tt.wantCalls {
+				if calls[name] != want {
+					t.Errorf("subscriber %s called %d times, want %d", name, calls[name], want)
+				}
+			}
+			for name, got := range calls {
+				if _, ok := tt.wantCalls[name]; !ok {
+					t.Errorf("subscriber %s called %d times, want none", name, got)
+				}
+			}
+			for _, id := range seen {
+				if id != event.EventID {
+					t.Errorf("handler got event %d, want %d on every delivery", id, event.EventID)
+				}
+			}
+			stored := outbox.events[event.EventID]
+			if stored.Status != tt.wantStatus || stored.Attempts != tt.wantAttempts {
+				t.Errorf("event status %s after %d attempts, want %s after %d", stored.Status, stored.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/fhir"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// FHIRService exports leads and their results as FHIR R4 bundles for hospital and insurer partners.
+// base is the absolute URL of the FHIR endpoints (e.g. https://host/api/v1/fhir) used for the
+// bundle entries' full URLs.
+type FHIRService interface {
+	ServiceRequestBundle(leadID int64, base string, scope domain.TenantScope) (*fhir.Bundle, error)
+	DiagnosticReportBundle(leadID int64, base string, scope domain.TenantScope) (*fhir.Bundle, error)
+}
+
+type fhirService struct {
+	leadRepo    repository.LeadRepository
+	patientRepo repository.PatientRepository
+	packageRepo repository.PackageRepository
+	testRepo    repository.TestRepository
+	paramRepo   repository.TestParameterRepository
+	resultRepo  repository.TestResultRepository
+	workflow    *domain.LeadStatusWorkflow
+}
+
+func NewFHIRService(
+	leadRepo repository.LeadRepository,
+	patientRepo repository.PatientRepository,
+	packageRepo repository.PackageRepository,
+	testRepo repository.TestRepository,
+	paramRepo repository.TestParameterRepository,
+	resultRepo repository.TestResultRepository,
+	workflow *domain.LeadStatusWorkflow,
+) FHIRService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	return &fhirService{
+		leadRepo:    leadRepo,
+		patientRepo: patientRepo,
+		packageRepo: packageRepo,
+		testRepo:    testRepo,
+		paramRepo:   paramRepo,
+		resultRepo:  resultRepo,
+		workflow:    workflow,
+	}
+}
+
+// leadOrder is a lead exported as an order: the patient, the package-level ServiceRequest and one
+// ServiceRequest per test of the package, based on it.
+type leadOrder struct {
+	lead     *domain.Lead
+	patient  *fhir.Patient
+	request  *fhir.ServiceRequest
+	items    []*fhir.ServiceRequest
+	itemByID map[int]*fhir.ServiceRequest
+}
+
+// ServiceRequestBundle returns the lead's Patient, its package ServiceRequest and a ServiceRequest
+// item per test of the package.
+func (s *fhirService) ServiceRequestBundle(leadID int64, base string, scope domain.TenantScope) (*fhir.Bundle, error) {
+	order, err := s.buildOrder(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	resources := []fhir.Resource{order.patient, order.request}
+	for _, item := range order.items {
+		resources = append(resources, item)
+	}
+	return fhir.NewCollectionBundle(order.request.ID, base, resources...), nil
+}
+
+// DiagnosticReportBundle returns the lead's DiagnosticReport with an Observation per recorded result,
+// plus the Patient and ServiceRequests they refer to. Results are preliminary until the lead is
+// delivered.
+func (s *fhirService) DiagnosticReportBundle(leadID int64, base string, scope domain.TenantScope) (*fhir.Bundle, error) {
+	order, err := s.buildOrder(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.resultRepo.FindByLeadID(leadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, apperrors.NewNotFound("No results recorded for lead", nil)
+	}
+	var testIDs []int
+	for _, r := range results {
+		if !containsInt(testIDs, r.TestID) {
+			testIDs = append(testIDs, r.TestID)
+		}
+	}
+	params, err := s.paramRepo.FindByTestIDs(testIDs)
+	if err != nil {
+		return nil, err
+	}
+	paramsByID := make(map[int64]domain.TestParameter, len(params))
+	for _, p := range params {
+		paramsByID[p.ParameterID] = p
+	}
+
+	lead := order.lead
+	status := s.resultStatus(lead.LeadStatusID)
+	subject := fhir.LocalReference(order.patient)
+	performer := labPerformer(lead)
+
+	var observations []*fhir.Observation
+	abnormal := 0
+	for _, r := range results {
+		param := paramsByID[r.ParameterID]
+		reportedOn := r.ReportedOn.UTC()
+		obs := &fhir.Observation{
+			ResourceType:      "Observation",
+			ID:                fmt.Sprintf("result-%d", r.ResultID),
+			Identifier:        []fhir.Identifier{{System: fhir.SystemLead, Value: fmt.Sprintf("%d-%d", lead.LeadID, r.ParameterID)}},
+			Status:            status,
+			Category:          []fhir.CodeableConcept{fhir.LaboratoryCategory()},
+			Code:              fhir.CodeableConcept{Coding: []fhir.Coding{{System: fhir.SystemParameter, Code: strconv.FormatInt(r.ParameterID, 10), Display: param.Analyte}}, Text: param.Analyte},
+			Subject:           subject,
+			EffectiveDateTime: &reportedOn,
+			Issued:            &reportedOn,
+			Performer:         performer,
+			ValueQuantity:     &fhir.Quantity{Value: r.Value, Unit: r.Unit},
+		}
+		if item, ok := order.itemByID[r.TestID]; ok {
+			obs.BasedOn = []fhir.Reference{fhir.LocalReference(item)}
+		}
+		if interp := fhir.Interpretation(r.Flag); interp != nil {
+			obs.Interpretation = []fhir.CodeableConcept{*interp}
+		}
+		if r.ReferenceLow != nil || r.ReferenceHigh != nil {
+			var rr fhir.ObservationReferenceRange
+			if r.ReferenceLow != nil {
+				rr.Low = &fhir.Quantity{Value: *r.ReferenceLow, Unit: r.Unit}
+			}
+			if r.ReferenceHigh != nil {
+				rr.High = &fhir.Quantity{Value: *r.ReferenceHigh, Unit: r.Unit}
+			}
+			obs.ReferenceRange = []fhir.ObservationReferenceRange{rr}
+		}
+		if r.Comment != "" {
+			obs.Note = []fhir.Annotation{{Text: r.Comment}}
+		}
+		if r.IsAbnormal() {
+			abnormal++
+		}
+		observations = append(observations, obs)
+	}
+
+	issued := results[0].ReportedOn
+	for _, r := range results {
+		if r.ReportedOn.After(issued) {
+			issued = r.ReportedOn
+		}
+	}
+	issued = issued.UTC()
+	report := &fhir.DiagnosticReport{
+		ResourceType: "DiagnosticReport",
+		ID:           order.request.ID,
+		Identifier:   order.request.Identifier,
+		BasedOn:      []fhir.Reference{fhir.LocalReference(order.request)},
+		Status:       status,
+		Category:     []fhir.CodeableConcept{{Coding: []fhir.Coding{{System: fhir.SystemDiagnosticServiceSection, Code: "LAB", Display: "Laboratory"}}}},
+		Code:         *order.request.Code,
+		Subject:      subject,
+		Issued:       &issued,
+		Performer:    performer,
+		Conclusion:   fmt.Sprintf("%d of %d results outside the reference range", abnormal, len(results)),
+	}
+	resources := []fhir.Resource{report, order.patient, order.request}
+	for _, item := range order.items {
+		resources = append(resources, item)
+	}
+	for _, obs := range observations {
+		report.Result = append(report.Result, fhir.LocalReference(obs))
+		resources = append(resources, obs)
+	}
+	return fhir.NewCollectionBundle(report.ID, base, resources...), nil
+}
+
+func (s *fhirService) buildOrder(leadID int64, scope domain.TenantScope) (*leadOrder, error) {
+	lead, err := s.leadRepo.WithScope(scope).FindByID(leadID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	patient, err := s.patient(lead)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := s.packageRepo.FindByID(lead.PackageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Package not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	testIDs, err := s.packageRepo.FindActiveTestIDs(lead.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	tests, err := s.testRepo.FindByIDs(testIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	leadKey := strconv.FormatInt(lead.LeadID, 10)
+	requisition := &fhir.Identifier{System: fhir.SystemLead, Value: leadKey}
+	status := s.requestStatus(lead.LeadStatusID)
+	subject := fhir.LocalReference(patient)
+	authoredOn := lead.CreatedOn.UTC()
+	lastUpdated := lead.LastUpdatedOn.UTC()
+	requester := fhir.LogicalReference("Organization", fhir.SystemClient, strconv.FormatInt(lead.ClientID, 10), "")
+	performer := labPerformer(lead)
+
+	order := &leadOrder{lead: lead, patient: patient, itemByID: make(map[int]*fhir.ServiceRequest, len(tests))}
+	order.request = &fhir.ServiceRequest{
+		ResourceType: "ServiceRequest",
+		ID:           "lead-" + leadKey,
+		Meta:         &fhir.Meta{LastUpdated: &lastUpdated},
+		Identifier:   []fhir.Identifier{*requisition},
+		Requisition:  requisition,
+		Status:       status,
+		Intent:       "order",
+		Category:     []fhir.CodeableConcept{fhir.LaboratoryCategory()},
+		Code: &fhir.CodeableConcept{
+			Coding: []fhir.Coding{{System: fhir.SystemPackage, Code: strconv.Itoa(pkg.PackageID), Display: pkg.PackageName}},
+			Text:   pkg.PackageName,
+		},
+		Subject:    subject,
+		AuthoredOn: &authoredOn,
+		Requester:  &requester,
+		Performer:  performer,
+	}
+	for _, t := range tests {
+		item := &fhir.ServiceRequest{
+			ResourceType: "ServiceRequest",
+			ID:           fmt.Sprintf("lead-%d-test-%d", lead.LeadID, t.TestID),
+			BasedOn:      []fhir.Reference{fhir.LocalReference(order.request)},
+			Requisition:  requisition,
+			Status:       status,
+			Intent:       "order",
+			Category:     []fhir.CodeableConcept{fhir.LaboratoryCategory()},
+			Code: &fhir.CodeableConcept{
+				Coding: []fhir.Coding{{System: fhir.SystemTest, Code: strconv.Itoa(t.TestID), Display: t.TestName}},
+				Text:   t.TestName,
+			},
+			Subject:    subject,
+			AuthoredOn: &authoredOn,
+			Requester:  &requester,
+			Performer:  performer,
+		}
+		order.items = append(order.items, item)
+		order.itemByID[t.TestID] = item
+	}
+	return order, nil
+}
+
+// patient exports the lead's patient record, or the patient details on the lead itself for leads
+// created before patients existed. Access was already checked through the lead.
+func (s *fhirService) patient(lead *domain.Lead) (*fhir.Patient, error) {
+	p := domain.PatientFromLead(*lead)
+	id := fmt.Sprintf("lead-%d-patient", lead.LeadID)
+	if lead.PatientID != 0 {
+		found, err := s.patientRepo.FindByID(lead.PatientID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if found != nil {
+			p = *found
+		}
+		id = fmt.Sprintf("patient-%d", lead.PatientID)
+	}
+
+	out := &fhir.Patient{ResourceType: "Patient", ID: id, Active: true, Gender: fhir.AdministrativeGender(p.Gender)}
+	if p.PatientID != 0 {
+		out.Identifier = []fhir.Identifier{{System: fhir.SystemPatient, Value: strconv.FormatInt(p.PatientID, 10)}}
+	}
+	if name := strings.TrimSpace(p.FullName); name != "" {
+		out.Name = []fhir.HumanName{{Use: "official", Text: name}}
+	}
+	if phone := strings.TrimSpace(p.ContactNumber); phone != "" {
+		out.Telecom = append(out.Telecom, fhir.ContactPoint{System: "phone", Value: phone, Use: "mobile"})
+	}
+	if email := strings.TrimSpace(p.Emailid); email != "" {
+		out.Telecom = append(out.Telecom, fhir.ContactPoint{System: "email", Value: email})
+	}
+	if address := strings.TrimSpace(p.Address); address != "" || p.Pincode != "" {
+		a := fhir.Address{Use: "home", PostalCode: strings.TrimSpace(p.Pincode), Country: "IN"}
+		if address != "" {
+			a.Line = []string{address}
+		}
+		out.Address = []fhir.Address{a}
+	}
+	return out, nil
+}
+
+// requestStatus maps the lead status to a ServiceRequest status.
+func (s *fhirService) requestStatus(statusID int8) string {
+	switch s.workflow.Normalize(statusID) {
+	case domain.LeadStatusCancelled:
+		return "revoked"
+	case domain.LeadStatusReportReady, domain.LeadStatusDelivered:
+		return "completed"
+	}
+	return "active"
+}
+
+// resultStatus maps the lead status to a DiagnosticReport and Observation status: results are final
+// once the report has been delivered.
+func (s *fhirService) resultStatus(statusID int8) string {
+	switch s.workflow.Normalize(statusID) {
+	case domain.LeadStatusCancelled:
+		return "cancelled"
+	case domain.LeadStatusDelivered:
+		return "final"
+	}
+	return "preliminary"
+}
+
+func labPerformer(lead *domain.Lead) []fhir.Reference {
+	if lead.LabID == nil {
+		return nil
+	}
+	return []fhir.Reference{fhir.LogicalReference("Organization", fhir.SystemLab, strconv.FormatInt(*lead.LabID, 10), "")}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/fhir/fhirtest"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+// The fakes embed the repository interfaces and implement only what fhirService calls.
+
+type fhirLeadRepo struct {
+	repository.LeadRepository
+	lead domain.Lead
+}
+
+func (r fhirLeadRepo) WithScope(domain.TenantScope) repository.LeadRepository { return r }
+func (r fhirLeadRepo) FindByID(int64) (*domain.Lead, error) {
+	lead := r.lead
+	return &lead, nil
+}
+
+type fhirPatientRepo struct {
+	repository.PatientRepository
+	patient domain.Patient
+}
+
+func (r fhirPatientRepo) FindByID(int64) (*domain.Patient, error) {
+	patient := r.patient
+	return &patient, nil
+}
+
+type fhirPackageRepo struct {
+	repository.PackageRepository
+}
+
+func (fhirPackageRepo) FindByID(id int) (*domain.Package, error) {
+	return &domain.Package{PackageID: id, PackageName: "Full Body Checkup", IsActive: true}, nil
+}
+func (fhirPackageRepo) FindActiveTestIDs(int) ([]int, error) { return []int{10, 11}, nil }
+
+type fhirTestRepo struct {
+	repository.TestRepository
+}
+
+func (fhirTestRepo) FindByIDs(ids []int) ([]domain.Test, error) {
+	names := map[int]string{10: "Complete Blood Count", 11: "Lipid Profile"}
+	var tests []domain.Test
+	for _, id := range ids {
+		tests = append(tests, domain.Test{TestID: id, TestName: names[id], IsActive: true})
+	}
+	return tests, nil
+}
+
+type fhirParamRepo struct {
+	repository.TestParameterRepository
+}
+
+func (fhirParamRepo) FindByTestIDs([]int) ([]domain.TestParameter, error) {
+	return []domain.TestParameter{
+		{ParameterID: 100, TestID: 10, Analyte: "Hemoglobin", Unit: "g/dL"},
+		{ParameterID: 110, TestID: 11, Analyte: "LDL Cholesterol", Unit: "mg/dL"},
+	}, nil
+}
+
+type fhirResultRepo struct {
+	repository.TestResultRepository
+	results []domain.TestResult
+}
+
+func (r fhirResultRepo) FindByLeadID(int64) ([]domain.TestResult, error) { return r.results, nil }
+
+func newTestFHIRService(t *testing.T, lead domain.Lead) FHIRService {
+	t.Helper()
+	low, high := 13.0, 17.0
+	reported := time.Date(2024, 5, 2, 9, 30, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	results := []domain.TestResult{
+		{ResultID: 1, LeadID: lead.LeadID, TestID: 10, ParameterID: 100, Value: 12.1, Unit: "g/dL", ReferenceLow: &low, ReferenceHigh: &high, Flag: domain.ResultFlagLow, Comment: "Repeat in 3 months", ReportedOn: reported},
+		{ResultID: 2, LeadID: lead.LeadID, TestID: 11, ParameterID: 110, Value: 96, Unit: "mg/dL", Flag: domain.ResultFlagNormal, ReportedOn: reported},
+	}
+	patient := domain.Patient{PatientID: lead.PatientID, ClientID: lead.ClientID, FullName: "Asha Rao", Age: 42, Gender: "Female", ContactNumber: "9876543210", Emailid: "asha@example.com", Address: "12 MG Road", Pincode: "560001"}
+	return NewFHIRService(fhirLeadRepo{lead: lead}, fhirPatientRepo{patient: patient}, fhirPackageRepo{}, fhirTestRepo{}, fhirParamRepo{}, fhirResultRepo{results: results}, nil)
+}
+
+func TestFHIRBundlesConformToR4(t *testing.T) {
+	validator, err := fhirtest.Load()
+	if err != nil {
+		t.Fatalf("loading structure definitions: %v", err)
+	}
+	labID := int64(7)
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	leads := map[string]domain.Lead{
+		"patient record": {LeadID: 42, ClientID: 3, PatientID: 9, PackageID: 5, LeadStatusID: domain.LeadStatusDelivered, LabID: &labID, CreatedOn: created, LastUpdatedOn: created},
+		"legacy lead":    {LeadID: 43, ClientID: 3, PatientName: "Ravi K", Gender: "M", ContactNumber: "9000000000", PackageID: 5, LeadStatusID: domain.LeadStatusNew, CreatedOn: created, LastUpdatedOn: created},
+	}
+	const base = "https://api.example.com/api/v1/fhir"
+	for name, lead := range leads {
+		svc := newTestFHIRService(t, lead)
+		builds := map[string]func() (interface{}, error){
+			"ServiceRequest": func() (interface{}, error) {
+				return svc.ServiceRequestBundle(lead.LeadID, base, domain.TenantScope{})
+			},
+			"DiagnosticReport": func() (interface{}, error) {
+				return svc.DiagnosticReportBundle(lead.LeadID, base, domain.TenantScope{})
+			},
+		}
+		for kind, build := range builds {
+			t.Run(name+"/"+kind, func(t *testing.T) {
+				bundle, err := build()
+				if err != nil {
+					t.Fatalf("building bundle: %v", err)
+				}
+				data, err := json.Marshal(bundle)
+				if err != nil {
+					t.Fatalf("marshalling bundle: %v", err)
+				}
+				for _, err := range validator.Validate(data) {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// LabEventService receives status events labs push for their leads. Each request is signed with the
+// lab's event secret: X-Lab-Signature is the hex HMAC-SHA256 of "<timestamp>.<nonce>.<body>", where
+// the timestamp (unix seconds) must be within the tolerance and the nonce unused. Events are applied
+// as lead status transitions once per lab event ID; duplicates return the first outcome.
+type LabEventService interface {
+	RotateSecret(labID int64, actor domain.Actor) (*dto.LabEventSecret, error)
+	Authenticate(labID int64, timestamp, nonce, signature string, body []byte) error
+	Apply(labID int64, req dto.LabEventRequest) (*dto.LabEventResult, error)
+}
+
+type labEventService struct {
+	integrationRepo repository.LabIntegrationRepository
+	labRepo         repository.LabRepository
+	uow             repository.LabEventUnitOfWork
+	workflow        *domain.LeadStatusWorkflow
+	audit           AuditService
+	tolerance       time.Duration
+}
+
+func NewLabEventService(
+	integrationRepo repository.LabIntegrationRepository,
+	labRepo repository.LabRepository,
+	uow repository.LabEventUnitOfWork,
+	workflow *domain.LeadStatusWorkflow,
+	audit AuditService,
+	tolerance time.Duration,
+) LabEventService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	return &labEventService{
+		integrationRepo: integrationRepo,
+		labRepo:         labRepo,
+		uow:             uow,
+		workflow:        workflow,
+		audit:           audit,
+		tolerance:       tolerance,
+	}
+}
+
+// RotateSecret generates a new event secret for the lab, replacing any previous one. Labs without an
+// integration get a manual one to hold the secret.
+func (s *labEventService) RotateSecret(labID int64, actor domain.Actor) (*dto.LabEventSecret, error) {
+	if _, err := s.labRepo.FindByID(labID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lab not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := hex.EncodeToString(raw)
+
+	now := time.Now()
+	existing, err := s.integrationRepo.FindByLabID(labID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		integration := &domain.LabIntegration{
+			LabID:         labID,
+			Adapter:       domain.LabAdapterManual,
+			EventSecret:   secret,
+			IsActive:      true,
+			CreatedBy:     actor.UserID,
+			CreatedOn:     now,
+			LastUpdatedBy: actor.UserID,
+			LastUpdatedOn: now,
+		}
+		if err := s.integrationRepo.Create(integration); err != nil {
+			return nil, err
+		}
+		s.audit.Record(domain.AuditEntityLabIntegration, labID, domain.AuditActionCreate, actor, nil, integration)
+	case err != nil:
+		return nil, err
+	default:
+		updated := *existing
+		updated.EventSecret = secret
+		updated.LastUpdatedBy = actor.UserID
+		updated.LastUpdatedOn = now
+		if err := s.integrationRepo.Update(&updated); err != nil {
+			return nil, err
+		}
+		s.audit.Record(domain.AuditEntityLabIntegration, labID, domain.AuditActionUpdate, actor, existing, &updated)
+	}
+	return &dto.LabEventSecret{LabID: labID, Secret: secret}, nil
+}
+
+// Authenticate verifies a request's signature, timestamp and nonce, and records the nonce so the
+// request cannot be replayed.
+func (s *labEventService) Authenticate(labID int64, timestamp, nonce, signature string, body []byte) error {
+	integration, err := s.integrationRepo.FindByLabID(labID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && (integration.EventSecret == "" || !integration.IsActive)) {
+		return apperrors.NewUnauthorized("Lab is not set up to send events", err)
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	unix, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return apperrors.NewUnauthorized("X-Lab-Timestamp must be unix seconds", err)
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > s.tolerance || skew < -s.tolerance {
+		return apperrors.NewUnauthorized("Request timestamp is outside the allowed window", nil)
+	}
+	nonce = strings.TrimSpace(nonce)
+	if len(nonce) < 8 || len(nonce) > 100 {
+		return apperrors.NewUnauthorized("X-Lab-Nonce must be 8 to 100 characters", nil)
+	}
+	mac := hmac.New(sha256.New, []byte(integration.EventSecret))
+	mac.Write([]byte(strings.TrimSpace(timestamp) + "." + nonce + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	signature = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return apperrors.NewUnauthorized("Invalid signature", nil)
+	}
+
+	return s.uow.WithinTransaction(func(eventRepo repository.LabEventRepository, _ repository.LeadRepository, _ repository.SampleRepository, _ repository.LeadHistoryRepository, _ repository.OutboxRepository) error {
+		if err := eventRepo.PruneNonces(now.Add(-2 * s.tolerance)); err != nil {
+			return err
+		}
+		fresh, err := eventRepo.ClaimNonce(labID, nonce, now)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return apperrors.NewUnauthorized("Request was already received (nonce reused)", nil)
+		}
+		return nil
+	})
+}
+
+// Apply maps an authenticated event onto its lead. SAMPLE_RECEIVED marks the named sample received
+// and moves the lead to Sent to Lab; REPORT_READY moves it to Report Ready. Transitions the workflow
+// no longer allows are recorded as ignored.
+func (s *labEventService) Apply(labID int64, req dto.LabEventRequest) (*dto.LabEventResult, error) {
+	if !domain.IsLabEventType(req.Type) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Unknown event type %s", req.Type), nil)
+	}
+	accession := strings.TrimSpace(req.AccessionNumber)
+	if req.LeadID == 0 && accession == "" {
+		return nil, apperrors.NewBadRequest("leadId or accessionNumber is required", nil)
+	}
+
+	var result *dto.LabEventResult
+	err := s.uow.WithinTransaction(func(eventRepo repository.LabEventRepository, leadRepo repository.LeadRepository, sampleRepo repository.SampleRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		existing, err := eventRepo.FindByExternalID(labID, req.EventID)
+		if err == nil {
+			result = &dto.LabEventResult{EventID: existing.ExternalEventID, LeadID: existing.LeadID, Outcome: existing.Outcome, Note: existing.Note, Duplicate: true}
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var sample *domain.Sample
+		leadID := req.LeadID
+		if accession != "" {
+			sample, err = sampleRepo.FindByAccessionNumber(accession)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.NewNotFound("Sample not found", err)
+			} else if err != nil {
+				return err
+			}
+			if leadID != 0 && sample.LeadID != leadID {
+				return apperrors.NewBadRequest(fmt.Sprintf("Sample %s does not belong to lead %d", accession, leadID), nil)
+			}
+			leadID = sample.LeadID
+		}
+		lead, err := leadRepo.FindByID(leadID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && (lead.LabID == nil || *lead.LabID != labID)) {
+			return apperrors.NewNotFound("Lead not found", err)
+		} else if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		target := domain.LeadStatusSentToLab
+		if req.Type == domain.LabEventReportReady {
+			target = domain.LeadStatusReportReady
+		}
+		updatedLead := *lead
+		var notes []string
+		if s.workflow.CanTransition(lead.LeadStatusID, target) {
+			updatedLead.LeadStatusID = target
+			updatedLead.LastUpdatedOn = now
+		} else {
+			current, _ := s.workflow.Status(lead.LeadStatusID)
+			notes = append(notes, "lead is "+current.Name)
+		}
+		changes := domain.DiffLeads(*lead, updatedLead)
+
+		var updatedSample *domain.Sample
+		if sample != nil && req.Type == domain.LabEventSampleReceived {
+			if domain.CanAdvanceSample(sample.Status, domain.SampleStatusReceived) {
+				next := *sample
+				next.Status = domain.SampleStatusReceived
+				next.ReceivedOn = &now
+				next.LastUpdatedOn = now
+				updatedSample = &next
+			} else {
+				notes = append(notes, fmt.Sprintf("sample %s is %s", sample.AccessionNumber, sample.Status))
+			}
+		}
+
+		event := &domain.LabEvent{
+			LabID:           labID,
+			ExternalEventID: req.EventID,
+			EventType:       req.Type,
+			LeadID:          lead.LeadID,
+			AccessionNumber: accession,
+			OccurredOn:      req.OccurredAt,
+			Outcome:         domain.LabEventOutcomeApplied,
+			ReceivedOn:      now,
+		}
+		if len(changes) == 0 && updatedSample == nil {
+			event.Outcome = domain.LabEventOutcomeIgnored
+			event.Note = "Nothing to change: " + strings.Join(notes, ", ")
+		}
+		if err := eventRepo.Create(event); err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := leadRepo.Update(&updatedLead); err != nil {
+				return err
+			}
+		}
+		if updatedSample != nil {
+			if err := sampleRepo.Update(updatedSample); err != nil {
+				return err
+			}
+		}
+
+		reason := fmt.Sprintf("Lab event %s (%s) %s", req.Type, req.EventID, strings.ToLower(event.Outcome))
+		if accession != "" {
+			reason += " for sample " + accession
+		}
+		if note := strings.TrimSpace(req.Note); note != "" {
+			reason += ": " + note
+		}
+		if err := historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:  lead.LeadID,
+			Action:  domain.LeadActionLabEvent,
+			Reason:  reason,
+			Changes: changes,
+		}); err != nil {
+			return err
+		}
+		if err := recordLeadEvents(outbox, s.workflow, lead, updatedLead); err != nil {
+			return err
+		}
+		result = &dto.LabEventResult{EventID: event.ExternalEventID, LeadID: event.LeadID, Outcome: event.Outcome, Note: event.Note}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const testLabEventSecret = "0f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+type fakeLabIntegrationRepo struct {
+	repository.LabIntegrationRepository
+	integration *domain.LabIntegration
+}
+
+func (r fakeLabIntegrationRepo) FindByLabID(int64) (*domain.LabIntegration, error) {
+	if r.integration == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	integration := *r.integration
+	return &integration, nil
+}
+
+// memLabEvents holds the lab event tables and the lead, sample and history rows events touch.
+type memLabEvents struct {
+	repository.LabEventRepository
+	nonces  map[string]bool
+	events  map[string]domain.LabEvent
+	leads   map[int64]domain.Lead
+	samples map[string]domain.Sample
+	history []domain.LeadHistory
+	outbox  *memOutbox
+}
+
+func newMemLabEvents() *memLabEvents {
+	return &memLabEvents{
+		nonces:  make(map[string]bool),
+		events:  make(map[string]domain.LabEvent),
+		leads:   make(map[int64]domain.Lead),
+		samples: make(map[string]domain.Sample),
+		outbox:  newMemOutbox(),
+	}
+}
+
+func (m *memLabEvents) WithinTransaction(fn func(repository.LabEventRepository, repository.LeadRepository, repository.SampleRepository, repository.LeadHistoryRepository, repository.OutboxRepository) error) error {
+	return fn(m, memLabEventLeads{m: m}, memLabEventSamples{m: m}, memLabEventHistory{m: m}, m.outbox)
+}
+
+func (m *memLabEvents) FindByExternalID(labID int64, externalEventID string) (*domain.LabEvent, error) {
+	e, ok := m.events[strconv.FormatInt(labID, 10)+"/"+externalEventID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &e, nil
+}
+
+func (m *memLabEvents) Create(e *domain.LabEvent) error {
+	m.events[strconv.FormatInt(e.LabID, 10)+"/"+e.ExternalEventID] = *e
+	return nil
+}
+
+func (m *memLabEvents) ClaimNonce(labID int64, nonce string, _ time.Time) (bool, error) {
+	key := strconv.FormatInt(labID, 10) + "/" + nonce
+	if m.nonces[key] {
+		return false, nil
+	}
+	m.nonces[key] = true
+	return true, nil
+}
+
+func (m *memLabEvents) PruneNonces(time.Time) error { return nil }
+
+type memLabEventLeads struct {
+	repository.LeadRepository
+	m *memLabEvents
+}
+
+func (r memLabEventLeads) FindByID(id int64) (*domain.Lead, error) {
+	lead, ok := r.m.leads[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &lead, nil
+}
+
+func (r memLabEventLeads) Update(l *domain.Lead) error {
+	r.m.leads[l.LeadID] = *l
+	return nil
+}
+
+type memLabEventSamples struct {
+	repository.SampleRepository
+	m *memLabEvents
+}
+
+func (r memLabEventSamples) FindByAccessionNumber(accession string) (*domain.Sample, error) {
+	sample, ok := r.m.samples[accession]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &sample, nil
+}
+
+func (r memLabEventSamples) Update(s *domain.Sample) error {
+	r.m.samples[s.AccessionNumber] = *s
+	return nil
+}
+
+type memLabEventHistory struct {
+	repository.LeadHistoryRepository
+	m *memLabEvents
+}
+
+func (r memLabEventHistory) LogAction(h *domain.LeadHistory) error {
+	r.m.history = append(r.m.history, *h)
+	return nil
+}
+
+func signLabEvent(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func wantAppError(t *testing.T, err error, kind apperrors.Kind, message string) {
+	t.Helper()
+	appErr := apperrors.From(err)
+	if appErr == nil || appErr.Kind != kind || !strings.Contains(appErr.Message, message) {
+		t.Fatalf("error = %v, want %s error containing %q", err, kind, message)
+	}
+}
+
+func TestLabEventAuthenticate(t *testing.T) {
+	body := []byte(`{"eventId":"evt-1","type":"REPORT_READY","leadId":42}`)
+	now := time.Now().Unix()
+	ts := func(offset time.Duration) string { return strconv.FormatInt(now+int64(offset/time.Second), 10) }
+	active := &domain.LabIntegration{LabID: 7, EventSecret: testLabEventSecret, IsActive: true}
+
+	tests := []struct {
+		name        string
+		integration *domain.LabIntegration
+		timestamp   string
+		nonce       string
+		signature   string // empty: sign correctly
+		body        []byte // nil: the signed body
+		wantErr     string // empty: accepted
+	}{
+		{name: "valid", integration: active, timestamp: ts(0), nonce: "nonce-valid-1"},
+		{name: "prefixed upper-case signature", integration: active, timestamp: ts(0), nonce: "nonce-prefix-1",
+			signature: "sha256=" + strings.ToUpper(signLabEvent(testLabEventSecret, ts(0), "nonce-prefix-1", body))},
+		{name: "within tolerance", integration: active, timestamp: ts(-4 * time.Minute), nonce: "nonce-skew-1"},
+		{name: "wrong secret", integration: active, timestamp: ts(0), nonce: "nonce-secret-1",
+			signature: signLabEvent("another-secret", ts(0), "nonce-secret-1", body), wantErr: "Invalid signature"},
+		{name: "tampered body", integration: active, timestamp: ts(0), nonce: "nonce-body-1",
+			body: []byte(`{"eventId":"evt-1","type":"REPORT_READY","leadId":43}`), wantErr: "Invalid signature"},
+		{name: "signature over another nonce", integration: active, timestamp: ts(0), nonce: "nonce-swap-1",
+			signature: signLabEvent(testLabEventSecret, ts(0), "nonce-swap-2", body), wantErr: "Invalid signature"},
+		{name: "stale timestamp", integration: active, timestamp: ts(-6 * time.Minute), nonce: "nonce-old-1", wantErr: "outside the allowed window"},
+		{name: "future timestamp", integration: active, timestamp: ts(6 * time.Minute), nonce: "nonce-future-1", wantErr: "outside the allowed window"},
+		{name: "timestamp not unix seconds", integration: active, timestamp: time.Now().Format(time.RFC3339), nonce: "nonce-format-1", wantErr: "unix seconds"},
+		{name: "short nonce", integration: active, timestamp: ts(0), nonce: "abc", wantErr: "X-Lab-Nonce"},
+		{name: "lab without integration", timestamp: ts(0), nonce: "nonce-none-1", wantErr: "not set up"},
+		{name: "inactive integration", integration: &domain.LabIntegration{LabID: 7, EventSecret: testLabEventSecret}, timestamp: ts(0), nonce: "nonce-off-1", wantErr: "not set up"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewLabEventService(fakeLabIntegrationRepo{integration: tt.integration}, nil, newMemLabEvents(), nil, nil, 5*time.Minute)
+			signature := tt.signature
+			if signature == "" {
+				signature = signLabEvent(testLabEventSecret, tt.timestamp, tt.nonce, body)
+			}
+			sent := body
+			if tt.body != nil {
+				sent = tt.body
+			}
+			err := svc.Authenticate(7, tt.timestamp, tt.nonce, signature, sent)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				return
+			}
+			wantAppError(t, err, apperrors.KindUnauthorized, tt.wantErr)
+		})
+	}
+}
+
+func TestLabEventAuthenticateRejectsReplay(t *testing.T) {
+	body := []byte(`{"eventId":"evt-1","type":"REPORT_READY","leadId":42}`)
+	svc := NewLabEventService(fakeLabIntegrationRepo{integration: &domain.LabIntegration{LabID: 7, EventSecret: testLabEventSecret, IsActive: true}},
+		nil, newMemLabEvents(), nil, nil, 5*time.Minute)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := signLabEvent(testLabEventSecret, timestamp, "nonce-replay-1", body)
+
+	if err := svc.Authenticate(7, timestamp, "nonce-replay-1", signature, body); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	err := svc.Authenticate(7, timestamp, "nonce-replay-1", signature, body)
+	wantAppError(t, err, apperrors.KindUnauthorized, "nonce reused")
+
+	// The same nonce is still fresh for another lab
+	other := NewLabEventService(fakeLabIntegrationRepo{integration: &domain.LabIntegration{LabID: 8, EventSecret: testLabEventSecret, IsActive: true}},
+		nil, newMemLabEvents(), nil, nil, 5*time.Minute)
+	if err := other.Authenticate(8, timestamp, "nonce-replay-1", signature, body); err != nil {
+		t.Fatalf("other lab: %v", err)
+	}
+}
+
+func TestLabEventApply(t *testing.T) {
+	labID := int64(7)
+	otherLab := int64(9)
+	tests := []struct {
+		name        string
+		lead        domain.Lead
+		sample      *domain.Sample
+		requests    []dto.LabEventRequest
+		wantErr     string // on the last request
+		wantErrKind apperrors.Kind
+		wantOutcome string
+		wantDup     bool
+		wantStatus  int8
+		wantSample  string
+		wantHistory int
+		wantEvents  int // outbox events written
+	}{
+		{
+			name:        "report ready moves the lead",
+			lead:        domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSentToLab},
+			requests:    []dto.LabEventRequest{{EventID: "evt-1", Type: domain.LabEventReportReady, LeadID: 42}},
+			wantOutcome: domain.LabEventOutcomeApplied,
+			wantStatus:  domain.LeadStatusReportReady,
+			wantHistory: 1,
+			wantEvents:  1,
+		},
+		{
+			name:   "sample received by accession number",
+			lead:   domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSampleCollected},
+			sample: &domain.Sample{SampleID: 5, LeadID: 42, AccessionNumber: "ACC-1", Status: domain.SampleStatusInTransit},
+			requests: []dto.LabEventRequest{
+				{EventID: "evt-1", Type: domain.LabEventSampleReceived, AccessionNumber: "ACC-1"},
+			},
+			wantOutcome: domain.LabEventOutcomeApplied,
+			wantStatus:  domain.LeadStatusSentToLab,
+			wantSample:  domain.SampleStatusReceived,
+			wantHistory: 1,
+			wantEvents:  1,
+		},
+		{
+			name: "duplicate event ID returns the first outcome without reapplying",
+			lead: domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSentToLab},
+			requests: []dto.LabEventRequest{
+				{EventID: "evt-1", Type: domain.LabEventReportReady, LeadID: 42},
+				{EventID: "evt-1", Type: domain.LabEventReportReady, LeadID: 42},
+			},
+			wantOutcome: domain.LabEventOutcomeApplied,
+			wantDup:     true,
+			wantStatus:  domain.LeadStatusReportReady,
+			wantHistory: 1,
+			wantEvents:  1,
+		},
+		{
+			name: "duplicate event ID with a different payload is not applied",
+			lead: domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSampleCollected},
+			requests: []dto.LabEventRequest{
+				{EventID: "evt-1", Type: domain.LabEventReportReady, LeadID: 42},
+				{EventID: "evt-1", Type: domain.LabEventSampleReceived, LeadID: 42},
+			},
+			wantOutcome: domain.LabEventOutcomeIgnored,
+			wantDup:     true,
+			wantStatus:  domain.LeadStatusSampleCollected,
+			wantHistory: 1,
+		},
+		{
+			name:        "transition the workflow does not allow is ignored",
+			lead:        domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusScheduled},
+			requests:    []dto.LabEventRequest{{EventID: "evt-1", Type: domain.LabEventReportReady, LeadID: 42}},
+			wantOutcome: domain.LabEventOutcomeIgnored,
+			wantStatus:  domain.LeadStatusScheduled,
+			wantHistory: 1,
+		},
+		{
+			name:        "lead of another lab",
+			lead:        domain.Lead{LeadID: 42, ClientID: 3, LabID: &otherLab, LeadStatusID: domain.LeadStatusSentToLab},
+			requests:    []dto.LabEventRequest{{EventID: "evt-1", Type: domain.LabEventReportReady, LeadID: 42}},
+			wantErr:     "Lead not found",
+			wantErrKind: apperrors.KindNotFound,
+			wantStatus:  domain.LeadStatusSentToLab,
+		},
+		{
+			name:        "sample of another lead",
+			lead:        domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSampleCollected},
+			sample:      &domain.Sample{SampleID: 5, LeadID: 43, AccessionNumber: "ACC-1", Status: domain.SampleStatusInTransit},
+			requests:    []dto.LabEventRequest{{EventID: "evt-1", Type: domain.LabEventSampleReceived, LeadID: 42, AccessionNumber: "ACC-1"}},
+			wantErr:     "does not belong to lead 42",
+			wantErrKind: apperrors.KindBadRequest,
+			wantStatus:  domain.LeadStatusSampleCollected,
+			wantSample:  domain.SampleStatusInTransit,
+		},
+		{
+			name:        "unknown event type",
+			lead:        domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSentToLab},
+			requests:    []dto.LabEventRequest{{EventID: "evt-1", Type: "RESULT_AMENDED", LeadID: 42}},
+			wantErr:     "Unknown event type",
+			wantErrKind: apperrors.KindBadRequest,
+			wantStatus:  domain.LeadStatusSentToLab,
+		},
+		{
+			name:        "no lead or accession number",
+			lead:        domain.Lead{LeadID: 42, ClientID: 3, LabID: &labID, LeadStatusID: domain.LeadStatusSentToLab},
+			requests:    []dto.LabEventRequest{{EventID: "evt-1", Type: domain.LabEventReportReady}},
+			wantErr:     "leadId or accessionNumber is required",
+			wantErrKind: apperrors.KindBadRequest,
+			wantStatus:  domain.LeadStatusSentToLab,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemLabEvents()
+			store.leads[tt.lead.LeadID] = tt.lead
+			if tt.sample != nil {
+				store.samples[tt.sample.AccessionNumber] = *tt.sample
+			}
+			svc := NewLabEventService(fakeLabIntegrationRepo{}, nil, store, nil, nil, 0)
+
+			var result *dto.LabEventResult
+			var err error
+			for _, req := range tt.requests {
+				result, err = svc.Apply(labID, req)
+			}
+			if tt.wantErr != "" {
+				wantAppError(t, err, tt.wantErrKind, tt.wantErr)
+			} else {
+				if err != nil {
+					t.Fatalf("Apply: %v", err)
+				}
+				if result.Outcome != tt.wantOutcome || result.Duplicate != tt.wantDup {
+					t.Errorf("result outcome %s duplicate %v, want %s duplicate %v", result.Outcome, result.Duplicate, tt.wantOutcome, tt.wantDup)
+				}
+			}
+			if got := store.leads[tt.lead.LeadID].LeadStatusID; got != tt.wantStatus {
+				t.Errorf("lead status %d, want %d", got, tt.wantStatus)
+			}
+			if tt.sample != nil && store.samples[tt.sample.AccessionNumber].Status != tt.wantSample {
+				t.Errorf("sample status %s, want %s", store.samples[tt.sample.AccessionNumber].Status, tt.wantSample)
+			}
+			if len(store.history) != tt.wantHistory {
+				t.Errorf("%d history entries, want %d", len(store.history), tt.wantHistory)
+			}
+			if len(store.outbox.events) != tt.wantEvents {
+				t.Errorf("%d outbox events, want %d", len(store.outbox.events), tt.wantEvents)
+			}
+		})
+	}
+}
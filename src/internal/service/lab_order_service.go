@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/labintegration"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// LabOrderSettings tunes the lab order delivery queue.
+type LabOrderSettings struct {
+	MaxAttempts int           // attempts per delivery when the lab configures none
+	BatchSize   int           // deliveries sent per dispatch run
+	Lease       time.Duration // how long a claimed delivery is hidden from other dispatchers
+}
+
+// LabOrderService pushes lead orders to the assigned lab's LIS through the lab's configured adapter.
+// Assigning a lab queues a delivery; the dispatcher sends due deliveries, records every attempt and
+// retries failures with backoff until the delivery's attempts run out.
+type LabOrderService interface {
+	GetIntegration(labID int64) (*domain.LabIntegration, error)
+	SaveIntegration(labID int64, req dto.LabIntegrationRequest, actor domain.Actor) (*domain.LabIntegration, error)
+	Enqueue(leadID int64, actor domain.Actor) (*domain.LabOrderDelivery, error)
+	ListDeliveries(leadID int64, scope domain.TenantScope) ([]domain.LabOrderDelivery, error)
+	Retry(leadID, deliveryID int64, actor domain.Actor) (*domain.LabOrderDelivery, error)
+	DispatchDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type labOrderService struct {
+	integrationRepo repository.LabIntegrationRepository
+	deliveryRepo    repository.LabOrderDeliveryRepository
+	uow             repository.LabOrderUnitOfWork
+	leadRepo        repository.LeadRepository
+	labRepo         repository.LabRepository
+	packageRepo     repository.PackageRepository
+	testRepo        repository.TestRepository
+	audit           AuditService
+	settings        LabOrderSettings
+}
+
+func NewLabOrderService(
+	integrationRepo repository.LabIntegrationRepository,
+	deliveryRepo repository.LabOrderDeliveryRepository,
+	uow repository.LabOrderUnitOfWork,
+	leadRepo repository.LeadRepository,
+	labRepo repository.LabRepository,
+	packageRepo repository.PackageRepository,
+	testRepo repository.TestRepository,
+	audit AuditService,
+	settings LabOrderSettings,
+) LabOrderService {
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 5
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 20
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = 5 * time.Minute
+	}
+	return &labOrderService{
+		integrationRepo: integrationRepo,
+		deliveryRepo:    deliveryRepo,
+		uow:             uow,
+		leadRepo:        leadRepo,
+		labRepo:         labRepo,
+		packageRepo:     packageRepo,
+		testRepo:        testRepo,
+		audit:           audit,
+		settings:        settings,
+	}
+}
+
+// GetIntegration returns the lab's integration; labs never configured are manual.
+func (s *labOrderService) GetIntegration(labID int64) (*domain.LabIntegration, error) {
+	if _, err := s.findLab(labID); err != nil {
+		return nil, err
+	}
+	return s.integration(labID)
+}
+
+func (s *labOrderService) SaveIntegration(labID int64, req dto.LabIntegrationRequest, actor domain.Actor) (*domain.LabIntegration, error) {
+	if _, err := s.findLab(labID); err != nil {
+		return nil, err
+	}
+	existing, err := s.integrationRepo.FindByLabID(labID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	updated := domain.LabIntegration{LabID: labID, IsActive: true, CreatedBy: actor.UserID, CreatedOn: now}
+	if existing != nil {
+		updated = *existing
+	}
+	updated.Adapter = req.Adapter
+	updated.Endpoint = strings.TrimSpace(req.Endpoint)
+	if req.AuthToken != nil {
+		updated.AuthToken = strings.TrimSpace(*req.AuthToken)
+	}
+	updated.ReceivingApplication = strings.TrimSpace(req.ReceivingApplication)
+	updated.ReceivingFacility = strings.TrimSpace(req.ReceivingFacility)
+	updated.TimeoutSeconds = req.TimeoutSeconds
+	updated.MaxAttempts = req.MaxAttempts
+	if req.IsActive != nil {
+		updated.IsActive = *req.IsActive
+	}
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = now
+	if err := validateLabEndpoint(updated.Adapter, updated.Endpoint); err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		if err := s.integrationRepo.Create(&updated); err != nil {
+			return nil, err
+		}
+		s.audit.Record(domain.AuditEntityLabIntegration, labID, domain.AuditActionCreate, actor, nil, &updated)
+		return &updated, nil
+	}
+	if err := s.integrationRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityLabIntegration, labID, domain.AuditActionUpdate, actor, existing, &updated)
+	return &updated, nil
+}
+
+// Enqueue queues the lead's order for its assigned lab, superseding earlier undelivered orders.
+// Orders for manual labs are recorded as manual and never sent.
+func (s *labOrderService) Enqueue(leadID int64, actor domain.Actor) (*domain.LabOrderDelivery, error) {
+	lead, err := s.leadRepo.FindByID(leadID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	if lead.LabID == nil {
+		return nil, apperrors.NewBadRequest("Lead has no lab assigned", nil)
+	}
+	lab, err := s.findLab(*lead.LabID)
+	if err != nil {
+		return nil, err
+	}
+	integration, err := s.integration(lab.LabID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &domain.LabOrderDelivery{
+		LeadID:        leadID,
+		LabID:         lab.LabID,
+		Adapter:       integration.Adapter,
+		Status:        domain.LabOrderStatusManual,
+		MaxAttempts:   s.maxAttempts(integration),
+		CreatedBy:     actor.UserID,
+		CreatedOn:     now,
+		LastUpdatedOn: now,
+	}
+	note := fmt.Sprintf("Order for %s to be entered manually in the lab's LIS", lab.LabName)
+	if integration.Pushes() {
+		delivery.Status = domain.LabOrderStatusPending
+		delivery.NextAttemptOn = &now
+		note = fmt.Sprintf("Order queued for %s via %s", lab.LabName, integration.Adapter)
+	} else {
+		delivery.Adapter = domain.LabAdapterManual
+	}
+	err = s.uow.WithinTransaction(func(repo repository.LabOrderDeliveryRepository, historyRepo repository.LeadHistoryRepository) error {
+		if _, err := repo.SupersedeOpen(leadID); err != nil {
+			return err
+		}
+		if err := repo.Create(delivery); err != nil {
+			return err
+		}
+		return historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionLabOrderPush,
+			Reason:        fmt.Sprintf("%s (%s)", note, delivery.Reference()),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *labOrderService) ListDeliveries(leadID int64, scope domain.TenantScope) ([]domain.LabOrderDelivery, error) {
+	if _, err := s.leadRepo.WithScope(scope).FindByID(leadID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.FindByLeadID(leadID)
+}
+
+// Retry requeues a failed delivery for another round of attempts. The lead must still be assigned
+// to the delivery's lab.
+func (s *labOrderService) Retry(leadID, deliveryID int64, actor domain.Actor) (*domain.LabOrderDelivery, error) {
+	delivery, err := s.deliveryRepo.FindByID(deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && delivery.LeadID != leadID) {
+		return nil, apperrors.NewNotFound("Lab order not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	if delivery.Status != domain.LabOrderStatusFailed {
+		return nil, apperrors.NewConflict(fmt.Sprintf("Only failed lab orders can be retried; this one is %s", delivery.Status), nil)
+	}
+	lead, err := s.leadRepo.FindByID(leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead.LabID == nil || *lead.LabID != delivery.LabID {
+		return nil, apperrors.NewConflict("The lead is no longer assigned to this lab", nil)
+	}
+	integration, err := s.integration(delivery.LabID)
+	if err != nil {
+		return nil, err
+	}
+	if !integration.Pushes() {
+		return nil, apperrors.NewConflict("The lab no longer has an active integration", nil)
+	}
+
+	now := time.Now()
+	delivery.Status = domain.LabOrderStatusPending
+	delivery.Adapter = integration.Adapter
+	delivery.MaxAttempts = delivery.Attempts + s.maxAttempts(integration)
+	delivery.NextAttemptOn = &now
+	delivery.LastUpdatedOn = now
+	err = s.uow.WithinTransaction(func(repo repository.LabOrderDeliveryRepository, historyRepo repository.LeadHistoryRepository) error {
+		if err := repo.Update(delivery); err != nil {
+			return err
+		}
+		return historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionLabOrderPush,
+			Reason:        fmt.Sprintf("Retry requested for order %s", delivery.Reference()),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// DispatchDue sends the deliveries that are due, one attempt each, and returns how many it attempted.
+func (s *labOrderService) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.deliveryRepo.FindDue(now, s.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	attempted := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		// Whole seconds so the lease compares equal after a round trip through the datetime column.
+		leaseUntil := now.Add(s.settings.Lease).Truncate(time.Second)
+		claimed, err := s.deliveryRepo.Claim(due[i].DeliveryID, now, leaseUntil)
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.attempt(ctx, due[i], leaseUntil); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// Run dispatches due deliveries every interval until ctx is cancelled.
+func (s *labOrderService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchDue(ctx); err != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"lab_order_dispatch_failed","error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), err.Error())
+			}
+		}
+	}
+}
+
+// attempt makes one delivery attempt under the claim ending at leaseUntil and records it. Delivery
+// errors are recorded on the delivery; only failures to record are returned. The outcome is dropped
+// when the delivery changed while the order was being sent, e.g. superseded because the lead moved
+// to another lab.
+func (s *labOrderService) attempt(ctx context.Context, delivery domain.LabOrderDelivery, leaseUntil time.Time) error {
+	started := time.Now()
+	order, adapter, sendErr := s.prepare(delivery)
+	var result labintegration.Result
+	if sendErr == nil {
+		result, sendErr = adapter.Send(ctx, *order)
+	}
+	finished := time.Now()
+
+	attempt := domain.LabOrderAttempt{
+		DeliveryID:    delivery.DeliveryID,
+		AttemptNumber: delivery.Attempts + 1,
+		AttemptedOn:   started,
+		DurationMs:    finished.Sub(started).Milliseconds(),
+		Success:       sendErr == nil,
+		ResponseCode:  result.Code,
+		Response:      result.Response,
+	}
+	delivery.Attempts++
+	delivery.LastUpdatedOn = finished
+	var note string
+	switch {
+	case errors.Is(sendErr, labintegration.ErrManual):
+		delivery.Status = domain.LabOrderStatusManual
+		delivery.NextAttemptOn = nil
+		attempt.Error = "The lab's integration was switched to manual entry"
+		note = fmt.Sprintf("Order %s not sent: the lab's integration was switched to manual entry", delivery.Reference())
+	case sendErr != nil:
+		attempt.Error = truncateError(sendErr)
+		delivery.LastError = attempt.Error
+		if delivery.Attempts >= delivery.MaxAttempts {
+			delivery.Status = domain.LabOrderStatusFailed
+			delivery.NextAttemptOn = nil
+			note = fmt.Sprintf("Order %s failed after %d attempts: %s", delivery.Reference(), delivery.Attempts, attempt.Error)
+		} else {
+			next := finished.Add(domain.LabOrderRetryDelay(delivery.Attempts))
+			delivery.NextAttemptOn = &next
+		}
+	default:
+		delivery.Status = domain.LabOrderStatusDelivered
+		delivery.NextAttemptOn = nil
+		delivery.DeliveredOn = &finished
+		delivery.LastError = ""
+		delivery.ExternalRef = result.ExternalRef
+		note = fmt.Sprintf("Order %s delivered via %s", delivery.Reference(), delivery.Adapter)
+		if result.ExternalRef != "" {
+			note += ", lab reference " + result.ExternalRef
+		}
+	}
+
+	return s.uow.WithinTransaction(func(repo repository.LabOrderDeliveryRepository, historyRepo repository.LeadHistoryRepository) error {
+		current, err := repo.CompleteAttempt(&delivery, leaseUntil)
+		if err != nil {
+			return err
+		}
+		if !current {
+			log.Printf(`{"timestamp":"%s","level":"warn","event":"lab_order_outcome_dropped","delivery_id":%d,"lead_id":%d}`,
+				time.Now().UTC().Format(time.RFC3339), delivery.DeliveryID, delivery.LeadID)
+			return nil
+		}
+		if err := repo.CreateAttempt(&attempt); err != nil {
+			return err
+		}
+		if note == "" {
+			return nil
+		}
+		return historyRepo.LogAction(&domain.LeadHistory{
+			LeadID: delivery.LeadID,
+			Action: domain.LeadActionLabOrderPush,
+			Reason: note,
+		})
+	})
+}
+
+// prepare builds the order and the adapter for an attempt from the current lead and lab settings.
+func (s *labOrderService) prepare(delivery domain.LabOrderDelivery) (*domain.LabOrder, labintegration.LabIntegration, error) {
+	integration, err := s.integration(delivery.LabID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !integration.Pushes() {
+		return nil, nil, labintegration.ErrManual
+	}
+	adapter, err := labintegration.New(*integration)
+	if err != nil {
+		return nil, nil, err
+	}
+	lead, err := s.leadRepo.FindByID(delivery.LeadID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading lead: %w", err)
+	}
+	if lead.LabID == nil || *lead.LabID != delivery.LabID {
+		return nil, nil, fmt.Errorf("lead %d is no longer assigned to lab %d", lead.LeadID, delivery.LabID)
+	}
+	lab, err := s.labRepo.FindByID(delivery.LabID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading lab: %w", err)
+	}
+	pkg, err := s.packageRepo.FindByID(lead.PackageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading package: %w", err)
+	}
+	testIDs, err := s.packageRepo.FindActiveTestIDs(lead.PackageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading package tests: %w", err)
+	}
+	tests, err := s.testRepo.FindByIDs(testIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading package tests: %w", err)
+	}
+	return &domain.LabOrder{
+		Reference:     delivery.Reference(),
+		LeadID:        lead.LeadID,
+		LabID:         lab.LabID,
+		LabName:       lab.LabName,
+		PatientID:     lead.PatientID,
+		PatientName:   lead.PatientName,
+		Age:           lead.Age,
+		Gender:        lead.Gender,
+		ContactNumber: lead.ContactNumber,
+		Address:       lead.Address,
+		Pincode:       lead.Pincode,
+		PackageID:     pkg.PackageID,
+		PackageName:   pkg.PackageName,
+		Tests:         tests,
+		OrderedOn:     lead.CreatedOn,
+	}, adapter, nil
+}
+
+// integration returns the lab's configured integration, or a manual one when none is configured.
+func (s *labOrderService) integration(labID int64) (*domain.LabIntegration, error) {
+	integration, err := s.integrationRepo.FindByLabID(labID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.LabIntegration{LabID: labID, Adapter: domain.LabAdapterManual, IsActive: true}, nil
+	}
+	return integration, err
+}
+
+func (s *labOrderService) maxAttempts(integration *domain.LabIntegration) int {
+	if integration.MaxAttempts > 0 {
+		return integration.MaxAttempts
+	}
+	return s.settings.MaxAttempts
+}
+
+func (s *labOrderService) findLab(id int64) (*domain.Lab, error) {
+	lab, err := s.labRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lab not found", err)
+	}
+	return lab, err
+}
+
+// validateLabEndpoint checks the endpoint suits the adapter: an http(s) URL for HTTP_JSON and
+// host:port for HL7_MLLP. Manual labs need none.
+func validateLabEndpoint(adapter, endpoint string) error {
+	switch adapter {
+	case domain.LabAdapterHTTPJSON:
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apperrors.NewBadRequest("HTTP_JSON integrations need an http(s) endpoint URL", err)
+		}
+	case domain.LabAdapterHL7MLLP:
+		host, port, err := net.SplitHostPort(strings.TrimPrefix(endpoint, "mllp://"))
+		if err != nil || host == "" || port == "" {
+			return apperrors.NewBadRequest("HL7_MLLP integrations need a host:port endpoint", err)
+		}
+	}
+	return nil
+}
+
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > 1000 {
+		msg = msg[:1000]
+	}
+	return msg
+}
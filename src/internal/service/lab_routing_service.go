@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// LabRoutingService picks labs for leads: a lab is eligible when it collects from the lead's
+// pincode, offers the lead's package, has a current MOU and is accredited. Eligible labs are ranked
+// by lowest lab price for the package. Assigning a lab queues the order for the lab's LIS.
+type LabRoutingService interface {
+	LabOptions(leadID int64, collectionType string) ([]domain.LabOption, error)
+	AssignLab(leadID int64, labID *int64, collectionType string, actor domain.Actor) (*domain.Lead, error)
+	// ChooseLab returns labID's option for the lead, or the top-ranked lab when labID is nil, and
+	// fails when that lab is not eligible. Services that assign the lab inside their own transaction
+	// write it with writeLabAssignment and call QueueOrder once the transaction has committed.
+	ChooseLab(lead *domain.Lead, labID *int64, collectionType string) (*domain.LabOption, error)
+	// QueueOrder queues the lead's order for its lab's LIS. A failure is logged rather than returned:
+	// the assignment stands and ops can resend the order from the lead.
+	QueueOrder(leadID int64, actor domain.Actor)
+}
+
+type labRoutingService struct {
+	leadRepo   repository.LeadRepository
+	labRepo    repository.LabRepository
+	labMapRepo repository.PackageLabMappingRepository
+	uow        repository.LeadUnitOfWork
+	orders     LabOrderService
+	workflow   *domain.LeadStatusWorkflow
+}
+
+func NewLabRoutingService(
+	leadRepo repository.LeadRepository,
+	labRepo repository.LabRepository,
+	labMapRepo repository.PackageLabMappingRepository,
+	uow repository.LeadUnitOfWork,
+	orders LabOrderService,
+	workflow *domain.LeadStatusWorkflow,
+) LabRoutingService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	return &labRoutingService{leadRepo: leadRepo, labRepo: labRepo, labMapRepo: labMapRepo, uow: uow, orders: orders, workflow: workflow}
+}
+
+// LabOptions evaluates every active lab for the lead: eligible labs first, cheapest first, then the
+// ineligible labs with the reasons they were excluded.
+func (s *labRoutingService) LabOptions(leadID int64, collectionType string) ([]domain.LabOption, error) {
+	lead, err := s.findLead(leadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rankLabs(lead, collectionType)
+}
+
+// AssignLab stores labID on the lead, or the top-ranked lab when labID is nil. The chosen lab must
+// be eligible.
+func (s *labRoutingService) AssignLab(leadID int64, labID *int64, collectionType string, actor domain.Actor) (*domain.Lead, error) {
+	lead, err := s.findLead(leadID)
+	if err != nil {
+		return nil, err
+	}
+	chosen, err := s.ChooseLab(lead, labID, collectionType)
+	if err != nil {
+		return nil, err
+	}
+	var updated *domain.Lead
+	err = s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, _ repository.PatientRepository, outbox repository.OutboxRepository) error {
+		var err error
+		updated, err = writeLabAssignment(leadRepo, historyRepo, outbox, s.workflow, lead, *chosen, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updated != lead {
+		s.QueueOrder(leadID, actor)
+	}
+	return updated, nil
+}
+
+func (s *labRoutingService) ChooseLab(lead *domain.Lead, labID *int64, collectionType string) (*domain.LabOption, error) {
+	options, err := s.rankLabs(lead, collectionType)
+	if err != nil {
+		return nil, err
+	}
+	if labID == nil {
+		if len(options) == 0 || !options[0].Eligible {
+			return nil, apperrors.NewConflict(fmt.Sprintf("No eligible lab for lead %d", lead.LeadID), nil)
+		}
+		return &options[0], nil
+	}
+	for i := range options {
+		if options[i].LabID != *labID {
+			continue
+		}
+		if !options[i].Eligible {
+			return nil, apperrors.NewBadRequest(fmt.Sprintf("Lab %d cannot take lead %d: %s", *labID, lead.LeadID, strings.Join(options[i].Reasons, "; ")), nil)
+		}
+		return &options[i], nil
+	}
+	return nil, apperrors.NewBadRequest(fmt.Sprintf("Lab %d does not exist or is inactive", *labID), nil)
+}
+
+func (s *labRoutingService) QueueOrder(leadID int64, actor domain.Actor) {
+	if _, err := s.orders.Enqueue(leadID, actor); err != nil {
+		log.Printf(`{"timestamp":"%s","level":"error","event":"lab_order_enqueue_failed","lead_id":%d,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339), leadID, err.Error())
+	}
+}
+
+// writeLabAssignment stores the chosen lab on the lead with its history entry and lead events, using
+// the caller's transaction. It returns lead itself when the lead already has that lab.
+func writeLabAssignment(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository,
+	workflow *domain.LeadStatusWorkflow, lead *domain.Lead, chosen domain.LabOption, actor domain.Actor) (*domain.Lead, error) {
+	updated := *lead
+	updated.LabID = &chosen.LabID
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = time.Now()
+	changes := domain.DiffLeads(*lead, updated)
+	if len(changes) == 0 {
+		return lead, nil
+	}
+	if err := leadRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	err := historyRepo.LogAction(&domain.LeadHistory{
+		LeadID:        updated.LeadID,
+		Action:        domain.LeadActionLabAssign,
+		Reason:        fmt.Sprintf("Assigned to %s (rank %d)", chosen.LabName, chosen.Rank),
+		CreatedBy:     actor.UserID,
+		CreatedByType: actor.UserType,
+		Changes:       changes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := recordLeadEvents(outbox, workflow, lead, updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *labRoutingService) findLead(id int64) (*domain.Lead, error) {
+	lead, err := s.leadRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	}
+	return lead, err
+}
+
+func (s *labRoutingService) rankLabs(lead *domain.Lead, collectionType string) ([]domain.LabOption, error) {
+	labs, err := s.labRepo.FindAllActive()
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := s.labMapRepo.FindByPackageID(lead.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[int64]float64, len(mappings))
+	for _, m := range mappings {
+		if !m.IsActive {
+			continue
+		}
+		if current, ok := prices[m.LabID]; !ok || m.Price < current {
+			prices[m.LabID] = m.Price
+		}
+	}
+
+	criteria := domain.LabRoutingCriteria{Pincode: lead.Pincode, CollectionType: strings.TrimSpace(collectionType), On: time.Now()}
+	options := make([]domain.LabOption, 0, len(labs))
+	for _, lab := range labs {
+		var price *float64
+		if p, ok := prices[lab.LabID]; ok {
+			price = &p
+		}
+		options = append(options, domain.EvaluateLab(lab, price, criteria))
+	}
+	domain.RankLabOptions(options)
+	return options, nil
+}
@@ -16,20 +16,21 @@ type LabService interface {
 	ListLabs(filter repository.LabListFilter) ([]domain.Lab, int64, error)
 	GetLabByID(id int64) (*domain.Lab, error)
 	GetLabByContactNumber(contactNumber string) (*domain.Lab, error)
-	CreateLab(l *domain.Lab, createdBy int64) error
-	UpdateLab(id int64, update *dto.LabUpdateRequest, lastUpdatedBy int64) (*domain.Lab, error)
-	DeleteLab(id int64) error
+	CreateLab(l *domain.Lab, actor domain.Actor) error
+	UpdateLab(id int64, update *dto.LabUpdateRequest, actor domain.Actor) (*domain.Lab, error)
+	DeleteLab(id int64, actor domain.Actor) error
 	GetActiveLabs() ([]domain.Lab, error)
 	GetLabsByCity(cityID int8) ([]domain.Lab, error)
 	GetLabsByState(stateID int8) ([]domain.Lab, error)
 }
 
 type labService struct {
-	repo repository.LabRepository
+	repo  repository.LabRepository
+	audit AuditService
 }
 
-func NewLabService(repo repository.LabRepository) LabService {
-	return &labService{repo: repo}
+func NewLabService(repo repository.LabRepository, audit AuditService) LabService {
+	return &labService{repo: repo, audit: audit}
 }
 
 func (s *labService) ListLabs(filter repository.LabListFilter) ([]domain.Lab, int64, error) {
@@ -52,16 +53,21 @@ func (s *labService) GetLabByContactNumber(contactNumber string) (*domain.Lab, e
 	return lab, err
 }
 
-func (s *labService) CreateLab(l *domain.Lab, createdBy int64) error {
+func (s *labService) CreateLab(l *domain.Lab, actor domain.Actor) error {
 	now := time.Now()
+	createdBy := actor.UserID
 	l.CreatedBy = &createdBy
 	l.CreatedOn = &now
 	l.LastUpdatedBy = &createdBy
 	l.LastUpdatedOn = &now
-	return s.repo.Create(l)
+	if err := s.repo.Create(l); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityLab, l.LabID, domain.AuditActionCreate, actor, nil, l)
+	return nil
 }
 
-func (s *labService) UpdateLab(id int64, update *dto.LabUpdateRequest, lastUpdatedBy int64) (*domain.Lab, error) {
+func (s *labService) UpdateLab(id int64, update *dto.LabUpdateRequest, actor domain.Actor) (*domain.Lab, error) {
 	existing, err := s.repo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -140,24 +146,30 @@ func (s *labService) UpdateLab(id int64, update *dto.LabUpdateRequest, lastUpdat
 		l.IsActive = update.IsActive
 	}
 	l.LabID = id
+	lastUpdatedBy := actor.UserID
 	l.LastUpdatedBy = &lastUpdatedBy
 	now := time.Now()
 	l.LastUpdatedOn = &now
 	if err := s.repo.Update(&l); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityLab, id, domain.AuditActionUpdate, actor, existing, &l)
 	return &l, nil
 }
 
-func (s *labService) DeleteLab(id int64) error {
-	exists, err := s.repo.ExistsByID(id)
+func (s *labService) DeleteLab(id int64, actor domain.Actor) error {
+	existing, err := s.repo.FindByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NewNotFound("Lab not found", err)
+		}
 		return err
 	}
-	if !exists {
-		return apperrors.NewNotFound("Lab not found", gorm.ErrRecordNotFound)
+	if err := s.repo.Delete(id); err != nil {
+		return err
 	}
-	return s.repo.Delete(id)
+	s.audit.Record(domain.AuditEntityLab, id, domain.AuditActionDelete, actor, existing, nil)
+	return nil
 }
 
 func (s *labService) GetActiveLabs() ([]domain.Lab, error) {
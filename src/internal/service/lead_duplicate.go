@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// findDuplicate returns the most recent existing lead that l duplicates, or nil.
+func (s *leadService) findDuplicate(l domain.Lead) (*domain.Lead, error) {
+	existing, err := s.repo.FindByClientAndContactNumbers(l.ClientID, []string{l.ContactNumber})
+	if err != nil {
+		return nil, err
+	}
+	var match *domain.Lead
+	for i := range existing {
+		if s.duplicates.IsDuplicate(existing[i], l) && (match == nil || existing[i].CreatedOn.After(match.CreatedOn)) {
+			match = &existing[i]
+		}
+	}
+	return match, nil
+}
+
+// ListDuplicates returns groups of leads (within scope, optionally one client) that look like the
+// same order. It is empty when duplicate detection is disabled.
+func (s *leadService) ListDuplicates(clientID *int64, scope domain.TenantScope) ([]dto.LeadDuplicateGroup, error) {
+	groups := []dto.LeadDuplicateGroup{}
+	if !s.duplicates.Enabled() {
+		return groups, nil
+	}
+	candidates, err := s.repo.WithScope(scope).FindDuplicateCandidates(clientID, s.duplicates.Window)
+	if err != nil {
+		return nil, err
+	}
+	for _, leads := range s.duplicates.Group(candidates) {
+		first := leads[0]
+		groups = append(groups, dto.LeadDuplicateGroup{
+			ClientID:      first.ClientID,
+			PackageID:     first.PackageID,
+			ContactNumber: first.ContactNumber,
+			PatientName:   domain.NormalizePatientName(first.PatientName),
+			Leads:         leads,
+		})
+	}
+	return groups, nil
+}
+
+// MergeLeads folds the duplicate leads into survivorID: their history moves to the survivor, they
+// are deleted and a MERGE entry is written on the survivor. All leads must belong to one client, and
+// the duplicates must have no appointments, samples, reports, results or lab orders; work already
+// done on a lead is not moved.
+func (s *leadService) MergeLeads(survivorID int64, duplicateIDs []int64, reason string, actor domain.Actor) (*domain.Lead, error) {
+	seen := make(map[int64]bool, len(duplicateIDs))
+	var ids []int64
+	for _, id := range duplicateIDs {
+		if id == survivorID {
+			return nil, apperrors.NewBadRequest("A lead cannot be merged into itself", nil)
+		}
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, apperrors.NewBadRequest("duplicateLeadIds must contain at least one lead ID", nil)
+	}
+
+	survivor, err := s.repo.FindByID(survivorID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	duplicates, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]bool, len(duplicates))
+	for _, dup := range duplicates {
+		found[dup.LeadID] = true
+		if dup.ClientID != survivor.ClientID {
+			return nil, apperrors.NewBadRequest(fmt.Sprintf("Lead %d belongs to a different client than lead %d", dup.LeadID, survivorID), nil)
+		}
+	}
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, strconv.FormatInt(id, 10))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apperrors.NewNotFound("Leads not found: "+strings.Join(missing, ", "), nil)
+	}
+
+	merged := make([]string, len(ids))
+	for i, id := range ids {
+		merged[i] = strconv.FormatInt(id, 10)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "Merged duplicate leads " + strings.Join(merged, ", ")
+	}
+
+	err = s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, _ repository.PatientRepository, outbox repository.OutboxRepository) error {
+		for _, dup := range duplicates {
+			id := dup.LeadID
+			dependents, err := leadRepo.CountDependents(id)
+			if err != nil {
+				return err
+			}
+			if found := dependents.Describe(); len(found) > 0 {
+				return apperrors.NewConflict(fmt.Sprintf("Lead %d has %s and cannot be merged; cancel it instead", id, strings.Join(found, ", ")), nil)
+			}
+			if err := historyRepo.ReassignLead(id, survivorID); err != nil {
+				return err
+			}
+			if err := leadRepo.Delete(id); err != nil {
+				return err
+			}
+			if err := recordLeadDeleted(outbox, s.workflow, dup, &survivorID); err != nil {
+				return err
+			}
+		}
+		return historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        survivorID,
+			Action:        domain.LeadActionMerge,
+			Reason:        reason,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       []domain.LeadFieldChange{{Field: "MergedLeadIDs", NewValue: strings.Join(merged, ",")}},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return survivor, nil
+}
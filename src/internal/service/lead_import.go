@@ -0,0 +1,603 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// leadImportColumns must all be present in the CSV header (matched case-insensitively).
+// LeadStatusID is optional.
+var leadImportColumns = []string{"PatientName", "ContactNumber", "Age", "Gender", "Emailid", "Address", "CityID", "StateID", "Pincode"}
+
+// leadImportProgressEvery is how many rows a job processes between progress writes.
+const leadImportProgressEvery = 50
+
+// LeadImportSettings tunes the import worker.
+type LeadImportSettings struct {
+	StaleAfter time.Duration // a running job with no progress or heartbeat for this long has lost its worker
+}
+
+// leadImportRecord is one CSV data row with the file line it started on.
+type leadImportRecord struct {
+	line   int
+	fields []string
+}
+
+// StartCSVImport checks the file and header and records a queued job with the file; the import
+// worker (RunImportJobs) processes the rows. Row problems are reported on the job rather than
+// returned.
+func (s *leadService) StartCSVImport(csvContent []byte, clientID int64, packageID int, strict bool, actor domain.Actor) (*domain.LeadImportJob, error) {
+	if err := s.validateImportTarget(csvContent, clientID, packageID); err != nil {
+		return nil, err
+	}
+	_, records, err := readLeadImportCSV(csvContent)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.LeadImportJob{
+		ClientID:      clientID,
+		PackageID:     packageID,
+		Strict:        strict,
+		Status:        domain.LeadImportQueued,
+		TotalRows:     len(records),
+		CreatedBy:     actor.UserID,
+		CreatedByType: actor.UserType,
+		CreatedOn:     time.Now(),
+	}
+	if err := s.importJobRepo.Create(job, csvContent); err != nil {
+		return nil, err
+	}
+	select {
+	case s.importWake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// PreviewCSVImport runs the import's validation and duplicate checks without writing anything.
+func (s *leadService) PreviewCSVImport(csvContent []byte, clientID int64, packageID int, strict bool) (*dto.LeadImportPreview, error) {
+	if err := s.validateImportTarget(csvContent, clientID, packageID); err != nil {
+		return nil, err
+	}
+	columns, records, err := readLeadImportCSV(csvContent)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.parseImportRows(&domain.LeadImportJob{ClientID: clientID, PackageID: packageID, Strict: strict}, columns, records, domain.Actor{})
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &dto.LeadImportPreview{
+		DryRun:     true,
+		Strict:     strict,
+		TotalRows:  len(rows),
+		ToInsert:   []dto.LeadImportPreviewRow{},
+		Rejected:   []dto.LeadImportPreviewRow{},
+		Duplicates: []dto.LeadImportPreviewRow{},
+	}
+	for _, row := range rows {
+		item := dto.LeadImportPreviewRow{RowNumber: row.line, PatientName: row.lead.PatientName, ContactNumber: row.lead.ContactNumber}
+		for _, e := range row.errors {
+			item.Errors = append(item.Errors, dto.LeadImportRowError{RowNumber: e.RowNumber, Column: e.Column, Reason: e.Reason})
+		}
+		switch {
+		case row.dupLeadID != 0:
+			item.DuplicateOfLeadID = &row.dupLeadID
+			preview.Duplicates = append(preview.Duplicates, item)
+		case row.dupRow != 0:
+			item.DuplicateOfRow = &row.dupRow
+			preview.Duplicates = append(preview.Duplicates, item)
+		case len(row.errors) > 0:
+			preview.Rejected = append(preview.Rejected, item)
+		default:
+			preview.ToInsert = append(preview.ToInsert, item)
+		}
+	}
+	preview.WouldCommit = !strict || len(preview.ToInsert) == len(rows)
+	return preview, nil
+}
+
+// validateImportTarget checks the upload and that the client and package it imports into exist.
+func (s *leadService) validateImportTarget(csvContent []byte, clientID int64, packageID int) error {
+	if len(csvContent) == 0 {
+		return apperrors.NewBadRequest("CSV file is required", nil)
+	}
+	if clientID == 0 || packageID == 0 {
+		return apperrors.NewBadRequest("ClientID and PackageID are required", nil)
+	}
+	if exists, err := s.clientRepo.ExistsByID(clientID); err != nil {
+		return err
+	} else if !exists {
+		return apperrors.NewBadRequest(fmt.Sprintf("Client %d does not exist", clientID), nil)
+	}
+	if exists, err := s.packageRepo.ExistsByID(packageID); err != nil {
+		return err
+	} else if !exists {
+		return apperrors.NewBadRequest(fmt.Sprintf("Package %d does not exist", packageID), nil)
+	}
+	return nil
+}
+
+// GetImportJob returns the job and its row errors.
+func (s *leadService) GetImportJob(id int64) (*domain.LeadImportJob, []domain.LeadImportRowError, error) {
+	job, err := s.importJobRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, apperrors.NewNotFound("Import job not found", err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	rowErrors, err := s.importJobRepo.FindErrors(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, rowErrors, nil
+}
+
+// readLeadImportCSV parses the file, checks the header and returns the column index by lower-case
+// name plus the non-blank data rows.
+func readLeadImportCSV(csvContent []byte) (map[string]int, []leadImportRecord, error) {
+	reader := csv.NewReader(strings.NewReader(string(csvContent)))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, apperrors.NewBadRequest("CSV contains no data rows", nil)
+	}
+	if err != nil {
+		return nil, nil, apperrors.NewBadRequest("Invalid CSV: "+err.Error(), err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := columns[name]; !dup {
+			columns[name] = i
+		}
+	}
+	for _, name := range leadImportColumns {
+		if _, ok := columns[strings.ToLower(name)]; !ok {
+			return nil, nil, apperrors.NewBadRequest("CSV missing required column: "+name, nil)
+		}
+	}
+
+	var records []leadImportRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, apperrors.NewBadRequest("Invalid CSV: "+err.Error(), err)
+		}
+		if isBlankRecord(fields) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, leadImportRecord{line: line, fields: fields})
+	}
+	if len(records) == 0 {
+		return nil, nil, apperrors.NewBadRequest("CSV contains no data rows", nil)
+	}
+	return columns, records, nil
+}
+
+func isBlankRecord(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// RunImportJobs is the import worker: every interval, and whenever a job is queued on this instance,
+// it fails jobs whose worker stopped and runs the queued ones, until ctx is cancelled.
+func (s *leadService) RunImportJobs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.failStaleImportJobs()
+		if _, err := s.ProcessImportJobs(ctx); err != nil {
+			log.Printf(`{"timestamp":"%s","level":"error","event":"lead_import_worker_failed","error":%q}`,
+				time.Now().UTC().Format(time.RFC3339), err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.importWake:
+		}
+	}
+}
+
+// ProcessImportJobs runs queued import jobs, oldest first, until none is left or ctx is cancelled,
+// and returns how many it ran. Each job is claimed before it runs, so it runs on one instance only.
+func (s *leadService) ProcessImportJobs(ctx context.Context) (int, error) {
+	ran := 0
+	for ctx.Err() == nil {
+		queued, err := s.importJobRepo.FindQueued(1)
+		if err != nil || len(queued) == 0 {
+			return ran, err
+		}
+		job := queued[0]
+		now := time.Now()
+		claimed, err := s.importJobRepo.Claim(job.JobID, now)
+		if err != nil {
+			return ran, err
+		}
+		if !claimed {
+			continue
+		}
+		job.Status = domain.LeadImportRunning
+		job.StartedOn = &now
+		job.LastUpdatedOn = &now
+		s.runImportJob(job)
+		ran++
+	}
+	return ran, nil
+}
+
+// failStaleImportJobs fails running jobs that stopped reporting progress, e.g. because their instance
+// was restarted mid-import. A lenient job may have inserted some rows; a strict job inserted none.
+func (s *leadService) failStaleImportJobs() {
+	failed, err := s.importJobRepo.FailStale(time.Now().Add(-s.imports.StaleAfter),
+		"Import was interrupted; check the job's inserted rows and upload the remaining rows again")
+	if err != nil {
+		log.Printf(`{"timestamp":"%s","level":"error","event":"lead_import_recovery_failed","error":%q}`,
+			time.Now().UTC().Format(time.RFC3339), err.Error())
+		return
+	}
+	if failed > 0 {
+		log.Printf(`{"timestamp":"%s","level":"warn","event":"lead_import_jobs_interrupted","count":%d}`,
+			time.Now().UTC().Format(time.RFC3339), failed)
+	}
+}
+
+// runImportJob processes a claimed job. A heartbeat keeps the job from looking stale while a long
+// strict import runs in one transaction.
+func (s *leadService) runImportJob(job domain.LeadImportJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.finishImportJob(&job, domain.LeadImportFailed, fmt.Sprintf("Import stopped unexpectedly: %v", r))
+		}
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go s.importHeartbeat(job.JobID, done)
+
+	content, err := s.importJobRepo.FindContent(job.JobID)
+	if err != nil {
+		s.finishImportJob(&job, domain.LeadImportFailed, "Import failed; the uploaded file could not be read: "+err.Error())
+		return
+	}
+	columns, records, err := readLeadImportCSV(content)
+	if err != nil {
+		s.finishImportJob(&job, domain.LeadImportFailed, "Import failed; no leads were inserted: "+err.Error())
+		return
+	}
+	actor := domain.Actor{UserID: job.CreatedBy, UserType: job.CreatedByType}
+
+	if job.Strict {
+		s.runStrictImport(&job, columns, records, actor)
+	} else {
+		s.runLenientImport(&job, columns, records, actor)
+	}
+}
+
+// importHeartbeat touches the running job until done is closed.
+func (s *leadService) importHeartbeat(jobID int64, done <-chan struct{}) {
+	ticker := time.NewTicker(s.imports.StaleAfter / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if err := s.importJobRepo.Touch(jobID, now); err != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"lead_import_heartbeat_failed","job_id":%d,"error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), jobID, err.Error())
+			}
+		}
+	}
+}
+
+// runLenientImport inserts each valid row in its own transaction and skips invalid and duplicate ones.
+func (s *leadService) runLenientImport(job *domain.LeadImportJob, columns map[string]int, records []leadImportRecord, actor domain.Actor) {
+	rows, err := s.parseImportRows(job, columns, records, actor)
+	if err != nil {
+		s.finishImportJob(job, domain.LeadImportFailed, "Import failed; no leads were inserted: "+err.Error())
+		return
+	}
+	var pending []domain.LeadImportRowError
+	for i := range rows {
+		row := &rows[i]
+		if len(row.errors) == 0 {
+			err := s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, patientRepo repository.PatientRepository, outbox repository.OutboxRepository) error {
+				return s.insertImportedLead(leadRepo, historyRepo, patientRepo, outbox, &row.lead, actor)
+			})
+			if err != nil {
+				row.errors = []domain.LeadImportRowError{{JobID: job.JobID, RowNumber: row.line, Reason: "Failed to save lead: " + err.Error()}}
+			}
+		}
+		if len(row.errors) > 0 {
+			job.FailedRows++
+			pending = append(pending, row.errors...)
+		} else {
+			job.InsertedRows++
+		}
+		job.ProcessedRows++
+		if job.ProcessedRows%leadImportProgressEvery == 0 {
+			s.saveImportJob(job, pending)
+			pending = nil
+		}
+	}
+	s.saveImportJob(job, pending)
+	s.finishImportJob(job, domain.LeadImportCompleted,
+		fmt.Sprintf("Inserted %d of %d rows; %d rows skipped", job.InsertedRows, job.TotalRows, job.FailedRows))
+}
+
+// runStrictImport inserts nothing if any row is invalid or a duplicate; otherwise all rows are
+// inserted in one transaction.
+func (s *leadService) runStrictImport(job *domain.LeadImportJob, columns map[string]int, records []leadImportRecord, actor domain.Actor) {
+	rows, err := s.parseImportRows(job, columns, records, actor)
+	if err != nil {
+		s.finishImportJob(job, domain.LeadImportFailed, "Import failed; no leads were inserted: "+err.Error())
+		return
+	}
+	var allErrors []domain.LeadImportRowError
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			job.FailedRows++
+			allErrors = append(allErrors, row.errors...)
+		}
+	}
+	job.ProcessedRows = len(rows)
+	if len(allErrors) > 0 {
+		s.saveImportJob(job, allErrors)
+		s.finishImportJob(job, domain.LeadImportFailed,
+			fmt.Sprintf("Strict import rejected: %d of %d rows are invalid or duplicates; no leads were inserted", job.FailedRows, job.TotalRows))
+		return
+	}
+
+	err = s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, patientRepo repository.PatientRepository, outbox repository.OutboxRepository) error {
+		for i := range rows {
+			if err := s.insertImportedLead(leadRepo, historyRepo, patientRepo, outbox, &rows[i].lead, actor); err != nil {
+				return fmt.Errorf("row %d: %w", rows[i].line, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.finishImportJob(job, domain.LeadImportFailed, "Import failed; no leads were inserted: "+err.Error())
+		return
+	}
+	job.InsertedRows = len(rows)
+	s.finishImportJob(job, domain.LeadImportCompleted, fmt.Sprintf("Inserted %d of %d rows", job.InsertedRows, job.TotalRows))
+}
+
+func (s *leadService) insertImportedLead(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, patientRepo repository.PatientRepository, outbox repository.OutboxRepository, lead *domain.Lead, actor domain.Actor) error {
+	if err := linkPatient(patientRepo, lead); err != nil {
+		return err
+	}
+	if err := leadRepo.Create(lead); err != nil {
+		return err
+	}
+	err := historyRepo.LogAction(&domain.LeadHistory{
+		LeadID:        lead.LeadID,
+		Action:        domain.LeadActionCsvImport,
+		CreatedBy:     lead.CreatedBy,
+		CreatedByType: actor.UserType,
+		Changes:       domain.DiffLeads(domain.Lead{}, *lead),
+	})
+	if err != nil {
+		return err
+	}
+	return recordLeadEvents(outbox, s.workflow, nil, *lead)
+}
+
+// leadImportRow is a parsed CSV row: the lead it would create and anything that stops it from being
+// inserted. A duplicate row also has an entry in errors.
+type leadImportRow struct {
+	line      int
+	lead      domain.Lead
+	errors    []domain.LeadImportRowError
+	dupLeadID int64 // existing lead this row duplicates
+	dupRow    int   // earlier row of the file this row duplicates
+}
+
+// parseImportRows parses every record and flags valid rows that duplicate an existing lead of the
+// client or an earlier row of the file.
+func (s *leadService) parseImportRows(job *domain.LeadImportJob, columns map[string]int, records []leadImportRecord, actor domain.Actor) ([]leadImportRow, error) {
+	rows := make([]leadImportRow, len(records))
+	var contactNumbers []string
+	for i, rec := range records {
+		lead, rowErrors := s.parseImportRow(job, columns, rec, actor)
+		rows[i] = leadImportRow{line: rec.line, lead: lead, errors: rowErrors}
+		if len(rowErrors) == 0 {
+			contactNumbers = append(contactNumbers, lead.ContactNumber)
+		}
+	}
+	if !s.duplicates.Enabled() || len(contactNumbers) == 0 {
+		return rows, nil
+	}
+
+	existing, err := s.repo.FindByClientAndContactNumbers(job.ClientID, contactNumbers)
+	if err != nil {
+		return nil, err
+	}
+	var accepted []int
+	for i := range rows {
+		row := &rows[i]
+		if len(row.errors) > 0 {
+			continue
+		}
+		for _, lead := range existing {
+			if s.duplicates.IsDuplicate(lead, row.lead) {
+				row.dupLeadID = lead.LeadID
+				break
+			}
+		}
+		if row.dupLeadID == 0 {
+			for _, j := range accepted {
+				if s.duplicates.IsDuplicate(rows[j].lead, row.lead) {
+					row.dupRow = rows[j].line
+					break
+				}
+			}
+		}
+		switch {
+		case row.dupLeadID != 0:
+			row.errors = append(row.errors, domain.LeadImportRowError{JobID: job.JobID, RowNumber: row.line, Reason: fmt.Sprintf("Duplicate of lead %d", row.dupLeadID)})
+		case row.dupRow != 0:
+			row.errors = append(row.errors, domain.LeadImportRowError{JobID: job.JobID, RowNumber: row.line, Reason: fmt.Sprintf("Duplicate of row %d", row.dupRow)})
+		default:
+			accepted = append(accepted, i)
+		}
+	}
+	return rows, nil
+}
+
+// parseImportRow builds a lead from one CSV row, reporting every invalid column.
+func (s *leadService) parseImportRow(job *domain.LeadImportJob, columns map[string]int, rec leadImportRecord, actor domain.Actor) (domain.Lead, []domain.LeadImportRowError) {
+	var rowErrors []domain.LeadImportRowError
+	fail := func(column, reason string) {
+		rowErrors = append(rowErrors, domain.LeadImportRowError{JobID: job.JobID, RowNumber: rec.line, Column: column, Reason: reason})
+	}
+	at := func(column string) string {
+		if i, ok := columns[strings.ToLower(column)]; ok && i < len(rec.fields) {
+			return strings.TrimSpace(rec.fields[i])
+		}
+		return ""
+	}
+	text := func(column string, maxLen int) string {
+		v := at(column)
+		switch {
+		case v == "":
+			fail(column, "is required")
+		case len([]rune(v)) > maxLen:
+			fail(column, fmt.Sprintf("must be at most %d characters", maxLen))
+		}
+		return v
+	}
+	digits := func(column string, minLen, maxLen int) string {
+		v := at(column)
+		switch {
+		case v == "":
+			fail(column, "is required")
+		case !isDigits(v) || len(v) < minLen || len(v) > maxLen:
+			if minLen == maxLen {
+				fail(column, fmt.Sprintf("must be %d digits", maxLen))
+			} else {
+				fail(column, fmt.Sprintf("must be %d to %d digits", minLen, maxLen))
+			}
+		}
+		return v
+	}
+	positiveInt8 := func(column string) int8 {
+		v := at(column)
+		if v == "" {
+			fail(column, "is required")
+			return 0
+		}
+		n, err := strconv.ParseInt(v, 10, 8)
+		if err != nil || n <= 0 {
+			fail(column, fmt.Sprintf("must be a whole number between 1 and 127, got %q", v))
+			return 0
+		}
+		return int8(n)
+	}
+
+	patientName := text("PatientName", 100)
+	contactNumber := digits("ContactNumber", 10, 10)
+	age := positiveInt8("Age")
+	gender := strings.ToUpper(text("Gender", 1))
+	email := text("Emailid", 75)
+	if email != "" && len(email) <= 75 {
+		if _, err := mail.ParseAddress(email); err != nil {
+			fail("Emailid", "is not a valid email address")
+		}
+	}
+	address := text("Address", 150)
+	cityID := positiveInt8("CityID")
+	stateID := positiveInt8("StateID")
+	pincode := digits("Pincode", 6, 6)
+
+	if raw := at("LeadStatusID"); raw != "" {
+		status, err := strconv.ParseInt(raw, 10, 8)
+		if err != nil {
+			fail("LeadStatusID", fmt.Sprintf("must be a number, got %q", raw))
+		} else if err := s.validateInitialStatus(int8(status)); err != nil {
+			fail("LeadStatusID", err.Error())
+		}
+	}
+
+	now := time.Now()
+	return domain.Lead{
+		ClientID:      job.ClientID,
+		PatientCode:   s.GeneratePatientCode(patientName, contactNumber),
+		PatientName:   patientName,
+		Age:           age,
+		Gender:        gender,
+		PackageID:     job.PackageID,
+		ContactNumber: contactNumber,
+		Emailid:       email,
+		Address:       address,
+		CityID:        cityID,
+		StateID:       stateID,
+		Pincode:       pincode,
+		LeadStatusID:  s.workflow.InitialStatus(),
+		CreatedBy:     actor.UserID,
+		CreatedOn:     now,
+		LastUpdatedBy: actor.UserID,
+		LastUpdatedOn: now,
+	}, rowErrors
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// saveImportJob writes progress and any new row errors. Failures are logged; the job keeps running.
+func (s *leadService) saveImportJob(job *domain.LeadImportJob, rowErrors []domain.LeadImportRowError) {
+	if err := s.importJobRepo.AddErrors(rowErrors); err != nil {
+		log.Printf(`{"timestamp":"%s","level":"error","event":"lead_import_errors_save_failed","job_id":%d,"rows":%d,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339), job.JobID, len(rowErrors), err.Error())
+	}
+	if err := s.importJobRepo.Update(job); err != nil {
+		log.Printf(`{"timestamp":"%s","level":"error","event":"lead_import_progress_save_failed","job_id":%d,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339), job.JobID, err.Error())
+	}
+}
+
+func (s *leadService) finishImportJob(job *domain.LeadImportJob, status, message string) {
+	completed := time.Now()
+	job.Status = status
+	job.Message = message
+	job.CompletedOn = &completed
+	s.saveImportJob(job, nil)
+	log.Printf(`{"timestamp":"%s","level":"info","event":"lead_import_finished","job_id":%d,"status":%q,"message":%q}`,
+		time.Now().UTC().Format(time.RFC3339), job.JobID, status, message)
+}
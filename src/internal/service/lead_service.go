@@ -1,7 +1,7 @@
 package service
 
 import (
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -12,44 +12,96 @@ import (
 	"b2b-diagnostic-aggregator/apis/internal/domain"
 	"b2b-diagnostic-aggregator/apis/internal/dto"
 	"b2b-diagnostic-aggregator/apis/internal/repository"
+	"b2b-diagnostic-aggregator/apis/pkg/utils"
 
 	"gorm.io/gorm"
 )
 
 type LeadService interface {
-	ListLeads(filter repository.LeadListFilter) ([]domain.Lead, int64, error)
-	GetLeadByID(id int64) (*domain.LeadDetail, error)
-	CreateLead(l *domain.Lead, createdBy int64) error
-	UpdateLead(id int64, update *dto.LeadUpdateRequest, lastUpdatedBy int64) (*domain.Lead, error)
-	DeleteLead(id int64, actorID int64) error
-	BulkUpdateLeadStatus(leadIDs []int64, statusID int8, lastUpdatedBy int64) (int64, error)
-	BulkImportFromCSV(csvContent []byte, clientID int64, packageID int, createdBy int64) (int, error)
+	ListLeads(filter repository.LeadListFilter, scope domain.TenantScope) ([]domain.Lead, int64, error)
+	GetLeadByID(id int64, scope domain.TenantScope) (*domain.LeadDetail, error)
+	CreateLead(l *domain.Lead, allowDuplicate bool, actor domain.Actor, scope domain.TenantScope) error
+	UpdateLead(id int64, update *dto.LeadUpdateRequest, actor domain.Actor, scope domain.TenantScope) (*domain.Lead, error)
+	DeleteLead(id int64, actor domain.Actor) error
+	BulkUpdateLeadStatus(leadIDs []int64, statusID int8, reason string, actor domain.Actor) (int64, error)
+	PreviewBulkLeadStatus(leadIDs []int64, statusID int8, reason string) (*dto.BulkLeadStatusPreview, error)
+	StartCSVImport(csvContent []byte, clientID int64, packageID int, strict bool, actor domain.Actor) (*domain.LeadImportJob, error)
+	PreviewCSVImport(csvContent []byte, clientID int64, packageID int, strict bool) (*dto.LeadImportPreview, error)
+	GetImportJob(id int64) (*domain.LeadImportJob, []domain.LeadImportRowError, error)
+	ProcessImportJobs(ctx context.Context) (int, error)
+	RunImportJobs(ctx context.Context, interval time.Duration)
+	GetStatusWorkflow(leadIDs []int64, scope domain.TenantScope) (*dto.LeadStatusWorkflowResponse, error)
+	GetLeadHistory(id int64, scope domain.TenantScope) ([]dto.LeadHistoryEntry, error)
+	ListDuplicates(clientID *int64, scope domain.TenantScope) ([]dto.LeadDuplicateGroup, error)
+	MergeLeads(survivorID int64, duplicateIDs []int64, reason string, actor domain.Actor) (*domain.Lead, error)
 }
 
 type leadService struct {
-	repo        repository.LeadRepository
-	uow         repository.LeadUnitOfWork
-	clientRepo  repository.ClientRepository
-	packageRepo repository.PackageRepository
+	repo          repository.LeadRepository
+	historyRepo   repository.LeadHistoryRepository
+	uow           repository.LeadUnitOfWork
+	clientRepo    repository.ClientRepository
+	packageRepo   repository.PackageRepository
+	employeeRepo  repository.EmployeeRepository
+	labRepo       repository.LabRepository
+	workflow      *domain.LeadStatusWorkflow
+	importJobRepo repository.LeadImportJobRepository
+	patientRepo   repository.PatientRepository
+	duplicates    domain.LeadDuplicatePolicy
+	imports       LeadImportSettings
+	importWake    chan struct{} // nudges the import worker when a job is queued
 }
 
-func NewLeadService(repo repository.LeadRepository, uow repository.LeadUnitOfWork, clientRepo repository.ClientRepository, packageRepo repository.PackageRepository) LeadService {
-	return &leadService{repo: repo, uow: uow, clientRepo: clientRepo, packageRepo: packageRepo}
+func NewLeadService(
+	repo repository.LeadRepository,
+	historyRepo repository.LeadHistoryRepository,
+	uow repository.LeadUnitOfWork,
+	clientRepo repository.ClientRepository,
+	packageRepo repository.PackageRepository,
+	employeeRepo repository.EmployeeRepository,
+	labRepo repository.LabRepository,
+	workflow *domain.LeadStatusWorkflow,
+	importJobRepo repository.LeadImportJobRepository,
+	patientRepo repository.PatientRepository,
+	duplicates domain.LeadDuplicatePolicy,
+	imports LeadImportSettings,
+) LeadService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	if imports.StaleAfter <= 0 {
+		imports.StaleAfter = 5 * time.Minute
+	}
+	return &leadService{
+		repo:          repo,
+		historyRepo:   historyRepo,
+		uow:           uow,
+		clientRepo:    clientRepo,
+		packageRepo:   packageRepo,
+		employeeRepo:  employeeRepo,
+		labRepo:       labRepo,
+		workflow:      workflow,
+		importJobRepo: importJobRepo,
+		patientRepo:   patientRepo,
+		duplicates:    duplicates,
+		imports:       imports,
+		importWake:    make(chan struct{}, 1),
+	}
 }
 
-func (s *leadService) ListLeads(filter repository.LeadListFilter) ([]domain.Lead, int64, error) {
-	return s.repo.List(filter)
+func (s *leadService) ListLeads(filter repository.LeadListFilter, scope domain.TenantScope) ([]domain.Lead, int64, error) {
+	return s.repo.WithScope(scope).List(filter)
 }
 
-func (s *leadService) GetLeadByID(id int64) (*domain.LeadDetail, error) {
-	lead, err := s.repo.FindByID(id)
+func (s *leadService) GetLeadByID(id int64, scope domain.TenantScope) (*domain.LeadDetail, error) {
+	lead, err := s.repo.WithScope(scope).FindByID(id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, apperrors.NewNotFound("Lead not found", err)
 	}
 	if err != nil {
 		return nil, err
 	}
-	detail := &domain.LeadDetail{Lead: *lead}
+	detail := &domain.LeadDetail{Lead: *lead, NextStatuses: s.workflow.NextStatuses(lead.LeadStatusID)}
 	if lead.ClientID != 0 {
 		if client, _ := s.clientRepo.FindByID(lead.ClientID); client != nil {
 			detail.ClientName = client.ClientName
@@ -63,35 +115,64 @@ func (s *leadService) GetLeadByID(id int64) (*domain.LeadDetail, error) {
 	return detail, nil
 }
 
-func (s *leadService) CreateLead(l *domain.Lead, createdBy int64) error {
+// CreateLead inserts the lead unless it looks like a duplicate of a recent lead (see
+// LeadDuplicatePolicy); allowDuplicate skips that check.
+func (s *leadService) CreateLead(l *domain.Lead, allowDuplicate bool, actor domain.Actor, scope domain.TenantScope) error {
+	if !scope.AllowsClient(l.ClientID) {
+		return apperrors.NewForbidden("You can only create leads for your own client account", nil)
+	}
+	if err := s.validateInitialStatus(l.LeadStatusID); err != nil {
+		return err
+	}
+	l.LeadStatusID = s.workflow.InitialStatus()
+	if l.PatientID != 0 {
+		if err := s.applyPatient(l); err != nil {
+			return err
+		}
+	}
 	now := time.Now()
-	l.CreatedBy = createdBy
+	if !allowDuplicate && s.duplicates.Enabled() {
+		candidate := *l
+		candidate.CreatedOn = now
+		if dup, err := s.findDuplicate(candidate); err != nil {
+			return err
+		} else if dup != nil {
+			return apperrors.NewConflict(fmt.Sprintf("Lead looks like a duplicate of lead %d created on %s; set AllowDuplicate to create it anyway",
+				dup.LeadID, dup.CreatedOn.Format("2006-01-02")), nil)
+		}
+	}
+	l.CreatedBy = actor.UserID
 	l.CreatedOn = now
-	l.LastUpdatedBy = createdBy
+	l.LastUpdatedBy = actor.UserID
 	l.LastUpdatedOn = now
-	l.PatientID = s.GeneratePatientID(l.PatientName, l.ContactNumber)
+	l.PatientCode = s.GeneratePatientCode(l.PatientName, l.ContactNumber)
 
-	return s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository) error {
+	return s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, patientRepo repository.PatientRepository, outbox repository.OutboxRepository) error {
+		if err := linkPatient(patientRepo, l); err != nil {
+			return err
+		}
 		if err := leadRepo.Create(l); err != nil {
 			return err
 		}
 
 		history := &domain.LeadHistory{
-			LeadID:    l.LeadID,
-			Action:    domain.LeadActionCreate,
-			CreatedBy: createdBy,
+			LeadID:        l.LeadID,
+			Action:        domain.LeadActionCreate,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       domain.DiffLeads(domain.Lead{}, *l),
 		}
 
 		if err := historyRepo.LogAction(history); err != nil {
 			return err
 		}
 
-		return nil
+		return recordLeadEvents(outbox, s.workflow, nil, *l)
 	})
 }
 
-func (s *leadService) UpdateLead(id int64, update *dto.LeadUpdateRequest, lastUpdatedBy int64) (*domain.Lead, error) {
-	existing, err := s.repo.FindByID(id)
+func (s *leadService) UpdateLead(id int64, update *dto.LeadUpdateRequest, actor domain.Actor, scope domain.TenantScope) (*domain.Lead, error) {
+	existing, err := s.repo.WithScope(scope).FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperrors.NewNotFound("Lead not found", err)
@@ -102,6 +183,9 @@ func (s *leadService) UpdateLead(id int64, update *dto.LeadUpdateRequest, lastUp
 	// Merge: only overwrite when payload field is provided (ISNULL(input, existing))
 	l := *existing
 	if update.ClientID != nil {
+		if !scope.IsUnrestricted() && *update.ClientID != existing.ClientID {
+			return nil, apperrors.NewForbidden("ClientID cannot be changed", nil)
+		}
 		l.ClientID = *update.ClientID
 	}
 	if update.PatientName != nil {
@@ -134,31 +218,42 @@ func (s *leadService) UpdateLead(id int64, update *dto.LeadUpdateRequest, lastUp
 	if update.Pincode != nil {
 		l.Pincode = *update.Pincode
 	}
-	if update.LeadStatusID != nil {
+	statusReason := ""
+	if update.LeadStatusID != nil && *update.LeadStatusID != s.workflow.Normalize(existing.LeadStatusID) {
+		if update.StatusReason != nil {
+			statusReason = strings.TrimSpace(*update.StatusReason)
+		}
+		if err := s.validateStatusChange(existing.LeadStatusID, *update.LeadStatusID, statusReason); err != nil {
+			return nil, err
+		}
 		l.LeadStatusID = *update.LeadStatusID
 	}
 
 	l.LeadID = id
-	l.LastUpdatedBy = lastUpdatedBy
+	l.LastUpdatedBy = actor.UserID
 	l.LastUpdatedOn = time.Now()
-	l.PatientID = s.GeneratePatientID(l.PatientName, l.ContactNumber)
+	l.PatientCode = s.GeneratePatientCode(l.PatientName, l.ContactNumber)
+	changes := domain.DiffLeads(*existing, l)
 
-	err = s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository) error {
+	err = s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, _ repository.PatientRepository, outbox repository.OutboxRepository) error {
 		if err := leadRepo.Update(&l); err != nil {
 			return err
 		}
 
 		history := &domain.LeadHistory{
-			LeadID:    l.LeadID,
-			Action:    domain.LeadActionUpdate,
-			CreatedBy: lastUpdatedBy,
+			LeadID:        l.LeadID,
+			Action:        domain.LeadActionUpdate,
+			Reason:        statusReason,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       changes,
 		}
 
 		if err := historyRepo.LogAction(history); err != nil {
 			return err
 		}
 
-		return nil
+		return recordLeadEvents(outbox, s.workflow, existing, l)
 	})
 	if err != nil {
 		return nil, err
@@ -166,48 +261,76 @@ func (s *leadService) UpdateLead(id int64, update *dto.LeadUpdateRequest, lastUp
 	return &l, nil
 }
 
-func (s *leadService) DeleteLead(id int64, actorID int64) error {
-	exists, err := s.repo.ExistsByID(id)
+func (s *leadService) DeleteLead(id int64, actor domain.Actor) error {
+	lead, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.NewNotFound("Lead not found", err)
+	}
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return apperrors.NewNotFound("Lead not found", gorm.ErrRecordNotFound)
-	}
-	return s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository) error {
+	return s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, _ repository.PatientRepository, outbox repository.OutboxRepository) error {
 		if err := leadRepo.Delete(id); err != nil {
 			return err
 		}
 
 		history := &domain.LeadHistory{
-			LeadID:    id,
-			Action:    domain.LeadActionDelete,
-			CreatedBy: actorID,
+			LeadID:        id,
+			Action:        domain.LeadActionDelete,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
 		}
 
 		if err := historyRepo.LogAction(history); err != nil {
 			return err
 		}
 
-		return nil
+		return recordLeadDeleted(outbox, s.workflow, *lead, nil)
 	})
 }
 
-func (s *leadService) BulkUpdateLeadStatus(leadIDs []int64, statusID int8, lastUpdatedBy int64) (int64, error) {
+// BulkUpdateLeadStatus moves every lead to statusID. All leads must exist and be allowed to make the
+// transition; otherwise nothing is updated and the offending leads are listed in the error.
+func (s *leadService) BulkUpdateLeadStatus(leadIDs []int64, statusID int8, reason string, actor domain.Actor) (int64, error) {
+	reason = strings.TrimSpace(reason)
 	var affected int64
-	err := s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository) error {
-		n, err := leadRepo.UpdateStatusForIDs(leadIDs, statusID, lastUpdatedBy)
+	err := s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, _ repository.PatientRepository, outbox repository.OutboxRepository) error {
+		leads, err := leadRepo.FindByIDs(leadIDs)
+		if err != nil {
+			return err
+		}
+		notFound, notAllowed := s.checkBulkStatusChange(leadIDs, leads, statusID, reason)
+		if len(notFound) > 0 || len(notAllowed) > 0 {
+			var problems []string
+			for _, r := range notAllowed {
+				problems = append(problems, fmt.Sprintf("lead %d: %s", r.LeadID, r.Reason))
+			}
+			for _, id := range notFound {
+				problems = append(problems, fmt.Sprintf("lead %d: not found", id))
+			}
+			return apperrors.NewBadRequest("Status update rejected: "+strings.Join(problems, "; "), nil)
+		}
+
+		n, err := leadRepo.UpdateStatusForIDs(leadIDs, statusID, actor.UserID)
 		if err != nil {
 			return err
 		}
 		affected = n
 
-		histories := make([]domain.LeadHistory, len(leadIDs))
-		for i, id := range leadIDs {
+		histories := make([]domain.LeadHistory, len(leads))
+		for i, lead := range leads {
+			after := lead
+			after.LeadStatusID = statusID
 			histories[i] = domain.LeadHistory{
-				LeadID:    id,
-				Action:    domain.LeadActionStatusUpdate,
-				CreatedBy: lastUpdatedBy,
+				LeadID:        lead.LeadID,
+				Action:        domain.LeadActionStatusUpdate,
+				Reason:        reason,
+				CreatedBy:     actor.UserID,
+				CreatedByType: actor.UserType,
+				Changes:       domain.DiffLeads(lead, after),
+			}
+			if err := recordLeadEvents(outbox, s.workflow, &lead, after); err != nil {
+				return err
 			}
 		}
 
@@ -220,117 +343,230 @@ func (s *leadService) BulkUpdateLeadStatus(leadIDs []int64, statusID int8, lastU
 	return affected, err
 }
 
-func (s *leadService) GeneratePatientID(patientName, contactNumber string) string {
-	parts := strings.Fields(patientName)
-	var initials strings.Builder
-	for _, part := range parts {
-		if len(part) > 0 {
-			initials.WriteByte(strings.ToUpper(string(part[0]))[0])
+// PreviewBulkLeadStatus runs the BulkUpdateLeadStatus checks without writing and reports which leads
+// would be updated, which were not found and which may not move to statusID.
+func (s *leadService) PreviewBulkLeadStatus(leadIDs []int64, statusID int8, reason string) (*dto.BulkLeadStatusPreview, error) {
+	leads, err := s.repo.FindByIDs(leadIDs)
+	if err != nil {
+		return nil, err
+	}
+	notFound, notAllowed := s.checkBulkStatusChange(leadIDs, leads, statusID, strings.TrimSpace(reason))
+	preview := &dto.BulkLeadStatusPreview{
+		DryRun:       true,
+		LeadStatusID: statusID,
+		ToUpdate:     []int64{},
+		NotFound:     notFound,
+		NotAllowed:   notAllowed,
+		WouldCommit:  len(notFound) == 0 && len(notAllowed) == 0,
+	}
+	rejected := make(map[int64]bool, len(notAllowed))
+	for _, r := range notAllowed {
+		rejected[r.LeadID] = true
+	}
+	for _, lead := range leads {
+		if !rejected[lead.LeadID] {
+			preview.ToUpdate = append(preview.ToUpdate, lead.LeadID)
 		}
 	}
-	return fmt.Sprintf("%s%s", initials.String(), contactNumber)
+	return preview, nil
 }
 
-func (s *leadService) BulkImportFromCSV(csvContent []byte, clientID int64, packageID int, createdBy int64) (int, error) {
-	if len(csvContent) == 0 {
-		return 0, apperrors.NewBadRequest("CSV file is required", nil)
+// checkBulkStatusChange returns the requested IDs missing from leads and the leads whose move to
+// statusID the workflow does not allow.
+func (s *leadService) checkBulkStatusChange(leadIDs []int64, leads []domain.Lead, statusID int8, reason string) ([]int64, []dto.LeadStatusRejection) {
+	found := make(map[int64]bool, len(leads))
+	notFound := []int64{}
+	notAllowed := []dto.LeadStatusRejection{}
+	for _, lead := range leads {
+		found[lead.LeadID] = true
+		if err := s.validateStatusChange(lead.LeadStatusID, statusID, reason); err != nil {
+			notAllowed = append(notAllowed, dto.LeadStatusRejection{
+				LeadID:          lead.LeadID,
+				CurrentStatusID: s.workflow.Normalize(lead.LeadStatusID),
+				Reason:          err.Error(),
+			})
+		}
+	}
+	for _, id := range leadIDs {
+		if !found[id] {
+			notFound = append(notFound, id)
+		}
 	}
-	if createdBy == 0 {
-		createdBy = 1
+	return notFound, notAllowed
+}
+
+// GetStatusWorkflow returns the status catalog with its transition graph and, for the given leads
+// (within scope), the statuses each may move to next.
+func (s *leadService) GetStatusWorkflow(leadIDs []int64, scope domain.TenantScope) (*dto.LeadStatusWorkflowResponse, error) {
+	resp := &dto.LeadStatusWorkflowResponse{}
+	for _, st := range s.workflow.Statuses() {
+		resp.Statuses = append(resp.Statuses, dto.LeadStatusNode{
+			LeadStatus: st,
+			Next:       s.workflow.NextStatuses(st.ID),
+		})
 	}
-	if clientID == 0 || packageID == 0 {
-		return 0, apperrors.NewBadRequest("ClientID and PackageID are required", nil)
+	if len(leadIDs) == 0 {
+		return resp, nil
 	}
 
-	reader := csv.NewReader(strings.NewReader(string(csvContent)))
-	reader.FieldsPerRecord = -1
-	rows, err := reader.ReadAll()
+	leads, err := s.repo.WithScope(scope).FindByIDs(leadIDs)
 	if err != nil {
-		return 0, apperrors.NewBadRequest("Invalid CSV: "+err.Error(), err)
+		return nil, err
 	}
-	if len(rows) < 2 {
-		return 0, apperrors.NewBadRequest("CSV contains no data rows", nil)
+	for _, lead := range leads {
+		resp.Leads = append(resp.Leads, dto.LeadNextStatuses{
+			LeadID:       lead.LeadID,
+			StatusID:     s.workflow.Normalize(lead.LeadStatusID),
+			NextStatuses: s.workflow.NextStatuses(lead.LeadStatusID),
+		})
 	}
+	return resp, nil
+}
 
-	headers := rows[0]
-	colIndex := func(name string) int {
-		for i, h := range headers {
-			if strings.TrimSpace(strings.ToLower(h)) == strings.ToLower(name) {
-				return i
-			}
-		}
-		return -1
+// GetLeadHistory returns the lead's history (newest first) with field changes and actor names.
+func (s *leadService) GetLeadHistory(id int64, scope domain.TenantScope) ([]dto.LeadHistoryEntry, error) {
+	exists, err := s.repo.WithScope(scope).ExistsByID(id)
+	if err != nil {
+		return nil, err
 	}
-	at := func(row []string, name string) string {
-		if i := colIndex(name); i >= 0 && i < len(row) {
-			return strings.TrimSpace(row[i])
-		}
-		return ""
+	if !exists {
+		return nil, apperrors.NewNotFound("Lead not found", gorm.ErrRecordNotFound)
 	}
-	atInt8 := func(row []string, name string) int8 {
-		s := at(row, name)
-		if s == "" {
-			return 0
-		}
-		n, _ := strconv.ParseInt(s, 10, 8)
-		return int8(n)
+
+	histories, err := s.historyRepo.FindByLeadID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	requiredCols := []string{"PatientName", "ContactNumber", "Age", "Gender", "Emailid", "Address", "CityID", "StateID", "Pincode"}
-	for _, name := range requiredCols {
-		if colIndex(name) < 0 {
-			return 0, apperrors.NewBadRequest("CSV missing required column: "+name, nil)
+	names := make(map[domain.Actor]string)
+	entries := make([]dto.LeadHistoryEntry, 0, len(histories))
+	for _, h := range histories {
+		actor := domain.Actor{UserID: h.CreatedBy, UserType: h.CreatedByType}
+		name, ok := names[actor]
+		if !ok {
+			name = s.resolveActorName(actor)
+			names[actor] = name
+		}
+		entry := dto.LeadHistoryEntry{
+			UID:       h.UID,
+			Action:    h.Action,
+			Reason:    h.Reason,
+			CreatedOn: h.CreatedOn,
+			Actor:     dto.LeadHistoryActor{UserID: h.CreatedBy, UserType: h.CreatedByType, Name: name},
+			Changes:   make([]dto.LeadFieldChange, 0, len(h.Changes)),
 		}
+		for _, change := range h.Changes {
+			entry.Changes = append(entry.Changes, dto.LeadFieldChange{Field: change.Field, OldValue: change.OldValue, NewValue: change.NewValue})
+		}
+		entries = append(entries, entry)
 	}
+	return entries, nil
+}
 
-	inserted := 0
-	for rowIdx := 1; rowIdx < len(rows); rowIdx++ {
-		row := rows[rowIdx]
-		if len(row) == 0 {
-			continue
+// resolveActorName looks up the display name of an employee, client or lab. Entries recorded before
+// the actor type was stored are resolved as employees. Returns "" when the actor no longer exists.
+func (s *leadService) resolveActorName(actor domain.Actor) string {
+	switch actor.UserType {
+	case utils.UserTypeClient:
+		if client, _ := s.clientRepo.FindByID(actor.UserID); client != nil {
+			return client.ClientName
 		}
-		patientName := at(row, "PatientName")
-		contactNumber := at(row, "ContactNumber")
-		if patientName == "" || contactNumber == "" {
-			return inserted, apperrors.NewBadRequest(fmt.Sprintf("Row %d: PatientName and ContactNumber are required", rowIdx+1), nil)
+	case utils.UserTypeLab:
+		if lab, _ := s.labRepo.FindByID(actor.UserID); lab != nil {
+			return lab.LabName
 		}
-
-		now := time.Now()
-		lead := &domain.Lead{
-			ClientID:      clientID,
-			PatientID:     s.GeneratePatientID(patientName, contactNumber),
-			PatientName:   patientName,
-			Age:           atInt8(row, "Age"),
-			Gender:        at(row, "Gender"),
-			PackageID:     int(packageID),
-			ContactNumber: contactNumber,
-			Emailid:       at(row, "Emailid"),
-			Address:       at(row, "Address"),
-			CityID:        atInt8(row, "CityID"),
-			StateID:       atInt8(row, "StateID"),
-			Pincode:       at(row, "Pincode"),
-			LeadStatusID:  atInt8(row, "LeadStatusID"),
-			CreatedBy:     createdBy,
-			CreatedOn:     now,
-			LastUpdatedBy: createdBy,
-			LastUpdatedOn: now,
+	default:
+		if employee, _ := s.employeeRepo.FindByID(actor.UserID); employee != nil {
+			return employee.FullName
 		}
+	}
+	return ""
+}
 
-		err := s.uow.WithinTransaction(func(leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository) error {
-			if err := leadRepo.Create(lead); err != nil {
-				return err
-			}
-			return historyRepo.LogAction(&domain.LeadHistory{
-				LeadID:    lead.LeadID,
-				Action:    domain.LeadActionCsvImport,
-				CreatedBy: createdBy,
-			})
-		})
-		if err != nil {
-			return inserted, err
+// validateInitialStatus accepts an omitted status (0) or the workflow's initial status for new leads.
+func (s *leadService) validateInitialStatus(statusID int8) error {
+	if statusID != 0 && statusID != s.workflow.InitialStatus() {
+		initial, _ := s.workflow.Status(s.workflow.InitialStatus())
+		return apperrors.NewBadRequest(fmt.Sprintf("New leads must start in status %s", initial.Name), nil)
+	}
+	return nil
+}
+
+// validateStatusChange checks from -> to against the workflow and its reason requirement.
+func (s *leadService) validateStatusChange(from, to int8, reason string) error {
+	target, ok := s.workflow.Status(to)
+	if to <= 0 || !ok {
+		return apperrors.NewBadRequest(fmt.Sprintf("Unknown lead status %d", to), nil)
+	}
+	current, ok := s.workflow.Status(from)
+	if !ok {
+		current = domain.LeadStatus{Name: strconv.Itoa(int(from))}
+	}
+	if !s.workflow.CanTransition(from, to) {
+		return apperrors.NewBadRequest(fmt.Sprintf("Lead cannot move from %s to %s", current.Name, target.Name), nil)
+	}
+	if target.ReasonRequired && reason == "" {
+		return apperrors.NewBadRequest(fmt.Sprintf("A reason is required to move a lead to %s", target.Name), nil)
+	}
+	return nil
+}
+
+// applyPatient fills the lead's patient details from its PatientID. The patient must belong to the
+// lead's client.
+func (s *leadService) applyPatient(l *domain.Lead) error {
+	patient, err := s.patientRepo.FindByID(l.PatientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperrors.NewBadRequest(fmt.Sprintf("Patient %d does not exist", l.PatientID), err)
+	}
+	if err != nil {
+		return err
+	}
+	if patient.ClientID != l.ClientID {
+		return apperrors.NewBadRequest(fmt.Sprintf("Patient %d belongs to a different client", l.PatientID), nil)
+	}
+	patient.ApplyTo(l)
+	return nil
+}
+
+// linkPatient sets l.PatientID when it is not set yet: the client's patient with the same contact
+// number and name is reused, otherwise a new patient is created. Family members sharing a contact
+// number get separate patients. Call it with the transaction's patientRepo so a patient created for
+// the lead is rolled back with it.
+func linkPatient(patientRepo repository.PatientRepository, l *domain.Lead) error {
+	if l.PatientID != 0 {
+		return nil
+	}
+	family, err := patientRepo.FindByContactNumber(l.ClientID, l.ContactNumber)
+	if err != nil {
+		return err
+	}
+	name := domain.NormalizePatientName(l.PatientName)
+	for _, member := range family {
+		if domain.NormalizePatientName(member.FullName) == name {
+			l.PatientID = member.PatientID
+			return nil
 		}
-		inserted++
 	}
+	patient := domain.PatientFromLead(*l)
+	now := time.Now()
+	patient.CreatedBy = l.CreatedBy
+	patient.CreatedOn = now
+	patient.LastUpdatedBy = l.CreatedBy
+	patient.LastUpdatedOn = now
+	if err := patientRepo.Create(&patient); err != nil {
+		return err
+	}
+	l.PatientID = patient.PatientID
+	return nil
+}
 
-	return inserted, nil
+func (s *leadService) GeneratePatientCode(patientName, contactNumber string) string {
+	parts := strings.Fields(patientName)
+	var initials strings.Builder
+	for _, part := range parts {
+		if len(part) > 0 {
+			initials.WriteByte(strings.ToUpper(string(part[0]))[0])
+		}
+	}
+	return fmt.Sprintf("%s%s", initials.String(), contactNumber)
 }
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+)
+
+// leadStatusWorkflowFile is the JSON layout for a custom status graph, e.g.
+//
+//	{"statuses":[{"id":1,"code":"NEW","name":"New"}, ...],
+//	 "transitions":{"1":[2,3,8], ...}}
+type leadStatusWorkflowFile struct {
+	Statuses []struct {
+		ID             int8   `json:"id"`
+		Code           string `json:"code"`
+		Name           string `json:"name"`
+		ReasonRequired bool   `json:"reasonRequired"`
+	} `json:"statuses"`
+	Transitions map[string][]int8 `json:"transitions"`
+}
+
+// LoadLeadStatusWorkflow returns the default workflow when path is empty, otherwise the workflow
+// defined in the JSON file at path. Every status referenced by a transition must be in the catalog.
+func LoadLeadStatusWorkflow(path string) (*domain.LeadStatusWorkflow, error) {
+	if path == "" {
+		return domain.DefaultLeadStatusWorkflow(), nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file leadStatusWorkflowFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("lead status workflow %s: %w", path, err)
+	}
+
+	known := make(map[int8]bool, len(file.Statuses))
+	statuses := make([]domain.LeadStatus, 0, len(file.Statuses))
+	for _, st := range file.Statuses {
+		if st.ID <= 0 || known[st.ID] {
+			return nil, fmt.Errorf("lead status workflow %s: invalid or duplicate status id %d", path, st.ID)
+		}
+		known[st.ID] = true
+		statuses = append(statuses, domain.LeadStatus{ID: st.ID, Code: st.Code, Name: st.Name, ReasonRequired: st.ReasonRequired})
+	}
+	if !known[domain.LeadStatusNew] {
+		return nil, fmt.Errorf("lead status workflow %s: status %d (New) is required", path, domain.LeadStatusNew)
+	}
+
+	transitions := make(map[int8][]int8, len(file.Transitions))
+	for fromStr, to := range file.Transitions {
+		from, err := strconv.ParseInt(fromStr, 10, 8)
+		if err != nil || !known[int8(from)] {
+			return nil, fmt.Errorf("lead status workflow %s: unknown transition source %q", path, fromStr)
+		}
+		for _, id := range to {
+			if !known[id] {
+				return nil, fmt.Errorf("lead status workflow %s: unknown transition target %d", path, id)
+			}
+		}
+		transitions[int8(from)] = to
+	}
+	return domain.NewLeadStatusWorkflow(statuses, transitions), nil
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+// Lead stream event types, as sent in the SSE event field.
+const (
+	LeadStreamCreated       = "lead.created"
+	LeadStreamUpdated       = "lead.updated"
+	LeadStreamStatusChanged = "lead.status_changed"
+	LeadStreamDeleted       = "lead.deleted"
+)
+
+// LeadStreamSettings tunes the lead event stream.
+type LeadStreamSettings struct {
+	Buffer      int           // live events held per subscriber before it is dropped as too slow
+	BatchSize   int           // outbox events read per poll
+	ReplayLimit int           // most events replayed on resume before the client is told to reload
+	GapGrace    time.Duration // how long a gap in outbox IDs may be an uncommitted transaction
+}
+
+// LeadStream fans lead events out to stream subscribers, filtered by their tenant scope. It tails the
+// outbox itself instead of subscribing to the event dispatcher, because the dispatcher hands each
+// event to one API instance and every instance has its own streaming clients.
+//
+// Writers never wait on the stream: the outbox is read by one poller per instance, and each
+// subscriber has a bounded buffer. A subscriber that lets its buffer fill is dropped; its client
+// reconnects with Last-Event-ID and catches up from the outbox.
+type LeadStream interface {
+	// Subscribe starts a subscription for scope. With lastEventID set, the events written after it
+	// are returned as the subscription's backlog.
+	Subscribe(scope domain.TenantScope, lastEventID int64) (*LeadStreamSubscription, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// LeadStreamSubscription is one client's view of the stream. Send Backlog first, then Events; an
+// event may appear in both, so skip IDs already sent.
+type LeadStreamSubscription struct {
+	Backlog []dto.LeadStreamEvent
+	// Reset is set when the client missed more than the replay limit; it should reload its leads
+	// and resume from ResumeID.
+	Reset    bool
+	ResumeID int64
+	// Events delivers live events and is closed when the subscriber is dropped for falling behind or
+	// the stream stops.
+	Events <-chan dto.LeadStreamEvent
+
+	stream *leadStream
+	sub    *leadStreamSubscriber
+}
+
+// Close ends the subscription.
+func (s *LeadStreamSubscription) Close() {
+	s.stream.unsubscribe(s.sub)
+}
+
+type leadStreamSubscriber struct {
+	scope  domain.TenantScope
+	events chan dto.LeadStreamEvent
+}
+
+type leadStream struct {
+	outbox   repository.OutboxRepository
+	workflow *domain.LeadStatusWorkflow
+	settings LeadStreamSettings
+
+	mu          sync.Mutex
+	started     bool
+	stopped     bool  // Run has returned; subscriptions end straight away
+	cursor      int64 // last outbox event broadcast
+	subscribers map[*leadStreamSubscriber]struct{}
+}
+
+func NewLeadStream(outbox repository.OutboxRepository, workflow *domain.LeadStatusWorkflow, settings LeadStreamSettings) LeadStream {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	if settings.Buffer <= 0 {
+		settings.Buffer = 64
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 200
+	}
+	if settings.ReplayLimit <= 0 {
+		settings.ReplayLimit = 1000
+	}
+	if settings.GapGrace <= 0 {
+		settings.GapGrace = 5 * time.Second
+	}
+	return &leadStream{
+		outbox:      outbox,
+		workflow:    workflow,
+		settings:    settings,
+		subscribers: make(map[*leadStreamSubscriber]struct{}),
+	}
+}
+
+func (s *leadStream) Subscribe(scope domain.TenantScope, lastEventID int64) (*LeadStreamSubscription, error) {
+	sub := &leadStreamSubscriber{scope: scope, events: make(chan dto.LeadStreamEvent, s.settings.Buffer)}
+	s.mu.Lock()
+	if err := s.start(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	cursor := s.cursor
+	if s.stopped {
+		close(sub.events)
+	} else {
+		s.subscribers[sub] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	subscription := &LeadStreamSubscription{ResumeID: cursor, Events: sub.events, stream: s, sub: sub}
+	after := lastEventID
+	for after > 0 && after < cursor {
+		events, err := s.outbox.FindAfter(after, s.settings.BatchSize)
+		if err != nil {
+			s.unsubscribe(sub)
+			return nil, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, event := range events {
+			if event.EventID > cursor {
+				return subscription, nil
+			}
+			after = event.EventID
+			view, ok := s.toStreamEvent(event)
+			if !ok || !leadStreamVisible(scope, view.Data) {
+				continue
+			}
+			if len(subscription.Backlog) == s.settings.ReplayLimit {
+				subscription.Backlog, subscription.Reset = nil, true
+				return subscription, nil
+			}
+			subscription.Backlog = append(subscription.Backlog, view)
+		}
+	}
+	return subscription, nil
+}
+
+// Run polls the outbox every interval and broadcasts new lead events until ctx is cancelled. It then
+// ends every subscription so open streams do not hold up server shutdown; clients reconnect and resume.
+func (s *leadStream) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.stop()
+			return
+		case <-ticker.C:
+			if err := s.poll(); err != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"lead_stream_poll_failed","error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), err.Error())
+			}
+		}
+	}
+}
+
+// poll broadcasts the events written since the cursor. Outbox IDs are assigned on insert but become
+// visible on commit, so a gap may be a transaction still in flight; the poll stops at a gap until
+// the events after it are older than GapGrace, then treats the gap as a rollback.
+func (s *leadStream) poll() error {
+	s.mu.Lock()
+	err := s.start()
+	cursor := s.cursor
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	events, err := s.outbox.FindAfter(cursor, s.settings.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if event.EventID != cursor+1 && time.Since(event.OccurredOn) < s.settings.GapGrace {
+			break
+		}
+		cursor = event.EventID
+		view, ok := s.toStreamEvent(event)
+		s.mu.Lock()
+		s.cursor = cursor
+		if ok {
+			s.broadcast(view)
+		}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// start positions the cursor at the newest event the first time the stream is used, so it only
+// broadcasts events written from then on. Callers hold s.mu.
+func (s *leadStream) start() error {
+	if s.started {
+		return nil
+	}
+	latest, err := s.outbox.LatestID()
+	if err != nil {
+		return err
+	}
+	s.cursor, s.started = latest, true
+	return nil
+}
+
+// broadcast hands the event to every subscriber that may see it, dropping those whose buffer is
+// full. Callers hold s.mu.
+func (s *leadStream) broadcast(event dto.LeadStreamEvent) {
+	for sub := range s.subscribers {
+		if !leadStreamVisible(sub.scope, event.Data) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (s *leadStream) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (s *leadStream) unsubscribe(sub *leadStreamSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// toStreamEvent maps a lead domain event to its stream event; other events report false.
+func (s *leadStream) toStreamEvent(event domain.DomainEvent) (dto.LeadStreamEvent, bool) {
+	view := dto.LeadStreamEvent{ID: event.EventID}
+	data := &view.Data
+	var err error
+	switch event.Type {
+	case domain.EventLeadCreated:
+		var created domain.LeadCreated
+		err = event.Decode(&created)
+		view.Type = LeadStreamCreated
+		data.LeadID, data.ClientID, data.LabID = created.LeadID, created.ClientID, created.LabID
+		data.PackageID, data.PatientCode = created.PackageID, created.PatientCode
+		data.Status = s.status(created.StatusID)
+	case domain.EventLeadUpdated:
+		var updated domain.LeadUpdated
+		err = event.Decode(&updated)
+		view.Type = LeadStreamUpdated
+		data.LeadID, data.ClientID, data.LabID = updated.LeadID, updated.ClientID, updated.LabID
+		data.PackageID, data.PatientCode = updated.PackageID, updated.PatientCode
+		data.Status = s.status(updated.StatusID)
+		data.Fields = updated.Fields
+	case domain.EventLeadStatusChanged:
+		var changed domain.LeadStatusChanged
+		err = event.Decode(&changed)
+		view.Type = LeadStreamStatusChanged
+		data.LeadID, data.ClientID, data.LabID = changed.LeadID, changed.ClientID, changed.LabID
+		data.PackageID, data.PatientCode = changed.PackageID, changed.PatientCode
+		data.Status = s.status(changed.ToStatusID)
+		previous := s.status(changed.FromStatusID)
+		data.PreviousStatus = &previous
+	case domain.EventLeadDeleted:
+		var deleted domain.LeadDeleted
+		err = event.Decode(&deleted)
+		view.Type = LeadStreamDeleted
+		data.LeadID, data.ClientID, data.LabID = deleted.LeadID, deleted.ClientID, deleted.LabID
+		data.PackageID, data.PatientCode = deleted.PackageID, deleted.PatientCode
+		data.Status = s.status(deleted.StatusID)
+		data.MergedIntoLeadID = deleted.MergedIntoLeadID
+	default:
+		return view, false
+	}
+	if err != nil {
+		log.Printf(`{"timestamp":"%s","level":"error","event":"lead_stream_decode_failed","event_id":%d,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339), event.EventID, err.Error())
+		return view, false
+	}
+	data.OccurredAt = event.OccurredOn.UTC()
+	return view, true
+}
+
+func (s *leadStream) status(id int8) dto.LeadStreamStatus {
+	status, _ := s.workflow.Status(id)
+	return dto.LeadStreamStatus{ID: id, Name: status.Name}
+}
+
+// leadStreamVisible applies the same tenant rules as lead queries: clients see their own leads and
+// labs the leads assigned to them.
+func leadStreamVisible(scope domain.TenantScope, data dto.LeadStreamData) bool {
+	if scope.ClientID != 0 && scope.ClientID != data.ClientID {
+		return false
+	}
+	if scope.LabID != 0 && (data.LabID == nil || *data.LabID != scope.LabID) {
+		return false
+	}
+	return true
+}
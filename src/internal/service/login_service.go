@@ -1,9 +1,9 @@
 package service
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"strconv"
 	"time"
 
@@ -11,6 +11,8 @@ import (
 	"b2b-diagnostic-aggregator/apis/internal/config"
 	"b2b-diagnostic-aggregator/apis/internal/domain"
 	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/lockout"
+	"b2b-diagnostic-aggregator/apis/internal/notification"
 	"b2b-diagnostic-aggregator/apis/internal/repository"
 	"b2b-diagnostic-aggregator/apis/pkg/utils"
 
@@ -20,10 +22,13 @@ import (
 type LoginService interface {
 	Login(req dto.LoginRequest) (*dto.LoginResponse, error)
 	CreateForgotPasswordRecord(domainName, mobileNumber string) (int, error)
-	GetLatestForgotPasswordKey(domainName, mobileNumber string) (*dto.ForgotPasswordKeyResponse, error)
-	ForgotPasswordReset(forgetPasswordKey, newPassword string) (bool, error)
-	ChangePassword(domainName, mobileNumber, oldPassword, newPassword string) (bool, error)
+	ForgotPasswordReset(domainName, mobileNumber, otp, newPassword string) (bool, error)
+	ChangePassword(domainName, mobileNumber, oldPassword, newPassword, clientIP string) (bool, error)
+	UnlockAccount(userType int, userID int64, clientIP string) error
 	GetProfile(domainName string, userID, mobileNumber *string) (interface{}, error)
+	RefreshToken(refreshToken string) (*dto.RefreshTokenResponse, error)
+	Logout(sessionID string) error
+	IsSessionActive(sessionID string) (bool, error)
 }
 
 type loginService struct {
@@ -32,7 +37,11 @@ type loginService struct {
 	clientRepo   repository.ClientRepository
 	employeeRepo repository.EmployeeRepository
 	labRepo      repository.LabRepository
-	jwtSecret    string
+	refreshRepo  repository.RefreshTokenRepository
+	notifier     notification.Notifier
+	userGuard    *lockout.Guard
+	ipGuard      *lockout.Guard
+	jwtSecrets   utils.TokenSecrets
 	accessTTL    time.Duration
 	refreshTTL   time.Duration
 }
@@ -43,6 +52,10 @@ func NewLoginService(
 	clientRepo repository.ClientRepository,
 	employeeRepo repository.EmployeeRepository,
 	labRepo repository.LabRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	notifier notification.Notifier,
+	userGuard *lockout.Guard,
+	ipGuard *lockout.Guard,
 	jwtCfg config.JWTConfig,
 ) LoginService {
 	accessTTL, err := time.ParseDuration(jwtCfg.ExpiresIn)
@@ -53,13 +66,21 @@ func NewLoginService(
 	if err != nil {
 		refreshTTL = 7 * 24 * time.Hour
 	}
+	refreshSecret := jwtCfg.RefreshSecret
+	if refreshSecret == "" {
+		refreshSecret = jwtCfg.Secret
+	}
 	return &loginService{
 		repo:         repo,
 		forgotRepo:   forgotRepo,
 		clientRepo:   clientRepo,
 		employeeRepo: employeeRepo,
 		labRepo:      labRepo,
-		jwtSecret:    jwtCfg.Secret,
+		refreshRepo:  refreshRepo,
+		notifier:     notifier,
+		userGuard:    userGuard,
+		ipGuard:      ipGuard,
+		jwtSecrets:   utils.TokenSecrets{Access: jwtCfg.Secret, Refresh: refreshSecret},
 		accessTTL:    accessTTL,
 		refreshTTL:   refreshTTL,
 	}
@@ -110,6 +131,10 @@ func (s *loginService) resolveUserByMobileNumber(domainName, mobileNumber string
 
 func (s *loginService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 	fmt.Println("[LOGIN] Service.Login: entry")
+	ipKey := lockout.IPKey(req.ClientIP)
+	if err := attemptError(s.ipGuard.Check(ipKey), "Login from this address"); err != nil {
+		return nil, err
+	}
 	var userID int64
 	var userType int
 	var userTypeStr string
@@ -120,6 +145,9 @@ func (s *loginService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 		uid, ut, ud, err := s.resolveUserByMobileNumber(req.Domain, req.MobileNumber)
 		if err != nil {
 			fmt.Printf("[LOGIN] Service.Login: resolveUserByMobileNumber failed: %v\n", err)
+			if appErr := apperrors.From(err); appErr != nil && appErr.Kind == apperrors.KindNotFound {
+				_ = s.ipGuard.Fail(ipKey)
+			}
 			return nil, err
 		}
 		userID, userType, userData = uid, ut, ud
@@ -130,6 +158,7 @@ func (s *loginService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 		login, err := s.repo.FindByUserID(req.UserID)
 		if err != nil {
 			fmt.Printf("[LOGIN] Service.Login: FindByUserID failed: %v\n", err)
+			_ = s.ipGuard.Fail(ipKey)
 			return nil, apperrors.NewUnauthorized("Invalid user ID or password", err)
 		}
 		userID = login.UserID
@@ -150,65 +179,178 @@ func (s *loginService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 		return nil, apperrors.NewBadRequest("Either (domain + mobileNumber) or userId is required", nil)
 	}
 
-	fmt.Println("[LOGIN] Service.Login: encrypting password")
-	encryptedPassword, err := utils.Encrypt(req.Password)
-	if err != nil {
-		fmt.Printf("[LOGIN] Service.Login: Encrypt failed: %v\n", err)
-		return nil, apperrors.NewInternal("Error validating credentials", err)
+	if userType == 0 {
+		userType = utils.UserTypeClient
+	}
+	userKey := lockout.UserKey(userType, userID)
+	if err := attemptError(s.userGuard.Check(userKey), "Account"); err != nil {
+		return nil, err
 	}
 
 	fmt.Printf("[LOGIN] Service.Login: authenticating userID=%d userTypeStr=%s\n", userID, userTypeStr)
-	ok, err := s.repo.Authenticate(userID, encryptedPassword, userTypeStr)
+	login, err := s.repo.FindByUserIDAndType(userID, userTypeStr)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = s.ipGuard.Fail(ipKey)
+			return nil, apperrors.NewUnauthorized("Invalid credentials", err)
+		}
+		return nil, apperrors.NewInternal("Error validating credentials", err)
+	}
+	ok, needsRehash, err := utils.VerifyPassword(login.Pwd, req.Password)
 	if err != nil {
-		fmt.Printf("[LOGIN] Service.Login: Authenticate error: %v\n", err)
 		return nil, apperrors.NewInternal("Error validating credentials", err)
 	}
 	if !ok {
 		fmt.Println("[LOGIN] Service.Login: authentication failed - password mismatch")
+		if err := s.recordFailure(userKey, ipKey); err != nil {
+			return nil, err
+		}
 		return nil, apperrors.NewUnauthorized("Invalid credentials", errors.New("password mismatch"))
 	}
+	s.userGuard.Reset(userKey)
+	if needsRehash {
+		// Transparent migration from the legacy AES value (or an outdated cost) to bcrypt.
+		if hash, err := utils.HashPassword(req.Password); err == nil {
+			if err := s.repo.UpdatePassword(userID, hash); err != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"password_rehash_failed","user_id":%d,"error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), userID, err.Error())
+			}
+		}
+	}
 	fmt.Println("[LOGIN] Service.Login: authentication OK")
 
-	if userType == 0 {
-		userType = utils.UserTypeClient
-	}
 	fmt.Println("[LOGIN] Service.Login: generating tokens")
-	accessToken, refreshToken, err := utils.GenerateToken(userID, userType, s.jwtSecret, s.accessTTL, s.refreshTTL)
+	sessionID, err := utils.NewTokenID()
+	if err != nil {
+		return nil, apperrors.NewInternal("Failed to create session", err)
+	}
+	tokens, err := s.issueTokens(userID, userType, sessionID)
 	if err != nil {
-		fmt.Printf("[LOGIN] Service.Login: GenerateToken failed: %v\n", err)
 		return nil, err
 	}
 
 	fmt.Println("[LOGIN] Service.Login: success")
 	return &dto.LoginResponse{
 		User:         userData,
-		Token:        accessToken,
-		RefreshToken: refreshToken,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// issueTokens signs a new token pair for the session and records the refresh token's jti so it can
+// be rotated exactly once.
+func (s *loginService) issueTokens(userID int64, userType int, sessionID string) (*utils.TokenPair, error) {
+	tokens, err := utils.GenerateToken(userID, userType, sessionID, s.jwtSecrets, s.accessTTL, s.refreshTTL)
+	if err != nil {
+		return nil, apperrors.NewInternal("Failed to generate tokens", err)
+	}
+	rec := &domain.RefreshToken{
+		TokenID:   tokens.RefreshTokenID,
+		SessionID: sessionID,
+		UserID:    userID,
+		UserType:  strconv.Itoa(userType),
+		ExpiresAt: tokens.RefreshExpiresAt,
+		CreatedOn: time.Now().UTC(),
+	}
+	if err := s.refreshRepo.Create(rec); err != nil {
+		return nil, apperrors.NewInternal("Failed to store session", err)
+	}
+	return tokens, nil
+}
+
+// RefreshToken exchanges a refresh token for a new pair. Each refresh token is single-use: presenting
+// one that was already rotated is treated as theft and revokes the whole session.
+func (s *loginService) RefreshToken(refreshToken string) (*dto.RefreshTokenResponse, error) {
+	claims, err := utils.ValidateTokenOfType(refreshToken, s.jwtSecrets.Refresh, utils.TokenTypeRefresh)
+	if err != nil {
+		return nil, apperrors.NewUnauthorized("Invalid or expired refresh token", err)
+	}
+
+	rec, err := s.refreshRepo.FindByTokenID(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewUnauthorized("Invalid or expired refresh token", err)
+		}
+		return nil, err
+	}
+	if rec.RevokedOn != nil {
+		return nil, apperrors.NewUnauthorized("Session has been revoked", nil)
+	}
+
+	rotated := false
+	if rec.UsedOn == nil {
+		rotated, err = s.refreshRepo.MarkAsUsed(rec.TokenID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !rotated {
+		if err := s.refreshRepo.RevokeSession(rec.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.NewUnauthorized("Refresh token reuse detected; session revoked", nil)
+	}
+
+	tokens, err := s.issueTokens(claims.UserID, claims.UserType, rec.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
+func (s *loginService) Logout(sessionID string) error {
+	if sessionID == "" {
+		return apperrors.NewUnauthorized("Authentication required", nil)
+	}
+	return s.refreshRepo.RevokeSession(sessionID)
+}
+
+func (s *loginService) IsSessionActive(sessionID string) (bool, error) {
+	return s.refreshRepo.IsSessionActive(sessionID)
+}
+
+// Forgot-password OTP policy.
+const (
+	forgotPasswordOTPDigits      = 6
+	forgotPasswordOTPTTL         = 5 * time.Minute
+	forgotPasswordMaxOTPAttempts = 5
+)
+
+// CreateForgotPasswordRecord issues a numeric OTP, stores only its hash and sends it to the user's
+// mobile number through the notifier. Older outstanding OTPs are invalidated. Unknown mobile numbers
+// return success without sending anything so the endpoint cannot be used to enumerate accounts.
 func (s *loginService) CreateForgotPasswordRecord(domainName, mobileNumber string) (int, error) {
 	userID, userType, _, err := s.resolveUserByMobileNumber(domainName, mobileNumber)
 	if err != nil {
+		if appErr := apperrors.From(err); appErr != nil && appErr.Kind == apperrors.KindNotFound {
+			return 1, nil
+		}
 		return 0, err
 	}
+	userTypeStr := strconv.Itoa(userType)
 
-	now := time.Now().UTC()
-	expiry := now.Add(5 * time.Minute)
-
-	payload := map[string]interface{}{
-		"userId": userID, "userType": userType, "expiry": expiry.Format(time.RFC3339),
+	otp, err := utils.GenerateOTP(forgotPasswordOTPDigits)
+	if err != nil {
+		return 0, apperrors.NewInternal("Failed to generate OTP", err)
 	}
-	payloadBytes, _ := json.Marshal(payload)
-	resetKey, err := utils.Encrypt(string(payloadBytes))
+	otpHash, err := utils.HashPassword(otp)
 	if err != nil {
-		return 0, apperrors.NewInternal("Failed to generate reset key", err)
+		return 0, apperrors.NewInternal("Failed to generate OTP", err)
 	}
 
+	if err := s.forgotRepo.InvalidateActiveKeys(userID, userTypeStr); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	expiry := now.Add(forgotPasswordOTPTTL)
 	rec := &domain.ForgotPassword{
 		UserID:            userID,
-		UserType:          strconv.Itoa(userType),
-		ForgetPasswordKey: resetKey,
+		UserType:          userTypeStr,
+		ForgetPasswordKey: otpHash,
 		CreatedOn:         now,
 		ExpiryTimestamp:   expiry,
 		IsPasswordChanged: false,
@@ -216,82 +358,158 @@ func (s *loginService) CreateForgotPasswordRecord(domainName, mobileNumber strin
 	if err := s.forgotRepo.Create(rec); err != nil {
 		return 0, err
 	}
+
+	err = s.notifier.Send(notification.Message{
+		Recipient: mobileNumber,
+		Subject:   "Password reset OTP",
+		Body:      fmt.Sprintf("Your password reset OTP is %s. It expires in %d minutes.", otp, int(forgotPasswordOTPTTL.Minutes())),
+	})
+	if err != nil {
+		return 0, apperrors.NewInternal("Failed to send OTP", err)
+	}
 	return 1, nil
 }
 
-func (s *loginService) GetLatestForgotPasswordKey(domainName, mobileNumber string) (*dto.ForgotPasswordKeyResponse, error) {
+// ForgotPasswordReset verifies the latest OTP for the user and sets the new password. Each attempt is
+// claimed against the record before the OTP is checked; after forgotPasswordMaxOTPAttempts the OTP is
+// burned and a new one must be requested. Returns false (no error) for a wrong, expired or missing OTP.
+func (s *loginService) ForgotPasswordReset(domainName, mobileNumber, otp, newPassword string) (bool, error) {
 	userID, userType, _, err := s.resolveUserByMobileNumber(domainName, mobileNumber)
 	if err != nil {
-		return nil, err
+		if appErr := apperrors.From(err); appErr != nil && appErr.Kind == apperrors.KindNotFound {
+			return false, nil
+		}
+		return false, err
 	}
+	userTypeStr := strconv.Itoa(userType)
 
-	rec, err := s.forgotRepo.FindLatestValidKey(userID, strconv.Itoa(userType))
+	rec, err := s.forgotRepo.FindLatestValidKey(userID, userTypeStr)
 	if err != nil || rec == nil {
-		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, apperrors.NewNotFound("Forgot password key not found or expired", err)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
 		}
-		return nil, apperrors.NewNotFound("Forgot password key not found or expired", nil)
+		return false, nil
 	}
-
-	return &dto.ForgotPasswordKeyResponse{
-		ForgetPasswordKey: rec.ForgetPasswordKey,
-		Expiry:            rec.ExpiryTimestamp.Format(time.RFC3339),
-	}, nil
-}
-
-func (s *loginService) ForgotPasswordReset(forgetPasswordKey, newPassword string) (bool, error) {
-	decrypted, err := utils.Decrypt(forgetPasswordKey)
+	claimed, err := s.forgotRepo.ClaimAttempt(rec, forgotPasswordMaxOTPAttempts)
 	if err != nil {
-		return false, apperrors.NewBadRequest("Invalid forgot password key", err)
-	}
-
-	var payload struct {
-		UserID   int64 `json:"userId"`
-		UserType int   `json:"userType"`
-	}
-	if err := json.Unmarshal([]byte(decrypted), &payload); err != nil {
-		return false, apperrors.NewBadRequest("Invalid forgot password key payload", err)
+		return false, err
 	}
-	if payload.UserID == 0 {
-		return false, apperrors.NewBadRequest("Invalid forgot password key", nil)
+	if !claimed {
+		_ = s.forgotRepo.InvalidateActiveKeys(userID, userTypeStr)
+		return false, apperrors.NewBadRequest("Too many invalid attempts; request a new OTP", nil)
 	}
 
-	rec, err := s.forgotRepo.FindByKey(forgetPasswordKey, payload.UserID, strconv.Itoa(payload.UserType))
-	if err != nil || rec == nil {
+	ok, _, err := utils.VerifyPassword(rec.ForgetPasswordKey, otp)
+	if err != nil {
+		return false, apperrors.NewInternal("Error validating OTP", err)
+	}
+	if !ok {
 		return false, nil
 	}
 
-	encryptedNew, err := utils.Encrypt(newPassword)
+	newHash, err := utils.HashPassword(newPassword)
 	if err != nil {
 		return false, apperrors.NewInternal("Failed to update password", err)
 	}
-	if err := s.repo.UpdatePassword(payload.UserID, encryptedNew); err != nil {
+	if err := s.repo.UpdatePassword(userID, newHash); err != nil {
 		return false, err
 	}
 	_ = s.forgotRepo.MarkAsUsed(rec)
+	_ = s.forgotRepo.InvalidateActiveKeys(userID, userTypeStr)
 	return true, nil
 }
 
-func (s *loginService) ChangePassword(domainName, mobileNumber, oldPassword, newPassword string) (bool, error) {
-	userID, _, _, err := s.resolveUserByMobileNumber(domainName, mobileNumber)
+func (s *loginService) ChangePassword(domainName, mobileNumber, oldPassword, newPassword, clientIP string) (bool, error) {
+	ipKey := lockout.IPKey(clientIP)
+	if err := attemptError(s.ipGuard.Check(ipKey), "Login from this address"); err != nil {
+		return false, err
+	}
+	userID, userType, _, err := s.resolveUserByMobileNumber(domainName, mobileNumber)
 	if err != nil {
+		if appErr := apperrors.From(err); appErr != nil && appErr.Kind == apperrors.KindNotFound {
+			_ = s.ipGuard.Fail(ipKey)
+		}
+		return false, err
+	}
+	userKey := lockout.UserKey(userType, userID)
+	if err := attemptError(s.userGuard.Check(userKey), "Account"); err != nil {
 		return false, err
 	}
 
-	oldEnc, err := utils.Encrypt(oldPassword)
+	login, err := s.repo.FindByUserIDAndType(userID, strconv.Itoa(userType))
 	if err != nil {
-		return false, apperrors.NewInternal("Error validating password", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
 	}
-	newEnc, err := utils.Encrypt(newPassword)
+	ok, _, err := utils.VerifyPassword(login.Pwd, oldPassword)
 	if err != nil {
-		return false, apperrors.NewInternal("Failed to set new password", err)
+		return false, apperrors.NewInternal("Error validating password", err)
 	}
+	if !ok {
+		if err := s.recordFailure(userKey, ipKey); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	s.userGuard.Reset(userKey)
 
-	rows, err := s.repo.ChangePassword(userID, oldEnc, newEnc)
+	newHash, err := utils.HashPassword(newPassword)
 	if err != nil {
+		return false, apperrors.NewInternal("Failed to set new password", err)
+	}
+	if err := s.repo.UpdatePassword(userID, newHash); err != nil {
 		return false, err
 	}
-	return rows > 0, nil
+	return true, nil
+}
+
+// UnlockAccount clears failed-attempt lockouts for the user (when userID is set) and/or the client IP
+// (admin action; locks otherwise expire after the configured lock duration).
+func (s *loginService) UnlockAccount(userType int, userID int64, clientIP string) error {
+	if userID == 0 && clientIP == "" {
+		return apperrors.NewBadRequest("userId and userType, or ip, is required", nil)
+	}
+	if userID != 0 {
+		if userType != utils.UserTypeEmployee && userType != utils.UserTypeClient && userType != utils.UserTypeLab {
+			return apperrors.NewBadRequest("Invalid userType", nil)
+		}
+		s.userGuard.Reset(lockout.UserKey(userType, userID))
+	}
+	if clientIP != "" {
+		s.ipGuard.Reset(lockout.IPKey(clientIP))
+	}
+	return nil
+}
+
+// recordFailure counts a wrong password against the user and the client IP. Returns the lockout
+// error when this failure locks either of them.
+func (s *loginService) recordFailure(userKey, ipKey string) error {
+	userErr := s.userGuard.Fail(userKey)
+	ipErr := s.ipGuard.Fail(ipKey)
+	if userErr != nil {
+		return attemptError(userErr, "Account")
+	}
+	return attemptError(ipErr, "Login from this address")
+}
+
+// attemptError maps a lockout.Guard rejection to a 429 error; subject names what is locked.
+func attemptError(err error, subject string) error {
+	if err == nil {
+		return nil
+	}
+	var locked *lockout.LockedError
+	if errors.As(err, &locked) {
+		until := locked.Until.UTC().Format(time.RFC3339)
+		return apperrors.NewTooManyRequests(fmt.Sprintf("%s locked until %s due to too many failed attempts", subject, until), err)
+	}
+	var throttled *lockout.ThrottledError
+	if errors.As(err, &throttled) {
+		seconds := int(throttled.RetryAfter.Seconds() + 0.999)
+		return apperrors.NewTooManyRequests(fmt.Sprintf("Too many failed attempts; try again in %d seconds", seconds), err)
+	}
+	return err
 }
 
 func (s *loginService) GetProfile(domainName string, userIDStr, mobileNumber *string) (interface{}, error) {
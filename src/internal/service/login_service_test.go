@@ -0,0 +1,248 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/config"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/lockout"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+	"b2b-diagnostic-aggregator/apis/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var testJWTConfig = config.JWTConfig{
+	Secret:           "access-secret-for-tests",
+	RefreshSecret:    "refresh-secret-for-tests",
+	ExpiresIn:        "15m",
+	RefreshExpiresIn: "24h",
+}
+
+type fakeLoginRepo struct {
+	repository.LoginRepository
+	logins map[int64]domain.Login
+}
+
+func (r fakeLoginRepo) FindByUserID(userID int64) (*domain.Login, error) {
+	login, ok := r.logins[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &login, nil
+}
+
+func (r fakeLoginRepo) FindByUserIDAndType(userID int64, userType string) (*domain.Login, error) {
+	login, ok := r.logins[userID]
+	if !ok || login.UserType != userType {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &login, nil
+}
+
+func (r fakeLoginRepo) UpdatePassword(int64, string) error { return nil }
+
+// memRefreshTokens is an in-memory RefreshTokenRepository.
+type memRefreshTokens struct {
+	tokens map[string]domain.RefreshToken
+}
+
+func newMemRefreshTokens() *memRefreshTokens {
+	return &memRefreshTokens{tokens: make(map[string]domain.RefreshToken)}
+}
+
+func (m *memRefreshTokens) Create(token *domain.RefreshToken) error {
+	m.tokens[token.TokenID] = *token
+	return nil
+}
+
+func (m *memRefreshTokens) FindByTokenID(tokenID string) (*domain.RefreshToken, error) {
+	token, ok := m.tokens[tokenID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+func (m *memRefreshTokens) MarkAsUsed(tokenID string) (bool, error) {
+	token, ok := m.tokens[tokenID]
+	if !ok || token.UsedOn != nil || token.RevokedOn != nil {
+		return false, nil
+	}
+	now := time.Now().UTC()
+	token.UsedOn = &now
+	m.tokens[tokenID] = token
+	return true, nil
+}
+
+func (m *memRefreshTokens) RevokeSession(sessionID string) error {
+	now := time.Now().UTC()
+	for id, token := range m.tokens {
+		if token.SessionID == sessionID && token.RevokedOn == nil {
+			token.RevokedOn = &now
+			m.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (m *memRefreshTokens) IsSessionActive(sessionID string) (bool, error) {
+	for _, token := range m.tokens {
+		if token.SessionID == sessionID && token.RevokedOn == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newTestLoginService(t *testing.T, policy lockout.Policy, tokens *memRefreshTokens) LoginService {
+	t.Helper()
+	hash, err := utils.HashPassword("correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins := fakeLoginRepo{logins: map[int64]domain.Login{
+		11: {UserID: 11, Pwd: hash, UserType: "2"},
+	}}
+	userGuard := lockout.NewGuard(lockout.NewMemoryStore(time.Hour), policy)
+	ipGuard := lockout.NewGuard(lockout.NewMemoryStore(time.Hour), lockout.Policy{})
+	return NewLoginService(logins, nil, nil, nil, nil, tokens, nil, userGuard, ipGuard, testJWTConfig)
+}
+
+func TestLoginLockout(t *testing.T) {
+	policy := lockout.Policy{MaxFailures: 3, Window: 15 * time.Minute, LockDuration: 15 * time.Minute}
+	tests := []struct {
+		name      string
+		passwords []string // attempts in order; the last one is checked
+		wantKind  apperrors.Kind
+		wantMsg   string
+	}{
+		{
+			name:      "correct password",
+			passwords: []string{"correct-horse"},
+		},
+		{
+			name:      "wrong password",
+			passwords: []string{"wrong"},
+			wantKind:  apperrors.KindUnauthorized,
+			wantMsg:   "Invalid credentials",
+		},
+		{
+			name:      "success resets earlier failures",
+			passwords: []string{"wrong", "wrong", "correct-horse", "wrong", "wrong"},
+			wantKind:  apperrors.KindUnauthorized,
+			wantMsg:   "Invalid credentials",
+		},
+		{
+			name:      "failure that reaches the limit locks the account",
+			passwords: []string{"wrong", "wrong", "wrong"},
+			wantKind:  apperrors.KindTooManyRequests,
+			wantMsg:   "Account locked until",
+		},
+		{
+			name:      "locked account rejects the correct password",
+			passwords: []string{"wrong", "wrong", "wrong", "correct-horse"},
+			wantKind:  apperrors.KindTooManyRequests,
+			wantMsg:   "Account locked until",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestLoginService(t, policy, newMemRefreshTokens())
+			var resp *dto.LoginResponse
+			var err error
+			for _, password := range tt.passwords {
+				resp, err = svc.Login(dto.LoginRequest{UserID: 11, Password: password, ClientIP: "203.0.113.9"})
+			}
+			if tt.wantKind == "" {
+				if err != nil || resp.Token == "" || resp.RefreshToken == "" {
+					t.Fatalf("Login() = %+v, %v, want tokens", resp, err)
+				}
+				return
+			}
+			wantAppError(t, err, tt.wantKind, tt.wantMsg)
+		})
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	tests := []struct {
+		name string
+		// refresh presents tokens from the login response (index 0) or from earlier refreshes
+		// (index i is the refresh token returned by refresh i-1), in order; the last call is checked.
+		refresh     []int
+		wantKind    apperrors.Kind
+		wantMsg     string
+		wantRevoked bool
+	}{
+		{
+			name:    "fresh token rotates",
+			refresh: []int{0},
+		},
+		{
+			name:    "rotated token rotates again",
+			refresh: []int{0, 1, 2},
+		},
+		{
+			name:        "reusing a rotated token revokes the session",
+			refresh:     []int{0, 0},
+			wantKind:    apperrors.KindUnauthorized,
+			wantMsg:     "reuse detected",
+			wantRevoked: true,
+		},
+		{
+			name:        "successor of a reused token is revoked too",
+			refresh:     []int{0, 0, 1},
+			wantKind:    apperrors.KindUnauthorized,
+			wantMsg:     "Session has been revoked",
+			wantRevoked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := newMemRefreshTokens()
+			svc := newTestLoginService(t, lockout.Policy{}, tokens)
+			login, err := svc.Login(dto.LoginRequest{UserID: 11, Password: "correct-horse"})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			issued := []string{login.RefreshToken}
+			for _, i := range tt.refresh {
+				var resp *dto.RefreshTokenResponse
+				resp, err = svc.RefreshToken(issued[i])
+				if err == nil {
+					issued = append(issued, resp.RefreshToken)
+				}
+			}
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("RefreshToken() error = %v", err)
+				}
+			} else {
+				wantAppError(t, err, tt.wantKind, tt.wantMsg)
+			}
+
+			claims, err := utils.ValidateTokenOfType(login.Token, testJWTConfig.Secret, utils.TokenTypeAccess)
+			if err != nil {
+				t.Fatalf("access token: %v", err)
+			}
+			active, _ := svc.IsSessionActive(claims.SessionID)
+			if active == tt.wantRevoked {
+				t.Fatalf("session active = %v, want %v", active, !tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenRejectsAccessToken(t *testing.T) {
+	svc := newTestLoginService(t, lockout.Policy{}, newMemRefreshTokens())
+	login, err := svc.Login(dto.LoginRequest{UserID: 11, Password: "correct-horse"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	_, err = svc.RefreshToken(login.Token)
+	wantAppError(t, err, apperrors.KindUnauthorized, "Invalid or expired refresh token")
+}
@@ -15,19 +15,19 @@ import (
 type PackageService interface {
 	ListPackages(filter repository.PackageListFilter) ([]domain.Package, int64, error)
 	GetPackageByID(id int) (*domain.Package, error)
-	CreatePackage(p *domain.Package, createdBy int64) error
-	UpdatePackage(p *domain.Package, lastUpdatedBy int64) error
-	DeletePackage(id int) error
+	CreatePackage(p *domain.Package, actor domain.Actor) error
+	UpdatePackage(p *domain.Package, actor domain.Actor) error
+	DeletePackage(id int, actor domain.Actor) error
 	GetActivePackages() ([]domain.Package, error)
-	CreatePackageWithTests(p *domain.Package, testIDs []int, createdBy int64) (*CreatePackageWithTestsResult, error)
+	CreatePackageWithTests(p *domain.Package, testIDs []int, actor domain.Actor) (*CreatePackageWithTestsResult, error)
 	GetAllPackagesWithTestsDetails() ([]domain.PackageWithTestsDetail, error)
-	UpdatePackageStatus(packageID int, isActive bool, lastUpdatedBy int64) (*UpdatePackageStatusResult, error)
-	CreatePackageClientMapping(packageID int, clientID int64, price float64, createdBy, lastUpdatedBy int64) (*PackageClientMappingResult, error)
-	GetAllPackageClientMappings() ([]domain.PackageClientMappingView, error)
-	UpdatePackageClientMappingStatus(id int, isActive bool, lastUpdatedBy int64) (*PackageClientMappingUpdateResult, error)
-	CreatePackageLabMapping(packageID int, labID int64, price float64, createdBy, lastUpdatedBy int64) (*PackageLabMappingResult, error)
-	GetAllPackageLabMappings() ([]domain.PackageLabMappingView, error)
-	UpdatePackageLabMappingStatus(id int, isActive bool, lastUpdatedBy int64) (*PackageLabMappingUpdateResult, error)
+	UpdatePackageStatus(packageID int, isActive bool, actor domain.Actor) (*UpdatePackageStatusResult, error)
+	CreatePackageClientMapping(packageID int, clientID int64, price float64, actor domain.Actor) (*PackageClientMappingResult, error)
+	GetAllPackageClientMappings(scope domain.TenantScope) ([]domain.PackageClientMappingView, error)
+	UpdatePackageClientMappingStatus(id int, isActive bool, actor domain.Actor) (*PackageClientMappingUpdateResult, error)
+	CreatePackageLabMapping(packageID int, labID int64, price float64, actor domain.Actor) (*PackageLabMappingResult, error)
+	GetAllPackageLabMappings(scope domain.TenantScope) ([]domain.PackageLabMappingView, error)
+	UpdatePackageLabMappingStatus(id int, isActive bool, actor domain.Actor) (*PackageLabMappingUpdateResult, error)
 }
 
 type CreatePackageWithTestsResult struct {
@@ -76,6 +76,8 @@ type packageService struct {
 	labRepo     repository.LabRepository
 	clientMapRepo repository.PackageClientMappingRepository
 	labMapRepo  repository.PackageLabMappingRepository
+	uow         repository.PackageUnitOfWork
+	audit       AuditService
 }
 
 func NewPackageService(
@@ -85,6 +87,8 @@ func NewPackageService(
 	labMapRepo repository.PackageLabMappingRepository,
 	clientRepo repository.ClientRepository,
 	labRepo repository.LabRepository,
+	uow repository.PackageUnitOfWork,
+	audit AuditService,
 ) PackageService {
 	return &packageService{
 		repo:         repo,
@@ -93,6 +97,8 @@ func NewPackageService(
 		labRepo:      labRepo,
 		clientMapRepo: clientMapRepo,
 		labMapRepo:   labMapRepo,
+		uow:          uow,
+		audit:        audit,
 	}
 }
 
@@ -108,16 +114,20 @@ func (s *packageService) GetPackageByID(id int) (*domain.Package, error) {
 	return pkg, err
 }
 
-func (s *packageService) CreatePackage(p *domain.Package, createdBy int64) error {
+func (s *packageService) CreatePackage(p *domain.Package, actor domain.Actor) error {
 	now := time.Now()
-	p.CreatedBy = createdBy
+	p.CreatedBy = actor.UserID
 	p.CreatedOn = now
-	p.LastUpdatedBy = createdBy
+	p.LastUpdatedBy = actor.UserID
 	p.LastUpdatedOn = now
-	return s.repo.Create(p)
+	if err := s.repo.Create(p); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityPackage, int64(p.PackageID), domain.AuditActionCreate, actor, nil, p)
+	return nil
 }
 
-func (s *packageService) UpdatePackage(p *domain.Package, lastUpdatedBy int64) error {
+func (s *packageService) UpdatePackage(p *domain.Package, actor domain.Actor) error {
 	existing, err := s.repo.FindByID(p.PackageID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -127,27 +137,35 @@ func (s *packageService) UpdatePackage(p *domain.Package, lastUpdatedBy int64) e
 	}
 	p.CreatedBy = existing.CreatedBy
 	p.CreatedOn = existing.CreatedOn
-	p.LastUpdatedBy = lastUpdatedBy
+	p.LastUpdatedBy = actor.UserID
 	p.LastUpdatedOn = time.Now()
-	return s.repo.Update(p)
+	if err := s.repo.Update(p); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityPackage, int64(p.PackageID), domain.AuditActionUpdate, actor, existing, p)
+	return nil
 }
 
-func (s *packageService) DeletePackage(id int) error {
-	exists, err := s.repo.ExistsByID(id)
+func (s *packageService) DeletePackage(id int, actor domain.Actor) error {
+	existing, err := s.repo.FindByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.NewNotFound("Package not found", err)
+		}
 		return err
 	}
-	if !exists {
-		return apperrors.NewNotFound("Package not found", gorm.ErrRecordNotFound)
+	if err := s.repo.Delete(id); err != nil {
+		return err
 	}
-	return s.repo.Delete(id)
+	s.audit.Record(domain.AuditEntityPackage, int64(id), domain.AuditActionDelete, actor, existing, nil)
+	return nil
 }
 
 func (s *packageService) GetActivePackages() ([]domain.Package, error) {
 	return s.repo.FindAllActive()
 }
 
-func (s *packageService) CreatePackageWithTests(p *domain.Package, testIDs []int, createdBy int64) (*CreatePackageWithTestsResult, error) {
+func (s *packageService) CreatePackageWithTests(p *domain.Package, testIDs []int, actor domain.Actor) (*CreatePackageWithTestsResult, error) {
 	if len(testIDs) == 0 {
 		return nil, apperrors.NewBadRequest("TestIDs is required and must be a non-empty array", nil)
 	}
@@ -177,13 +195,14 @@ func (s *packageService) CreatePackageWithTests(p *domain.Package, testIDs []int
 		}
 	}
 	now := time.Now()
-	p.CreatedBy = createdBy
+	p.CreatedBy = actor.UserID
 	p.CreatedOn = now
-	p.LastUpdatedBy = createdBy
+	p.LastUpdatedBy = actor.UserID
 	p.LastUpdatedOn = now
 	if err := s.repo.CreateWithTests(p, unique); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityPackage, int64(p.PackageID), domain.AuditActionCreate, actor, nil, map[string]interface{}{"package": p, "testIds": unique})
 	return &CreatePackageWithTestsResult{
 		RetVal:  1,
 		Package: p,
@@ -246,19 +265,33 @@ func (s *packageService) GetAllPackagesWithTestsDetails() ([]domain.PackageWithT
 	return result, nil
 }
 
-func (s *packageService) UpdatePackageStatus(packageID int, isActive bool, lastUpdatedBy int64) (*UpdatePackageStatusResult, error) {
-	exists, err := s.repo.ExistsByID(packageID)
+func (s *packageService) UpdatePackageStatus(packageID int, isActive bool, actor domain.Actor) (*UpdatePackageStatusResult, error) {
+	existing, err := s.repo.FindByID(packageID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFound("Package not found", err)
+		}
 		return nil, err
 	}
-	if !exists {
-		return nil, apperrors.NewNotFound("Package not found", gorm.ErrRecordNotFound)
-	}
-	testCount, clientCount, labCount, err := s.repo.UpdatePackageStatusCascade(packageID, isActive, lastUpdatedBy)
+	var testCount, clientCount, labCount int
+	err = s.uow.WithinTransaction(func(repo repository.PackageRepository, _ repository.PackageClientMappingRepository, _ repository.PackageLabMappingRepository, outbox repository.OutboxRepository) error {
+		var err error
+		testCount, clientCount, labCount, err = repo.UpdatePackageStatusCascade(packageID, isActive, actor.UserID)
+		if err != nil || isActive || !existing.IsActive {
+			return err
+		}
+		return recordEvent(outbox, domain.EventPackageDeactivated, domain.AggregatePackage, int64(packageID), domain.PackageDeactivated{
+			PackageID:      packageID,
+			PackageName:    existing.PackageName,
+			ClientMappings: clientCount,
+			LabMappings:    labCount,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 	pkg, _ := s.repo.FindByID(packageID)
+	s.audit.Record(domain.AuditEntityPackage, int64(packageID), domain.AuditActionUpdate, actor, existing, pkg)
 	return &UpdatePackageStatusResult{
 		Package:                    pkg,
 		UpdatedTestMappingsCount:   testCount,
@@ -268,7 +301,7 @@ func (s *packageService) UpdatePackageStatus(packageID int, isActive bool, lastU
 	}, nil
 }
 
-func (s *packageService) CreatePackageClientMapping(packageID int, clientID int64, price float64, createdBy, lastUpdatedBy int64) (*PackageClientMappingResult, error) {
+func (s *packageService) CreatePackageClientMapping(packageID int, clientID int64, price float64, actor domain.Actor) (*PackageClientMappingResult, error) {
 	if _, err := s.repo.FindByID(packageID); err != nil {
 		return nil, apperrors.NewNotFound("Package not found", err)
 	}
@@ -294,11 +327,20 @@ func (s *packageService) CreatePackageClientMapping(packageID int, clientID int6
 	}
 	m := &persistencemodels.PackageClientMapping{
 		PackageID: packageID, ClientID: clientID, Price: price,
-		IsActive: true, CreatedBy: createdBy, LastUpdatedBy: lastUpdatedBy,
+		IsActive: true, CreatedBy: actor.UserID, LastUpdatedBy: actor.UserID,
 	}
-	if err := s.clientMapRepo.Create(m); err != nil {
+	err := s.uow.WithinTransaction(func(_ repository.PackageRepository, clientMapRepo repository.PackageClientMappingRepository, _ repository.PackageLabMappingRepository, outbox repository.OutboxRepository) error {
+		if err := clientMapRepo.Create(m); err != nil {
+			return err
+		}
+		return recordEvent(outbox, domain.EventPriceChanged, domain.AggregatePackage, int64(packageID), domain.PriceChanged{
+			PackageID: packageID, ClientID: &clientID, Price: price,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityPackageClientMapping, int64(m.PackageClientID), domain.AuditActionCreate, actor, nil, mappingToClientView(m, "", ""))
 	v := mappingToClientView(m, "", "")
 	pkg, _ := s.repo.FindByID(packageID)
 	cli, _ := s.clientRepo.FindByID(clientID)
@@ -330,8 +372,8 @@ func mappingToClientView(m *persistencemodels.PackageClientMapping, pkgName, cli
 	}
 }
 
-func (s *packageService) GetAllPackageClientMappings() ([]domain.PackageClientMappingView, error) {
-	list, err := s.clientMapRepo.FindAll()
+func (s *packageService) GetAllPackageClientMappings(scope domain.TenantScope) ([]domain.PackageClientMappingView, error) {
+	list, err := s.clientMapRepo.WithScope(scope).FindAll()
 	if err != nil {
 		return nil, err
 	}
@@ -353,7 +395,7 @@ func (s *packageService) GetAllPackageClientMappings() ([]domain.PackageClientMa
 	return out, nil
 }
 
-func (s *packageService) UpdatePackageClientMappingStatus(id int, isActive bool, lastUpdatedBy int64) (*PackageClientMappingUpdateResult, error) {
+func (s *packageService) UpdatePackageClientMappingStatus(id int, isActive bool, actor domain.Actor) (*PackageClientMappingUpdateResult, error) {
 	m, err := s.clientMapRepo.FindByID(id)
 	if err != nil || m == nil {
 		return nil, apperrors.NewNotFound("Package-Client mapping not found", gorm.ErrRecordNotFound)
@@ -377,11 +419,13 @@ func (s *packageService) UpdatePackageClientMappingStatus(id int, isActive bool,
 			}, nil
 		}
 	}
+	before := mappingToClientView(m, "", "")
 	m.IsActive = isActive
-	m.LastUpdatedBy = lastUpdatedBy
+	m.LastUpdatedBy = actor.UserID
 	if err := s.clientMapRepo.Update(m); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityPackageClientMapping, int64(m.PackageClientID), domain.AuditActionUpdate, actor, before, mappingToClientView(m, "", ""))
 	v := mappingToClientView(m, "", "")
 	pkg, _ := s.repo.FindByID(m.PackageID)
 	cli, _ := s.clientRepo.FindByID(m.ClientID)
@@ -394,7 +438,7 @@ func (s *packageService) UpdatePackageClientMappingStatus(id int, isActive bool,
 	return &PackageClientMappingUpdateResult{RetVal: 1, Mapping: v, Message: "Package-Client mapping status updated successfully"}, nil
 }
 
-func (s *packageService) CreatePackageLabMapping(packageID int, labID int64, price float64, createdBy, lastUpdatedBy int64) (*PackageLabMappingResult, error) {
+func (s *packageService) CreatePackageLabMapping(packageID int, labID int64, price float64, actor domain.Actor) (*PackageLabMappingResult, error) {
 	if _, err := s.repo.FindByID(packageID); err != nil {
 		return nil, apperrors.NewNotFound("Package not found", err)
 	}
@@ -420,11 +464,20 @@ func (s *packageService) CreatePackageLabMapping(packageID int, labID int64, pri
 	}
 	m := &persistencemodels.PackageLabMapping{
 		PackageID: packageID, LabID: labID, Price: price,
-		IsActive: true, CreatedBy: createdBy, LastUpdatedBy: lastUpdatedBy,
+		IsActive: true, CreatedBy: actor.UserID, LastUpdatedBy: actor.UserID,
 	}
-	if err := s.labMapRepo.Create(m); err != nil {
+	err := s.uow.WithinTransaction(func(_ repository.PackageRepository, _ repository.PackageClientMappingRepository, labMapRepo repository.PackageLabMappingRepository, outbox repository.OutboxRepository) error {
+		if err := labMapRepo.Create(m); err != nil {
+			return err
+		}
+		return recordEvent(outbox, domain.EventPriceChanged, domain.AggregatePackage, int64(packageID), domain.PriceChanged{
+			PackageID: packageID, LabID: &labID, Price: price,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityPackageLabMapping, int64(m.PackageLabID), domain.AuditActionCreate, actor, nil, mappingToLabView(m, "", ""))
 	v := mappingToLabView(m, "", "")
 	pkg, _ := s.repo.FindByID(packageID)
 	lab, _ := s.labRepo.FindByID(labID)
@@ -456,8 +509,8 @@ func mappingToLabView(m *persistencemodels.PackageLabMapping, pkgName, labName s
 	}
 }
 
-func (s *packageService) GetAllPackageLabMappings() ([]domain.PackageLabMappingView, error) {
-	list, err := s.labMapRepo.FindAll()
+func (s *packageService) GetAllPackageLabMappings(scope domain.TenantScope) ([]domain.PackageLabMappingView, error) {
+	list, err := s.labMapRepo.WithScope(scope).FindAll()
 	if err != nil {
 		return nil, err
 	}
@@ -479,7 +532,7 @@ func (s *packageService) GetAllPackageLabMappings() ([]domain.PackageLabMappingV
 	return out, nil
 }
 
-func (s *packageService) UpdatePackageLabMappingStatus(id int, isActive bool, lastUpdatedBy int64) (*PackageLabMappingUpdateResult, error) {
+func (s *packageService) UpdatePackageLabMappingStatus(id int, isActive bool, actor domain.Actor) (*PackageLabMappingUpdateResult, error) {
 	m, err := s.labMapRepo.FindByID(id)
 	if err != nil || m == nil {
 		return nil, apperrors.NewNotFound("Package-Lab mapping not found", gorm.ErrRecordNotFound)
@@ -503,11 +556,13 @@ func (s *packageService) UpdatePackageLabMappingStatus(id int, isActive bool, la
 			}, nil
 		}
 	}
+	before := mappingToLabView(m, "", "")
 	m.IsActive = isActive
-	m.LastUpdatedBy = lastUpdatedBy
+	m.LastUpdatedBy = actor.UserID
 	if err := s.labMapRepo.Update(m); err != nil {
 		return nil, err
 	}
+	s.audit.Record(domain.AuditEntityPackageLabMapping, int64(m.PackageLabID), domain.AuditActionUpdate, actor, before, mappingToLabView(m, "", ""))
 	v := mappingToLabView(m, "", "")
 	pkg, _ := s.repo.FindByID(m.PackageID)
 	lab, _ := s.labRepo.FindByID(m.LabID)
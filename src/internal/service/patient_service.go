@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type PatientService interface {
+	GetPatient(id int64, scope domain.TenantScope) (*dto.PatientDetail, error)
+	GetTimeline(id int64, scope domain.TenantScope) (*dto.PatientTimeline, error)
+}
+
+type patientService struct {
+	repo        repository.PatientRepository
+	leadRepo    repository.LeadRepository
+	packageRepo repository.PackageRepository
+	workflow    *domain.LeadStatusWorkflow
+}
+
+func NewPatientService(
+	repo repository.PatientRepository,
+	leadRepo repository.LeadRepository,
+	packageRepo repository.PackageRepository,
+	workflow *domain.LeadStatusWorkflow,
+) PatientService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	return &patientService{repo: repo, leadRepo: leadRepo, packageRepo: packageRepo, workflow: workflow}
+}
+
+// GetPatient returns the patient with the other patients sharing their contact number.
+func (s *patientService) GetPatient(id int64, scope domain.TenantScope) (*dto.PatientDetail, error) {
+	patient, err := s.findPatient(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	family, err := s.repo.WithScope(scope).FindByContactNumber(patient.ClientID, patient.ContactNumber)
+	if err != nil {
+		return nil, err
+	}
+	detail := &dto.PatientDetail{Patient: *patient, FamilyMembers: []domain.Patient{}}
+	for _, member := range family {
+		if member.PatientID != patient.PatientID {
+			detail.FamilyMembers = append(detail.FamilyMembers, member)
+		}
+	}
+	return detail, nil
+}
+
+// GetTimeline returns the patient's leads, oldest first, with package and status names.
+func (s *patientService) GetTimeline(id int64, scope domain.TenantScope) (*dto.PatientTimeline, error) {
+	patient, err := s.findPatient(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	leads, err := s.leadRepo.WithScope(scope).FindByPatientID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &dto.PatientTimeline{Patient: *patient, Leads: make([]dto.PatientTimelineEntry, 0, len(leads))}
+	packageNames := make(map[int]string)
+	for _, lead := range leads {
+		name, ok := packageNames[lead.PackageID]
+		if !ok {
+			if pkg, _ := s.packageRepo.FindByID(lead.PackageID); pkg != nil {
+				name = pkg.PackageName
+			}
+			packageNames[lead.PackageID] = name
+		}
+		entry := dto.PatientTimelineEntry{
+			LeadID:        lead.LeadID,
+			PackageID:     lead.PackageID,
+			PackageName:   name,
+			LeadStatusID:  s.workflow.Normalize(lead.LeadStatusID),
+			LabID:         lead.LabID,
+			CreatedOn:     lead.CreatedOn,
+			LastUpdatedOn: lead.LastUpdatedOn,
+		}
+		if st, ok := s.workflow.Status(lead.LeadStatusID); ok {
+			entry.Status = st.Name
+		}
+		timeline.Leads = append(timeline.Leads, entry)
+	}
+	return timeline, nil
+}
+
+func (s *patientService) findPatient(id int64, scope domain.TenantScope) (*domain.Patient, error) {
+	patient, err := s.repo.WithScope(scope).FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Patient not found", err)
+	}
+	return patient, err
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+	"b2b-diagnostic-aggregator/apis/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+const reportContentType = "application/pdf"
+
+// ReportSettings configures report uploads and signed download links.
+type ReportSettings struct {
+	MaxUploadBytes int64
+	SigningSecret  string
+	DownloadURLTTL time.Duration
+}
+
+// ReportService stores lab report PDFs as numbered versions of a lead's report. Uploading moves the
+// lead to Report Ready and releasing the latest version to Delivered, when the workflow allows.
+// Clients only see released reports, downloaded through time-limited signed links.
+type ReportService interface {
+	Upload(leadID int64, fileName string, data []byte, checksum, amendmentReason string, actor domain.Actor, scope domain.TenantScope) (*domain.LabReport, error)
+	ListReports(leadID int64, scope domain.TenantScope) ([]domain.LabReport, error)
+	Release(leadID, reportID int64, actor domain.Actor, scope domain.TenantScope) (*domain.LabReport, error)
+	DownloadLink(leadID, reportID int64, scope domain.TenantScope) (*domain.ReportDownloadLink, error)
+	Download(reportID, expires int64, signature string) (*domain.LabReport, []byte, error)
+}
+
+type reportService struct {
+	repo     repository.LabReportRepository
+	leadRepo repository.LeadRepository
+	uow      repository.LabReportUnitOfWork
+	store    storage.Store
+	workflow *domain.LeadStatusWorkflow
+	settings ReportSettings
+	now      func() time.Time
+}
+
+func NewReportService(
+	repo repository.LabReportRepository,
+	leadRepo repository.LeadRepository,
+	uow repository.LabReportUnitOfWork,
+	store storage.Store,
+	workflow *domain.LeadStatusWorkflow,
+	settings ReportSettings,
+) ReportService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	return &reportService{
+		repo:     repo,
+		leadRepo: leadRepo,
+		uow:      uow,
+		store:    store,
+		workflow: workflow,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// Upload stores a new report version. checksum, when given, must match the file's SHA-256. Every
+// version after the first is an amendment and needs a reason; it supersedes the earlier versions,
+// so an amended report has to be released again. The version is numbered under a lock on the lead's
+// reports, so concurrent uploads cannot claim the same one.
+func (s *reportService) Upload(leadID int64, fileName string, data []byte, checksum, amendmentReason string, actor domain.Actor, scope domain.TenantScope) (*domain.LabReport, error) {
+	lead, err := s.findLead(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, apperrors.NewBadRequest("Report file is empty", nil)
+	}
+	if s.settings.MaxUploadBytes > 0 && int64(len(data)) > s.settings.MaxUploadBytes {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Report file exceeds %d MB", s.settings.MaxUploadBytes>>20), nil)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, apperrors.NewBadRequest("Report file must be a PDF", nil)
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	if checksum = strings.TrimSpace(checksum); checksum != "" && !strings.EqualFold(checksum, digest) {
+		return nil, apperrors.NewBadRequest("Checksum does not match the uploaded file", nil)
+	}
+
+	amendmentReason = strings.TrimSpace(amendmentReason)
+	now := s.now()
+	var report *domain.LabReport
+	stored := false
+	err = s.uow.WithinTransaction(func(repo repository.LabReportRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		version := 1
+		latest, err := repo.FindLatestForUpdate(leadID)
+		if err == nil {
+			if latest.Checksum == digest {
+				return apperrors.NewConflict(fmt.Sprintf("The file is identical to report version %d", latest.Version), nil)
+			}
+			if amendmentReason == "" {
+				return apperrors.NewBadRequest("An amendment reason is required to replace an existing report", nil)
+			}
+			version = latest.Version + 1
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		report = &domain.LabReport{
+			LeadID:          leadID,
+			LabID:           lead.LabID,
+			Version:         version,
+			FileName:        reportFileName(fileName, leadID, version),
+			ContentType:     reportContentType,
+			SizeBytes:       int64(len(data)),
+			Checksum:        digest,
+			StorageKey:      fmt.Sprintf("reports/lead-%d/v%d-%s.pdf", leadID, version, digest[:12]),
+			Status:          domain.ReportStatusUploaded,
+			AmendmentReason: amendmentReason,
+			UploadedBy:      actor.UserID,
+			UploadedByType:  actor.UserType,
+			UploadedOn:      now,
+		}
+		if err := s.store.Put(report.StorageKey, data, reportContentType); err != nil {
+			return err
+		}
+		stored = true
+
+		updatedLead := *lead
+		if s.workflow.CanTransition(lead.LeadStatusID, domain.LeadStatusReportReady) {
+			updatedLead.LeadStatusID = domain.LeadStatusReportReady
+			updatedLead.LastUpdatedBy = actor.UserID
+			updatedLead.LastUpdatedOn = now
+		}
+		changes := domain.DiffLeads(*lead, updatedLead)
+		note := fmt.Sprintf("Report version %d uploaded (%s, sha256 %s)", version, report.FileName, digest)
+		if amendmentReason != "" {
+			note += ": " + amendmentReason
+		}
+
+		if err := repo.SupersedeVersions(leadID, version); err != nil {
+			return err
+		}
+		if err := repo.Create(report); err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := leadRepo.Update(&updatedLead); err != nil {
+				return err
+			}
+		}
+		err = historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionReportUpload,
+			Reason:        note,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       changes,
+		})
+		if err != nil {
+			return err
+		}
+		return recordLeadEvents(outbox, s.workflow, lead, updatedLead)
+	})
+	if err != nil {
+		if stored {
+			if delErr := s.store.Delete(report.StorageKey); delErr != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"report_cleanup_failed","key":%q,"error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), report.StorageKey, delErr.Error())
+			}
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports returns the lead's report versions, latest first; clients only see released ones.
+func (s *reportService) ListReports(leadID int64, scope domain.TenantScope) ([]domain.LabReport, error) {
+	if _, err := s.findLead(leadID, scope); err != nil {
+		return nil, err
+	}
+	reports, err := s.repo.FindByLeadID(leadID)
+	if err != nil || scope.ClientID == 0 {
+		return reports, err
+	}
+	released := make([]domain.LabReport, 0, len(reports))
+	for _, r := range reports {
+		if r.Status == domain.ReportStatusReleased {
+			released = append(released, r)
+		}
+	}
+	return released, nil
+}
+
+// Release makes the latest report version available to the owning client.
+func (s *reportService) Release(leadID, reportID int64, actor domain.Actor, scope domain.TenantScope) (*domain.LabReport, error) {
+	lead, err := s.findLead(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.findReport(leadID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	switch report.Status {
+	case domain.ReportStatusReleased:
+		return nil, apperrors.NewConflict(fmt.Sprintf("Report version %d is already released", report.Version), nil)
+	case domain.ReportStatusSuperseded:
+		return nil, apperrors.NewConflict(fmt.Sprintf("Report version %d has been superseded by an amended report", report.Version), nil)
+	}
+
+	now := s.now()
+	updated := *report
+	updated.Status = domain.ReportStatusReleased
+	updated.ReleasedBy = &actor.UserID
+	updated.ReleasedOn = &now
+
+	updatedLead := *lead
+	if s.workflow.CanTransition(lead.LeadStatusID, domain.LeadStatusDelivered) {
+		updatedLead.LeadStatusID = domain.LeadStatusDelivered
+		updatedLead.LastUpdatedBy = actor.UserID
+		updatedLead.LastUpdatedOn = now
+	}
+	changes := domain.DiffLeads(*lead, updatedLead)
+
+	err = s.uow.WithinTransaction(func(repo repository.LabReportRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		if err := repo.Update(&updated); err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := leadRepo.Update(&updatedLead); err != nil {
+				return err
+			}
+		}
+		err := historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionReportRelease,
+			Reason:        fmt.Sprintf("Report version %d released to client", report.Version),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       changes,
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordLeadEvents(outbox, s.workflow, lead, updatedLead); err != nil {
+			return err
+		}
+		return recordReportReleased(outbox, updatedLead, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DownloadLink signs a download link for a report of the lead. Clients can only get links to
+// released reports.
+func (s *reportService) DownloadLink(leadID, reportID int64, scope domain.TenantScope) (*domain.ReportDownloadLink, error) {
+	if _, err := s.findLead(leadID, scope); err != nil {
+		return nil, err
+	}
+	report, err := s.findReport(leadID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if scope.ClientID != 0 && report.Status != domain.ReportStatusReleased {
+		return nil, apperrors.NewNotFound("Report not found", nil)
+	}
+	expiresAt := s.now().Add(s.settings.DownloadURLTTL).Truncate(time.Second)
+	return &domain.ReportDownloadLink{
+		ReportID:  reportID,
+		ExpiresAt: expiresAt,
+		Signature: s.sign(reportID, expiresAt.Unix()),
+	}, nil
+}
+
+// Download checks a signed link and returns the report file. A superseded version is no longer
+// served, and the stored file is verified against its recorded checksum.
+func (s *reportService) Download(reportID, expires int64, signature string) (*domain.LabReport, []byte, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(reportID, expires))) {
+		return nil, nil, apperrors.NewForbidden("Invalid download link", nil)
+	}
+	if s.now().Unix() > expires {
+		return nil, nil, apperrors.NewForbidden("Download link has expired", nil)
+	}
+	report, err := s.repo.FindByID(reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, apperrors.NewNotFound("Report not found", err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	// The link outlives the version it was issued for; once amended, only the new version is served.
+	if report.Status == domain.ReportStatusSuperseded {
+		return nil, nil, apperrors.NewNotFound("This report has been replaced by a newer version", nil)
+	}
+	data, err := s.store.Get(report.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, apperrors.NewNotFound("Report file not found", err)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != report.Checksum {
+		return nil, nil, apperrors.NewInternal("Report file failed its checksum", nil)
+	}
+	return report, data, nil
+}
+
+func (s *reportService) sign(reportID, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.settings.SigningSecret))
+	mac.Write([]byte(strconv.FormatInt(reportID, 10) + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *reportService) findLead(id int64, scope domain.TenantScope) (*domain.Lead, error) {
+	lead, err := s.leadRepo.WithScope(scope).FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	}
+	return lead, err
+}
+
+func (s *reportService) findReport(leadID, reportID int64) (*domain.LabReport, error) {
+	report, err := s.repo.FindByID(reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && report.LeadID != leadID) {
+		return nil, apperrors.NewNotFound("Report not found", err)
+	}
+	return report, err
+}
+
+// reportFileName keeps the uploaded file's base name, or names the file after the lead and version.
+func reportFileName(name string, leadID int64, version int) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return fmt.Sprintf("lead-%d-report-v%d.pdf", leadID, version)
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/barcode"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// SampleService tracks collected tubes from collection to the lab. Collecting moves the lead to
+// Sample Collected and the first sample sent or received moves it to Sent to Lab, when the workflow
+// allows; every sample event is written to the lead's history.
+type SampleService interface {
+	CollectSamples(leadID int64, tubeTypes []string, actor domain.Actor, scope domain.TenantScope) ([]domain.Sample, error)
+	ListLeadSamples(leadID int64, scope domain.TenantScope) ([]domain.Sample, error)
+	ScanSample(accessionNumber, status, reason string, actor domain.Actor, scope domain.TenantScope) (*domain.Sample, error)
+	Barcode(id int64, format string, scope domain.TenantScope) ([]byte, string, error)
+}
+
+type sampleService struct {
+	repo     repository.SampleRepository
+	leadRepo repository.LeadRepository
+	uow      repository.SampleUnitOfWork
+	workflow *domain.LeadStatusWorkflow
+}
+
+func NewSampleService(
+	repo repository.SampleRepository,
+	leadRepo repository.LeadRepository,
+	uow repository.SampleUnitOfWork,
+	workflow *domain.LeadStatusWorkflow,
+) SampleService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	return &sampleService{repo: repo, leadRepo: leadRepo, uow: uow, workflow: workflow}
+}
+
+// CollectSamples registers one sample per tube type, numbering tubes after the lead's existing ones.
+// The lead must be Scheduled (or already Sample Collected).
+func (s *sampleService) CollectSamples(leadID int64, tubeTypes []string, actor domain.Actor, scope domain.TenantScope) ([]domain.Sample, error) {
+	lead, err := s.findLead(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	status := s.workflow.Normalize(lead.LeadStatusID)
+	if status != domain.LeadStatusSampleCollected && !s.workflow.CanTransition(status, domain.LeadStatusSampleCollected) {
+		current, _ := s.workflow.Status(status)
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Samples cannot be collected for a lead in status %s", current.Name), nil)
+	}
+
+	now := time.Now()
+	updated := *lead
+	updated.LeadStatusID = domain.LeadStatusSampleCollected
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = now
+	changes := domain.DiffLeads(*lead, updated)
+
+	samples := make([]domain.Sample, len(tubeTypes))
+	err = s.uow.WithinTransaction(func(repo repository.SampleRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		last, err := repo.MaxTubeNumber(leadID)
+		if err != nil {
+			return err
+		}
+		accessions := make([]string, len(tubeTypes))
+		for i, tubeType := range tubeTypes {
+			tube := last + i + 1
+			samples[i] = domain.Sample{
+				LeadID:          leadID,
+				LabID:           lead.LabID,
+				TubeNumber:      tube,
+				TubeType:        strings.TrimSpace(tubeType),
+				AccessionNumber: domain.AccessionNumber(leadID, tube),
+				Status:          domain.SampleStatusCollected,
+				CollectedBy:     actor.UserID,
+				CollectedOn:     now,
+				LastUpdatedBy:   actor.UserID,
+				LastUpdatedOn:   now,
+			}
+			if err := repo.Create(&samples[i]); err != nil {
+				return err
+			}
+			accessions[i] = fmt.Sprintf("%s (%s)", samples[i].AccessionNumber, samples[i].TubeType)
+		}
+		if len(changes) > 0 {
+			if err := leadRepo.Update(&updated); err != nil {
+				return err
+			}
+		}
+		err = historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionSampleCollect,
+			Reason:        "Collected " + strings.Join(accessions, ", "),
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       changes,
+		})
+		if err != nil {
+			return err
+		}
+		return recordLeadEvents(outbox, s.workflow, lead, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func (s *sampleService) ListLeadSamples(leadID int64, scope domain.TenantScope) ([]domain.Sample, error) {
+	if _, err := s.findLead(leadID, scope); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByLeadID(leadID)
+}
+
+// ScanSample advances a sample along its lifecycle. Labs only see samples of leads assigned to them.
+func (s *sampleService) ScanSample(accessionNumber, status, reason string, actor domain.Actor, scope domain.TenantScope) (*domain.Sample, error) {
+	sample, err := s.repo.FindByAccessionNumber(strings.TrimSpace(accessionNumber))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Sample not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	lead, err := s.leadRepo.WithScope(scope).FindByID(sample.LeadID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Sample not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	if !domain.IsSampleStatus(status) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Unknown sample status %s", status), nil)
+	}
+	if !domain.CanAdvanceSample(sample.Status, status) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Sample %s cannot move from %s to %s", sample.AccessionNumber, sample.Status, status), nil)
+	}
+	reason = strings.TrimSpace(reason)
+	if status == domain.SampleStatusRejected && reason == "" {
+		return nil, apperrors.NewBadRequest("A reason is required to reject a sample", nil)
+	}
+
+	now := time.Now()
+	previous := sample.Status
+	updated := *sample
+	updated.Status = status
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = now
+	switch status {
+	case domain.SampleStatusReceived:
+		updated.ReceivedOn = &now
+	case domain.SampleStatusRejected:
+		updated.RejectionReason = reason
+	}
+
+	updatedLead := *lead
+	if status == domain.SampleStatusInTransit || status == domain.SampleStatusReceived {
+		if s.workflow.CanTransition(lead.LeadStatusID, domain.LeadStatusSentToLab) {
+			updatedLead.LeadStatusID = domain.LeadStatusSentToLab
+			updatedLead.LastUpdatedBy = actor.UserID
+			updatedLead.LastUpdatedOn = now
+		}
+	}
+	changes := domain.DiffLeads(*lead, updatedLead)
+
+	note := fmt.Sprintf("Sample %s %s -> %s", sample.AccessionNumber, previous, status)
+	if reason != "" {
+		note += ": " + reason
+	}
+	err = s.uow.WithinTransaction(func(repo repository.SampleRepository, leadRepo repository.LeadRepository, historyRepo repository.LeadHistoryRepository, outbox repository.OutboxRepository) error {
+		if err := repo.Update(&updated); err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := leadRepo.Update(&updatedLead); err != nil {
+				return err
+			}
+		}
+		err := historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        lead.LeadID,
+			Action:        domain.LeadActionSampleScan,
+			Reason:        note,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+			Changes:       changes,
+		})
+		if err != nil {
+			return err
+		}
+		return recordLeadEvents(outbox, s.workflow, lead, updatedLead)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Barcode renders the sample's accession number as a Code 128 barcode, PNG by default or SVG.
+// It returns the image and its content type.
+func (s *sampleService) Barcode(id int64, format string, scope domain.TenantScope) ([]byte, string, error) {
+	sample, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", apperrors.NewNotFound("Sample not found", err)
+	} else if err != nil {
+		return nil, "", err
+	}
+	if _, err := s.leadRepo.WithScope(scope).FindByID(sample.LeadID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", apperrors.NewNotFound("Sample not found", err)
+	} else if err != nil {
+		return nil, "", err
+	}
+	if format == "svg" {
+		img, err := barcode.SVG(sample.AccessionNumber, barcode.Options{})
+		return img, "image/svg+xml", err
+	}
+	img, err := barcode.PNG(sample.AccessionNumber, barcode.Options{})
+	return img, "image/png", err
+}
+
+func (s *sampleService) findLead(id int64, scope domain.TenantScope) (*domain.Lead, error) {
+	lead, err := s.leadRepo.WithScope(scope).FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	}
+	return lead, err
+}
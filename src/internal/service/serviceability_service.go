@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+)
+
+type ServiceabilityService interface {
+	Lookup(pincode string, packageID *int) (*dto.ServiceabilityResponse, error)
+}
+
+type serviceabilityService struct {
+	labRepo     repository.LabRepository
+	labMapRepo  repository.PackageLabMappingRepository
+	packageRepo repository.PackageRepository
+}
+
+func NewServiceabilityService(
+	labRepo repository.LabRepository,
+	labMapRepo repository.PackageLabMappingRepository,
+	packageRepo repository.PackageRepository,
+) ServiceabilityService {
+	return &serviceabilityService{labRepo: labRepo, labMapRepo: labMapRepo, packageRepo: packageRepo}
+}
+
+// Lookup lists the active labs collecting from pincode with their collection types and, when
+// packageID is given, each lab's price for that package.
+func (s *serviceabilityService) Lookup(pincode string, packageID *int) (*dto.ServiceabilityResponse, error) {
+	pincode = strings.TrimSpace(pincode)
+	if !domain.IsPincode(pincode) {
+		return nil, apperrors.NewBadRequest("pincode must be 6 digits", nil)
+	}
+	resp := &dto.ServiceabilityResponse{Pincode: pincode, PackageID: packageID, Labs: []dto.ServiceableLab{}}
+
+	prices := make(map[int64]float64)
+	if packageID != nil {
+		pkg, err := s.packageRepo.FindByID(*packageID)
+		if err != nil || pkg == nil {
+			return nil, apperrors.NewNotFound(fmt.Sprintf("Package %d not found", *packageID), err)
+		}
+		resp.PackageName = pkg.PackageName
+		mappings, err := s.labMapRepo.FindByPackageID(*packageID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mappings {
+			if current, ok := prices[m.LabID]; m.IsActive && (!ok || m.Price < current) {
+				prices[m.LabID] = m.Price
+			}
+		}
+	}
+
+	labs, err := s.labRepo.FindActiveByCollectionPincode(pincode)
+	if err != nil {
+		return nil, err
+	}
+	for _, lab := range labs {
+		item := dto.ServiceableLab{
+			LabID:           lab.LabID,
+			LabName:         lab.LabName,
+			CollectionTypes: domain.SplitList(lab.CollectionTypes),
+		}
+		if item.CollectionTypes == nil {
+			item.CollectionTypes = []string{}
+		}
+		if packageID != nil {
+			if price, ok := prices[lab.LabID]; ok {
+				item.Price = &price
+				item.OffersPackage = true
+			}
+		}
+		resp.Labs = append(resp.Labs, item)
+	}
+	resp.Serviceable = len(resp.Labs) > 0
+	if packageID != nil {
+		resp.Serviceable = false
+		for _, lab := range resp.Labs {
+			if lab.OffersPackage {
+				resp.Serviceable = true
+				break
+			}
+		}
+	}
+	return resp, nil
+}
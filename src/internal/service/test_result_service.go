@@ -0,0 +1,353 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMinAge = 0
+	defaultMaxAge = 150
+)
+
+// TestResultService manages test parameters with their reference ranges and the structured results
+// labs record per lead. Each value is flagged against the range for the lead's age and gender.
+type TestResultService interface {
+	ListParameters(testID int) ([]domain.TestParameter, error)
+	CreateParameter(testID int, req dto.TestParameterRequest, actor domain.Actor) (*domain.TestParameter, error)
+	UpdateParameter(testID int, parameterID int64, req dto.TestParameterRequest, actor domain.Actor) (*domain.TestParameter, error)
+	RecordResults(leadID int64, req dto.RecordResultsRequest, actor domain.Actor, scope domain.TenantScope) ([]domain.TestResult, error)
+	GetResults(leadID int64, scope domain.TenantScope) (*dto.LeadResults, error)
+}
+
+type testResultService struct {
+	paramRepo   repository.TestParameterRepository
+	resultRepo  repository.TestResultRepository
+	testRepo    repository.TestRepository
+	packageRepo repository.PackageRepository
+	leadRepo    repository.LeadRepository
+	uow         repository.TestResultUnitOfWork
+	audit       AuditService
+}
+
+func NewTestResultService(
+	paramRepo repository.TestParameterRepository,
+	resultRepo repository.TestResultRepository,
+	testRepo repository.TestRepository,
+	packageRepo repository.PackageRepository,
+	leadRepo repository.LeadRepository,
+	uow repository.TestResultUnitOfWork,
+	audit AuditService,
+) TestResultService {
+	return &testResultService{
+		paramRepo:   paramRepo,
+		resultRepo:  resultRepo,
+		testRepo:    testRepo,
+		packageRepo: packageRepo,
+		leadRepo:    leadRepo,
+		uow:         uow,
+		audit:       audit,
+	}
+}
+
+func (s *testResultService) ListParameters(testID int) ([]domain.TestParameter, error) {
+	if _, err := s.findTest(testID); err != nil {
+		return nil, err
+	}
+	return s.paramRepo.FindByTestIDs([]int{testID})
+}
+
+func (s *testResultService) CreateParameter(testID int, req dto.TestParameterRequest, actor domain.Actor) (*domain.TestParameter, error) {
+	if _, err := s.findTest(testID); err != nil {
+		return nil, err
+	}
+	ranges, err := referenceRangesFromRequest(req.Ranges)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	param := &domain.TestParameter{
+		TestID:        testID,
+		Analyte:       strings.TrimSpace(req.Analyte),
+		Unit:          strings.TrimSpace(req.Unit),
+		DisplayOrder:  req.DisplayOrder,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+		Ranges:        ranges,
+		CreatedBy:     actor.UserID,
+		CreatedOn:     now,
+		LastUpdatedBy: actor.UserID,
+		LastUpdatedOn: now,
+	}
+	if err := s.paramRepo.Create(param); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityTestParameter, param.ParameterID, domain.AuditActionCreate, actor, nil, param)
+	return param, nil
+}
+
+// UpdateParameter replaces the parameter's definition and reference ranges. Results already recorded
+// keep the range and flag they were evaluated with.
+func (s *testResultService) UpdateParameter(testID int, parameterID int64, req dto.TestParameterRequest, actor domain.Actor) (*domain.TestParameter, error) {
+	existing, err := s.paramRepo.FindByID(parameterID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && existing.TestID != testID) {
+		return nil, apperrors.NewNotFound("Test parameter not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	ranges, err := referenceRangesFromRequest(req.Ranges)
+	if err != nil {
+		return nil, err
+	}
+	updated := *existing
+	updated.Analyte = strings.TrimSpace(req.Analyte)
+	updated.Unit = strings.TrimSpace(req.Unit)
+	updated.DisplayOrder = req.DisplayOrder
+	if req.IsActive != nil {
+		updated.IsActive = *req.IsActive
+	}
+	updated.Ranges = ranges
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = time.Now()
+	if err := s.paramRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityTestParameter, parameterID, domain.AuditActionUpdate, actor, existing, &updated)
+	return &updated, nil
+}
+
+// RecordResults stores values for one test of the lead's package, flagging each against the
+// reference range for the lead's age and gender, and writes a history entry summarising them.
+func (s *testResultService) RecordResults(leadID int64, req dto.RecordResultsRequest, actor domain.Actor, scope domain.TenantScope) ([]domain.TestResult, error) {
+	lead, err := s.findLead(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	test, err := s.findTest(req.TestID)
+	if err != nil {
+		return nil, err
+	}
+	testIDs, err := s.packageRepo.FindActiveTestIDs(lead.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsInt(testIDs, req.TestID) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("Test %s is not part of the lead's package", test.TestName), nil)
+	}
+	params, err := s.paramRepo.FindByTestIDs([]int{req.TestID})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]domain.TestParameter, len(params))
+	for _, p := range params {
+		if p.IsActive {
+			byID[p.ParameterID] = p
+		}
+	}
+
+	now := time.Now()
+	seen := make(map[int64]bool, len(req.Results))
+	results := make([]domain.TestResult, 0, len(req.Results))
+	var abnormal []string
+	for _, v := range req.Results {
+		param, ok := byID[v.ParameterID]
+		if !ok {
+			return nil, apperrors.NewBadRequest(fmt.Sprintf("Parameter %d is not an active parameter of %s", v.ParameterID, test.TestName), nil)
+		}
+		if seen[v.ParameterID] {
+			return nil, apperrors.NewBadRequest(fmt.Sprintf("Parameter %d is listed more than once", v.ParameterID), nil)
+		}
+		seen[v.ParameterID] = true
+
+		result := domain.TestResult{
+			LeadID:         leadID,
+			TestID:         req.TestID,
+			ParameterID:    v.ParameterID,
+			Value:          *v.Value,
+			Unit:           param.Unit,
+			Comment:        strings.TrimSpace(v.Comment),
+			ReportedBy:     actor.UserID,
+			ReportedByType: actor.UserType,
+			ReportedOn:     now,
+		}
+		if r := param.RangeFor(int(lead.Age), lead.Gender); r != nil {
+			result.ReferenceLow = r.Low
+			result.ReferenceHigh = r.High
+			result.Flag = r.Flag(result.Value)
+		}
+		if result.IsAbnormal() {
+			abnormal = append(abnormal, fmt.Sprintf("%s %s", param.Analyte, result.Flag))
+		}
+		results = append(results, result)
+	}
+
+	note := fmt.Sprintf("Results recorded for %s: %d values", test.TestName, len(results))
+	if len(abnormal) > 0 {
+		note += ", abnormal: " + strings.Join(abnormal, ", ")
+	}
+	err = s.uow.WithinTransaction(func(resultRepo repository.TestResultRepository, historyRepo repository.LeadHistoryRepository) error {
+		if err := resultRepo.Upsert(results); err != nil {
+			return err
+		}
+		return historyRepo.LogAction(&domain.LeadHistory{
+			LeadID:        leadID,
+			Action:        domain.LeadActionResultsRecord,
+			Reason:        note,
+			CreatedBy:     actor.UserID,
+			CreatedByType: actor.UserType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetResults returns the lead's results grouped by test, in the tests' parameter display order.
+func (s *testResultService) GetResults(leadID int64, scope domain.TenantScope) (*dto.LeadResults, error) {
+	lead, err := s.findLead(leadID, scope)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.resultRepo.FindByLeadID(leadID)
+	if err != nil {
+		return nil, err
+	}
+	out := &dto.LeadResults{
+		LeadID:    lead.LeadID,
+		PatientID: lead.PatientID,
+		Age:       lead.Age,
+		Gender:    lead.Gender,
+		Tests:     []dto.TestResults{},
+	}
+	if len(results) == 0 {
+		return out, nil
+	}
+
+	var testIDs []int
+	byTest := make(map[int][]domain.TestResult)
+	for _, r := range results {
+		if _, ok := byTest[r.TestID]; !ok {
+			testIDs = append(testIDs, r.TestID)
+		}
+		byTest[r.TestID] = append(byTest[r.TestID], r)
+	}
+	tests, err := s.testRepo.FindByIDs(testIDs)
+	if err != nil {
+		return nil, err
+	}
+	testNames := make(map[int]string, len(tests))
+	for _, t := range tests {
+		testNames[t.TestID] = t.TestName
+	}
+	params, err := s.paramRepo.FindByTestIDs(testIDs)
+	if err != nil {
+		return nil, err
+	}
+	paramsByID := make(map[int64]domain.TestParameter, len(params))
+	for _, p := range params {
+		paramsByID[p.ParameterID] = p
+	}
+
+	for _, testID := range testIDs {
+		group := byTest[testID]
+		sort.SliceStable(group, func(i, j int) bool {
+			return paramsByID[group[i].ParameterID].DisplayOrder < paramsByID[group[j].ParameterID].DisplayOrder
+		})
+		entries := make([]dto.ResultEntry, len(group))
+		for i, r := range group {
+			entries[i] = dto.ResultEntry{
+				ParameterID:   r.ParameterID,
+				Analyte:       paramsByID[r.ParameterID].Analyte,
+				Unit:          r.Unit,
+				Value:         r.Value,
+				ReferenceLow:  r.ReferenceLow,
+				ReferenceHigh: r.ReferenceHigh,
+				Flag:          r.Flag,
+				Comment:       r.Comment,
+				ReportedOn:    r.ReportedOn,
+			}
+			if r.IsAbnormal() {
+				out.AbnormalCount++
+			}
+			if r.Flag == domain.ResultFlagCriticalLow || r.Flag == domain.ResultFlagCriticalHigh {
+				out.CriticalCount++
+			}
+		}
+		out.Tests = append(out.Tests, dto.TestResults{TestID: testID, TestName: testNames[testID], Results: entries})
+	}
+	return out, nil
+}
+
+func (s *testResultService) findTest(id int) (*domain.Test, error) {
+	test, err := s.testRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Test not found", err)
+	}
+	return test, err
+}
+
+func (s *testResultService) findLead(id int64, scope domain.TenantScope) (*domain.Lead, error) {
+	lead, err := s.leadRepo.WithScope(scope).FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Lead not found", err)
+	}
+	return lead, err
+}
+
+// referenceRangesFromRequest validates the ranges: a sensible age band and bounds ordered
+// criticalLow <= low <= high <= criticalHigh.
+func referenceRangesFromRequest(reqs []dto.ReferenceRangeRequest) ([]domain.ReferenceRange, error) {
+	ranges := make([]domain.ReferenceRange, len(reqs))
+	for i, req := range reqs {
+		r := domain.ReferenceRange{
+			Gender:       req.Gender,
+			MinAge:       defaultMinAge,
+			MaxAge:       defaultMaxAge,
+			Low:          req.Low,
+			High:         req.High,
+			CriticalLow:  req.CriticalLow,
+			CriticalHigh: req.CriticalHigh,
+		}
+		if req.MinAge != nil {
+			r.MinAge = *req.MinAge
+		}
+		if req.MaxAge != nil {
+			r.MaxAge = *req.MaxAge
+		}
+		if r.MinAge > r.MaxAge {
+			return nil, apperrors.NewBadRequest(fmt.Sprintf("Range %d: minAge must not exceed maxAge", i+1), nil)
+		}
+		bounds := []*float64{r.CriticalLow, r.Low, r.High, r.CriticalHigh}
+		var prev *float64
+		for _, b := range bounds {
+			if b == nil {
+				continue
+			}
+			if prev != nil && *b < *prev {
+				return nil, apperrors.NewBadRequest(fmt.Sprintf("Range %d: bounds must satisfy criticalLow <= low <= high <= criticalHigh", i+1), nil)
+			}
+			prev = b
+		}
+		ranges[i] = r
+	}
+	return ranges, nil
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errWebhookRedirect stops the webhook client from following redirects; a 3xx is a failed delivery.
+var errWebhookRedirect = errors.New("webhook endpoints must not redirect")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), internal on most networks.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// newWebhookClient builds the HTTP client for subscriber endpoints. Subscribers choose the URL, so the
+// client connects only to public addresses, checked on the resolved IP at dial time so DNS cannot
+// point it inward, and it neither follows redirects nor uses an environment proxy.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkWebhookIP(net.ParseIP(host))
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errWebhookRedirect
+		},
+	}
+}
+
+// checkWebhookIP rejects addresses a subscriber must not reach: loopback, private, link-local
+// (including cloud metadata endpoints), shared, multicast and unspecified addresses.
+func checkWebhookIP(ip net.IP) error {
+	if ip == nil {
+		return errors.New("webhook endpoint address is not an IP")
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("webhook endpoint address %s is not public", ip)
+	}
+	return nil
+}
+
+// checkWebhookHost rejects hosts that are plainly internal when a subscription is saved. Names that
+// resolve to internal addresses are caught when dialling.
+func checkWebhookHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".local") {
+		return fmt.Errorf("webhook host %s is not public", host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return checkWebhookIP(ip)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+)
+
+func TestCheckWebhookHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"example.com", true},
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"localhost", false},
+		{"api.localhost", false},
+		{"metadata.google.internal", false},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.10", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		err := checkWebhookHost(tt.host)
+		if (err == nil) != tt.allowed {
+			t.Errorf("checkWebhookHost(%q) = %v, want allowed=%v", tt.host, err, tt.allowed)
+		}
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback endpoint")
+	}))
+	defer srv.Close()
+
+	_, err := newWebhookClient(2*time.Second).Post(srv.URL, "application/json", nil)
+	if err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Fatalf("dial to %s: got %v, want a not-public error", srv.URL, err)
+	}
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	client := newWebhookClient(2 * time.Second)
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/hook", nil)
+	if err := client.CheckRedirect(req, []*http.Request{req}); err != errWebhookRedirect {
+		t.Fatalf("CheckRedirect = %v, want errWebhookRedirect", err)
+	}
+}
+
+func TestApplyWebhookRequestRequiresHTTPS(t *testing.T) {
+	tests := []struct {
+		url       string
+		allowHTTP bool
+		wantErr   bool
+	}{
+		{"https://hooks.example.com/in", false, false},
+		{"http://hooks.example.com/in", false, true},
+		{"http://hooks.example.com/in", true, false},
+		{"https://127.0.0.1/in", true, true},
+		{"https://169.254.169.254/latest/meta-data", false, true},
+		{"ftp://hooks.example.com/in", true, true},
+	}
+	for _, tt := range tests {
+		req := dto.WebhookSubscriptionRequest{URL: tt.url, EventTypes: []string{"lead.created"}}
+		var subscription domain.WebhookSubscription
+		err := applyWebhookRequest(&subscription, req, tt.allowHTTP)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("applyWebhookRequest(%q, allowHTTP=%v) = %v, wantErr %v", tt.url, tt.allowHTTP, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"b2b-diagnostic-aggregator/apis/internal/apperrors"
+	"b2b-diagnostic-aggregator/apis/internal/domain"
+	"b2b-diagnostic-aggregator/apis/internal/dto"
+	"b2b-diagnostic-aggregator/apis/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSettings tunes webhook delivery.
+type WebhookSettings struct {
+	MaxAttempts int           // attempts before a delivery is dead-lettered
+	BatchSize   int           // deliveries sent per dispatch run
+	Timeout     time.Duration // per request
+	Lease       time.Duration // how long a claimed delivery is hidden from other dispatchers
+	AllowHTTP   bool          // accept plain http endpoints; development only
+}
+
+// WebhookService manages client webhook subscriptions and delivers the lead events queued for them.
+// HandleEvent subscribes to the domain event dispatcher and queues a delivery per interested
+// subscription; DispatchDue POSTs each delivery, signed like this:
+//
+//	X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body))
+//
+// Failed deliveries are retried with exponential backoff and dead-lettered when their attempts run
+// out; dead letters can be redelivered by hand.
+type WebhookService interface {
+	ListSubscriptions(clientID *int64, scope domain.TenantScope) ([]domain.WebhookSubscription, error)
+	GetSubscription(id int64, scope domain.TenantScope) (*domain.WebhookSubscription, error)
+	CreateSubscription(req dto.WebhookSubscriptionRequest, actor domain.Actor, scope domain.TenantScope) (*dto.WebhookSubscriptionSecret, error)
+	UpdateSubscription(id int64, req dto.WebhookSubscriptionRequest, actor domain.Actor, scope domain.TenantScope) (*domain.WebhookSubscription, error)
+	DeleteSubscription(id int64, actor domain.Actor, scope domain.TenantScope) error
+	ListDeadLetters(filter repository.WebhookDeliveryListFilter, scope domain.TenantScope) ([]domain.WebhookDelivery, int64, error)
+	Redeliver(deliveryID int64, scope domain.TenantScope) (*domain.WebhookDelivery, error)
+	HandleEvent(ctx context.Context, event domain.DomainEvent) error
+	DispatchDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type webhookService struct {
+	subscriptionRepo repository.WebhookSubscriptionRepository
+	deliveryRepo     repository.WebhookDeliveryRepository
+	clientRepo       repository.ClientRepository
+	audit            AuditService
+	workflow         *domain.LeadStatusWorkflow
+	client           *http.Client
+	settings         WebhookSettings
+}
+
+func NewWebhookService(
+	subscriptionRepo repository.WebhookSubscriptionRepository,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	clientRepo repository.ClientRepository,
+	audit AuditService,
+	workflow *domain.LeadStatusWorkflow,
+	settings WebhookSettings,
+) WebhookService {
+	if workflow == nil {
+		workflow = domain.DefaultLeadStatusWorkflow()
+	}
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 8
+	}
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 50
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 10 * time.Second
+	}
+	if settings.Lease <= 0 {
+		settings.Lease = 2 * time.Minute
+	}
+	return &webhookService{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		clientRepo:       clientRepo,
+		audit:            audit,
+		workflow:         workflow,
+		client:           newWebhookClient(settings.Timeout),
+		settings:         settings,
+	}
+}
+
+// ListSubscriptions returns the client's subscriptions; employees see every client's unless they
+// filter by clientID.
+func (s *webhookService) ListSubscriptions(clientID *int64, scope domain.TenantScope) ([]domain.WebhookSubscription, error) {
+	if scope.ClientID != 0 {
+		return s.subscriptionRepo.FindByClientID(scope.ClientID)
+	}
+	if clientID != nil {
+		return s.subscriptionRepo.FindByClientID(*clientID)
+	}
+	return s.subscriptionRepo.FindAll()
+}
+
+func (s *webhookService) GetSubscription(id int64, scope domain.TenantScope) (*domain.WebhookSubscription, error) {
+	return s.findSubscription(id, scope)
+}
+
+func (s *webhookService) CreateSubscription(req dto.WebhookSubscriptionRequest, actor domain.Actor, scope domain.TenantScope) (*dto.WebhookSubscriptionSecret, error) {
+	clientID := req.ClientID
+	if scope.ClientID != 0 {
+		if clientID != 0 && clientID != scope.ClientID {
+			return nil, apperrors.NewForbidden("You can only subscribe your own client account", nil)
+		}
+		clientID = scope.ClientID
+	}
+	if clientID == 0 {
+		return nil, apperrors.NewBadRequest("clientId is required", nil)
+	}
+	if _, err := s.clientRepo.FindByID(clientID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFound("Client not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	subscription := &domain.WebhookSubscription{
+		ClientID:      clientID,
+		IsActive:      true,
+		CreatedBy:     actor.UserID,
+		CreatedOn:     now,
+		LastUpdatedBy: actor.UserID,
+		LastUpdatedOn: now,
+	}
+	if err := applyWebhookRequest(subscription, req, s.settings.AllowHTTP); err != nil {
+		return nil, err
+	}
+	if subscription.Secret == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+	if err := s.subscriptionRepo.Create(subscription); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityWebhookSubscription, subscription.SubscriptionID, domain.AuditActionCreate, actor, nil, subscription)
+	return &dto.WebhookSubscriptionSecret{Subscription: subscription, Secret: subscription.Secret}, nil
+}
+
+func (s *webhookService) UpdateSubscription(id int64, req dto.WebhookSubscriptionRequest, actor domain.Actor, scope domain.TenantScope) (*domain.WebhookSubscription, error) {
+	existing, err := s.findSubscription(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClientID != 0 && req.ClientID != existing.ClientID {
+		return nil, apperrors.NewBadRequest("A subscription cannot move to another client", nil)
+	}
+	updated := *existing
+	if err := applyWebhookRequest(&updated, req, s.settings.AllowHTTP); err != nil {
+		return nil, err
+	}
+	updated.LastUpdatedBy = actor.UserID
+	updated.LastUpdatedOn = time.Now()
+	if err := s.subscriptionRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	s.audit.Record(domain.AuditEntityWebhookSubscription, id, domain.AuditActionUpdate, actor, existing, &updated)
+	return &updated, nil
+}
+
+// DeleteSubscription removes the subscription along with its queued and dead-lettered deliveries.
+func (s *webhookService) DeleteSubscription(id int64, actor domain.Actor, scope domain.TenantScope) error {
+	existing, err := s.findSubscription(id, scope)
+	if err != nil {
+		return err
+	}
+	if err := s.subscriptionRepo.Delete(id); err != nil {
+		return err
+	}
+	s.audit.Record(domain.AuditEntityWebhookSubscription, id, domain.AuditActionDelete, actor, existing, nil)
+	return nil
+}
+
+// ListDeadLetters returns deliveries that ran out of attempts, latest first.
+func (s *webhookService) ListDeadLetters(filter repository.WebhookDeliveryListFilter, scope domain.TenantScope) ([]domain.WebhookDelivery, int64, error) {
+	if scope.ClientID != 0 {
+		filter.ClientID = scope.ClientID
+	}
+	filter.Status = domain.WebhookDeliveryDead
+	return s.deliveryRepo.List(filter)
+}
+
+// Redeliver queues a dead-lettered or delivered event again with a fresh set of attempts.
+func (s *webhookService) Redeliver(deliveryID int64, scope domain.TenantScope) (*domain.WebhookDelivery, error) {
+	delivery, err := s.deliveryRepo.FindByID(deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !scope.AllowsClient(delivery.ClientID)) {
+		return nil, apperrors.NewNotFound("Webhook delivery not found", err)
+	} else if err != nil {
+		return nil, err
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		return nil, apperrors.NewConflict("The delivery is already queued", nil)
+	}
+	subscription, err := s.subscriptionRepo.FindByID(delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive {
+		return nil, apperrors.NewConflict("The subscription is disabled", nil)
+	}
+
+	now := time.Now()
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptOn = &now
+	delivery.DeliveredOn = nil
+	delivery.LastUpdatedOn = now
+	if err := s.deliveryRepo.Update(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// DispatchDue sends the deliveries that are due, one attempt each, and returns how many it attempted.
+func (s *webhookService) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.deliveryRepo.FindDue(now, s.settings.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	subscriptions := make(map[int64]*domain.WebhookSubscription)
+	attempted := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		claimed, err := s.deliveryRepo.Claim(due[i].DeliveryID, now, now.Add(s.settings.Lease))
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			continue
+		}
+		subscription, ok := subscriptions[due[i].SubscriptionID]
+		if !ok {
+			subscription, err = s.subscriptionRepo.FindByID(due[i].SubscriptionID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return attempted, err
+			}
+			subscriptions[due[i].SubscriptionID] = subscription
+		}
+		if err := s.attempt(ctx, due[i], subscription); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// Run dispatches due deliveries every interval until ctx is cancelled.
+func (s *webhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchDue(ctx); err != nil {
+				log.Printf(`{"timestamp":"%s","level":"error","event":"webhook_dispatch_failed","error":%q}`,
+					time.Now().UTC().Format(time.RFC3339), err.Error())
+			}
+		}
+	}
+}
+
+// attempt POSTs the delivery once and records the outcome. Only failures to record are returned.
+func (s *webhookService) attempt(ctx context.Context, delivery domain.WebhookDelivery, subscription *domain.WebhookSubscription) error {
+	var code int
+	var sendErr error
+	gone := subscription == nil || !subscription.IsActive
+	if gone {
+		sendErr = errors.New("the subscription was disabled or deleted")
+	} else {
+		code, sendErr = s.send(ctx, delivery, *subscription)
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastResponseCode = code
+	delivery.LastUpdatedOn = now
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.NextAttemptOn = nil
+		delivery.DeliveredOn = &now
+		delivery.LastError = ""
+	case gone || delivery.Attempts >= s.settings.MaxAttempts:
+		delivery.Status = domain.WebhookDeliveryDead
+		delivery.NextAttemptOn = nil
+		delivery.LastError = truncateError(sendErr)
+		log.Printf(`{"timestamp":"%s","level":"warn","event":"webhook_dead_lettered","delivery_id":%d,"subscription_id":%d,"error":%q}`,
+			now.UTC().Format(time.RFC3339), delivery.DeliveryID, delivery.SubscriptionID, delivery.LastError)
+	default:
+		next := now.Add(domain.WebhookRetryDelay(delivery.Attempts))
+		delivery.NextAttemptOn = &next
+		delivery.LastError = truncateError(sendErr)
+	}
+	return s.deliveryRepo.Update(&delivery)
+}
+
+// send POSTs the payload; any 2xx response is success. The response body is discarded: the outcome is
+// shown to the subscribing client, and the endpoint's answer must not be readable through it.
+func (s *webhookService) send(ctx context.Context, delivery domain.WebhookDelivery, subscription domain.WebhookSubscription) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "b2b-diagnostic-aggregator-webhooks/1")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *webhookService) findSubscription(id int64, scope domain.TenantScope) (*domain.WebhookSubscription, error) {
+	subscription, err := s.subscriptionRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !scope.AllowsClient(subscription.ClientID)) {
+		return nil, apperrors.NewNotFound("Webhook subscription not found", err)
+	}
+	return subscription, err
+}
+
+func applyWebhookRequest(subscription *domain.WebhookSubscription, req dto.WebhookSubscriptionRequest, allowHTTP bool) error {
+	endpoint := strings.TrimSpace(req.URL)
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && !(allowHTTP && u.Scheme == "http")) || u.Hostname() == "" {
+		if allowHTTP {
+			return apperrors.NewBadRequest("url must be an http(s) URL", err)
+		}
+		return apperrors.NewBadRequest("url must be an https URL", err)
+	}
+	if err := checkWebhookHost(u.Hostname()); err != nil {
+		return apperrors.NewBadRequest("url must point to a public host", err)
+	}
+	seen := make(map[string]bool, len(req.EventTypes))
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	for _, t := range req.EventTypes {
+		if !domain.IsWebhookEventType(t) {
+			return apperrors.NewBadRequest(fmt.Sprintf("Unknown event type %s", t), nil)
+		}
+		if !seen[t] {
+			seen[t] = true
+			eventTypes = append(eventTypes, t)
+		}
+	}
+	subscription.URL = endpoint
+	subscription.EventTypes = eventTypes
+	if req.Secret != nil {
+		subscription.Secret = strings.TrimSpace(*req.Secret)
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// HandleEvent is the event dispatcher subscriber that turns lead domain events into webhook
+// deliveries for the client's subscriptions. The webhook event ID is derived from the outbox event, so
+// a redispatched event queues no second delivery.
+func (s *webhookService) HandleEvent(ctx context.Context, event domain.DomainEvent) error {
+	var webhookType string
+	var clientID int64
+	var data interface{}
+	switch event.Type {
+	case domain.EventLeadCreated:
+		var created domain.LeadCreated
+		if err := event.Decode(&created); err != nil {
+			return err
+		}
+		webhookType, clientID = domain.WebhookEventLeadCreated, created.ClientID
+		data = dto.WebhookLeadData{
+			LeadID:      created.LeadID,
+			ClientID:    created.ClientID,
+			PatientCode: created.PatientCode,
+			PackageID:   created.PackageID,
+			Status:      webhookLeadStatus(s.workflow, created.StatusID),
+		}
+	case domain.EventLeadStatusChanged:
+		var changed domain.LeadStatusChanged
+		if err := event.Decode(&changed); err != nil {
+			return err
+		}
+		previous := webhookLeadStatus(s.workflow, changed.FromStatusID)
+		webhookType, clientID = domain.WebhookEventLeadStatusChanged, changed.ClientID
+		data = dto.WebhookLeadData{
+			LeadID:         changed.LeadID,
+			ClientID:       changed.ClientID,
+			PatientCode:    changed.PatientCode,
+			PackageID:      changed.PackageID,
+			Status:         webhookLeadStatus(s.workflow, changed.ToStatusID),
+			PreviousStatus: &previous,
+		}
+	case domain.EventReportReleased:
+		var released domain.ReportReleased
+		if err := event.Decode(&released); err != nil {
+			return err
+		}
+		webhookType, clientID = domain.WebhookEventReportReleased, released.ClientID
+		data = dto.WebhookReportData{
+			LeadID:     released.LeadID,
+			ClientID:   released.ClientID,
+			ReportID:   released.ReportID,
+			Version:    released.Version,
+			ReleasedAt: released.ReleasedOn,
+		}
+	default:
+		return nil
+	}
+	if clientID == 0 {
+		return nil
+	}
+
+	eventID := "evt_" + strconv.FormatInt(event.EventID, 10)
+	payload, err := json.Marshal(dto.WebhookPayload{ID: eventID, Type: webhookType, OccurredAt: event.OccurredOn.UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	_, err = s.deliveryRepo.Enqueue(domain.WebhookEvent{
+		EventID:    eventID,
+		Type:       webhookType,
+		ClientID:   clientID,
+		LeadID:     event.AggregateID,
+		OccurredOn: event.OccurredOn,
+		Payload:    string(payload),
+	})
+	return err
+}
+
+func webhookLeadStatus(workflow *domain.LeadStatusWorkflow, id int8) dto.WebhookLeadStatus {
+	id = workflow.Normalize(id)
+	status, _ := workflow.Status(id)
+	return dto.WebhookLeadStatus{ID: id, Name: status.Name}
+}
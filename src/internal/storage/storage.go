@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by Get when no object is stored under the key.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Store keeps binary objects (lab reports, ...) under slash-separated keys. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+type Config struct {
+	Driver    string // "local" (default) or "s3"
+	LocalDir  string // root directory for the local driver
+	Endpoint  string // S3 endpoint URL, e.g. http://localhost:9000 for MinIO
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // address the bucket in the path (emulators) instead of the host name
+}
+
+// NewStore returns the store selected by cfg.Driver.
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
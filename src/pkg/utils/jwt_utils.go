@@ -1,15 +1,31 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTClaims matches Node.js payload: userId and userType (1=employee, 2=client, 3=lab)
+// Token types carried in the tokenType claim; access tokens are rejected at /login/refresh and
+// refresh tokens are rejected by AuthMiddleware.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// ErrWrongTokenType is returned when a valid token of the wrong type is presented.
+var ErrWrongTokenType = errors.New("wrong token type")
+
+// JWTClaims matches Node.js payload: userId and userType (1=employee, 2=client, 3=lab),
+// plus tokenType and the session id (sid) shared by every token issued for one login.
 type JWTClaims struct {
-	UserID   int64 `json:"userId"`
-	UserType int   `json:"userType"`
+	UserID    int64  `json:"userId"`
+	UserType  int    `json:"userType"`
+	TokenType string `json:"tokenType"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -27,40 +43,86 @@ func (c *JWTClaims) Role() string {
 	}
 }
 
-// GenerateToken creates access and refresh tokens with userId and userType (Node-compatible)
-func GenerateToken(userID int64, userType int, secret string, accessTTL, refreshTTL time.Duration) (string, string, error) {
+// TokenSecrets holds the signing keys. Refresh tokens are signed with their own secret so they can
+// never be verified as access tokens.
+type TokenSecrets struct {
+	Access  string
+	Refresh string
+}
+
+// TokenPair is an access/refresh token pair. RefreshTokenID is the refresh token's jti, stored
+// server-side so each refresh token can be used exactly once.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshTokenID   string
+	RefreshExpiresAt time.Time
+}
+
+// NewTokenID returns a random 128-bit hex identifier for jti and session ids.
+func NewTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GenerateToken creates access and refresh tokens with userId and userType (Node-compatible) for sessionID
+func GenerateToken(userID int64, userType int, sessionID string, secrets TokenSecrets, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
+	now := time.Now()
+
+	accessID, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
 	accessClaims := &JWTClaims{
-		UserID:   userID,
-		UserType: userType,
+		UserID:    userID,
+		UserType:  userType,
+		TokenType: TokenTypeAccess,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTTL)),
+			ID:        accessID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTTL)),
 		},
 	}
-
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessString, err := accessToken.SignedString([]byte(secret))
+	accessString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString([]byte(secrets.Access))
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
+	refreshID, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := now.Add(refreshTTL)
 	refreshClaims := &JWTClaims{
-		UserID:   userID,
-		UserType: userType,
+		UserID:    userID,
+		UserType:  userType,
+		TokenType: TokenTypeRefresh,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(refreshTTL)),
+			ID:        refreshID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(refreshExpiresAt),
 		},
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshString, err := refreshToken.SignedString([]byte(secret))
+	refreshString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(secrets.Refresh))
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	return accessString, refreshString, nil
+	return &TokenPair{
+		AccessToken:      accessString,
+		RefreshToken:     refreshString,
+		RefreshTokenID:   refreshID,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
 }
 
 // Legacy: GenerateTokenWithRole for backward compatibility (maps role to userType)
-func GenerateTokenWithRole(userID int64, role string, secret string, accessTTL, refreshTTL time.Duration) (string, string, error) {
+func GenerateTokenWithRole(userID int64, role string, sessionID string, secrets TokenSecrets, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
 	userType := 0
 	switch role {
 	case "employee":
@@ -70,13 +132,13 @@ func GenerateTokenWithRole(userID int64, role string, secret string, accessTTL,
 	case "lab":
 		userType = 3
 	}
-	return GenerateToken(userID, userType, secret, accessTTL, refreshTTL)
+	return GenerateToken(userID, userType, sessionID, secrets, accessTTL, refreshTTL)
 }
 
 func ValidateToken(tokenString string, secret string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -88,3 +150,15 @@ func ValidateToken(tokenString string, secret string) (*JWTClaims, error) {
 
 	return nil, jwt.ErrSignatureInvalid
 }
+
+// ValidateTokenOfType validates the token and requires its tokenType claim to equal tokenType.
+func ValidateTokenOfType(tokenString, secret, tokenType string) (*JWTClaims, error) {
+	claims, err := ValidateToken(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != tokenType || claims.SessionID == "" {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
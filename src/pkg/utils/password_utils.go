@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHashCost is the bcrypt work factor for newly hashed passwords. Stored hashes with a lower
+// cost are upgraded on the next successful login.
+const PasswordHashCost = 12
+
+// HashPassword returns a salted one-way bcrypt hash of password for storage in tbl_Login.Pwd.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordHashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsPasswordHash reports whether stored is a bcrypt hash rather than a legacy AES ciphertext.
+func IsPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// VerifyPassword checks password against a stored value, which may be a bcrypt hash or a legacy
+// AES ciphertext produced by Encrypt. needsRehash is true when the password matched but the stored
+// value should be replaced with a fresh HashPassword result (legacy format or outdated cost).
+func VerifyPassword(stored, password string) (match bool, needsRehash bool, err error) {
+	if IsPasswordHash(stored) {
+		err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(stored))
+		return true, err == nil && cost < PasswordHashCost, nil
+	}
+
+	legacy, err := Encrypt(password)
+	if err != nil {
+		return false, false, err
+	}
+	if subtle.ConstantTimeCompare([]byte(legacy), []byte(stored)) != 1 {
+		return false, false, nil
+	}
+	return true, true, nil
+}
+
+// GenerateOTP returns a cryptographically random numeric one-time password with the given number of digits.
+func GenerateOTP(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}